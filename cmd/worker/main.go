@@ -2,16 +2,128 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"course-go-avito-Orurh/internal/app"
+	"course-go-avito-Orurh/internal/transport/kafka"
 )
 
+const usage = `usage: worker [reinject | replay <flags> | reconcile [--dry-run]]
+
+  (no command)  consume order events from Kafka
+  reinject      move dead-lettered events from KAFKA_DLQ_TOPIC back to KAFKA_ORDER_TOPIC and exit
+  replay        run a range of KAFKA_ORDER_TOPIC through the order events pipeline again, print a summary and exit
+  reconcile     compare orders of the orders service with the delivery table once, fix the drift, print a report and exit
+`
+
 func main() {
 	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer cancel()
 
-	container := app.MustBuildWorkerContainer(ctx)
-	app.NewWorkerRunner().MustRun(container)
+	switch cmd := command(os.Args); cmd {
+	case "":
+		container := app.MustBuildWorkerContainer(ctx)
+		app.NewWorkerRunner().MustRun(container)
+	case "reinject":
+		container := app.MustBuildReinjectContainer(ctx)
+		app.NewReinjectRunner().MustRun(container)
+	case "replay":
+		opts, err := replayOptions(os.Args[2:], os.Stderr)
+		if err != nil {
+			if !errors.Is(err, flag.ErrHelp) {
+				fmt.Fprintln(os.Stderr, err)
+			}
+			os.Exit(2)
+		}
+		container := app.MustBuildReplayContainer(ctx, opts)
+		app.NewReplayRunner().MustRun(container)
+	case "reconcile":
+		dryRun, err := reconcileOptions(os.Args[2:], os.Stderr)
+		if err != nil {
+			if !errors.Is(err, flag.ErrHelp) {
+				fmt.Fprintln(os.Stderr, err)
+			}
+			os.Exit(2)
+		}
+		container := app.MustBuildWorkerContainer(ctx)
+		app.NewReconcileRunner(dryRun).MustRun(container)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n%s", cmd, usage)
+		os.Exit(2)
+	}
+}
+
+func command(args []string) string {
+	if len(args) < 2 {
+		return ""
+	}
+	return args[1]
+}
+
+func replayOptions(args []string, out io.Writer) (kafka.ReplayOptions, error) {
+	fs := flag.NewFlagSet("replay", flag.ContinueOnError)
+	fs.SetOutput(out)
+
+	var from, to string
+	opts := kafka.ReplayOptions{}
+	fs.Int64Var(&opts.FromOffset, "from-offset", -1, "first offset to replay in every partition")
+	fs.StringVar(&from, "from-time", "", "replay events produced at or after this time, RFC3339")
+	fs.StringVar(&to, "to-time", "", "replay events produced before this time, RFC3339 (default: up to the end)")
+	fs.BoolVar(&opts.DryRun, "dry-run", false, "roll back every change and do not commit offsets")
+	fs.StringVar(&opts.Group, "group", "", "consumer group of the replay (default: KAFKA_GROUP_ID-replay)")
+	if err := fs.Parse(args); err != nil {
+		return opts, err
+	}
+	if fs.NArg() > 0 {
+		return opts, fmt.Errorf("replay: unexpected arguments %v", fs.Args())
+	}
+
+	var err error
+	if opts.FromTime, err = parseTime("from-time", from); err != nil {
+		return opts, err
+	}
+	if opts.ToTime, err = parseTime("to-time", to); err != nil {
+		return opts, err
+	}
+
+	// the group is filled by the container when empty
+	check := opts
+	if check.Group == "" {
+		check.Group = "replay"
+	}
+	if err := check.Validate(); err != nil {
+		return opts, fmt.Errorf("replay: %w", err)
+	}
+	return opts, nil
+}
+
+func reconcileOptions(args []string, out io.Writer) (dryRun bool, err error) {
+	fs := flag.NewFlagSet("reconcile", flag.ContinueOnError)
+	fs.SetOutput(out)
+	fs.BoolVar(&dryRun, "dry-run", false, "only report the drift, fix nothing and keep the watermark")
+	if err := fs.Parse(args); err != nil {
+		return false, err
+	}
+	if fs.NArg() > 0 {
+		return false, fmt.Errorf("reconcile: unexpected arguments %v", fs.Args())
+	}
+	return dryRun, nil
+}
+
+func parseTime(name, v string) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("replay: --%s: %w", name, err)
+	}
+	return t, nil
 }
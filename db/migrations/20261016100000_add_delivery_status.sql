@@ -0,0 +1,54 @@
+-- +goose Up
+ALTER TABLE IF EXISTS delivery
+    ADD COLUMN IF NOT EXISTS status TEXT NOT NULL DEFAULT 'assigned'
+        CHECK (status IN ('assigned','picked_up','delivered','canceled','expired','failed')),
+    ADD COLUMN IF NOT EXISTS picked_up_at TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS delivered_at TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS canceled_at  TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS expired_at   TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS failed_at    TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS updated_at   TIMESTAMP NOT NULL DEFAULT now();
+
+-- rows written before this migration were never deleted on completion, only the courier was freed:
+-- a delivery is still in progress only while its courier is busy and its deadline is ahead
+UPDATE delivery d
+SET status       = 'delivered',
+    delivered_at = LEAST(d.deadline, now()),
+    updated_at   = now()
+WHERE d.deadline <= now()
+   OR NOT EXISTS (SELECT 1 FROM couriers c WHERE c.id = d.courier_id AND c.status = 'busy');
+
+-- an order may have several historical deliveries but only one active at a time
+DROP INDEX IF EXISTS ux_delivery_order_id;
+
+CREATE UNIQUE INDEX IF NOT EXISTS ux_delivery_order_id_active
+    ON delivery (order_id)
+    WHERE status IN ('assigned','picked_up');
+
+CREATE INDEX IF NOT EXISTS ix_delivery_order_id
+    ON delivery (order_id, id);
+
+CREATE INDEX IF NOT EXISTS ix_delivery_status_deadline
+    ON delivery (status, deadline);
+
+-- +goose Down
+DROP INDEX IF EXISTS ix_delivery_status_deadline;
+DROP INDEX IF EXISTS ix_delivery_order_id;
+DROP INDEX IF EXISTS ux_delivery_order_id_active;
+
+DELETE FROM delivery d
+USING delivery newer
+WHERE newer.order_id = d.order_id
+  AND newer.id > d.id;
+
+CREATE UNIQUE INDEX IF NOT EXISTS ux_delivery_order_id
+    ON delivery (order_id);
+
+ALTER TABLE IF EXISTS delivery
+    DROP COLUMN IF EXISTS updated_at,
+    DROP COLUMN IF EXISTS failed_at,
+    DROP COLUMN IF EXISTS expired_at,
+    DROP COLUMN IF EXISTS canceled_at,
+    DROP COLUMN IF EXISTS delivered_at,
+    DROP COLUMN IF EXISTS picked_up_at,
+    DROP COLUMN IF EXISTS status;
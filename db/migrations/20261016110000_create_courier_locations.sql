@@ -0,0 +1,14 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS courier_locations (
+    courier_id  BIGINT PRIMARY KEY REFERENCES couriers(id) ON DELETE CASCADE,
+    lat         DOUBLE PRECISION NOT NULL CHECK (lat BETWEEN -90 AND 90),
+    lon         DOUBLE PRECISION NOT NULL CHECK (lon BETWEEN -180 AND 180),
+    updated_at  TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS ix_courier_locations_lat_lon
+    ON courier_locations (lat, lon);
+
+-- +goose Down
+DROP INDEX IF EXISTS ix_courier_locations_lat_lon;
+DROP TABLE IF EXISTS courier_locations;
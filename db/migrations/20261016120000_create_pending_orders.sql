@@ -0,0 +1,16 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS pending_orders (
+    order_id    TEXT PRIMARY KEY,
+    pickup_lat  DOUBLE PRECISION,
+    pickup_lon  DOUBLE PRECISION,
+    priority    INT NOT NULL DEFAULT 0,
+    enqueued_at TIMESTAMP NOT NULL DEFAULT now(),
+    CHECK ((pickup_lat IS NULL) = (pickup_lon IS NULL))
+);
+
+CREATE INDEX IF NOT EXISTS ix_pending_orders_priority_enqueued_at
+    ON pending_orders (priority DESC, enqueued_at, order_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS ix_pending_orders_priority_enqueued_at;
+DROP TABLE IF EXISTS pending_orders;
@@ -0,0 +1,16 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS delivery_reassignments (
+    id                  BIGSERIAL PRIMARY KEY,
+    order_id            VARCHAR(255) NOT NULL,
+    expired_delivery_id BIGINT NOT NULL REFERENCES delivery(id) ON DELETE CASCADE,
+    from_courier_id     BIGINT NOT NULL,
+    to_courier_id       BIGINT,
+    created_at          TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS ix_delivery_reassignments_order_id
+    ON delivery_reassignments (order_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS ix_delivery_reassignments_order_id;
+DROP TABLE IF EXISTS delivery_reassignments;
@@ -0,0 +1,8 @@
+-- +goose Up
+-- per-courier override of the transport capacity, NULL means the transport default
+ALTER TABLE IF EXISTS couriers
+    ADD COLUMN IF NOT EXISTS capacity INT CHECK (capacity > 0);
+
+-- +goose Down
+ALTER TABLE IF EXISTS couriers
+    DROP COLUMN IF EXISTS capacity;
@@ -0,0 +1,27 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS transport_types (
+    code                  TEXT PRIMARY KEY,
+    speed_kmh             DOUBLE PRECISION NOT NULL CHECK (speed_kmh > 0),
+    max_capacity          INT NOT NULL CHECK (max_capacity > 0),
+    base_deadline_seconds INT NOT NULL CHECK (base_deadline_seconds > 0),
+    created_at            TIMESTAMP WITHOUT TIME ZONE DEFAULT now() NOT NULL,
+    updated_at            TIMESTAMP WITHOUT TIME ZONE DEFAULT now() NOT NULL
+);
+
+INSERT INTO transport_types (code, speed_kmh, max_capacity, base_deadline_seconds)
+VALUES ('on_foot', 5, 1, 1800),
+       ('bike', 15, 2, 1200),
+       ('scooter', 20, 2, 900),
+       ('car', 40, 4, 300)
+ON CONFLICT (code) DO NOTHING;
+
+-- a transport type cannot be deleted while couriers use it
+ALTER TABLE IF EXISTS couriers
+    ADD CONSTRAINT couriers_transport_type_fkey
+        FOREIGN KEY (transport_type) REFERENCES transport_types (code);
+
+-- +goose Down
+ALTER TABLE IF EXISTS couriers
+    DROP CONSTRAINT IF EXISTS couriers_transport_type_fkey;
+
+DROP TABLE IF EXISTS transport_types;
@@ -0,0 +1,11 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS processed_events (
+    topic        TEXT NOT NULL,
+    partition    INT NOT NULL,
+    "offset"     BIGINT NOT NULL,
+    processed_at TIMESTAMP NOT NULL DEFAULT now(),
+    PRIMARY KEY (topic, partition, "offset")
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS processed_events;
@@ -0,0 +1,16 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS outbox (
+    id         BIGSERIAL PRIMARY KEY,
+    event_type TEXT NOT NULL,
+    event_key  TEXT NOT NULL,
+    payload    JSONB NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT now(),
+    sent_at    TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS ix_outbox_unsent
+    ON outbox (id) WHERE sent_at IS NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS ix_outbox_unsent;
+DROP TABLE IF EXISTS outbox;
@@ -0,0 +1,10 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS order_versions (
+    order_id   VARCHAR(255) PRIMARY KEY,
+    status     TEXT NOT NULL,
+    event_at   TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS order_versions;
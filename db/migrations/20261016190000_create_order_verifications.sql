@@ -0,0 +1,16 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS order_verifications (
+    order_id   VARCHAR(255) PRIMARY KEY,
+    status     TEXT NOT NULL,
+    event_at   TIMESTAMP,
+    due_at     TIMESTAMP NOT NULL,
+    attempts   INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS ix_order_verifications_due_at
+    ON order_verifications (due_at);
+
+-- +goose Down
+DROP INDEX IF EXISTS ix_order_verifications_due_at;
+DROP TABLE IF EXISTS order_verifications;
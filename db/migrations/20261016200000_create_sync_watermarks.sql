@@ -0,0 +1,9 @@
+-- +goose Up
+CREATE TABLE IF NOT EXISTS sync_watermarks (
+    name       TEXT PRIMARY KEY,
+    watermark  TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS sync_watermarks;
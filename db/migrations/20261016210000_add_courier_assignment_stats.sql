@@ -0,0 +1,20 @@
+-- +goose Up
+-- lifetime workload of a courier, kept up to date on assignment instead of aggregated over its deliveries
+ALTER TABLE IF EXISTS couriers
+    ADD COLUMN IF NOT EXISTS total_deliveries INT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS last_assigned_at TIMESTAMP;
+
+UPDATE couriers c
+SET total_deliveries = s.total,
+    last_assigned_at = s.last_assigned_at
+FROM (
+    SELECT courier_id, COUNT(*) AS total, MAX(assigned_at) AS last_assigned_at
+    FROM delivery
+    GROUP BY courier_id
+) s
+WHERE s.courier_id = c.id;
+
+-- +goose Down
+ALTER TABLE IF EXISTS couriers
+    DROP COLUMN IF EXISTS last_assigned_at,
+    DROP COLUMN IF EXISTS total_deliveries;
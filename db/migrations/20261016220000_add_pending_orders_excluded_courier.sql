@@ -0,0 +1,8 @@
+-- +goose Up
+-- the courier an expired delivery was taken from, the dispatcher does not give the order back to them
+ALTER TABLE IF EXISTS pending_orders
+    ADD COLUMN IF NOT EXISTS excluded_courier_id BIGINT REFERENCES couriers (id) ON DELETE SET NULL;
+
+-- +goose Down
+ALTER TABLE IF EXISTS pending_orders
+    DROP COLUMN IF EXISTS excluded_courier_id;
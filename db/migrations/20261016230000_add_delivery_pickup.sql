@@ -0,0 +1,12 @@
+-- +goose Up
+-- the pickup point the courier was chosen for, a reassignment looks for the next courier near it
+ALTER TABLE IF EXISTS delivery
+    ADD COLUMN IF NOT EXISTS pickup_lat DOUBLE PRECISION,
+    ADD COLUMN IF NOT EXISTS pickup_lon DOUBLE PRECISION,
+    ADD CONSTRAINT delivery_pickup_both_or_none CHECK ((pickup_lat IS NULL) = (pickup_lon IS NULL));
+
+-- +goose Down
+ALTER TABLE IF EXISTS delivery
+    DROP CONSTRAINT IF EXISTS delivery_pickup_both_or_none,
+    DROP COLUMN IF EXISTS pickup_lon,
+    DROP COLUMN IF EXISTS pickup_lat;
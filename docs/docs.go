@@ -15,9 +15,569 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/admin/transport-types": {
+            "get": {
+                "description": "Возвращает типы транспорта со средней скоростью, вместимостью и базовым дедлайном",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "transport-types"
+                ],
+                "summary": "Список типов транспорта",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/handlers.transportTypeDTO"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "internal error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Добавляет тип транспорта, курьеров с ним можно создавать без передеплоя",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "transport-types"
+                ],
+                "summary": "Создать тип транспорта",
+                "parameters": [
+                    {
+                        "description": "Transport type",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.transportTypeDTO"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.transportTypeDTO"
+                        },
+                        "headers": {
+                            "Location": {
+                                "type": "string",
+                                "description": "URL созданного ресурса"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "invalid input",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "transport type already exists",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "internal error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/transport-types/{code}": {
+            "get": {
+                "description": "Возвращает тип транспорта по коду",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "transport-types"
+                ],
+                "summary": "Получить тип транспорта",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Transport type code",
+                        "name": "code",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.transportTypeDTO"
+                        }
+                    },
+                    "404": {
+                        "description": "not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "internal error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Заменяет скорость, вместимость и базовый дедлайн типа транспорта",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "transport-types"
+                ],
+                "summary": "Обновить тип транспорта",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Transport type code",
+                        "name": "code",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Transport type rules",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.transportTypeRulesRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.transportTypeDTO"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid input",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "internal error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Удаляет тип транспорта, если на нём нет ни одного курьера",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "transport-types"
+                ],
+                "summary": "Удалить тип транспорта",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Transport type code",
+                        "name": "code",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "status ok",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.StatusResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "transport type is in use",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "internal error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
         "/courier": {
             "post": {
-                "description": "Частично обновляет данные курьера по телу запроса",
+                "description": "Частично обновляет данные курьера по телу запроса",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "couriers"
+                ],
+                "summary": "Обновить курьера",
+                "parameters": [
+                    {
+                        "description": "Create courier payload",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.createCourierRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "status ok",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.StatusResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid input",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "phone already exists",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "internal error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/courier/{id}": {
+            "get": {
+                "description": "Возвращает курьера по идентификатору",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "couriers"
+                ],
+                "summary": "Получить курьера по ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Courier ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.courierDTO"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid id",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "internal error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/courier/{id}/deliveries": {
+            "get": {
+                "description": "Возвращает доставки курьера, начиная с последних, с опциональной пагинацией (limit/offset).\nС active=true возвращаются только незавершённые доставки",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "deliveries"
+                ],
+                "summary": "Доставки курьера",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Courier ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only active deliveries",
+                        "name": "active",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 500,
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "Limit",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "minimum": 0,
+                        "type": "integer",
+                        "description": "Offset",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/handlers.deliveryDTO"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "invalid input",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "internal error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/courier/{id}/location": {
+            "put": {
+                "description": "Сохраняет последние координаты курьера, по ним выбирается ближайший курьер при назначении",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "couriers"
+                ],
+                "summary": "Обновить местоположение курьера",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Courier ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Courier coordinates",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.locationDTO"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "status ok",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.StatusResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid input",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "internal error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/couriers": {
+            "get": {
+                "description": "Возвращает список курьеров с опциональной пагинацией (limit/offset)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "couriers"
+                ],
+                "summary": "Список курьеров",
+                "parameters": [
+                    {
+                        "minimum": 0,
+                        "type": "integer",
+                        "description": "Limit",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "minimum": 0,
+                        "type": "integer",
+                        "description": "Offset",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/handlers.courierDTO"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "invalid limit/offset",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "internal error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/deliveries/overdue": {
+            "get": {
+                "description": "Возвращает активные доставки с истёкшим дедлайном, начиная с самых просроченных,\nс опциональной пагинацией (limit/offset)",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "deliveries"
+                ],
+                "summary": "Просроченные доставки",
+                "parameters": [
+                    {
+                        "maximum": 500,
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "Limit",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "minimum": 0,
+                        "type": "integer",
+                        "description": "Offset",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/handlers.deliveryDTO"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "invalid input",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "internal error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/delivery/assign": {
+            "post": {
+                "description": "Назначает курьера на заказ по order_id. Если переданы координаты pickup,\nвыбирается ближайший свободный курьер в пределах радиуса назначения",
                 "consumes": [
                     "application/json"
                 ],
@@ -25,25 +585,26 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "couriers"
+                    "deliveries"
                 ],
-                "summary": "Обновить курьера",
+                "summary": "Назначить доставку",
                 "parameters": [
                     {
-                        "description": "Create courier payload",
+                        "description": "Assign delivery payload",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handlers.createCourierRequest"
+                            "$ref": "#/definitions/handlers.assignDeliveryRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "status ok",
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/handlers.StatusResponse"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
                     "400": {
@@ -52,14 +613,8 @@ const docTemplate = `{
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "not found",
-                        "schema": {
-                            "$ref": "#/definitions/handlers.ErrorResponse"
-                        }
-                    },
                     "409": {
-                        "description": "phone already exists",
+                        "description": "no available couriers",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
@@ -73,40 +628,51 @@ const docTemplate = `{
                 }
             }
         },
-        "/courier/{id}": {
-            "get": {
-                "description": "Возвращает курьера по идентификатору",
+        "/delivery/complete": {
+            "post": {
+                "description": "Переводит доставку по order_id в статус delivered и освобождает курьера",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "couriers"
+                    "deliveries"
                 ],
-                "summary": "Получить курьера по ID",
+                "summary": "Завершить доставку",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Courier ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Delivery status payload",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.deliveryStatusRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/handlers.courierDTO"
+                            "$ref": "#/definitions/handlers.deliveryStatusResponse"
                         }
                     },
                     "400": {
-                        "description": "invalid id",
+                        "description": "invalid input",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
                     "404": {
-                        "description": "not found",
+                        "description": "delivery not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "transition not allowed",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
@@ -120,44 +686,51 @@ const docTemplate = `{
                 }
             }
         },
-        "/couriers": {
-            "get": {
-                "description": "Возвращает список курьеров с опциональной пагинацией (limit/offset)",
+        "/delivery/fail": {
+            "post": {
+                "description": "Переводит доставку по order_id в статус failed и освобождает курьера",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "couriers"
+                    "deliveries"
                 ],
-                "summary": "Список курьеров",
+                "summary": "Отметить доставку как неудачную",
                 "parameters": [
                     {
-                        "minimum": 0,
-                        "type": "integer",
-                        "description": "Limit",
-                        "name": "limit",
-                        "in": "query"
-                    },
-                    {
-                        "minimum": 0,
-                        "type": "integer",
-                        "description": "Offset",
-                        "name": "offset",
-                        "in": "query"
+                        "description": "Delivery status payload",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.deliveryStatusRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/handlers.courierDTO"
-                            }
+                            "$ref": "#/definitions/handlers.deliveryStatusResponse"
                         }
                     },
                     "400": {
-                        "description": "invalid limit/offset",
+                        "description": "invalid input",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "delivery not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "transition not allowed",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
@@ -171,9 +744,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/delivery/assign": {
+        "/delivery/pickup": {
             "post": {
-                "description": "Назначает курьера на заказ по order_id",
+                "description": "Переводит доставку по order_id в статус picked_up",
                 "consumes": [
                     "application/json"
                 ],
@@ -183,15 +756,15 @@ const docTemplate = `{
                 "tags": [
                     "deliveries"
                 ],
-                "summary": "Назначить доставку",
+                "summary": "Отметить заказ как забранный",
                 "parameters": [
                     {
-                        "description": "Assign delivery payload",
+                        "description": "Delivery status payload",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handlers.assignDeliveryRequest"
+                            "$ref": "#/definitions/handlers.deliveryStatusRequest"
                         }
                     }
                 ],
@@ -199,8 +772,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/handlers.deliveryStatusResponse"
                         }
                     },
                     "400": {
@@ -209,8 +781,14 @@ const docTemplate = `{
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
+                    "404": {
+                        "description": "delivery not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
                     "409": {
-                        "description": "no available couriers",
+                        "description": "transition not allowed",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
@@ -268,6 +846,59 @@ const docTemplate = `{
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
+                    "409": {
+                        "description": "delivery is not active",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "internal error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/delivery/{order_id}": {
+            "get": {
+                "description": "Возвращает последнюю доставку заказа по order_id",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "deliveries"
+                ],
+                "summary": "Получить доставку по заказу",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Order ID",
+                        "name": "order_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.deliveryDTO"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid input",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "delivery not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "internal error",
                         "schema": {
@@ -374,12 +1005,19 @@ const docTemplate = `{
             "properties": {
                 "order_id": {
                     "type": "string"
+                },
+                "pickup": {
+                    "$ref": "#/definitions/handlers.locationDTO"
                 }
             }
         },
         "handlers.courierDTO": {
             "type": "object",
             "properties": {
+                "capacity": {
+                    "type": "integer",
+                    "example": 1
+                },
                 "id": {
                     "type": "integer",
                     "example": 1
@@ -413,6 +1051,10 @@ const docTemplate = `{
         "handlers.createCourierRequest": {
             "type": "object",
             "properties": {
+                "capacity": {
+                    "type": "integer",
+                    "example": 2
+                },
                 "name": {
                     "type": "string",
                     "example": "Иван"
@@ -439,6 +1081,124 @@ const docTemplate = `{
                 }
             }
         },
+        "handlers.deliveryDTO": {
+            "type": "object",
+            "properties": {
+                "assigned_at": {
+                    "type": "string"
+                },
+                "canceled_at": {
+                    "type": "string"
+                },
+                "courier_id": {
+                    "type": "integer"
+                },
+                "deadline": {
+                    "type": "string"
+                },
+                "delivered_at": {
+                    "type": "string"
+                },
+                "expired_at": {
+                    "type": "string"
+                },
+                "failed_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "order_id": {
+                    "type": "string"
+                },
+                "picked_up_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.deliveryStatusRequest": {
+            "type": "object",
+            "properties": {
+                "order_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.deliveryStatusResponse": {
+            "type": "object",
+            "properties": {
+                "changed_at": {
+                    "type": "string"
+                },
+                "courier_id": {
+                    "type": "integer"
+                },
+                "order_id": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.locationDTO": {
+            "type": "object",
+            "properties": {
+                "lat": {
+                    "type": "number",
+                    "example": 55.7558
+                },
+                "lon": {
+                    "type": "number",
+                    "example": 37.6173
+                }
+            }
+        },
+        "handlers.transportTypeDTO": {
+            "type": "object",
+            "properties": {
+                "base_deadline_seconds": {
+                    "type": "integer",
+                    "example": 1200
+                },
+                "code": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/domain.CourierTransportType"
+                        }
+                    ],
+                    "example": "bike"
+                },
+                "max_capacity": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "speed_kmh": {
+                    "type": "number",
+                    "example": 15
+                }
+            }
+        },
+        "handlers.transportTypeRulesRequest": {
+            "type": "object",
+            "properties": {
+                "base_deadline_seconds": {
+                    "type": "integer",
+                    "example": 1200
+                },
+                "max_capacity": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "speed_kmh": {
+                    "type": "number",
+                    "example": 15
+                }
+            }
+        },
         "handlers.unassignDeliveryRequest": {
             "type": "object",
             "properties": {
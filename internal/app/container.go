@@ -12,7 +12,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"go.uber.org/dig"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/connectivity"
 
 	"course-go-avito-Orurh/internal/config"
 	ordersgw "course-go-avito-Orurh/internal/gateway/orders"
@@ -25,15 +25,36 @@ import (
 	"course-go-avito-Orurh/internal/repository"
 	"course-go-avito-Orurh/internal/service/courier"
 	"course-go-avito-Orurh/internal/service/delivery"
+	"course-go-avito-Orurh/internal/service/dispatch"
 	"course-go-avito-Orurh/internal/service/orders"
+	"course-go-avito-Orurh/internal/service/transporttype"
 	"course-go-avito-Orurh/internal/transport/kafka"
 )
 
 type metricsOut struct {
 	dig.Out
 
-	RateLimitExceededTotal prometheus.Counter `name:"rate_limit_exceeded_total"`
-	GatewayRetriesTotal    prometheus.Counter `name:"gateway_retries_total"`
+	RateLimitExceededTotal     prometheus.Counter     `name:"rate_limit_exceeded_total"`
+	GatewayRetriesTotal        prometheus.Counter     `name:"gateway_retries_total"`
+	GatewayBreakerState        prometheus.Gauge       `name:"gateway_breaker_state"`
+	GatewayCacheHitsTotal      prometheus.Counter     `name:"gateway_cache_hits_total"`
+	GatewayCacheMissesTotal    prometheus.Counter     `name:"gateway_cache_misses_total"`
+	DeliveryReassignmentsTotal prometheus.Counter     `name:"delivery_reassignments_total"`
+	DeliveryOverduePickedUp    prometheus.Gauge       `name:"delivery_overdue_picked_up"`
+	KafkaConsumerRetriesTotal  *prometheus.CounterVec `name:"kafka_consumer_retries_total"`
+	KafkaConsumerGiveUpsTotal  *prometheus.CounterVec `name:"kafka_consumer_give_ups_total"`
+	OrdersOutOfOrderTotal      *prometheus.CounterVec `name:"orders_out_of_order_events_total"`
+	OrdersPayloadFallbacks     *prometheus.CounterVec `name:"orders_payload_fallbacks_total"`
+	OrdersVerificationsTotal   *prometheus.CounterVec `name:"orders_verifications_total"`
+	OrdersReconcileDriftFound  *prometheus.CounterVec `name:"orders_reconcile_drift_found_total"`
+	OrdersReconcileDriftFixed  *prometheus.CounterVec `name:"orders_reconcile_drift_fixed_total"`
+
+	KafkaConsumerConsumedTotal *prometheus.CounterVec   `name:"kafka_consumer_messages_consumed_total"`
+	KafkaConsumerHandledTotal  *prometheus.CounterVec   `name:"kafka_consumer_messages_handled_total"`
+	KafkaConsumerSkippedTotal  *prometheus.CounterVec   `name:"kafka_consumer_messages_skipped_total"`
+	KafkaConsumerFailedTotal   *prometheus.CounterVec   `name:"kafka_consumer_messages_failed_total"`
+	KafkaConsumerHandlerTime   *prometheus.HistogramVec `name:"kafka_consumer_handler_duration_seconds"`
+	KafkaConsumerLag           *prometheus.GaugeVec     `name:"kafka_consumer_lag"`
 }
 
 // MustBuildWorkerContainer builds and returns a new dig container
@@ -128,6 +149,72 @@ func (b *ContainerBuilder) buildWorker(ctx context.Context) (*dig.Container, err
 	return container, nil
 }
 
+// MustBuildReinjectContainer builds a container for the worker "reinject" subcommand.
+// It holds only what moving dead letters needs, no database or HTTP.
+func MustBuildReinjectContainer(ctx context.Context) *dig.Container {
+	b := NewContainerBuilder()
+	container, err := b.buildReinject(ctx)
+	if err != nil {
+		b.logFatalf("failed to build reinject container: %v", err)
+	}
+	return container
+}
+
+func (b *ContainerBuilder) buildReinject(ctx context.Context) (*dig.Container, error) {
+	container := dig.New()
+
+	if err := registerCore(container, ctx); err != nil {
+		return nil, fmt.Errorf("core: %w", err)
+	}
+	err := provideAll(container, func(cfg *config.Config, logger logx.Logger) (*kafka.Reinjector, error) {
+		k := cfg.Kafka
+		return kafka.NewReinjector(logger, k.Brokers, kafkaClientConfig(k), k.GroupID+"-reinject", k.DLQTopic, k.Topic)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("reinject: %w", err)
+	}
+	return container, nil
+}
+
+// MustBuildReplayContainer builds a container for the worker "replay" subcommand: the worker
+// pipeline without the consumer, fed by a kafka.Replayer. An empty opts.Group defaults to
+// KAFKA_GROUP_ID with a "-replay" suffix.
+func MustBuildReplayContainer(ctx context.Context, opts kafka.ReplayOptions) *dig.Container {
+	b := NewContainerBuilder()
+	container, err := b.buildReplay(ctx, opts)
+	if err != nil {
+		b.logFatalf("failed to build replay container: %v", err)
+	}
+	return container
+}
+
+func (b *ContainerBuilder) buildReplay(ctx context.Context, opts kafka.ReplayOptions) (*dig.Container, error) {
+	container, err := b.buildWorker(ctx)
+	if err != nil {
+		return nil, err
+	}
+	err = provideAll(container,
+		func(cfg *config.Config, logger logx.Logger, h kafka.HandleFunc, dec *kafka.Decoder, tx *repository.TxRunner) (*kafka.Replayer, error) {
+			k := cfg.Kafka
+			if opts.Group == "" {
+				opts.Group = k.GroupID + "-replay"
+			}
+			if opts.Group == k.GroupID {
+				return nil, fmt.Errorf("replay group must differ from the worker group %q", k.GroupID)
+			}
+			r, err := kafka.NewReplayer(logger, k.Brokers, kafkaClientConfig(k), k.Topic, opts, makeReplayKafka(h, tx, opts.DryRun))
+			if err != nil {
+				return nil, err
+			}
+			return r.WithDecoder(dec), nil
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("replay: %w", err)
+	}
+	return container, nil
+}
+
 // MustBuildContainer builds and returns a new dig container
 func MustBuildContainer(ctx context.Context) *dig.Container {
 	return NewContainerBuilder().MustBuild(ctx)
@@ -164,46 +251,159 @@ func registerDb(
 	return provideAll(container, providerDB)
 }
 
+type deliveryServiceIn struct {
+	dig.In
+	Repo            *repository.DeliveryRepo
+	Timeout         time.Duration
+	Factory         delivery.TimeFactory
+	Strategy        delivery.AssignmentStrategy
+	Types           delivery.TransportTypes
+	Signal          *dispatch.Signal
+	Logger          logx.Logger
+	Reassignments   prometheus.Counter `name:"delivery_reassignments_total" optional:"true"`
+	OverduePickedUp prometheus.Gauge   `name:"delivery_overdue_picked_up" optional:"true"`
+}
+
 func registerDomainServices(container *dig.Container) error {
 	return provideAll(container,
 		repository.NewCourierRepo,
 		repository.NewDeliveryRepo,
+		repository.NewTransportTypeRepo,
 
 		func() time.Duration { return 3 * time.Second },
-		func(repo *repository.CourierRepo, timeout time.Duration) *courier.Service {
-			return courier.NewService(repo, timeout)
+		func(cfg *config.Config, repo *repository.TransportTypeRepo, logger logx.Logger) *transporttype.Catalog {
+			return transporttype.NewCatalog(repo, cfg.Delivery.TransportTypesRefresh, logger)
+		},
+		func(catalog *transporttype.Catalog) delivery.TransportTypes { return catalog },
+		func(repo *repository.TransportTypeRepo, catalog *transporttype.Catalog, timeout time.Duration) *transporttype.Service {
+			return transporttype.NewService(repo, catalog, timeout)
 		},
-		delivery.NewTimeFactory,
+		dispatch.NewSignal,
 		func(
-			repo *repository.DeliveryRepo,
+			repo *repository.CourierRepo,
+			tx *repository.DeliveryRepo,
+			catalog *transporttype.Catalog,
 			timeout time.Duration,
-			factory delivery.TimeFactory,
-			logger logx.Logger,
-		) *delivery.Service {
-			return delivery.NewDeliveryService(repo, factory, timeout, logger)
+			sig *dispatch.Signal,
+		) *courier.Service {
+			return courier.NewService(repo, tx, catalog, timeout).WithCourierAvailableHook(sig.Notify)
+		},
+		func(cfg *config.Config, types delivery.TransportTypes) (delivery.TimeFactory, error) {
+			policy, err := delivery.LoadDeadlinePolicy(cfg.Delivery.DeadlinePolicyFile)
+			if err != nil {
+				return nil, err
+			}
+			return delivery.NewRuleTimeFactory(policy, types)
+		},
+		func(cfg *config.Config) (delivery.AssignmentStrategy, error) {
+			return delivery.NewAssignmentStrategy(cfg.Delivery.AssignStrategy, cfg.Delivery.AssignRadiusKm)
+		},
+		func(in deliveryServiceIn) *delivery.Service {
+			svc := delivery.NewDeliveryService(in.Repo, in.Factory, in.Strategy, in.Timeout, in.Logger).
+				WithCourierAvailableHook(in.Signal.Notify).
+				WithTransportTypes(in.Types)
+			if in.Reassignments != nil {
+				svc.WithReassignCounter(in.Reassignments)
+			}
+			if in.OverduePickedUp != nil {
+				svc.WithOverduePickedUpGauge(in.OverduePickedUp)
+			}
+			return svc
+		},
+		func(cfg *config.Config, svc *delivery.Service, sig *dispatch.Signal, logger logx.Logger) *dispatch.Dispatcher {
+			return dispatch.NewDispatcher(svc, sig, cfg.Delivery.DispatchInterval, cfg.Delivery.DispatchBatchSize, logger)
 		},
 	)
 }
 
+type kafkaConsumerIn struct {
+	dig.In
+	Cfg     *config.Config
+	Handler kafka.HandleFunc
+	Decoder *kafka.Decoder
+	Logger  logx.Logger
+	Retries *prometheus.CounterVec `name:"kafka_consumer_retries_total"`
+	GiveUps *prometheus.CounterVec `name:"kafka_consumer_give_ups_total"`
+
+	Consumed *prometheus.CounterVec   `name:"kafka_consumer_messages_consumed_total"`
+	Handled  *prometheus.CounterVec   `name:"kafka_consumer_messages_handled_total"`
+	Skipped  *prometheus.CounterVec   `name:"kafka_consumer_messages_skipped_total"`
+	Failed   *prometheus.CounterVec   `name:"kafka_consumer_messages_failed_total"`
+	Duration *prometheus.HistogramVec `name:"kafka_consumer_handler_duration_seconds"`
+	Lag      *prometheus.GaugeVec     `name:"kafka_consumer_lag"`
+}
+
+type ordersProcessorIn struct {
+	dig.In
+	Delivery   *delivery.Service
+	Ledger     *repository.ProcessedEventRepo
+	Versions   *repository.OrderVersionRepo
+	Logger     logx.Logger
+	OutOfOrder *prometheus.CounterVec `name:"orders_out_of_order_events_total"`
+}
+
 func registerWorker(container *dig.Container) error {
 	return provideAll(container,
 		provideOrdersGateway,
-		func(deliverySvc orders.DeliveryPort, repo *repository.DeliveryRepo) *orders.Processor {
-			return orders.NewProcessorWithDeps(deliverySvc, repo)
+		repository.NewProcessedEventRepo,
+		repository.NewOrderVersionRepo,
+		repository.NewOrderVerificationRepo,
+		repository.NewTxRunner,
+		func(in ordersProcessorIn) *orders.Processor {
+			return orders.NewProcessorWithDeps(in.Delivery).
+				WithLedger(in.Ledger).
+				WithVersions(in.Versions, in.OutOfOrder).
+				WithLogger(in.Logger)
+		},
+		func(p *orders.Processor) ordersHandler { return p },
+
+		repository.NewOutboxRepo,
+		func(cfg *config.Config, logger logx.Logger, store *repository.OutboxRepo) (*kafka.OutboxRelay, error) {
+			k := cfg.Kafka
+			return kafka.NewOutboxRelay(logger, k.Brokers, kafkaClientConfig(k), k.EventsTopic, store, k.Outbox.Interval, k.Outbox.BatchSize)
 		},
 
+		providePayloadFallback,
+		provideOrderVerifier,
+		repository.NewWatermarkRepo,
+		provideReconciler,
 		makeOrdersKafka,
+		provideOrderDecoder,
 
-		func(cfg *config.Config, h kafka.HandleFunc, logger logx.Logger) (*kafka.Consumer, error) {
-			c, err := kafka.NewConsumer(logger, cfg.Kafka.Brokers, cfg.Kafka.GroupID, cfg.Kafka.Topic, h)
+		func(in kafkaConsumerIn) (*kafka.Consumer, error) {
+			cfg := in.Cfg
+			client := kafkaClientConfig(cfg.Kafka)
+			c, err := kafka.NewConsumer(in.Logger, cfg.Kafka.Brokers, client, cfg.Kafka.GroupID, cfg.Kafka.Topic, in.Handler)
 			if err != nil {
 				return nil, err
 			}
 			if c == nil {
 				return nil, fmt.Errorf("kafka config is missing: worker requires KAFKA_BROKERS/KAFKA_GROUP_ID/KAFKA_TOPIC")
 			}
-			return c, nil
+			dlq, err := kafka.NewDeadLetterWriter(cfg.Kafka.Brokers, client, cfg.Kafka.DLQTopic)
+			if err != nil {
+				_ = c.Close()
+				return nil, err
+			}
+			retry := kafka.RetryConfig{
+				MaxAttempts: cfg.Kafka.Retry.MaxAttempts,
+				BaseDelay:   cfg.Kafka.Retry.BaseDelay,
+				MaxDelay:    cfg.Kafka.Retry.MaxDelay,
+			}
+			metrics := kafka.Metrics{
+				Consumed: in.Consumed,
+				Handled:  in.Handled,
+				Skipped:  in.Skipped,
+				Failed:   in.Failed,
+				Duration: in.Duration,
+				Lag:      in.Lag,
+			}
+			return c.WithDeadLetters(dlq).WithRetry(retry, in.Retries, in.GiveUps).
+				WithConcurrency(cfg.Kafka.Concurrency).
+				WithDecoder(in.Decoder).
+				WithMetrics(metrics), nil
 		},
+		newWorkerServer,
 	)
 }
 
@@ -245,6 +445,8 @@ func registerHTTP(container *dig.Container) error {
 		handlers.NewCourierHandler,
 		handlers.NewDeliveryUsecase,
 		handlers.NewDeliveryHandler,
+		handlers.NewTransportTypeUsecase,
+		handlers.NewTransportTypeHandler,
 		newRateLimitClock,
 		newRateLimiter,
 		newRateLimitMiddleware,
@@ -255,28 +457,54 @@ func registerHTTP(container *dig.Container) error {
 
 type ordersConnCloser func() error
 
+// ordersConnProbe reports whether the orders service can be reached
+type ordersConnProbe func(ctx context.Context) error
+
 type ordersGatewayIn struct {
 	dig.In
 	Ctx     context.Context
 	Cfg     *config.Config
 	Logger  logx.Logger
 	Retries prometheus.Counter `name:"gateway_retries_total"`
+	Breaker prometheus.Gauge   `name:"gateway_breaker_state"`
+	Hits    prometheus.Counter `name:"gateway_cache_hits_total"`
+	Misses  prometheus.Counter `name:"gateway_cache_misses_total"`
+}
+
+// ordersClient is the orders gateway the decorators wrap
+type ordersClient interface {
+	GetByID(ctx context.Context, id string) (*ordersgw.Order, error)
+	ListFrom(ctx context.Context, from time.Time) ([]ordersgw.Order, error)
 }
 
-func provideOrdersGateway(in ordersGatewayIn) (ordersGateway, ordersConnCloser, error) {
+func provideOrdersGateway(in ordersGatewayIn) (ordersGateway, ordersConnCloser, ordersConnProbe, error) {
 	addr := strings.TrimSpace(in.Cfg.OrderService)
 
 	if addr == "" {
-		return nil, nil, nil
+		return nil, nil, nil, nil
+	}
+	opts, err := ordersClientConfig(in.Cfg.OrdersGateway.Client).DialOptions()
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("provideOrdersGateway grpc: %w", err)
 	}
-	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	conn, err := grpc.NewClient(addr, opts...)
 	if err != nil {
-		return nil, nil, fmt.Errorf("provideOrdersGateway grpc: %w", err)
+		return nil, nil, nil, fmt.Errorf("provideOrdersGateway grpc: %w", err)
 	}
 	client := ordersproto.NewOrdersServiceClient(conn)
-	base := ordersgw.NewGRPCGateway(client)
+	var base ordersClient = ordersgw.NewGRPCGateway(client)
+	if b := in.Cfg.OrdersGateway.Breaker; b.Enabled {
+		// inside the retrier: every attempt is counted and an open breaker stops the retries
+		base = ordersgw.NewBreakerGateway(base, in.Logger, in.Breaker, ordersgw.BreakerConfig{
+			Window:           b.Window,
+			MinRequests:      b.MinRequests,
+			FailureRatio:     b.FailureRatio,
+			CoolDown:         b.CoolDown,
+			HalfOpenRequests: b.HalfOpenRequests,
+		})
+	}
 
-	gw := ordersgw.NewRetryingGateway(
+	var gw ordersClient = ordersgw.NewRetryingGateway(
 		base,
 		in.Logger,
 		in.Retries,
@@ -286,38 +514,182 @@ func provideOrdersGateway(in ordersGatewayIn) (ordersGateway, ordersConnCloser,
 			MaxDelay:    in.Cfg.OrdersGateway.MaxDelay,
 		},
 	)
-	return gw, func() error { return conn.Close() }, nil
+	if c := in.Cfg.OrdersGateway.Cache; c.Enabled {
+		// outside the retrier: a hit makes no call at all and coalesced lookups share the retries
+		gw = ordersgw.NewCachingGateway(gw, in.Hits, in.Misses, ordersgw.CacheConfig{
+			TTL:         c.TTL,
+			NegativeTTL: c.NegativeTTL,
+			Size:        c.Size,
+		})
+	}
+	return gw, func() error { return conn.Close() }, grpcConnProbe(conn), nil
 }
 
-func provideMetrics() (metricsOut, error) {
-	rl := prometrics.NewRateLimitExceededTotal()
-	if err := prometheus.Register(rl); err != nil {
-		var are prometheus.AlreadyRegisteredError
-		if !errors.As(err, &are) {
-			return metricsOut{}, fmt.Errorf("register rate_limit_exceeded_total: %w", err)
-		}
-		existing, ok := are.ExistingCollector.(prometheus.Counter)
-		if !ok {
-			return metricsOut{}, fmt.Errorf("register rate_limit_exceeded_total: %w", err)
+// ordersClientConfig is how the gRPC client connects to the orders service
+func ordersClientConfig(c config.OrdersClient) ordersgw.ClientConfig {
+	return ordersgw.ClientConfig{
+		TLS: ordersgw.TLSConfig{
+			Enabled:    c.TLS.Enabled,
+			CAFile:     c.TLS.CAFile,
+			CertFile:   c.TLS.CertFile,
+			KeyFile:    c.TLS.KeyFile,
+			ServerName: c.TLS.ServerName,
+		},
+		Auth: ordersgw.AuthConfig{
+			Token:        c.Token,
+			APIKey:       c.APIKey,
+			APIKeyHeader: c.APIKeyHeader,
+		},
+		KeepaliveTime:    c.KeepaliveTime,
+		KeepaliveTimeout: c.KeepaliveTimeout,
+		CallTimeout:      c.CallTimeout,
+	}
+}
+
+// grpcConnProbe waits until conn is ready, connecting an idle one, or ctx is done.
+func grpcConnProbe(conn *grpc.ClientConn) ordersConnProbe {
+	return func(ctx context.Context) error {
+		for {
+			state := conn.GetState()
+			switch state {
+			case connectivity.Ready:
+				return nil
+			case connectivity.Shutdown:
+				return errors.New("connection is closed")
+			case connectivity.Idle:
+				conn.Connect()
+			}
+			if !conn.WaitForStateChange(ctx, state) {
+				return fmt.Errorf("connection is %s", strings.ToLower(state.String()))
+			}
 		}
-		rl = existing
 	}
+}
 
-	gr := prometrics.NewGatewayRetriesTotal()
-	if err := prometheus.Register(gr); err != nil {
+func provideMetrics() (metricsOut, error) {
+	rl, err := registerCounter("rate_limit_exceeded_total", prometrics.NewRateLimitExceededTotal())
+	if err != nil {
+		return metricsOut{}, err
+	}
+	gr, err := registerCounter("gateway_retries_total", prometrics.NewGatewayRetriesTotal())
+	if err != nil {
+		return metricsOut{}, err
+	}
+	gb, err := registerCollector("gateway_breaker_state", prometrics.NewGatewayBreakerState())
+	if err != nil {
+		return metricsOut{}, err
+	}
+	gch, err := registerCounter("gateway_cache_hits_total", prometrics.NewGatewayCacheHitsTotal())
+	if err != nil {
+		return metricsOut{}, err
+	}
+	gcm, err := registerCounter("gateway_cache_misses_total", prometrics.NewGatewayCacheMissesTotal())
+	if err != nil {
+		return metricsOut{}, err
+	}
+	dr, err := registerCounter("delivery_reassignments_total", prometrics.NewDeliveryReassignmentsTotal())
+	if err != nil {
+		return metricsOut{}, err
+	}
+	dop, err := registerCollector("delivery_overdue_picked_up", prometrics.NewDeliveryOverduePickedUp())
+	if err != nil {
+		return metricsOut{}, err
+	}
+	kr, err := registerCollector("kafka_consumer_retries_total", prometrics.NewKafkaConsumerRetriesTotal())
+	if err != nil {
+		return metricsOut{}, err
+	}
+	kg, err := registerCollector("kafka_consumer_give_ups_total", prometrics.NewKafkaConsumerGiveUpsTotal())
+	if err != nil {
+		return metricsOut{}, err
+	}
+	oo, err := registerCollector("orders_out_of_order_events_total", prometrics.NewOrdersOutOfOrderEventsTotal())
+	if err != nil {
+		return metricsOut{}, err
+	}
+	pf, err := registerCollector("orders_payload_fallbacks_total", prometrics.NewOrdersPayloadFallbacksTotal())
+	if err != nil {
+		return metricsOut{}, err
+	}
+	ov, err := registerCollector("orders_verifications_total", prometrics.NewOrdersVerificationsTotal())
+	if err != nil {
+		return metricsOut{}, err
+	}
+	rdf, err := registerCollector("orders_reconcile_drift_found_total", prometrics.NewOrdersReconcileDriftFoundTotal())
+	if err != nil {
+		return metricsOut{}, err
+	}
+	rdx, err := registerCollector("orders_reconcile_drift_fixed_total", prometrics.NewOrdersReconcileDriftFixedTotal())
+	if err != nil {
+		return metricsOut{}, err
+	}
+	kc, err := registerCollector("kafka_consumer_messages_consumed_total", prometrics.NewKafkaConsumerMessagesConsumedTotal())
+	if err != nil {
+		return metricsOut{}, err
+	}
+	kh, err := registerCollector("kafka_consumer_messages_handled_total", prometrics.NewKafkaConsumerMessagesHandledTotal())
+	if err != nil {
+		return metricsOut{}, err
+	}
+	ks, err := registerCollector("kafka_consumer_messages_skipped_total", prometrics.NewKafkaConsumerMessagesSkippedTotal())
+	if err != nil {
+		return metricsOut{}, err
+	}
+	kf, err := registerCollector("kafka_consumer_messages_failed_total", prometrics.NewKafkaConsumerMessagesFailedTotal())
+	if err != nil {
+		return metricsOut{}, err
+	}
+	kd, err := registerCollector("kafka_consumer_handler_duration_seconds", prometrics.NewKafkaConsumerHandlerDurationSeconds())
+	if err != nil {
+		return metricsOut{}, err
+	}
+	kl, err := registerCollector("kafka_consumer_lag", prometrics.NewKafkaConsumerLag())
+	if err != nil {
+		return metricsOut{}, err
+	}
+
+	return metricsOut{
+		RateLimitExceededTotal:     rl,
+		GatewayRetriesTotal:        gr,
+		GatewayBreakerState:        gb,
+		GatewayCacheHitsTotal:      gch,
+		GatewayCacheMissesTotal:    gcm,
+		DeliveryReassignmentsTotal: dr,
+		DeliveryOverduePickedUp:    dop,
+		KafkaConsumerRetriesTotal:  kr,
+		KafkaConsumerGiveUpsTotal:  kg,
+		OrdersOutOfOrderTotal:      oo,
+		OrdersPayloadFallbacks:     pf,
+		OrdersVerificationsTotal:   ov,
+		OrdersReconcileDriftFound:  rdf,
+		OrdersReconcileDriftFixed:  rdx,
+		KafkaConsumerConsumedTotal: kc,
+		KafkaConsumerHandledTotal:  kh,
+		KafkaConsumerSkippedTotal:  ks,
+		KafkaConsumerFailedTotal:   kf,
+		KafkaConsumerHandlerTime:   kd,
+		KafkaConsumerLag:           kl,
+	}, nil
+}
+
+// registerCounter registers c in the default registry and returns the already registered counter, if any.
+func registerCounter(name string, c prometheus.Counter) (prometheus.Counter, error) {
+	return registerCollector(name, c)
+}
+
+// registerCollector registers c in the default registry and returns the already registered collector, if any.
+func registerCollector[T prometheus.Collector](name string, c T) (T, error) {
+	if err := prometheus.Register(c); err != nil {
+		var zero T
 		var are prometheus.AlreadyRegisteredError
 		if !errors.As(err, &are) {
-			return metricsOut{}, fmt.Errorf("register gateway_retries_total: %w", err)
+			return zero, fmt.Errorf("register %s: %w", name, err)
 		}
-		existing, ok := are.ExistingCollector.(prometheus.Counter)
+		existing, ok := are.ExistingCollector.(T)
 		if !ok {
-			return metricsOut{}, fmt.Errorf("register gateway_retries_total: %w", err)
+			return zero, fmt.Errorf("register %s: %w", name, err)
 		}
-		gr = existing
+		return existing, nil
 	}
-
-	return metricsOut{
-		RateLimitExceededTotal: rl,
-		GatewayRetriesTotal:    gr,
-	}, nil
+	return c, nil
 }
@@ -97,6 +97,23 @@ func TestProvideMetrics_Success_RegistersAndReturnsCounters(t *testing.T) {
 	require.NoError(t, err)
 	require.NotNil(t, out.RateLimitExceededTotal)
 	require.NotNil(t, out.GatewayRetriesTotal)
+	require.NotNil(t, out.GatewayBreakerState)
+	require.NotNil(t, out.GatewayCacheHitsTotal)
+	require.NotNil(t, out.GatewayCacheMissesTotal)
+	require.NotNil(t, out.DeliveryReassignmentsTotal)
+	require.NotNil(t, out.KafkaConsumerRetriesTotal)
+	require.NotNil(t, out.KafkaConsumerGiveUpsTotal)
+	require.NotNil(t, out.OrdersPayloadFallbacks)
+	require.NotNil(t, out.OrdersVerificationsTotal)
+	require.NotNil(t, out.OrdersReconcileDriftFound)
+	require.NotNil(t, out.OrdersReconcileDriftFixed)
+	require.NotNil(t, out.OrdersOutOfOrderTotal)
+	require.NotNil(t, out.KafkaConsumerConsumedTotal)
+	require.NotNil(t, out.KafkaConsumerHandledTotal)
+	require.NotNil(t, out.KafkaConsumerSkippedTotal)
+	require.NotNil(t, out.KafkaConsumerFailedTotal)
+	require.NotNil(t, out.KafkaConsumerHandlerTime)
+	require.NotNil(t, out.KafkaConsumerLag)
 }
 
 func TestProvideMetrics_AlreadyRegistered_ReturnsExistingCounters(t *testing.T) {
@@ -113,15 +130,18 @@ func TestProvideMetrics_AlreadyRegistered_ReturnsExistingCounters(t *testing.T)
 	// те же метрики юзаем
 	existingRL := prometrics.NewRateLimitExceededTotal()
 	existingGR := prometrics.NewGatewayRetriesTotal()
+	existingKR := prometrics.NewKafkaConsumerRetriesTotal()
 
 	require.NoError(t, reg.Register(existingRL))
 	require.NoError(t, reg.Register(existingGR))
+	require.NoError(t, reg.Register(existingKR))
 
 	out, err := provideMetrics()
 	require.NoError(t, err)
 
 	require.Same(t, existingRL, out.RateLimitExceededTotal)
 	require.Same(t, existingGR, out.GatewayRetriesTotal)
+	require.Same(t, existingKR, out.KafkaConsumerRetriesTotal)
 }
 
 type errRegisterer struct{ err error }
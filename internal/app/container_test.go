@@ -13,8 +13,12 @@ import (
 	"go.uber.org/dig"
 
 	"course-go-avito-Orurh/internal/config"
+	ordersgw "course-go-avito-Orurh/internal/gateway/orders"
 	"course-go-avito-Orurh/internal/http/handlers"
 	"course-go-avito-Orurh/internal/logx"
+	"course-go-avito-Orurh/internal/service/delivery"
+	"course-go-avito-Orurh/internal/service/dispatch"
+	"course-go-avito-Orurh/internal/transport/kafka"
 )
 
 func newTestLogger() logx.Logger {
@@ -99,6 +103,37 @@ func TestRegisterServiceAndHTTP_ProvidesHttpServerAndHandlers(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestRegisterDomainServices_UnknownAssignStrategy_ReturnsError(t *testing.T) {
+	t.Parallel()
+
+	c := dig.New()
+	require.NoError(t, c.Provide(newTestLogger))
+	require.NoError(t, c.Provide(func() *config.Config {
+		return &config.Config{Delivery: config.Delivery{AssignStrategy: "fastest"}}
+	}))
+	require.NoError(t, c.Provide(func() *pgxpool.Pool { return &pgxpool.Pool{} }))
+	require.NoError(t, registerDomainServices(c))
+
+	err := c.Invoke(func(*delivery.Service) {})
+	require.ErrorContains(t, err, `unknown assignment strategy "fastest"`)
+}
+
+func TestRegisterDomainServices_ProvidesDispatcher(t *testing.T) {
+	t.Parallel()
+
+	c := dig.New()
+	require.NoError(t, c.Provide(newTestLogger))
+	require.NoError(t, c.Provide(func() *config.Config { return &config.Config{} }))
+	require.NoError(t, c.Provide(func() *pgxpool.Pool { return &pgxpool.Pool{} }))
+	require.NoError(t, registerDomainServices(c))
+
+	err := c.Invoke(func(d *dispatch.Dispatcher, sig *dispatch.Signal) {
+		require.NotNil(t, d)
+		require.NotNil(t, sig)
+	})
+	require.NoError(t, err)
+}
+
 func TestProvideAll_Success(t *testing.T) {
 	t.Parallel()
 
@@ -266,10 +301,63 @@ func TestProvideOrdersGateway_EmptyAddr_ReturnsNil(t *testing.T) {
 		}),
 	}
 
-	gw, closer, err := provideOrdersGateway(in)
+	gw, closer, probe, err := provideOrdersGateway(in)
 	require.NoError(t, err)
 	require.Nil(t, gw)
 	require.Nil(t, closer)
+	require.Nil(t, probe)
+}
+
+func TestProvideOrdersGateway_CacheWrapsRetrier(t *testing.T) {
+	t.Parallel()
+
+	cfg := &config.Config{OrderService: "service-order:50051", OrdersGateway: config.DefaultOrdersGateway()}
+	in := ordersGatewayIn{Ctx: context.Background(), Cfg: cfg, Logger: logx.Nop()}
+
+	gw, closer, _, err := provideOrdersGateway(in)
+	require.NoError(t, err)
+	require.IsType(t, &ordersgw.CachingGateway{}, gw)
+	require.NoError(t, closer())
+
+	cfg.OrdersGateway.Cache.Enabled = false
+	gw, closer, _, err = provideOrdersGateway(in)
+	require.NoError(t, err)
+	require.IsType(t, &ordersgw.RetryingGateway{}, gw)
+	require.NoError(t, closer())
+}
+
+func TestOrdersClientConfig_MapsConfig(t *testing.T) {
+	t.Parallel()
+
+	got := ordersClientConfig(config.OrdersClient{
+		TLS: config.OrdersTLS{
+			Enabled: true, CAFile: "/ca.pem", CertFile: "/c.pem", KeyFile: "/k.pem", ServerName: "orders.internal",
+		},
+		APIKey:           "k",
+		APIKeyHeader:     "x-api-key",
+		KeepaliveTime:    30 * time.Second,
+		KeepaliveTimeout: 5 * time.Second,
+		CallTimeout:      time.Second,
+	})
+	require.Equal(t, ordersgw.ClientConfig{
+		TLS: ordersgw.TLSConfig{
+			Enabled: true, CAFile: "/ca.pem", CertFile: "/c.pem", KeyFile: "/k.pem", ServerName: "orders.internal",
+		},
+		Auth:             ordersgw.AuthConfig{APIKey: "k", APIKeyHeader: "x-api-key"},
+		KeepaliveTime:    30 * time.Second,
+		KeepaliveTimeout: 5 * time.Second,
+		CallTimeout:      time.Second,
+	}, got)
+}
+
+func TestProvideOrdersGateway_InvalidClientConfig(t *testing.T) {
+	t.Parallel()
+
+	cfg := &config.Config{OrderService: "service-order:50051", OrdersGateway: config.DefaultOrdersGateway()}
+	cfg.OrdersGateway.Client.TLS = config.OrdersTLS{Enabled: true, CAFile: "/missing/ca.pem"}
+
+	_, _, _, err := provideOrdersGateway(ordersGatewayIn{Ctx: context.Background(), Cfg: cfg, Logger: logx.Nop()})
+	require.ErrorContains(t, err, "tls ca")
 }
 
 func TestProvideMetrics_AlreadyRegistered_WrongCollectorType_ReturnsError(t *testing.T) {
@@ -308,3 +396,70 @@ func TestContainerBuilder_BuildWorker_Success(t *testing.T) {
 	require.NoError(t, err)
 	require.NotNil(t, c)
 }
+
+func TestContainerBuilder_BuildWorker_ResolvesKafkaHandler(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	builder := NewContainerBuilder().
+		WithDBConnect(func(context.Context, logx.Logger, string, int, time.Duration) (*pgxpool.Pool, error) {
+			return &pgxpool.Pool{}, nil
+		})
+
+	c, err := builder.buildWorker(ctx)
+	require.NoError(t, err)
+
+	err = c.Invoke(func(h kafka.HandleFunc) {
+		require.NotNil(t, h)
+	})
+	require.NoError(t, err)
+}
+
+func TestContainerBuilder_BuildReinject_HasNoDatabase(t *testing.T) {
+	t.Parallel()
+
+	c, err := NewContainerBuilder().buildReinject(context.Background())
+	require.NoError(t, err)
+
+	err = c.Invoke(func(*pgxpool.Pool) {})
+	require.Error(t, err, "reinject does not connect to the database")
+}
+
+func TestContainerBuilder_BuildReplay_RejectsWorkerGroup(t *testing.T) {
+	t.Setenv("KAFKA_BROKERS", "localhost:9092")
+	t.Setenv("KAFKA_GROUP_ID", "courier")
+
+	builder := NewContainerBuilder().
+		WithDBConnect(func(context.Context, logx.Logger, string, int, time.Duration) (*pgxpool.Pool, error) {
+			return &pgxpool.Pool{}, nil
+		})
+
+	c, err := builder.buildReplay(context.Background(), kafka.ReplayOptions{Group: "courier", FromOffset: 0})
+	require.NoError(t, err)
+
+	err = c.Invoke(func(*kafka.Replayer) {})
+	require.ErrorContains(t, err, "must differ from the worker group")
+}
+
+func TestContainerBuilder_BuildWorker_ProvidesWorkerServer(t *testing.T) {
+	t.Setenv("WORKER_HTTP_ADDR", ":19091")
+
+	builder := NewContainerBuilder().
+		WithDBConnect(func(context.Context, logx.Logger, string, int, time.Duration) (*pgxpool.Pool, error) {
+			return &pgxpool.Pool{}, nil
+		})
+
+	c, err := builder.buildWorker(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, c.Decorate(func() *kafka.Consumer { return &kafka.Consumer{} }))
+
+	type in struct {
+		dig.In
+		Server *http.Server `name:"worker_server"`
+	}
+	err = c.Invoke(func(i in) {
+		require.Equal(t, ":19091", i.Server.Addr)
+	})
+	require.NoError(t, err)
+}
@@ -0,0 +1,80 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.uber.org/dig"
+
+	"course-go-avito-Orurh/internal/config"
+	"course-go-avito-Orurh/internal/http/health"
+	"course-go-avito-Orurh/internal/http/pprofserver"
+	"course-go-avito-Orurh/internal/transport/kafka"
+)
+
+type workerServerIn struct {
+	dig.In
+	Cfg         *config.Config
+	Pool        *pgxpool.Pool
+	Consumer    *kafka.Consumer
+	OrdersProbe ordersConnProbe `optional:"true"`
+}
+
+type dbPinger interface {
+	Ping(ctx context.Context) error
+}
+
+type groupMember interface {
+	Member() bool
+}
+
+// workerChecks lists what /readyz verifies: the database answers, the consumer holds a group
+// session and, when configured, the orders service is reachable.
+func workerChecks(db dbPinger, consumer groupMember, orders ordersConnProbe) []health.Check {
+	checks := []health.Check{
+		{Name: "db", Fn: db.Ping},
+		{Name: "kafka", Fn: func(context.Context) error {
+			if !consumer.Member() {
+				return errors.New("not a member of the consumer group")
+			}
+			return nil
+		}},
+	}
+	if orders != nil {
+		checks = append(checks, health.Check{Name: "orders", Fn: orders})
+	}
+	return checks
+}
+
+type workerServerOut struct {
+	dig.Out
+	Server *http.Server `name:"worker_server"`
+}
+
+// newWorkerServer builds the worker HTTP server: metrics, liveness and readiness probes
+// and, when PPROF_ENABLED is set, pprof under /debug/pprof/.
+func newWorkerServer(in workerServerIn) workerServerOut {
+	checks := workerChecks(in.Pool, in.Consumer, in.OrdersProbe)
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", promhttp.Handler())
+	mux.Handle("GET /healthz", health.Liveness())
+	mux.Handle("GET /readyz", health.Readiness(in.Cfg.Worker.ReadyTimeout, checks...))
+	if p := in.Cfg.Pprof; p.Enabled {
+		mux.Handle("/debug/pprof/", pprofserver.Handler(pprofserver.Config{User: p.User, Pass: p.Pass}))
+	}
+
+	return workerServerOut{Server: &http.Server{
+		Addr:              in.Cfg.Worker.HTTPAddr,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+		ReadTimeout:       15 * time.Second,
+		// pprof profiles stream for up to 30 seconds by default
+		WriteTimeout: 60 * time.Second,
+		IdleTimeout:  60 * time.Second,
+	}}
+}
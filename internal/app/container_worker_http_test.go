@@ -0,0 +1,103 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"course-go-avito-Orurh/internal/config"
+	"course-go-avito-Orurh/internal/http/health"
+	"course-go-avito-Orurh/internal/transport/kafka"
+)
+
+func serveWorker(t *testing.T, srv *http.Server, path string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.RemoteAddr = "127.0.0.1:40000"
+	rr := httptest.NewRecorder()
+	srv.Handler.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestNewWorkerServer_Routes(t *testing.T) {
+	t.Parallel()
+
+	cfg := &config.Config{Worker: config.Worker{HTTPAddr: ":9091", ReadyTimeout: time.Second}}
+	out := newWorkerServer(workerServerIn{Cfg: cfg, Consumer: &kafka.Consumer{}})
+	require.Equal(t, ":9091", out.Server.Addr)
+
+	require.Equal(t, http.StatusOK, serveWorker(t, out.Server, "/healthz").Code)
+	require.Equal(t, http.StatusOK, serveWorker(t, out.Server, "/metrics").Code)
+	require.Equal(t, http.StatusNotFound, serveWorker(t, out.Server, "/debug/pprof/").Code, "pprof is off by default")
+
+	cfg.Pprof.Enabled = true
+	out = newWorkerServer(workerServerIn{Cfg: cfg, Consumer: &kafka.Consumer{}})
+	require.Equal(t, http.StatusOK, serveWorker(t, out.Server, "/debug/pprof/").Code)
+}
+
+type stubPinger struct{ err error }
+
+func (p stubPinger) Ping(context.Context) error { return p.err }
+
+type stubMember bool
+
+func (m stubMember) Member() bool { return bool(m) }
+
+func readyz(t *testing.T, checks []health.Check) (int, health.Report) {
+	t.Helper()
+	rr := httptest.NewRecorder()
+	health.Readiness(time.Second, checks...).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	var rep health.Report
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&rep))
+	return rr.Code, rep
+}
+
+func TestWorkerChecks_AllReady(t *testing.T) {
+	t.Parallel()
+
+	code, rep := readyz(t, workerChecks(stubPinger{}, stubMember(true), func(context.Context) error { return nil }))
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, map[string]string{"db": "ok", "kafka": "ok", "orders": "ok"}, rep.Checks)
+}
+
+func TestWorkerChecks_ReportsEachFailure(t *testing.T) {
+	t.Parallel()
+
+	code, rep := readyz(t, workerChecks(stubPinger{err: errors.New("db down")}, stubMember(false), nil))
+	require.Equal(t, http.StatusServiceUnavailable, code)
+	require.Equal(t, map[string]string{
+		"db":    "db down",
+		"kafka": "not a member of the consumer group",
+	}, rep.Checks, "the orders check is skipped when the gateway is not configured")
+}
+
+func TestGrpcConnProbe_ClosedConnection(t *testing.T) {
+	t.Parallel()
+
+	conn, err := grpc.NewClient("127.0.0.1:1", grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	require.NoError(t, conn.Close())
+
+	err = grpcConnProbe(conn)(context.Background())
+	require.EqualError(t, err, "connection is closed")
+}
+
+func TestGrpcConnProbe_UnreachableTimesOut(t *testing.T) {
+	t.Parallel()
+
+	conn, err := grpc.NewClient("127.0.0.1:1", grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	require.Error(t, grpcConnProbe(conn)(ctx))
+}
@@ -2,41 +2,268 @@ package app
 
 import (
 	"context"
+	"fmt"
+	"strings"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/dig"
+
+	"course-go-avito-Orurh/internal/config"
+	"course-go-avito-Orurh/internal/domain"
 	ordersgw "course-go-avito-Orurh/internal/gateway/orders"
+	"course-go-avito-Orurh/internal/logx"
+	"course-go-avito-Orurh/internal/repository"
 	"course-go-avito-Orurh/internal/service/orders"
 	"course-go-avito-Orurh/internal/transport/kafka"
 )
 
 type ordersGateway interface {
 	GetByID(ctx context.Context, id string) (*ordersgw.Order, error)
+	ListFrom(ctx context.Context, from time.Time) ([]ordersgw.Order, error)
+}
+
+// orderCache is implemented by the orders gateway when its lookups are cached
+type orderCache interface {
+	Invalidate(id string)
+	ObserveStatus(id, status string, at time.Time)
 }
 
 type ordersHandler interface {
 	Handle(context.Context, orders.Event) error
 }
 
-func makeOrdersKafka(h ordersHandler, gw ordersGateway) kafka.HandleFunc {
+// ordersLookupTimeout bounds a single lookup of an order in the orders service
+const ordersLookupTimeout = 2 * time.Second
+
+// makeOrdersKafka takes the status of the order from the orders service. While the orders service
+// is unavailable the event is retried until it is back, or applied from its payload if fb is set.
+func makeOrdersKafka(h ordersHandler, gw ordersGateway, fb *payloadFallback) kafka.HandleFunc {
 	return func(ctx context.Context, event orders.Event) error {
 		if gw == nil {
 			return h.Handle(ctx, event)
 		}
 
-		gwCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+		// a cached order older than the event may lack the status the event announces
+		if c, ok := gw.(orderCache); ok {
+			c.ObserveStatus(event.OrderID, event.Status, event.At)
+		}
+
+		gwCtx, cancel := context.WithTimeout(ctx, ordersLookupTimeout)
 		defer cancel()
 
 		ord, err := gw.GetByID(gwCtx, event.OrderID)
 		if err != nil {
-			return err
+			// a canceled consumer is not an outage of the orders service
+			if ctx.Err() != nil || !ordersgw.IsUnavailable(err) {
+				return err
+			}
+			if fb == nil {
+				// strict mode: the event waits unacked for the orders service, an outage never dead-letters it
+				return kafka.Unavailable(err)
+			}
+			return fb.handle(ctx, h, event, err)
 		}
 
 		if ord == nil {
 			return nil
 		}
 
+		// event.At and event.Key stay those of the message: the processor versions the order by them
 		event.Status = ord.Status
 		event.CreatedAt = ord.CreatedAt
 		return h.Handle(ctx, event)
 	}
 }
+
+type txRunner interface {
+	InTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type verificationScheduler interface {
+	Schedule(ctx context.Context, v domain.OrderVerification) error
+}
+
+// payloadFallback applies order events from their own payload while the orders service is
+// unavailable. The event and a later check of its order against the orders service are
+// committed in one transaction, orders.Verifier corrects the order if the check disagrees.
+type payloadFallback struct {
+	tx      txRunner
+	queue   verificationScheduler
+	delay   time.Duration
+	logger  logx.Logger
+	applied *prometheus.CounterVec
+	now     func() time.Time
+}
+
+type payloadFallbackIn struct {
+	dig.In
+	Cfg     *config.Config
+	Tx      *repository.TxRunner
+	Queue   *repository.OrderVerificationRepo
+	Logger  logx.Logger
+	Applied *prometheus.CounterVec `name:"orders_payload_fallbacks_total"`
+}
+
+// providePayloadFallback returns nil in the strict mode
+func providePayloadFallback(in payloadFallbackIn) *payloadFallback {
+	fb := in.Cfg.OrdersGateway.Fallback
+	if fb.Mode != config.FallbackTrustPayload {
+		return nil
+	}
+	return &payloadFallback{
+		tx:      in.Tx,
+		queue:   in.Queue,
+		delay:   fb.VerifyDelay,
+		logger:  in.Logger,
+		applied: in.Applied,
+		now:     time.Now,
+	}
+}
+
+func (f *payloadFallback) handle(ctx context.Context, h ordersHandler, event orders.Event, cause error) error {
+	err := f.tx.InTx(ctx, func(ctx context.Context) error {
+		if err := h.Handle(ctx, event); err != nil {
+			return err
+		}
+		return f.queue.Schedule(ctx, domain.OrderVerification{
+			OrderID: event.OrderID,
+			Status:  event.Status,
+			At:      event.At,
+			DueAt:   f.now().Add(f.delay),
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	f.logger.Warn("orders service unavailable, order event applied from its payload",
+		logx.String("order_id", event.OrderID),
+		logx.String("status", event.Status),
+		logx.Duration("verify_in", f.delay),
+		logx.Any("err", cause),
+	)
+	if f.applied != nil {
+		label := kafka.ReasonUnknownStatus
+		if orders.KnownStatus(event.Status) {
+			label = strings.ToLower(strings.TrimSpace(event.Status))
+		}
+		f.applied.WithLabelValues(label).Inc()
+	}
+	return nil
+}
+
+// txOrdersHandler applies every event in its own transaction
+type txOrdersHandler struct {
+	h  ordersHandler
+	tx txRunner
+}
+
+func (t txOrdersHandler) Handle(ctx context.Context, e orders.Event) error {
+	return t.tx.InTx(ctx, func(ctx context.Context) error {
+		return t.h.Handle(ctx, e)
+	})
+}
+
+// gatewayStatuses looks orders up for orders.Verifier
+type gatewayStatuses struct{ gw ordersGateway }
+
+func (s gatewayStatuses) Status(ctx context.Context, orderID string) (string, error) {
+	// a check must not be confirmed by the cache
+	if c, ok := s.gw.(orderCache); ok {
+		c.Invalidate(orderID)
+	}
+	ctx, cancel := context.WithTimeout(ctx, ordersLookupTimeout)
+	defer cancel()
+
+	ord, err := s.gw.GetByID(ctx, orderID)
+	if err != nil || ord == nil {
+		return "", err
+	}
+	return ord.Status, nil
+}
+
+type orderVerifierIn struct {
+	dig.In
+	Cfg      *config.Config
+	Gateway  ordersGateway
+	Queue    *repository.OrderVerificationRepo
+	Handler  ordersHandler
+	Tx       *repository.TxRunner
+	Logger   logx.Logger
+	Outcomes *prometheus.CounterVec `name:"orders_verifications_total"`
+}
+
+// provideOrderVerifier returns nil unless the worker applies events from their payload
+func provideOrderVerifier(in orderVerifierIn) *orders.Verifier {
+	fb := in.Cfg.OrdersGateway.Fallback
+	if fb.Mode != config.FallbackTrustPayload || in.Gateway == nil {
+		return nil
+	}
+	return orders.NewVerifier(
+		in.Queue,
+		gatewayStatuses{gw: in.Gateway},
+		txOrdersHandler{h: in.Handler, tx: in.Tx},
+		fb.VerifyInterval,
+		fb.VerifyDelay,
+		fb.VerifyBatch,
+		in.Logger,
+	).WithMetrics(in.Outcomes)
+}
+
+type replayTx interface {
+	InTx(ctx context.Context, fn func(ctx context.Context) error) error
+	DryRun(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// makeReplayKafka adapts the worker pipeline h for replays. Every event runs in its own
+// transaction, rolled back on a dry run. The event key is dropped: the ledger has already
+// recorded replayed events and would skip them all, order versions still keep the state from going back.
+func makeReplayKafka(h kafka.HandleFunc, tx replayTx, dryRun bool) kafka.HandleFunc {
+	run := tx.InTx
+	if dryRun {
+		run = tx.DryRun
+	}
+	return func(ctx context.Context, event orders.Event) error {
+		event.Key = domain.EventKey{}
+		return run(ctx, func(ctx context.Context) error {
+			return h(ctx, event)
+		})
+	}
+}
+
+// provideOrderDecoder decodes order events by their content-type header, events without it
+// are decoded in the configured encoding
+func provideOrderDecoder(cfg *config.Config) (*kafka.Decoder, error) {
+	switch cfg.Kafka.Encoding {
+	case "", "json":
+		return kafka.NewDecoder(kafka.ContentTypeJSON)
+	case "protobuf":
+		return kafka.NewDecoder(kafka.ContentTypeProtobuf)
+	default:
+		return nil, fmt.Errorf("unknown order event encoding %q", cfg.Kafka.Encoding)
+	}
+}
+
+// kafkaClientConfig is how every Kafka client of the worker connects to the cluster
+func kafkaClientConfig(k config.Kafka) kafka.ClientConfig {
+	c := k.Client
+	return kafka.ClientConfig{
+		ClientID: c.ClientID,
+		Version:  c.Version,
+		TLS: kafka.TLSConfig{
+			Enabled:            c.TLS.Enabled,
+			CAFile:             c.TLS.CAFile,
+			CertFile:           c.TLS.CertFile,
+			KeyFile:            c.TLS.KeyFile,
+			InsecureSkipVerify: c.TLS.InsecureSkipVerify,
+		},
+		SASL:           kafka.SASLConfig{Mechanism: c.SASL.Mechanism, User: c.SASL.User, Password: c.SASL.Pass},
+		Rebalance:      c.Rebalance,
+		SessionTimeout: c.SessionTimeout,
+		FetchMin:       int32(c.FetchMinBytes),
+		FetchDefault:   int32(c.FetchDefaultBytes),
+		FetchMax:       int32(c.FetchMaxBytes),
+		InitialOffset:  c.InitialOffset,
+	}
+}
@@ -3,13 +3,23 @@ package app
 import (
 	"context"
 	"errors"
+	"fmt"
 	"testing"
 	"time"
 
+	"course-go-avito-Orurh/internal/config"
+	"course-go-avito-Orurh/internal/domain"
 	ordersgw "course-go-avito-Orurh/internal/gateway/orders"
 	"course-go-avito-Orurh/internal/service/orders"
+	testlog "course-go-avito-Orurh/internal/testutil"
+	"course-go-avito-Orurh/internal/transport/kafka"
 
+	"github.com/IBM/sarama"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type ctxKey struct{}
@@ -30,6 +40,7 @@ func (s *spyHandler) Handle(ctx context.Context, e orders.Event) error {
 
 type stubOrdersGateway struct {
 	getFn       func(ctx context.Context, id string) (*ordersgw.Order, error)
+	listFn      func(ctx context.Context, from time.Time) ([]ordersgw.Order, error)
 	capturedCtx context.Context
 	capturedID  string
 }
@@ -43,6 +54,13 @@ func (g *stubOrdersGateway) GetByID(ctx context.Context, id string) (*ordersgw.O
 	return g.getFn(ctx, id)
 }
 
+func (g *stubOrdersGateway) ListFrom(ctx context.Context, from time.Time) ([]ordersgw.Order, error) {
+	if g.listFn == nil {
+		return nil, nil
+	}
+	return g.listFn(ctx, from)
+}
+
 func requireTimeout2s(t *testing.T, ctx context.Context) {
 	t.Helper()
 	deadline, ok := ctx.Deadline()
@@ -66,7 +84,7 @@ func TestMakeOrdersKafka_NoGateway_DelegatesToHandler(t *testing.T) {
 	t.Parallel()
 
 	hSpy := &spyHandler{}
-	h := makeOrdersKafka(hSpy, nil)
+	h := makeOrdersKafka(hSpy, nil, nil)
 
 	ctx := context.WithValue(context.Background(), ctxKey{}, "v")
 	in := orders.Event{OrderID: "order-1", Status: "created"}
@@ -91,7 +109,7 @@ func TestMakeOrdersKafka_GatewayError_ReturnsError_AndDoesNotCallHandler(t *test
 		},
 	}
 
-	h := makeOrdersKafka(hSpy, gw)
+	h := makeOrdersKafka(hSpy, gw, nil)
 
 	ctx := context.WithValue(context.Background(), ctxKey{}, "v")
 	err := h(ctx, orders.Event{OrderID: "order-2", Status: "created"})
@@ -104,6 +122,26 @@ func TestMakeOrdersKafka_GatewayError_ReturnsError_AndDoesNotCallHandler(t *test
 	requireCanceled(t, gw.capturedCtx)
 }
 
+func TestMakeOrdersKafka_Strict_OrdersServiceDownIsTransient(t *testing.T) {
+	t.Parallel()
+
+	for name, cause := range map[string]error{
+		"unavailable":  status.Error(codes.Unavailable, "down"),
+		"circuit open": ordersgw.ErrCircuitOpen,
+	} {
+		hSpy := &spyHandler{}
+		gw := &stubOrdersGateway{
+			getFn: func(context.Context, string) (*ordersgw.Order, error) { return nil, cause },
+		}
+
+		err := makeOrdersKafka(hSpy, gw, nil)(context.Background(), orders.Event{OrderID: "order-2", Status: "created"})
+		var uerr kafka.UnavailableError
+		require.ErrorAs(t, err, &uerr, name)
+		require.ErrorIs(t, err, cause, name)
+		require.Zero(t, hSpy.called, name)
+	}
+}
+
 func TestMakeOrdersKafka_OrderNotFound_ReturnsNil_AndDoesNotCallHandler(t *testing.T) {
 	t.Parallel()
 
@@ -114,7 +152,7 @@ func TestMakeOrdersKafka_OrderNotFound_ReturnsNil_AndDoesNotCallHandler(t *testi
 		},
 	}
 
-	h := makeOrdersKafka(hSpy, gw)
+	h := makeOrdersKafka(hSpy, gw, nil)
 
 	ctx := context.WithValue(context.Background(), ctxKey{}, "v")
 	err := h(ctx, orders.Event{OrderID: "order-3", Status: "created"})
@@ -143,7 +181,7 @@ func TestMakeOrdersKafka_OrderFound_OverridesEvent_AndCallsHandler(t *testing.T)
 		},
 	}
 
-	h := makeOrdersKafka(hSpy, gw)
+	h := makeOrdersKafka(hSpy, gw, nil)
 
 	ctx := context.WithValue(context.Background(), ctxKey{}, "v")
 	in := orders.Event{OrderID: "order-4", Status: "created"}
@@ -162,3 +200,277 @@ func TestMakeOrdersKafka_OrderFound_OverridesEvent_AndCallsHandler(t *testing.T)
 	requireTimeout2s(t, gw.capturedCtx)
 	requireCanceled(t, gw.capturedCtx)
 }
+
+type stubScheduler struct {
+	scheduled []domain.OrderVerification
+	err       error
+}
+
+func (s *stubScheduler) Schedule(_ context.Context, v domain.OrderVerification) error {
+	s.scheduled = append(s.scheduled, v)
+	return s.err
+}
+
+func newTestFallback(t *testing.T, queue *stubScheduler) (*payloadFallback, *stubReplayTx, *prometheus.CounterVec, *testlog.Recorder) {
+	t.Helper()
+	tx := &stubReplayTx{}
+	applied := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "test_payload_fallbacks_total"}, []string{"status"})
+	rec := testlog.New()
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	return &payloadFallback{
+		tx:      tx,
+		queue:   queue,
+		delay:   time.Minute,
+		logger:  rec.Logger(),
+		applied: applied,
+		now:     func() time.Time { return now },
+	}, tx, applied, rec
+}
+
+func TestMakeOrdersKafka_TrustPayload_AppliesEventAndSchedulesVerification(t *testing.T) {
+	t.Parallel()
+
+	hSpy := &spyHandler{}
+	queue := &stubScheduler{}
+	fb, tx, applied, rec := newTestFallback(t, queue)
+	gw := &stubOrdersGateway{
+		getFn: func(context.Context, string) (*ordersgw.Order, error) {
+			return nil, fmt.Errorf("order gateway: %w", ordersgw.ErrCircuitOpen)
+		},
+	}
+
+	at := time.Date(2025, 1, 2, 3, 0, 0, 0, time.UTC)
+	in := orders.Event{OrderID: "order-7", Status: "Created", At: at}
+	require.NoError(t, makeOrdersKafka(hSpy, gw, fb)(context.Background(), in))
+
+	require.Equal(t, 1, hSpy.called)
+	require.Equal(t, in, hSpy.event, "the payload is applied as is")
+	require.Equal(t, 1, tx.committed, "the event and its verification are committed together")
+	require.Equal(t, []domain.OrderVerification{{
+		OrderID: "order-7",
+		Status:  "Created",
+		At:      at,
+		DueAt:   time.Date(2025, 1, 2, 3, 5, 5, 0, time.UTC),
+	}}, queue.scheduled)
+	require.InDelta(t, 1, testutil.ToFloat64(applied.WithLabelValues("created")), 0)
+
+	entries := rec.Entries()
+	require.Len(t, entries, 1)
+	require.Equal(t, "orders service unavailable, order event applied from its payload", entries[0].Msg)
+}
+
+func TestMakeOrdersKafka_TrustPayload_OnlyWhenOrdersServiceUnavailable(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		err    error
+		cancel bool
+	}{
+		"not found":         {err: status.Error(codes.NotFound, "no order")},
+		"other error":       {err: errors.New("gw boom")},
+		"consumer canceled": {err: status.Error(codes.Unavailable, "down"), cancel: true},
+	} {
+		hSpy := &spyHandler{}
+		queue := &stubScheduler{}
+		fb, _, _, _ := newTestFallback(t, queue)
+		gw := &stubOrdersGateway{
+			getFn: func(context.Context, string) (*ordersgw.Order, error) { return nil, tc.err },
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		if tc.cancel {
+			cancel()
+		}
+		err := makeOrdersKafka(hSpy, gw, fb)(ctx, orders.Event{OrderID: "order-8", Status: "created"})
+		cancel()
+		require.ErrorIs(t, err, tc.err, name)
+		require.Zero(t, hSpy.called, name)
+		require.Empty(t, queue.scheduled, name)
+	}
+}
+
+func TestMakeOrdersKafka_TrustPayload_HandlerErrorSchedulesNothing(t *testing.T) {
+	t.Parallel()
+
+	sentinel := errors.New("db down")
+	hSpy := &spyHandler{err: sentinel}
+	queue := &stubScheduler{}
+	fb, _, applied, _ := newTestFallback(t, queue)
+	gw := &stubOrdersGateway{
+		getFn: func(context.Context, string) (*ordersgw.Order, error) {
+			return nil, status.Error(codes.Unavailable, "down")
+		},
+	}
+
+	err := makeOrdersKafka(hSpy, gw, fb)(context.Background(), orders.Event{OrderID: "order-9", Status: "created"})
+	require.ErrorIs(t, err, sentinel)
+	require.Empty(t, queue.scheduled)
+	require.Zero(t, testutil.CollectAndCount(applied))
+}
+
+func TestGatewayStatuses(t *testing.T) {
+	t.Parallel()
+
+	gw := &stubOrdersGateway{
+		getFn: func(_ context.Context, id string) (*ordersgw.Order, error) {
+			if id == "known" {
+				return &ordersgw.Order{ID: id, Status: "completed"}, nil
+			}
+			return nil, nil
+		},
+	}
+	s := gatewayStatuses{gw: gw}
+
+	got, err := s.Status(context.Background(), "known")
+	require.NoError(t, err)
+	require.Equal(t, "completed", got)
+	requireTimeout2s(t, gw.capturedCtx)
+
+	got, err = s.Status(context.Background(), "missing")
+	require.NoError(t, err)
+	require.Empty(t, got)
+}
+
+func TestMakeOrdersKafka_NewerStatusInvalidatesCachedOrder(t *testing.T) {
+	t.Parallel()
+
+	calls, status := 0, "created"
+	gw := ordersgw.NewCachingGateway(&stubOrdersGateway{
+		getFn: func(_ context.Context, id string) (*ordersgw.Order, error) {
+			calls++
+			return &ordersgw.Order{ID: id, Status: status}, nil
+		},
+	}, nil, nil, ordersgw.CacheConfig{TTL: time.Minute, Size: 10})
+	hSpy := &spyHandler{}
+	h := makeOrdersKafka(hSpy, gw, nil)
+
+	require.NoError(t, h(context.Background(), orders.Event{OrderID: "o1", Status: "created"}))
+	require.NoError(t, h(context.Background(), orders.Event{OrderID: "o1", Status: "created"}))
+	require.Equal(t, 1, calls, "a repeated event is served from the cache")
+
+	status = "canceled"
+	require.NoError(t, h(context.Background(), orders.Event{OrderID: "o1", Status: "canceled"}))
+	require.Equal(t, 2, calls)
+	require.Equal(t, "canceled", hSpy.event.Status)
+}
+
+func TestGatewayStatuses_BypassesCache(t *testing.T) {
+	t.Parallel()
+
+	calls := 0
+	gw := ordersgw.NewCachingGateway(&stubOrdersGateway{
+		getFn: func(_ context.Context, id string) (*ordersgw.Order, error) {
+			calls++
+			return &ordersgw.Order{ID: id, Status: "created"}, nil
+		},
+	}, nil, nil, ordersgw.CacheConfig{TTL: time.Minute, Size: 10})
+	s := gatewayStatuses{gw: gw}
+
+	for range 2 {
+		_, err := s.Status(context.Background(), "o1")
+		require.NoError(t, err)
+	}
+	require.Equal(t, 2, calls)
+}
+
+func TestProvideOrderVerifier_OnlyInTrustPayloadMode(t *testing.T) {
+	t.Parallel()
+
+	cfg := &config.Config{OrdersGateway: config.DefaultOrdersGateway()}
+	require.Nil(t, provideOrderVerifier(orderVerifierIn{Cfg: cfg, Gateway: &stubOrdersGateway{}}))
+
+	cfg.OrdersGateway.Fallback.Mode = config.FallbackTrustPayload
+	require.Nil(t, provideOrderVerifier(orderVerifierIn{Cfg: cfg}), "there is nothing to verify against")
+	require.NotNil(t, provideOrderVerifier(orderVerifierIn{Cfg: cfg, Gateway: &stubOrdersGateway{}, Logger: testlog.New().Logger()}))
+}
+
+type stubReplayTx struct {
+	committed, rolledBack int
+}
+
+func (s *stubReplayTx) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	s.committed++
+	return fn(ctx)
+}
+
+func (s *stubReplayTx) DryRun(ctx context.Context, fn func(ctx context.Context) error) error {
+	s.rolledBack++
+	return fn(ctx)
+}
+
+func TestMakeReplayKafka_RunsInTxWithoutLedgerKey(t *testing.T) {
+	t.Parallel()
+
+	hSpy := &spyHandler{}
+	tx := &stubReplayTx{}
+	h := makeReplayKafka(makeOrdersKafka(hSpy, nil, nil), tx, false)
+
+	in := orders.Event{OrderID: "order-5", Status: "created", Key: domain.EventKey{Topic: "orders", Offset: 7}}
+	require.NoError(t, h(context.Background(), in))
+
+	require.Equal(t, 1, tx.committed)
+	require.Zero(t, tx.rolledBack)
+	require.Equal(t, 1, hSpy.called)
+	require.True(t, hSpy.event.Key.IsZero(), "the ledger would skip an already processed event")
+	require.Equal(t, "order-5", hSpy.event.OrderID)
+}
+
+func TestMakeReplayKafka_DryRunRollsBack(t *testing.T) {
+	t.Parallel()
+
+	sentinel := errors.New("boom")
+	hSpy := &spyHandler{err: sentinel}
+	tx := &stubReplayTx{}
+	h := makeReplayKafka(makeOrdersKafka(hSpy, nil, nil), tx, true)
+
+	require.ErrorIs(t, h(context.Background(), orders.Event{OrderID: "order-6"}), sentinel)
+	require.Equal(t, 1, tx.rolledBack)
+	require.Zero(t, tx.committed)
+}
+
+func TestProvideOrderDecoder_UsesConfiguredEncoding(t *testing.T) {
+	t.Parallel()
+
+	dec, err := provideOrderDecoder(&config.Config{Kafka: config.Kafka{Encoding: "protobuf"}})
+	require.NoError(t, err)
+	_, err = dec.Decode(&sarama.ConsumerMessage{Value: []byte(`{"order_id":"o1"}`)})
+	require.Error(t, err, "a JSON body is not a valid protobuf message")
+
+	dec, err = provideOrderDecoder(&config.Config{Kafka: config.Kafka{Encoding: "json"}})
+	require.NoError(t, err)
+	dto, err := dec.Decode(&sarama.ConsumerMessage{Value: []byte(`{"order_id":"o1"}`)})
+	require.NoError(t, err)
+	require.Equal(t, kafka.EventDTO{OrderID: "o1"}, dto)
+
+	_, err = provideOrderDecoder(&config.Config{Kafka: config.Kafka{Encoding: "avro"}})
+	require.Error(t, err)
+}
+
+func TestKafkaClientConfig_MapsConfig(t *testing.T) {
+	t.Parallel()
+
+	got := kafkaClientConfig(config.Kafka{Client: config.KafkaClient{
+		ClientID:          "courier",
+		Version:           "3.6.0",
+		TLS:               config.KafkaTLS{Enabled: true, CAFile: "/ca.pem", CertFile: "/c.pem", KeyFile: "/k.pem"},
+		SASL:              config.KafkaSASL{Mechanism: "SCRAM-SHA-512", User: "u", Pass: "p"},
+		Rebalance:         "sticky",
+		SessionTimeout:    30 * time.Second,
+		FetchMinBytes:     1,
+		FetchDefaultBytes: 1024,
+		FetchMaxBytes:     4096,
+		InitialOffset:     "newest",
+	}})
+	require.Equal(t, kafka.ClientConfig{
+		ClientID:       "courier",
+		Version:        "3.6.0",
+		TLS:            kafka.TLSConfig{Enabled: true, CAFile: "/ca.pem", CertFile: "/c.pem", KeyFile: "/k.pem"},
+		SASL:           kafka.SASLConfig{Mechanism: "SCRAM-SHA-512", User: "u", Password: "p"},
+		Rebalance:      kafka.RebalanceSticky,
+		SessionTimeout: 30 * time.Second,
+		FetchMin:       1,
+		FetchDefault:   1024,
+		FetchMax:       4096,
+		InitialOffset:  kafka.InitialOffsetNewest,
+	}, got)
+}
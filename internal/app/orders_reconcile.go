@@ -0,0 +1,117 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/dig"
+
+	"course-go-avito-Orurh/internal/config"
+	"course-go-avito-Orurh/internal/logx"
+	"course-go-avito-Orurh/internal/repository"
+	"course-go-avito-Orurh/internal/service/reconcile"
+)
+
+// ordersListTimeout bounds listing the orders of one reconciliation run
+const ordersListTimeout = 30 * time.Second
+
+// gatewayOrders lists orders for reconcile.Reconciler
+type gatewayOrders struct{ gw ordersGateway }
+
+func (s gatewayOrders) ListFrom(ctx context.Context, from time.Time) ([]reconcile.Order, error) {
+	ctx, cancel := context.WithTimeout(ctx, ordersListTimeout)
+	defer cancel()
+
+	list, err := s.gw.ListFrom(ctx, from)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]reconcile.Order, 0, len(list))
+	for _, o := range list {
+		out = append(out, reconcile.Order{ID: o.ID, Status: o.Status, CreatedAt: o.CreatedAt})
+	}
+	return out, nil
+}
+
+type reconcilerIn struct {
+	dig.In
+	Cfg        *config.Config
+	Gateway    ordersGateway
+	Deliveries *repository.DeliveryRepo
+	Watermarks *repository.WatermarkRepo
+	Handler    ordersHandler
+	Tx         *repository.TxRunner
+	Logger     logx.Logger
+	Found      *prometheus.CounterVec `name:"orders_reconcile_drift_found_total"`
+	Fixed      *prometheus.CounterVec `name:"orders_reconcile_drift_fixed_total"`
+}
+
+// provideReconciler returns nil unless the reconciliation job is enabled
+func provideReconciler(in reconcilerIn) *reconcile.Reconciler {
+	if !in.Cfg.OrdersGateway.Reconcile.Enabled || in.Gateway == nil {
+		return nil
+	}
+	return newReconciler(in, false)
+}
+
+// newReconciler fixes drift through the orders pipeline, every order in its own transaction,
+// so order versions keep a fix from overriding a later event
+func newReconciler(in reconcilerIn, dryRun bool) *reconcile.Reconciler {
+	cfg := in.Cfg.OrdersGateway.Reconcile
+	return reconcile.NewReconciler(
+		gatewayOrders{gw: in.Gateway},
+		in.Deliveries,
+		in.Watermarks,
+		txOrdersHandler{h: in.Handler, tx: in.Tx},
+		cfg.Interval,
+		cfg.Lookback,
+		in.Logger,
+	).WithDryRun(dryRun || cfg.DryRun).WithMetrics(in.Found, in.Fixed)
+}
+
+// NewReconcileRunner returns a WorkerRunner that reconciles orders once and prints the report.
+// The job runs whether ORDER_RECONCILE_ENABLED is set or not; dryRun adds to ORDER_RECONCILE_DRY_RUN.
+func NewReconcileRunner(dryRun bool) *WorkerRunner {
+	return &WorkerRunner{runFn: func(container *dig.Container) error {
+		return container.Invoke(func(
+			ctx context.Context,
+			pool *pgxpool.Pool,
+			in reconcilerIn,
+			ordersCloser ordersConnCloser,
+		) error {
+			return reconcileRun(ctx, os.Stdout, pool, in, ordersCloser, dryRun)
+		})
+	}}
+}
+
+func reconcileRun(
+	ctx context.Context,
+	out io.Writer,
+	pool *pgxpool.Pool,
+	in reconcilerIn,
+	ordersCloser ordersConnCloser,
+	dryRun bool,
+) error {
+	defer closeWorker(pool, in.Logger, nil, nil, ordersCloser)
+
+	if in.Gateway == nil {
+		return errors.New("reconcile: ORDER_SERVICE_HOST is not set")
+	}
+	report, err := newReconciler(in, dryRun).Reconcile(ctx)
+	if err != nil {
+		return err
+	}
+	return report.Write(out)
+}
+
+func startReconciler(ctx context.Context, r *reconcile.Reconciler) {
+	if r == nil {
+		return
+	}
+	go r.Run(ctx)
+}
@@ -0,0 +1,51 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"course-go-avito-Orurh/internal/config"
+	ordersgw "course-go-avito-Orurh/internal/gateway/orders"
+	"course-go-avito-Orurh/internal/service/reconcile"
+)
+
+func TestGatewayOrders_MapsOrders(t *testing.T) {
+	t.Parallel()
+
+	from := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	created := from.Add(time.Minute)
+	var captured context.Context
+	gw := &stubOrdersGateway{
+		listFn: func(ctx context.Context, got time.Time) ([]ordersgw.Order, error) {
+			captured = ctx
+			require.Equal(t, from, got)
+			return []ordersgw.Order{{ID: "o1", Status: "created", CreatedAt: created}}, nil
+		},
+	}
+
+	list, err := gatewayOrders{gw: gw}.ListFrom(context.Background(), from)
+	require.NoError(t, err)
+	require.Equal(t, []reconcile.Order{{ID: "o1", Status: "created", CreatedAt: created}}, list)
+	_, ok := captured.Deadline()
+	require.True(t, ok, "listing is bounded by ordersListTimeout")
+
+	sentinel := errors.New("boom")
+	gw.listFn = func(context.Context, time.Time) ([]ordersgw.Order, error) { return nil, sentinel }
+	_, err = gatewayOrders{gw: gw}.ListFrom(context.Background(), from)
+	require.ErrorIs(t, err, sentinel)
+}
+
+func TestProvideReconciler_OnlyWhenEnabled(t *testing.T) {
+	t.Parallel()
+
+	cfg := &config.Config{OrdersGateway: config.DefaultOrdersGateway()}
+	require.Nil(t, provideReconciler(reconcilerIn{Cfg: cfg, Gateway: &stubOrdersGateway{}}))
+
+	cfg.OrdersGateway.Reconcile.Enabled = true
+	require.Nil(t, provideReconciler(reconcilerIn{Cfg: cfg}), "there is nothing to reconcile against")
+	require.NotNil(t, provideReconciler(reconcilerIn{Cfg: cfg, Gateway: &stubOrdersGateway{}}))
+}
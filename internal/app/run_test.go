@@ -20,9 +20,8 @@ import (
 )
 
 type fakeDeliveryRepo struct {
-	mu           sync.Mutex
-	releaseCalls int
-	txCalls      int
+	mu      sync.Mutex
+	txCalls int
 }
 
 func hasMsg(entries []testlog.Entry, msg string) bool {
@@ -41,17 +40,29 @@ func (f *fakeDeliveryRepo) WithTx(ctx context.Context, fn func(delivery.TxReposi
 	return nil
 }
 
-func (f *fakeDeliveryRepo) ReleaseCouriers(ctx context.Context, now time.Time) (int64, error) {
-	f.mu.Lock()
-	f.releaseCalls++
-	f.mu.Unlock()
-	return 0, nil
+func (f *fakeDeliveryRepo) EnqueuePendingOrder(ctx context.Context, p domain.PendingOrder) error {
+	return nil
 }
 
-func (f *fakeDeliveryRepo) ReleaseCalls() int {
-	f.mu.Lock()
-	defer f.mu.Unlock()
-	return f.releaseCalls
+func (f *fakeDeliveryRepo) DeletePendingOrder(ctx context.Context, orderID string) (bool, error) {
+	return false, nil
+}
+
+func (f *fakeDeliveryRepo) GetByOrderID(ctx context.Context, orderID string) (*domain.Delivery, error) {
+	return nil, nil
+}
+
+func (f *fakeDeliveryRepo) ListByCourier(
+	ctx context.Context,
+	courierID int64,
+	activeOnly bool,
+	limit, offset int,
+) ([]domain.Delivery, error) {
+	return nil, nil
+}
+
+func (f *fakeDeliveryRepo) ListOverdue(ctx context.Context, now time.Time, limit, offset int) ([]domain.Delivery, error) {
+	return nil, nil
 }
 
 func (f *fakeDeliveryRepo) TxCalls() int {
@@ -96,7 +107,7 @@ func TestStartAutoReleaseLoop_CallsReleaseExpired(t *testing.T) {
 
 	repo := &fakeDeliveryRepo{}
 	logger := logx.Nop()
-	svc := delivery.NewDeliveryService(repo, fakeTimeFactory{}, time.Second, logger)
+	svc := delivery.NewDeliveryService(repo, fakeTimeFactory{}, nil, time.Second, logger)
 
 	startAutoReleaseLoop(ctx, logger, svc, 10*time.Millisecond)
 
@@ -104,7 +115,7 @@ func TestStartAutoReleaseLoop_CallsReleaseExpired(t *testing.T) {
 		t,
 		500*time.Millisecond,
 		5*time.Millisecond,
-		func() bool { return repo.ReleaseCalls() > 0 },
+		func() bool { return repo.TxCalls() > 0 },
 		"expected ReleaseExpired to be called at least once",
 	)
 	cancel()
@@ -200,7 +211,7 @@ func TestRun_InvokesAppRunViaContainer(t *testing.T) {
 
 	require.NoError(t, container.Provide(func(logger logx.Logger) *delivery.Service {
 		repo := &fakeDeliveryRepo{}
-		return delivery.NewDeliveryService(repo, fakeTimeFactory{}, time.Second, logger)
+		return delivery.NewDeliveryService(repo, fakeTimeFactory{}, nil, time.Second, logger)
 	}))
 
 	require.NoError(t, container.Provide(func() *kafka.Consumer {
@@ -11,6 +11,8 @@ import (
 
 	"course-go-avito-Orurh/internal/logx"
 	"course-go-avito-Orurh/internal/service/delivery"
+	"course-go-avito-Orurh/internal/service/dispatch"
+	"course-go-avito-Orurh/internal/service/transporttype"
 )
 
 type autoReleaseInterval time.Duration
@@ -66,6 +68,8 @@ type appDeps struct {
 	Logger              logx.Logger
 	DeliveryService     *delivery.Service
 	AutoReleaseInterval autoReleaseInterval
+	Dispatcher          *dispatch.Dispatcher   `optional:"true"`
+	TransportTypes      *transporttype.Catalog `optional:"true"`
 
 	OrdersCloser ordersConnCloser `optional:"true"`
 }
@@ -74,6 +78,8 @@ func appRun(d appDeps) error {
 	defer closeResources(d.Pool, d.Server, d.Logger, d.OrdersCloser)
 
 	startAutoReleaseLoop(d.AppCtx, d.Logger, d.DeliveryService, time.Duration(d.AutoReleaseInterval))
+	startDispatcher(d.AppCtx, d.Dispatcher)
+	startTransportCatalog(d.AppCtx, d.TransportTypes)
 
 	serverErrCh := startServer("service-courier", d.Server, d.Logger)
 	pprofServerErrCh := startOptionalPprofServer(d.PprofServer, d.Logger)
@@ -102,6 +108,20 @@ func startAutoReleaseLoop(ctx context.Context, logger logx.Logger, deliveryServi
 	}()
 }
 
+func startDispatcher(ctx context.Context, dispatcher *dispatch.Dispatcher) {
+	if dispatcher == nil {
+		return
+	}
+	go dispatcher.Run(ctx)
+}
+
+func startTransportCatalog(ctx context.Context, catalog *transporttype.Catalog) {
+	if catalog == nil {
+		return
+	}
+	go catalog.Run(ctx)
+}
+
 func startServer(name string, server *http.Server, logger logx.Logger) <-chan error {
 	ch := make(chan error, 1)
 	go func() {
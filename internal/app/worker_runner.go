@@ -4,11 +4,18 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"net/http"
+	"os"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	"go.uber.org/dig"
 
 	"course-go-avito-Orurh/internal/logx"
+	"course-go-avito-Orurh/internal/service/dispatch"
+	"course-go-avito-Orurh/internal/service/orders"
+	"course-go-avito-Orurh/internal/service/reconcile"
+	"course-go-avito-Orurh/internal/service/transporttype"
 	"course-go-avito-Orurh/internal/transport/kafka"
 )
 
@@ -31,32 +38,153 @@ func (r *WorkerRunner) MustRun(container *dig.Container) {
 	panic(err)
 }
 
+// NewReinjectRunner returns a WorkerRunner that moves dead-lettered events back onto the main topic
+func NewReinjectRunner() *WorkerRunner {
+	return &WorkerRunner{runFn: runReinject}
+}
+
+// NewReplayRunner returns a WorkerRunner that replays order events and prints what it did
+func NewReplayRunner() *WorkerRunner {
+	return &WorkerRunner{runFn: runReplay}
+}
+
 func runWorker(container *dig.Container) error {
 	return container.Invoke(workerRun)
 }
 
-func workerRun(
+func runReinject(container *dig.Container) error {
+	return container.Invoke(reinjectRun)
+}
+
+func reinjectRun(ctx context.Context, logger logx.Logger, r *kafka.Reinjector) error {
+	defer func() {
+		if err := r.Close(); err != nil {
+			logger.Error("kafka close error", logx.Any("err", err))
+		}
+	}()
+
+	n, err := r.Run(ctx)
+	logger.Info("kafka dead letters reinjected", logx.Int("count", n))
+	return err
+}
+
+func runReplay(container *dig.Container) error {
+	return container.Invoke(func(
+		ctx context.Context,
+		pool *pgxpool.Pool,
+		logger logx.Logger,
+		r *kafka.Replayer,
+		ordersCloser ordersConnCloser,
+	) error {
+		return replayRun(ctx, os.Stdout, pool, logger, r, ordersCloser)
+	})
+}
+
+func replayRun(
 	ctx context.Context,
+	out io.Writer,
 	pool *pgxpool.Pool,
 	logger logx.Logger,
-	consumer *kafka.Consumer,
+	r *kafka.Replayer,
 	ordersCloser ordersConnCloser,
 ) error {
-	if consumer == nil {
+	defer func() {
+		if err := r.Close(); err != nil {
+			logger.Error("kafka close error", logx.Any("err", err))
+		}
+		closeWorker(pool, logger, nil, nil, ordersCloser)
+	}()
+
+	summary, err := r.Run(ctx)
+	if wErr := summary.Write(out); wErr != nil {
+		logger.Error("replay summary write failed", logx.Any("err", wErr))
+	}
+	return err
+}
+
+type workerDeps struct {
+	dig.In
+
+	Server *http.Server `name:"worker_server" optional:"true"`
+
+	Ctx            context.Context
+	Pool           *pgxpool.Pool
+	Logger         logx.Logger
+	Consumer       *kafka.Consumer
+	Relay          *kafka.OutboxRelay     `optional:"true"`
+	OrdersCloser   ordersConnCloser       `optional:"true"`
+	Dispatcher     *dispatch.Dispatcher   `optional:"true"`
+	TransportTypes *transporttype.Catalog `optional:"true"`
+	Verifier       *orders.Verifier       `optional:"true"`
+	Reconciler     *reconcile.Reconciler  `optional:"true"`
+}
+
+func workerRun(d workerDeps) error {
+	if d.Consumer == nil {
 		return fmt.Errorf("kafka consumer is nil: worker container misconfigured")
 	}
-	defer closeWorker(pool, logger, consumer, ordersCloser)
+	defer closeWorker(d.Pool, d.Logger, d.Consumer, d.Relay, d.OrdersCloser)
+
+	ctx, cancel := context.WithCancel(d.Ctx)
+	defer cancel()
+
+	startDispatcher(ctx, d.Dispatcher)
+	startTransportCatalog(ctx, d.TransportTypes)
+	startOutboxRelay(ctx, d.Relay)
+	startOrderVerifier(ctx, d.Verifier)
+	startReconciler(ctx, d.Reconciler)
+
+	var serverErrCh <-chan error
+	if d.Server != nil {
+		serverErrCh = startServer("service-courier-worker", d.Server, d.Logger)
+		defer gracefulShutdown(d.Server, d.Logger, shutdownTimeout)
+	}
+
+	consumerErrCh := make(chan error, 1)
+	go func() { consumerErrCh <- d.Consumer.Run(ctx) }()
+
+	d.Logger.Info("service-courier-worker started")
+	select {
+	case err := <-consumerErrCh:
+		return err
+	case err := <-serverErrCh:
+		// without its probes the worker can not be supervised, so it stops as well
+		reportServerStop(d.Logger, "worker http server stopped", err)
+		cancel()
+		<-consumerErrCh
+		return err
+	}
+}
+
+func startOutboxRelay(ctx context.Context, relay *kafka.OutboxRelay) {
+	if relay == nil {
+		return
+	}
+	go relay.Run(ctx)
+}
 
-	logger.Info("service-courier-worker started")
-	return consumer.Run(ctx)
+func startOrderVerifier(ctx context.Context, v *orders.Verifier) {
+	if v == nil {
+		return
+	}
+	go v.Run(ctx)
 }
 
-func closeWorker(pool *pgxpool.Pool, logger logx.Logger, kafkaConsumer *kafka.Consumer, ordersCloser ordersConnCloser) {
+func closeWorker(
+	pool *pgxpool.Pool,
+	logger logx.Logger,
+	kafkaConsumer *kafka.Consumer,
+	relay *kafka.OutboxRelay,
+	ordersCloser ordersConnCloser,
+) {
 	if kafkaConsumer != nil {
 		if err := kafkaConsumer.Close(); err != nil {
 			logger.Error("kafka close error", logx.Any("err", err))
 		}
 	}
+	if err := relay.Close(); err != nil {
+		logger.Error("outbox relay close error", logx.Any("err", err))
+	}
 	if ordersCloser != nil {
 		if err := ordersCloser(); err != nil {
 			logger.Error("orders close error", logx.Any("err", err))
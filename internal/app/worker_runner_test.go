@@ -23,7 +23,7 @@ func TestWorkerRunner_MustRun_PanicsOnOtherError(t *testing.T) {
 }
 
 func TestWorkerRun_ReturnsError_WhenConsumerNil(t *testing.T) {
-	err := workerRun(context.Background(), nil, logx.Nop(), nil, nil)
+	err := workerRun(workerDeps{Ctx: context.Background(), Logger: logx.Nop()})
 	require.Error(t, err)
 	require.Contains(t, err.Error(), "kafka consumer is nil")
 }
@@ -1,6 +1,9 @@
 package apperr
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 // ErrInvalid is returned when the input fails domain validation.
 var ErrInvalid = errors.New("invalid input")
@@ -10,3 +13,7 @@ var ErrConflict = errors.New("conflict")
 
 // ErrNotFound indicates that the requested resource does not exist.
 var ErrNotFound = errors.New("not found")
+
+// ErrNoCourierAvailable indicates that no courier can take the order right now.
+// It wraps ErrConflict, so callers not interested in the reason can keep checking for ErrConflict.
+var ErrNoCourierAvailable = fmt.Errorf("no available courier: %w", ErrConflict)
@@ -3,12 +3,14 @@ package config
 import (
 	"errors"
 	"fmt"
+	"math"
 	"os"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/IBM/sarama"
 	"github.com/joho/godotenv"
 	"github.com/spf13/pflag"
 )
@@ -26,13 +28,114 @@ type Config struct {
 	Kafka         Kafka
 	Pprof         PprofConfig
 	RateLimit     rateLimit
+	Worker        Worker
+}
+
+// Worker stores settings of the worker HTTP server with metrics, health checks and pprof.
+type Worker struct {
+	// HTTPAddr is the listen address of the server
+	HTTPAddr string
+	// ReadyTimeout bounds the dependency checks of /readyz
+	ReadyTimeout time.Duration
 }
 
 // OrdersGateway stores orders gateway settings.
 type OrdersGateway struct {
+	// Client is how the gRPC client connects to the orders service
+	Client      OrdersClient
 	MaxAttempts int
 	BaseDelay   time.Duration
 	MaxDelay    time.Duration
+	// Breaker stops calling the orders service while it keeps failing
+	Breaker OrdersBreaker
+	// Cache serves repeated lookups of an order without calling the orders service
+	Cache OrdersCache
+	// Fallback is what the worker does with order events while the orders service is unavailable
+	Fallback OrdersFallback
+	// Reconcile periodically compares orders of the orders service with the delivery table
+	Reconcile OrdersReconcile
+}
+
+// Fallback modes of the worker while the orders service is unavailable.
+const (
+	// FallbackStrict retries the event until the orders service answers
+	FallbackStrict = "strict"
+	// FallbackTrustPayload applies the event from its payload and verifies it later
+	FallbackTrustPayload = "trust-payload"
+)
+
+// OrdersFallback stores how order events are handled while the orders service is unavailable.
+type OrdersFallback struct {
+	// Mode is FallbackStrict or FallbackTrustPayload
+	Mode string
+	// VerifyDelay is how long after the event its order is checked against the orders service,
+	// and how long a failed check waits for the next one
+	VerifyDelay time.Duration
+	// VerifyInterval is how often due checks are looked for
+	VerifyInterval time.Duration
+	// VerifyBatch is how many orders one run checks at most
+	VerifyBatch int
+}
+
+// OrdersReconcile stores settings of the order reconciliation job.
+type OrdersReconcile struct {
+	Enabled bool
+	// Interval is how often the job runs
+	Interval time.Duration
+	// Lookback is how far before the watermark orders are listed again
+	Lookback time.Duration
+	// DryRun only reports drift, nothing is fixed
+	DryRun bool
+}
+
+// OrdersClient stores connection settings of the orders gRPC client.
+type OrdersClient struct {
+	TLS OrdersTLS
+	// Token is sent as a bearer token, APIKey in the APIKeyHeader metadata key; at most one is set
+	Token        string
+	APIKey       string
+	APIKeyHeader string
+	// KeepaliveTime is how long an idle connection waits before a ping, zero disables pings
+	KeepaliveTime    time.Duration
+	KeepaliveTimeout time.Duration
+	// CallTimeout is the deadline of a call made without one, zero leaves it unbounded
+	CallTimeout time.Duration
+}
+
+// OrdersTLS stores TLS settings of the orders gRPC client.
+type OrdersTLS struct {
+	Enabled  bool
+	CAFile   string
+	CertFile string
+	KeyFile  string
+	// ServerName overrides the name the server certificate is checked against
+	ServerName string
+}
+
+// OrdersCache stores settings of the orders lookup cache.
+type OrdersCache struct {
+	Enabled bool
+	// TTL is how long a found order is served from the cache
+	TTL time.Duration
+	// NegativeTTL is how long an unknown order is served as not found, zero disables it
+	NegativeTTL time.Duration
+	// Size is how many orders the cache holds at most
+	Size int
+}
+
+// OrdersBreaker stores circuit breaker settings of the orders gateway.
+type OrdersBreaker struct {
+	Enabled bool
+	// Window is how far back calls are counted for the failure ratio
+	Window time.Duration
+	// MinRequests is how many calls the window needs before the breaker may open
+	MinRequests int
+	// FailureRatio opens the breaker when this share of the calls in the window failed
+	FailureRatio float64
+	// CoolDown is how long the breaker stays open before trial calls
+	CoolDown time.Duration
+	// HalfOpenRequests is how many trial calls must succeed to close the breaker
+	HalfOpenRequests int
 }
 
 // DB stores database settings.
@@ -49,11 +152,87 @@ type Kafka struct {
 	Brokers []string
 	Topic   string
 	GroupID string
+	// DLQTopic receives events the worker can never process, with the failure in message headers
+	DLQTopic string
+	// Retry configures per-message retries of transient handler errors
+	Retry KafkaRetry
+	// Concurrency is how many events are processed in parallel across all claimed partitions, sharded by order_id
+	Concurrency int
+	// EventsTopic receives the courier/delivery events relayed from the outbox
+	EventsTopic string
+	// Outbox configures the outbox relay
+	Outbox KafkaOutbox
+	// Encoding of order events without a content-type header, "json" or "protobuf"
+	Encoding string
+	// Client configures the connection to the cluster and the consumer groups
+	Client KafkaClient
+}
+
+// KafkaClient stores how the Kafka clients connect to the cluster and how consumer groups fetch.
+type KafkaClient struct {
+	ClientID string
+	// Version is the Kafka version of the cluster, empty keeps the client default
+	Version string
+	TLS     KafkaTLS
+	SASL    KafkaSASL
+	// Rebalance is the consumer group strategy: range, roundrobin or sticky
+	Rebalance      string
+	SessionTimeout time.Duration
+	FetchMinBytes  int
+	// FetchDefaultBytes is how much one fetch of a partition asks for
+	FetchDefaultBytes int
+	// FetchMaxBytes limits one fetch of a partition, 0 means no limit
+	FetchMaxBytes int
+	// InitialOffset is where a group without committed offsets starts: oldest or newest
+	InitialOffset string
+}
+
+// KafkaTLS stores TLS settings of the Kafka connection.
+type KafkaTLS struct {
+	Enabled  bool
+	CAFile   string
+	CertFile string
+	KeyFile  string
+	// InsecureSkipVerify disables the broker certificate check, for test clusters only
+	InsecureSkipVerify bool
+}
+
+// KafkaSASL stores SASL settings of the Kafka connection.
+type KafkaSASL struct {
+	// Mechanism is PLAIN, SCRAM-SHA-256 or SCRAM-SHA-512, empty disables SASL
+	Mechanism string
+	User      string
+	Pass      string
+}
+
+// KafkaOutbox stores settings of the outbox relay.
+type KafkaOutbox struct {
+	Interval  time.Duration
+	BatchSize int
+}
+
+// KafkaRetry stores per-message retry settings of the Kafka consumer.
+type KafkaRetry struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
 }
 
 // Delivery stores delivery-related settings.
 type Delivery struct {
 	AutoReleaseInterval time.Duration
+	// AssignRadiusKm limits how far from the pickup point an assigned courier may be
+	AssignRadiusKm float64
+	// AssignStrategy names the courier assignment strategy
+	AssignStrategy string
+	// DispatchInterval is how often pending orders are retried without a wake-up signal
+	DispatchInterval time.Duration
+	// DispatchBatchSize limits how many pending orders one dispatch run assigns
+	DispatchBatchSize int
+	// TransportTypesRefresh is how often the cached transport types are reloaded from the database
+	TransportTypesRefresh time.Duration
+	// DeadlinePolicyFile is the JSON file with deadline rules, empty means transport base deadlines only
+	DeadlinePolicyFile string
 }
 
 // PprofConfig stores pprof server settings.
@@ -85,6 +264,18 @@ func envOrDefault(key, def string) string {
 	return def
 }
 
+// envSecret reads key from the file named by key_FILE if it is set, otherwise from key itself
+func envSecret(key string) (string, error) {
+	v, ok, err := readSecretFromFile(key + "_FILE")
+	if err != nil {
+		return "", err
+	}
+	if ok {
+		return v, nil
+	}
+	return strings.TrimSpace(os.Getenv(key)), nil
+}
+
 func envBool(key string, def bool) (bool, error) {
 	defStr := strconv.FormatBool(def)
 	raw := envOrDefault(key, defStr)
@@ -205,7 +396,35 @@ func parseDelivery() (Delivery, error) {
 	if err != nil {
 		return Delivery{}, fmt.Errorf("invalid DELIVERY_AUTO_RELEASE_INTERVAL %q: %w", intervalStr, err)
 	}
-	return Delivery{AutoReleaseInterval: autoReleaseInterval}, nil
+	radiusKm, err := envFloat64("DELIVERY_ASSIGN_RADIUS_KM", defaultDelivery.AssignRadiusKm,
+		func(v float64) bool { return v > 0 })
+	if err != nil {
+		return Delivery{}, err
+	}
+	dispatchInterval, err := envDuration("DELIVERY_DISPATCH_INTERVAL", defaultDelivery.DispatchInterval,
+		func(v time.Duration) bool { return v > 0 })
+	if err != nil {
+		return Delivery{}, err
+	}
+	dispatchBatch, err := envInt("DELIVERY_DISPATCH_BATCH", defaultDelivery.DispatchBatchSize,
+		func(v int) bool { return v > 0 })
+	if err != nil {
+		return Delivery{}, err
+	}
+	transportRefresh, err := envDuration("DELIVERY_TRANSPORT_TYPES_REFRESH", defaultDelivery.TransportTypesRefresh,
+		func(v time.Duration) bool { return v > 0 })
+	if err != nil {
+		return Delivery{}, err
+	}
+	return Delivery{
+		AutoReleaseInterval:   autoReleaseInterval,
+		AssignRadiusKm:        radiusKm,
+		AssignStrategy:        strings.ToLower(envOrDefault("DELIVERY_ASSIGN_STRATEGY", defaultDelivery.AssignStrategy)),
+		DispatchInterval:      dispatchInterval,
+		DispatchBatchSize:     dispatchBatch,
+		TransportTypesRefresh: transportRefresh,
+		DeadlinePolicyFile:    strings.TrimSpace(os.Getenv("DELIVERY_DEADLINE_POLICY_FILE")),
+	}, nil
 }
 
 func parseOrdersGateway() (orderService string, cfg OrdersGateway, err error) {
@@ -235,10 +454,246 @@ func parseOrdersGateway() (orderService string, cfg OrdersGateway, err error) {
 		)
 	}
 
+	client, err := parseOrdersClient()
+	if err != nil {
+		return "", OrdersGateway{}, err
+	}
+	breaker, err := parseOrdersBreaker()
+	if err != nil {
+		return "", OrdersGateway{}, err
+	}
+	cache, err := parseOrdersCache()
+	if err != nil {
+		return "", OrdersGateway{}, err
+	}
+	fallback, err := parseOrdersFallback()
+	if err != nil {
+		return "", OrdersGateway{}, err
+	}
+	reconcile, err := parseOrdersReconcile()
+	if err != nil {
+		return "", OrdersGateway{}, err
+	}
+
 	return orderService, OrdersGateway{
+		Client:      client,
 		MaxAttempts: maxAttempts,
 		BaseDelay:   baseDelay,
 		MaxDelay:    maxDelay,
+		Breaker:     breaker,
+		Cache:       cache,
+		Fallback:    fallback,
+		Reconcile:   reconcile,
+	}, nil
+}
+
+func parseOrdersClient() (OrdersClient, error) {
+	def := defaultOrdersGateway.Client
+	tlsCfg, err := parseOrdersTLS()
+	if err != nil {
+		return OrdersClient{}, err
+	}
+	token, err := envSecret("ORDER_SERVICE_TOKEN")
+	if err != nil {
+		return OrdersClient{}, err
+	}
+	apiKey, err := envSecret("ORDER_SERVICE_API_KEY")
+	if err != nil {
+		return OrdersClient{}, err
+	}
+	keepaliveTime, err := envDuration("ORDER_SERVICE_KEEPALIVE_TIME", def.KeepaliveTime,
+		func(d time.Duration) bool { return d == 0 || d >= 10*time.Second })
+	if err != nil {
+		return OrdersClient{}, err
+	}
+	keepaliveTimeout, err := envDuration("ORDER_SERVICE_KEEPALIVE_TIMEOUT", def.KeepaliveTimeout,
+		func(d time.Duration) bool { return d > 0 })
+	if err != nil {
+		return OrdersClient{}, err
+	}
+	callTimeout, err := envDuration("ORDER_SERVICE_CALL_TIMEOUT", def.CallTimeout,
+		func(d time.Duration) bool { return d >= 0 })
+	if err != nil {
+		return OrdersClient{}, err
+	}
+	cfg := OrdersClient{
+		TLS:              tlsCfg,
+		Token:            token,
+		APIKey:           apiKey,
+		APIKeyHeader:     strings.ToLower(envOrDefault("ORDER_SERVICE_API_KEY_HEADER", def.APIKeyHeader)),
+		KeepaliveTime:    keepaliveTime,
+		KeepaliveTimeout: keepaliveTimeout,
+		CallTimeout:      callTimeout,
+	}
+
+	switch {
+	case cfg.Token != "" && cfg.APIKey != "":
+		return OrdersClient{}, errors.New("ORDER_SERVICE_TOKEN and ORDER_SERVICE_API_KEY can not be set together")
+	case (cfg.Token != "" || cfg.APIKey != "") && !cfg.TLS.Enabled:
+		return OrdersClient{}, errors.New("ORDER_SERVICE_TOKEN and ORDER_SERVICE_API_KEY require ORDER_SERVICE_TLS_ENABLED=true")
+	}
+	return cfg, nil
+}
+
+func parseOrdersTLS() (OrdersTLS, error) {
+	enabled, err := envBool("ORDER_SERVICE_TLS_ENABLED", false)
+	if err != nil {
+		return OrdersTLS{}, err
+	}
+	cfg := OrdersTLS{
+		Enabled:    enabled,
+		CAFile:     strings.TrimSpace(os.Getenv("ORDER_SERVICE_TLS_CA_FILE")),
+		CertFile:   strings.TrimSpace(os.Getenv("ORDER_SERVICE_TLS_CERT_FILE")),
+		KeyFile:    strings.TrimSpace(os.Getenv("ORDER_SERVICE_TLS_KEY_FILE")),
+		ServerName: strings.TrimSpace(os.Getenv("ORDER_SERVICE_TLS_SERVER_NAME")),
+	}
+
+	switch {
+	case !cfg.Enabled && (cfg.CAFile != "" || cfg.CertFile != "" || cfg.KeyFile != "" || cfg.ServerName != ""):
+		return OrdersTLS{}, errors.New("ORDER_SERVICE_TLS_* settings require ORDER_SERVICE_TLS_ENABLED=true")
+	case (cfg.CertFile == "") != (cfg.KeyFile == ""):
+		return OrdersTLS{}, errors.New("ORDER_SERVICE_TLS_CERT_FILE and ORDER_SERVICE_TLS_KEY_FILE must be set together")
+	}
+	for key, path := range map[string]string{
+		"ORDER_SERVICE_TLS_CA_FILE":   cfg.CAFile,
+		"ORDER_SERVICE_TLS_CERT_FILE": cfg.CertFile,
+		"ORDER_SERVICE_TLS_KEY_FILE":  cfg.KeyFile,
+	} {
+		if path == "" {
+			continue
+		}
+		if _, err := os.Stat(path); err != nil {
+			return OrdersTLS{}, fmt.Errorf("invalid %s: %w", key, err)
+		}
+	}
+	return cfg, nil
+}
+
+func parseOrdersBreaker() (OrdersBreaker, error) {
+	def := defaultOrdersGateway.Breaker
+	enabled, err := envBool("ORDER_GATEWAY_BREAKER_ENABLED", def.Enabled)
+	if err != nil {
+		return OrdersBreaker{}, err
+	}
+	window, err := envDuration("ORDER_GATEWAY_BREAKER_WINDOW", def.Window,
+		func(d time.Duration) bool { return d >= 100*time.Millisecond })
+	if err != nil {
+		return OrdersBreaker{}, err
+	}
+	minRequests, err := envInt("ORDER_GATEWAY_BREAKER_MIN_REQUESTS", def.MinRequests,
+		func(v int) bool { return v >= 1 })
+	if err != nil {
+		return OrdersBreaker{}, err
+	}
+	ratio, err := envFloat64("ORDER_GATEWAY_BREAKER_FAILURE_RATIO", def.FailureRatio,
+		func(v float64) bool { return v > 0 && v <= 1 })
+	if err != nil {
+		return OrdersBreaker{}, err
+	}
+	coolDown, err := envDuration("ORDER_GATEWAY_BREAKER_COOLDOWN", def.CoolDown,
+		func(d time.Duration) bool { return d > 0 })
+	if err != nil {
+		return OrdersBreaker{}, err
+	}
+	halfOpen, err := envInt("ORDER_GATEWAY_BREAKER_HALF_OPEN_REQUESTS", def.HalfOpenRequests,
+		func(v int) bool { return v >= 1 })
+	if err != nil {
+		return OrdersBreaker{}, err
+	}
+	return OrdersBreaker{
+		Enabled:          enabled,
+		Window:           window,
+		MinRequests:      minRequests,
+		FailureRatio:     ratio,
+		CoolDown:         coolDown,
+		HalfOpenRequests: halfOpen,
+	}, nil
+}
+
+func parseOrdersCache() (OrdersCache, error) {
+	def := defaultOrdersGateway.Cache
+	enabled, err := envBool("ORDER_GATEWAY_CACHE_ENABLED", def.Enabled)
+	if err != nil {
+		return OrdersCache{}, err
+	}
+	ttl, err := envDuration("ORDER_GATEWAY_CACHE_TTL", def.TTL,
+		func(d time.Duration) bool { return d > 0 })
+	if err != nil {
+		return OrdersCache{}, err
+	}
+	negativeTTL, err := envDuration("ORDER_GATEWAY_CACHE_NEGATIVE_TTL", def.NegativeTTL,
+		func(d time.Duration) bool { return d >= 0 })
+	if err != nil {
+		return OrdersCache{}, err
+	}
+	size, err := envInt("ORDER_GATEWAY_CACHE_SIZE", def.Size,
+		func(v int) bool { return v >= 1 })
+	if err != nil {
+		return OrdersCache{}, err
+	}
+	return OrdersCache{
+		Enabled:     enabled,
+		TTL:         ttl,
+		NegativeTTL: negativeTTL,
+		Size:        size,
+	}, nil
+}
+
+func parseOrdersFallback() (OrdersFallback, error) {
+	def := defaultOrdersGateway.Fallback
+	mode := strings.ToLower(envOrDefault("ORDER_GATEWAY_FALLBACK", def.Mode))
+	if mode != FallbackStrict && mode != FallbackTrustPayload {
+		return OrdersFallback{}, fmt.Errorf("invalid ORDER_GATEWAY_FALLBACK: %q, want %s or %s",
+			mode, FallbackStrict, FallbackTrustPayload)
+	}
+	delay, err := envDuration("ORDER_GATEWAY_VERIFY_DELAY", def.VerifyDelay,
+		func(d time.Duration) bool { return d >= time.Second })
+	if err != nil {
+		return OrdersFallback{}, err
+	}
+	interval, err := envDuration("ORDER_GATEWAY_VERIFY_INTERVAL", def.VerifyInterval,
+		func(d time.Duration) bool { return d > 0 })
+	if err != nil {
+		return OrdersFallback{}, err
+	}
+	batch, err := envInt("ORDER_GATEWAY_VERIFY_BATCH", def.VerifyBatch,
+		func(v int) bool { return v >= 1 && v <= 1000 })
+	if err != nil {
+		return OrdersFallback{}, err
+	}
+	return OrdersFallback{
+		Mode:           mode,
+		VerifyDelay:    delay,
+		VerifyInterval: interval,
+		VerifyBatch:    batch,
+	}, nil
+}
+
+func parseOrdersReconcile() (OrdersReconcile, error) {
+	def := defaultOrdersGateway.Reconcile
+	enabled, err := envBool("ORDER_RECONCILE_ENABLED", def.Enabled)
+	if err != nil {
+		return OrdersReconcile{}, err
+	}
+	interval, err := envDuration("ORDER_RECONCILE_INTERVAL", def.Interval,
+		func(d time.Duration) bool { return d >= time.Second })
+	if err != nil {
+		return OrdersReconcile{}, err
+	}
+	lookback, err := envDuration("ORDER_RECONCILE_LOOKBACK", def.Lookback,
+		func(d time.Duration) bool { return d > 0 })
+	if err != nil {
+		return OrdersReconcile{}, err
+	}
+	dryRun, err := envBool("ORDER_RECONCILE_DRY_RUN", def.DryRun)
+	if err != nil {
+		return OrdersReconcile{}, err
+	}
+	return OrdersReconcile{
+		Enabled:  enabled,
+		Interval: interval,
+		Lookback: lookback,
+		DryRun:   dryRun,
 	}, nil
 }
 
@@ -340,6 +795,11 @@ func Load() (*Config, error) {
 		return nil, err
 	}
 
+	workerCfg, err := parseWorker()
+	if err != nil {
+		return nil, err
+	}
+
 	return &Config{
 		Port:          port,
 		DB:            db,
@@ -349,9 +809,55 @@ func Load() (*Config, error) {
 		Kafka:         kafkaCfg,
 		Pprof:         pprofCfg,
 		RateLimit:     rateLimitCfg,
+		Worker:        workerCfg,
+	}, nil
+}
+
+func parseWorker() (Worker, error) {
+	timeout, err := envDuration("WORKER_READY_TIMEOUT", defaultWorker.ReadyTimeout,
+		func(d time.Duration) bool { return d > 0 })
+	if err != nil {
+		return Worker{}, err
+	}
+	return Worker{
+		HTTPAddr:     envOrDefault("WORKER_HTTP_ADDR", defaultWorker.HTTPAddr),
+		ReadyTimeout: timeout,
 	}, nil
 }
 
+func loadKafkaOutbox() (KafkaOutbox, error) {
+	interval, err := envDuration("OUTBOX_RELAY_INTERVAL", defaultKafkaOutbox.Interval,
+		func(d time.Duration) bool { return d > 0 })
+	if err != nil {
+		return KafkaOutbox{}, err
+	}
+	batch, err := envInt("OUTBOX_RELAY_BATCH", defaultKafkaOutbox.BatchSize,
+		func(v int) bool { return v >= 1 && v <= 1000 })
+	if err != nil {
+		return KafkaOutbox{}, err
+	}
+	return KafkaOutbox{Interval: interval, BatchSize: batch}, nil
+}
+
+func loadKafkaRetry() (KafkaRetry, error) {
+	maxAttempts, err := envInt("KAFKA_RETRY_MAX_ATTEMPTS", defaultKafkaRetry.MaxAttempts,
+		func(v int) bool { return v >= 1 && v <= 20 })
+	if err != nil {
+		return KafkaRetry{}, err
+	}
+	baseDelay, err := envDuration("KAFKA_RETRY_BASE_DELAY", defaultKafkaRetry.BaseDelay,
+		func(v time.Duration) bool { return v > 0 })
+	if err != nil {
+		return KafkaRetry{}, err
+	}
+	maxDelay, err := envDuration("KAFKA_RETRY_MAX_DELAY", defaultKafkaRetry.MaxDelay,
+		func(v time.Duration) bool { return v >= baseDelay })
+	if err != nil {
+		return KafkaRetry{}, fmt.Errorf("%w: must be >= KAFKA_RETRY_BASE_DELAY", err)
+	}
+	return KafkaRetry{MaxAttempts: maxAttempts, BaseDelay: baseDelay, MaxDelay: maxDelay}, nil
+}
+
 func loadKafka() (Kafka, error) {
 	brokersCSV := envOrDefault("KAFKA_BROKERS", "kafka:9092")
 	raw := strings.Split(brokersCSV, ",")
@@ -371,6 +877,184 @@ func loadKafka() (Kafka, error) {
 		Topic:   envOrDefault("KAFKA_ORDER_TOPIC", "order.status.changed"),
 		GroupID: envOrDefault("KAFKA_GROUP_ID", "service-courier"),
 	}
+	cfg.DLQTopic = envOrDefault("KAFKA_DLQ_TOPIC", cfg.Topic+".dlq")
+	if cfg.DLQTopic == cfg.Topic {
+		return Kafka{}, fmt.Errorf("KAFKA_DLQ_TOPIC must differ from KAFKA_ORDER_TOPIC: %q", cfg.DLQTopic)
+	}
+
+	retry, err := loadKafkaRetry()
+	if err != nil {
+		return Kafka{}, err
+	}
+	cfg.Retry = retry
+
+	cfg.Concurrency, err = envInt("KAFKA_CONCURRENCY", defaultKafkaConcurrency,
+		func(v int) bool { return v >= 1 && v <= 256 })
+	if err != nil {
+		return Kafka{}, err
+	}
 
+	cfg.EventsTopic = envOrDefault("KAFKA_EVENTS_TOPIC", "courier.delivery.events")
+	if cfg.EventsTopic == cfg.Topic || cfg.EventsTopic == cfg.DLQTopic {
+		return Kafka{}, fmt.Errorf("KAFKA_EVENTS_TOPIC must differ from the consumed topics: %q", cfg.EventsTopic)
+	}
+	cfg.Outbox, err = loadKafkaOutbox()
+	if err != nil {
+		return Kafka{}, err
+	}
+
+	cfg.Encoding = strings.ToLower(envOrDefault("KAFKA_ORDER_ENCODING", "json"))
+	if cfg.Encoding != "json" && cfg.Encoding != "protobuf" {
+		return Kafka{}, fmt.Errorf("invalid KAFKA_ORDER_ENCODING: %q, want json or protobuf", cfg.Encoding)
+	}
+	cfg.Client, err = loadKafkaClient()
+	if err != nil {
+		return Kafka{}, err
+	}
+
+	return cfg, nil
+}
+
+func loadKafkaClient() (KafkaClient, error) {
+	cfg := KafkaClient{
+		ClientID:      envOrDefault("KAFKA_CLIENT_ID", defaultKafkaClient.ClientID),
+		Version:       envOrDefault("KAFKA_VERSION", defaultKafkaClient.Version),
+		Rebalance:     strings.ToLower(envOrDefault("KAFKA_REBALANCE_STRATEGY", defaultKafkaClient.Rebalance)),
+		InitialOffset: strings.ToLower(envOrDefault("KAFKA_INITIAL_OFFSET", defaultKafkaClient.InitialOffset)),
+	}
+	version := sarama.DefaultVersion
+	if cfg.Version != "" {
+		v, err := sarama.ParseKafkaVersion(cfg.Version)
+		if err != nil {
+			return KafkaClient{}, fmt.Errorf("invalid KAFKA_VERSION %q: %w", cfg.Version, err)
+		}
+		version = v
+	}
+	switch cfg.Rebalance {
+	case "range", "roundrobin", "sticky":
+	default:
+		return KafkaClient{}, fmt.Errorf("invalid KAFKA_REBALANCE_STRATEGY %q, want range, roundrobin or sticky", cfg.Rebalance)
+	}
+	if cfg.InitialOffset != "oldest" && cfg.InitialOffset != "newest" {
+		return KafkaClient{}, fmt.Errorf("invalid KAFKA_INITIAL_OFFSET %q, want oldest or newest", cfg.InitialOffset)
+	}
+
+	var err error
+	// the default bounds of group.min.session.timeout.ms and group.max.session.timeout.ms of the broker
+	cfg.SessionTimeout, err = envDuration("KAFKA_SESSION_TIMEOUT", defaultKafkaClient.SessionTimeout,
+		func(d time.Duration) bool { return d >= 6*time.Second && d <= 30*time.Minute })
+	if err != nil {
+		return KafkaClient{}, err
+	}
+	if err = loadKafkaFetch(&cfg); err != nil {
+		return KafkaClient{}, err
+	}
+
+	if cfg.TLS, err = loadKafkaTLS(); err != nil {
+		return KafkaClient{}, err
+	}
+	if cfg.SASL, err = loadKafkaSASL(); err != nil {
+		return KafkaClient{}, err
+	}
+	switch {
+	case cfg.SASL.Mechanism == sarama.SASLTypePlaintext && !cfg.TLS.Enabled:
+		return KafkaClient{}, errors.New("KAFKA_SASL_MECHANISM=PLAIN sends the password in clear text, it requires KAFKA_TLS_ENABLED")
+	case strings.HasPrefix(cfg.SASL.Mechanism, "SCRAM") && !version.IsAtLeast(sarama.V0_10_2_0):
+		return KafkaClient{}, fmt.Errorf("KAFKA_SASL_MECHANISM=%s requires KAFKA_VERSION 0.10.2.0 or newer", cfg.SASL.Mechanism)
+	}
+	return cfg, nil
+}
+
+func loadKafkaFetch(cfg *KafkaClient) error {
+	var err error
+	fits := func(v int) bool { return v >= 0 && v <= math.MaxInt32 }
+	if cfg.FetchMinBytes, err = envInt("KAFKA_FETCH_MIN_BYTES", defaultKafkaClient.FetchMinBytes,
+		func(v int) bool { return v >= 1 && fits(v) }); err != nil {
+		return err
+	}
+	if cfg.FetchDefaultBytes, err = envInt("KAFKA_FETCH_DEFAULT_BYTES", defaultKafkaClient.FetchDefaultBytes,
+		func(v int) bool { return v >= 1 && fits(v) }); err != nil {
+		return err
+	}
+	if cfg.FetchMaxBytes, err = envInt("KAFKA_FETCH_MAX_BYTES", defaultKafkaClient.FetchMaxBytes, fits); err != nil {
+		return err
+	}
+	if cfg.FetchDefaultBytes < cfg.FetchMinBytes {
+		return fmt.Errorf("KAFKA_FETCH_DEFAULT_BYTES must be >= KAFKA_FETCH_MIN_BYTES: %d", cfg.FetchDefaultBytes)
+	}
+	if cfg.FetchMaxBytes > 0 && cfg.FetchMaxBytes < cfg.FetchDefaultBytes {
+		return fmt.Errorf("KAFKA_FETCH_MAX_BYTES must be 0 or >= KAFKA_FETCH_DEFAULT_BYTES: %d", cfg.FetchMaxBytes)
+	}
+	return nil
+}
+
+func loadKafkaTLS() (KafkaTLS, error) {
+	enabled, err := envBool("KAFKA_TLS_ENABLED", false)
+	if err != nil {
+		return KafkaTLS{}, err
+	}
+	insecure, err := envBool("KAFKA_TLS_INSECURE_SKIP_VERIFY", false)
+	if err != nil {
+		return KafkaTLS{}, err
+	}
+	cfg := KafkaTLS{
+		Enabled:            enabled,
+		CAFile:             strings.TrimSpace(os.Getenv("KAFKA_TLS_CA_FILE")),
+		CertFile:           strings.TrimSpace(os.Getenv("KAFKA_TLS_CERT_FILE")),
+		KeyFile:            strings.TrimSpace(os.Getenv("KAFKA_TLS_KEY_FILE")),
+		InsecureSkipVerify: insecure,
+	}
+
+	switch {
+	case !cfg.Enabled && (cfg.CAFile != "" || cfg.CertFile != "" || cfg.KeyFile != "" || cfg.InsecureSkipVerify):
+		return KafkaTLS{}, errors.New("KAFKA_TLS_* settings require KAFKA_TLS_ENABLED=true")
+	case (cfg.CertFile == "") != (cfg.KeyFile == ""):
+		return KafkaTLS{}, errors.New("KAFKA_TLS_CERT_FILE and KAFKA_TLS_KEY_FILE must be set together")
+	case cfg.InsecureSkipVerify && cfg.CAFile != "":
+		return KafkaTLS{}, errors.New("KAFKA_TLS_CA_FILE is not used with KAFKA_TLS_INSECURE_SKIP_VERIFY")
+	}
+	for key, path := range map[string]string{
+		"KAFKA_TLS_CA_FILE":   cfg.CAFile,
+		"KAFKA_TLS_CERT_FILE": cfg.CertFile,
+		"KAFKA_TLS_KEY_FILE":  cfg.KeyFile,
+	} {
+		if path == "" {
+			continue
+		}
+		if _, err := os.Stat(path); err != nil {
+			return KafkaTLS{}, fmt.Errorf("invalid %s: %w", key, err)
+		}
+	}
+	return cfg, nil
+}
+
+func loadKafkaSASL() (KafkaSASL, error) {
+	user, err := envSecret("KAFKA_SASL_USERNAME")
+	if err != nil {
+		return KafkaSASL{}, err
+	}
+	pass, err := envSecret("KAFKA_SASL_PASSWORD")
+	if err != nil {
+		return KafkaSASL{}, err
+	}
+	cfg := KafkaSASL{
+		Mechanism: strings.ToUpper(envOrDefault("KAFKA_SASL_MECHANISM", "")),
+		User:      user,
+		Pass:      pass,
+	}
+
+	switch cfg.Mechanism {
+	case "":
+		if cfg.User != "" || cfg.Pass != "" {
+			return KafkaSASL{}, errors.New("KAFKA_SASL_USERNAME and KAFKA_SASL_PASSWORD require KAFKA_SASL_MECHANISM")
+		}
+		return cfg, nil
+	case sarama.SASLTypePlaintext, sarama.SASLTypeSCRAMSHA256, sarama.SASLTypeSCRAMSHA512:
+	default:
+		return KafkaSASL{}, fmt.Errorf("invalid KAFKA_SASL_MECHANISM %q, want PLAIN, SCRAM-SHA-256 or SCRAM-SHA-512", cfg.Mechanism)
+	}
+	if cfg.User == "" || cfg.Pass == "" {
+		return KafkaSASL{}, errors.New("KAFKA_SASL_MECHANISM requires KAFKA_SASL_USERNAME and KAFKA_SASL_PASSWORD")
+	}
 	return cfg, nil
 }
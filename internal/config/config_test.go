@@ -3,6 +3,7 @@ package config
 import (
 	"io"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -43,7 +44,9 @@ func TestLoad_Defaults(t *testing.T) {
 		"PORT",
 		"POSTGRES_HOST", "POSTGRES_PORT", "POSTGRES_USER", "POSTGRES_PASSWORD", "POSTGRES_DB",
 		"POSTGRES_PASSWORD_FILE",
-		"DELIVERY_AUTO_RELEASE_INTERVAL",
+		"DELIVERY_AUTO_RELEASE_INTERVAL", "DELIVERY_ASSIGN_RADIUS_KM", "DELIVERY_ASSIGN_STRATEGY",
+		"DELIVERY_DISPATCH_INTERVAL", "DELIVERY_DISPATCH_BATCH", "DELIVERY_TRANSPORT_TYPES_REFRESH",
+		"DELIVERY_DEADLINE_POLICY_FILE",
 		"ORDER_SERVICE_HOST",
 		"ORDER_GATEWAY_MAX_ATTEMPTS", "ORDER_GATEWAY_BASE_DELAY", "ORDER_GATEWAY_MAX_DELAY",
 	)
@@ -65,18 +68,24 @@ func TestLoad_EnvOverrides(t *testing.T) {
 	resetFlags(t)
 
 	setEnvMap(t, map[string]string{
-		"PORT":                           "9090",
-		"POSTGRES_HOST":                  "db",
-		"POSTGRES_PORT":                  "15432",
-		"POSTGRES_USER":                  "u",
-		"POSTGRES_PASSWORD":              "p",
-		"POSTGRES_PASSWORD_FILE":         "",
-		"POSTGRES_DB":                    "service",
-		"DELIVERY_AUTO_RELEASE_INTERVAL": "30s",
-		"ORDER_SERVICE_HOST":             "service-order:50051",
-		"ORDER_GATEWAY_MAX_ATTEMPTS":     "5",
-		"ORDER_GATEWAY_BASE_DELAY":       "150ms",
-		"ORDER_GATEWAY_MAX_DELAY":        "2s",
+		"PORT":                             "9090",
+		"POSTGRES_HOST":                    "db",
+		"POSTGRES_PORT":                    "15432",
+		"POSTGRES_USER":                    "u",
+		"POSTGRES_PASSWORD":                "p",
+		"POSTGRES_PASSWORD_FILE":           "",
+		"POSTGRES_DB":                      "service",
+		"DELIVERY_AUTO_RELEASE_INTERVAL":   "30s",
+		"DELIVERY_ASSIGN_RADIUS_KM":        "2.5",
+		"DELIVERY_ASSIGN_STRATEGY":         "Round_Robin",
+		"DELIVERY_DISPATCH_INTERVAL":       "2s",
+		"DELIVERY_DISPATCH_BATCH":          "20",
+		"DELIVERY_TRANSPORT_TYPES_REFRESH": "1m",
+		"DELIVERY_DEADLINE_POLICY_FILE":    " /etc/courier/deadlines.json ",
+		"ORDER_SERVICE_HOST":               "service-order:50051",
+		"ORDER_GATEWAY_MAX_ATTEMPTS":       "5",
+		"ORDER_GATEWAY_BASE_DELAY":         "150ms",
+		"ORDER_GATEWAY_MAX_DELAY":          "2s",
 	})
 
 	cfg, err := Load()
@@ -88,13 +97,24 @@ func TestLoad_EnvOverrides(t *testing.T) {
 		Host: "db", Port: "15432", User: "u", Pass: "p", Name: "service",
 	}, cfg.DB)
 	require.Equal(t, Delivery{
-		AutoReleaseInterval: 30 * time.Second,
+		AutoReleaseInterval:   30 * time.Second,
+		AssignRadiusKm:        2.5,
+		AssignStrategy:        "round_robin",
+		DispatchInterval:      2 * time.Second,
+		DispatchBatchSize:     20,
+		TransportTypesRefresh: time.Minute,
+		DeadlinePolicyFile:    "/etc/courier/deadlines.json",
 	}, cfg.Delivery)
 	require.Equal(t, "service-order:50051", cfg.OrderService)
 	require.Equal(t, OrdersGateway{
+		Client:      DefaultOrdersGateway().Client,
 		MaxAttempts: 5,
 		BaseDelay:   150 * time.Millisecond,
 		MaxDelay:    2 * time.Second,
+		Breaker:     DefaultOrdersGateway().Breaker,
+		Cache:       DefaultOrdersGateway().Cache,
+		Fallback:    DefaultOrdersGateway().Fallback,
+		Reconcile:   DefaultOrdersGateway().Reconcile,
 	}, cfg.OrdersGateway)
 }
 
@@ -135,6 +155,48 @@ func TestLoad_InvalidReleaseInterval(t *testing.T) {
 	require.Nil(t, cfg)
 }
 
+func TestLoad_InvalidAssignRadius(t *testing.T) {
+	resetFlags(t)
+	setEnvEmpty(t,
+		"PORT",
+		"POSTGRES_PASSWORD_FILE",
+		"DELIVERY_AUTO_RELEASE_INTERVAL",
+	)
+	t.Setenv("DELIVERY_ASSIGN_RADIUS_KM", "0")
+
+	cfg, err := Load()
+	require.Error(t, err)
+	require.Nil(t, cfg)
+}
+
+func TestLoad_InvalidDispatchBatch(t *testing.T) {
+	resetFlags(t)
+	setEnvEmpty(t,
+		"PORT",
+		"POSTGRES_PASSWORD_FILE",
+		"DELIVERY_AUTO_RELEASE_INTERVAL",
+	)
+	t.Setenv("DELIVERY_DISPATCH_BATCH", "0")
+
+	cfg, err := Load()
+	require.Error(t, err)
+	require.Nil(t, cfg)
+}
+
+func TestLoad_InvalidTransportTypesRefresh(t *testing.T) {
+	resetFlags(t)
+	setEnvEmpty(t,
+		"PORT",
+		"POSTGRES_PASSWORD_FILE",
+		"DELIVERY_AUTO_RELEASE_INTERVAL",
+	)
+	t.Setenv("DELIVERY_TRANSPORT_TYPES_REFRESH", "-1s")
+
+	cfg, err := Load()
+	require.Error(t, err)
+	require.Nil(t, cfg)
+}
+
 func TestLoad_InvalidOrderGatewayMaxAttempts(t *testing.T) {
 	resetFlags(t)
 	setEnvEmpty(t,
@@ -239,6 +301,98 @@ func TestLoad_InvalidKafkaBrokers_EmptyAfterTrim(t *testing.T) {
 	require.Contains(t, err.Error(), "invalid KAFKA_BROKERS")
 }
 
+func TestLoadKafka_DLQTopic(t *testing.T) {
+	t.Setenv("KAFKA_BROKERS", "b:9092")
+	t.Setenv("KAFKA_ORDER_TOPIC", "orders")
+	t.Setenv("KAFKA_DLQ_TOPIC", "")
+
+	cfg, err := loadKafka()
+	require.NoError(t, err)
+	require.Equal(t, "orders.dlq", cfg.DLQTopic)
+
+	t.Setenv("KAFKA_DLQ_TOPIC", "orders")
+	_, err = loadKafka()
+	require.ErrorContains(t, err, "KAFKA_DLQ_TOPIC")
+}
+
+func TestLoadKafkaRetry(t *testing.T) {
+	t.Setenv("KAFKA_RETRY_MAX_ATTEMPTS", "")
+	t.Setenv("KAFKA_RETRY_BASE_DELAY", "")
+	t.Setenv("KAFKA_RETRY_MAX_DELAY", "")
+
+	got, err := loadKafkaRetry()
+	require.NoError(t, err)
+	require.Equal(t, KafkaRetry{MaxAttempts: 5, BaseDelay: 100 * time.Millisecond, MaxDelay: 5 * time.Second}, got)
+
+	t.Setenv("KAFKA_RETRY_MAX_ATTEMPTS", "0")
+	_, err = loadKafkaRetry()
+	require.ErrorContains(t, err, "KAFKA_RETRY_MAX_ATTEMPTS")
+
+	t.Setenv("KAFKA_RETRY_MAX_ATTEMPTS", "3")
+	t.Setenv("KAFKA_RETRY_BASE_DELAY", "1s")
+	t.Setenv("KAFKA_RETRY_MAX_DELAY", "500ms")
+	_, err = loadKafkaRetry()
+	require.ErrorContains(t, err, "KAFKA_RETRY_MAX_DELAY")
+}
+
+func TestLoadKafka_Concurrency(t *testing.T) {
+	t.Setenv("KAFKA_BROKERS", "b:9092")
+	t.Setenv("KAFKA_CONCURRENCY", "")
+
+	cfg, err := loadKafka()
+	require.NoError(t, err)
+	require.Equal(t, 8, cfg.Concurrency)
+
+	t.Setenv("KAFKA_CONCURRENCY", "3")
+	cfg, err = loadKafka()
+	require.NoError(t, err)
+	require.Equal(t, 3, cfg.Concurrency)
+
+	t.Setenv("KAFKA_CONCURRENCY", "0")
+	_, err = loadKafka()
+	require.ErrorContains(t, err, "KAFKA_CONCURRENCY")
+}
+
+func TestLoadKafka_Encoding(t *testing.T) {
+	t.Setenv("KAFKA_BROKERS", "b:9092")
+	t.Setenv("KAFKA_ORDER_ENCODING", "")
+
+	cfg, err := loadKafka()
+	require.NoError(t, err)
+	require.Equal(t, "json", cfg.Encoding)
+
+	t.Setenv("KAFKA_ORDER_ENCODING", " Protobuf ")
+	cfg, err = loadKafka()
+	require.NoError(t, err)
+	require.Equal(t, "protobuf", cfg.Encoding)
+
+	t.Setenv("KAFKA_ORDER_ENCODING", "avro")
+	_, err = loadKafka()
+	require.ErrorContains(t, err, "KAFKA_ORDER_ENCODING")
+}
+
+func TestLoadKafka_EventsTopicAndOutbox(t *testing.T) {
+	t.Setenv("KAFKA_BROKERS", "b:9092")
+	t.Setenv("KAFKA_ORDER_TOPIC", "orders")
+	t.Setenv("KAFKA_EVENTS_TOPIC", "")
+	t.Setenv("OUTBOX_RELAY_INTERVAL", "")
+	t.Setenv("OUTBOX_RELAY_BATCH", "")
+
+	cfg, err := loadKafka()
+	require.NoError(t, err)
+	require.Equal(t, "courier.delivery.events", cfg.EventsTopic)
+	require.Equal(t, KafkaOutbox{Interval: time.Second, BatchSize: 100}, cfg.Outbox)
+
+	t.Setenv("KAFKA_EVENTS_TOPIC", "orders")
+	_, err = loadKafka()
+	require.ErrorContains(t, err, "KAFKA_EVENTS_TOPIC")
+
+	t.Setenv("KAFKA_EVENTS_TOPIC", "")
+	t.Setenv("OUTBOX_RELAY_BATCH", "0")
+	_, err = loadKafka()
+	require.ErrorContains(t, err, "OUTBOX_RELAY_BATCH")
+}
+
 func TestParseRateLimit_InvalidEnabled(t *testing.T) {
 	t.Setenv("RATE_LIMIT_ENABLED", "notabool")
 
@@ -331,3 +485,382 @@ func TestParseRateLimit_InvalidMaxBuckets_ValidateNegative(t *testing.T) {
 	require.Error(t, err)
 	require.Contains(t, err.Error(), "RATE_LIMIT_MAX_BUCKETS")
 }
+
+func TestParseWorker(t *testing.T) {
+	t.Setenv("WORKER_HTTP_ADDR", "")
+	t.Setenv("WORKER_READY_TIMEOUT", "")
+
+	cfg, err := parseWorker()
+	require.NoError(t, err)
+	require.Equal(t, Worker{HTTPAddr: ":9091", ReadyTimeout: 2 * time.Second}, cfg)
+
+	t.Setenv("WORKER_HTTP_ADDR", "127.0.0.1:9999")
+	t.Setenv("WORKER_READY_TIMEOUT", "500ms")
+	cfg, err = parseWorker()
+	require.NoError(t, err)
+	require.Equal(t, Worker{HTTPAddr: "127.0.0.1:9999", ReadyTimeout: 500 * time.Millisecond}, cfg)
+
+	t.Setenv("WORKER_READY_TIMEOUT", "0s")
+	_, err = parseWorker()
+	require.ErrorContains(t, err, "WORKER_READY_TIMEOUT")
+}
+
+func clearKafkaClientEnv(t *testing.T) {
+	t.Helper()
+	setEnvEmpty(t,
+		"KAFKA_CLIENT_ID", "KAFKA_VERSION", "KAFKA_REBALANCE_STRATEGY", "KAFKA_SESSION_TIMEOUT",
+		"KAFKA_FETCH_MIN_BYTES", "KAFKA_FETCH_DEFAULT_BYTES", "KAFKA_FETCH_MAX_BYTES", "KAFKA_INITIAL_OFFSET",
+		"KAFKA_TLS_ENABLED", "KAFKA_TLS_CA_FILE", "KAFKA_TLS_CERT_FILE", "KAFKA_TLS_KEY_FILE",
+		"KAFKA_TLS_INSECURE_SKIP_VERIFY",
+		"KAFKA_SASL_MECHANISM", "KAFKA_SASL_USERNAME", "KAFKA_SASL_USERNAME_FILE",
+		"KAFKA_SASL_PASSWORD", "KAFKA_SASL_PASSWORD_FILE",
+	)
+}
+
+func TestLoadKafkaClient_Defaults(t *testing.T) {
+	clearKafkaClientEnv(t)
+
+	cfg, err := loadKafkaClient()
+	require.NoError(t, err)
+	require.Equal(t, KafkaClient{
+		ClientID:          "service-courier",
+		Rebalance:         "range",
+		SessionTimeout:    10 * time.Second,
+		FetchMinBytes:     1,
+		FetchDefaultBytes: 1 << 20,
+		InitialOffset:     "oldest",
+	}, cfg)
+}
+
+func TestLoadKafkaClient_SecuredCluster(t *testing.T) {
+	clearKafkaClientEnv(t)
+	dir := t.TempDir()
+	ca := filepath.Join(dir, "ca.pem")
+	pass := filepath.Join(dir, "kafka_password")
+	require.NoError(t, os.WriteFile(ca, []byte("ca"), 0o600))
+	require.NoError(t, os.WriteFile(pass, []byte("s3cret\n"), 0o600))
+
+	t.Setenv("KAFKA_VERSION", "3.6.0")
+	t.Setenv("KAFKA_REBALANCE_STRATEGY", "Sticky")
+	t.Setenv("KAFKA_SESSION_TIMEOUT", "45s")
+	t.Setenv("KAFKA_FETCH_DEFAULT_BYTES", "4096")
+	t.Setenv("KAFKA_FETCH_MAX_BYTES", "8192")
+	t.Setenv("KAFKA_INITIAL_OFFSET", "newest")
+	t.Setenv("KAFKA_TLS_ENABLED", "true")
+	t.Setenv("KAFKA_TLS_CA_FILE", ca)
+	t.Setenv("KAFKA_SASL_MECHANISM", "scram-sha-512")
+	t.Setenv("KAFKA_SASL_USERNAME", "courier")
+	t.Setenv("KAFKA_SASL_PASSWORD", "ignored")
+	t.Setenv("KAFKA_SASL_PASSWORD_FILE", pass)
+
+	cfg, err := loadKafkaClient()
+	require.NoError(t, err)
+	require.Equal(t, KafkaClient{
+		ClientID:          "service-courier",
+		Version:           "3.6.0",
+		TLS:               KafkaTLS{Enabled: true, CAFile: ca},
+		SASL:              KafkaSASL{Mechanism: "SCRAM-SHA-512", User: "courier", Pass: "s3cret"},
+		Rebalance:         "sticky",
+		SessionTimeout:    45 * time.Second,
+		FetchMinBytes:     1,
+		FetchDefaultBytes: 4096,
+		FetchMaxBytes:     8192,
+		InitialOffset:     "newest",
+	}, cfg)
+}
+
+func TestLoadKafkaClient_RejectsInvalidCombinations(t *testing.T) {
+	dir := t.TempDir()
+	cert := filepath.Join(dir, "cert.pem")
+	require.NoError(t, os.WriteFile(cert, []byte("cert"), 0o600))
+
+	cases := []struct {
+		name string
+		env  map[string]string
+		want string
+	}{
+		{"version", map[string]string{"KAFKA_VERSION": "latest"}, "KAFKA_VERSION"},
+		{"rebalance", map[string]string{"KAFKA_REBALANCE_STRATEGY": "cooperative"}, "KAFKA_REBALANCE_STRATEGY"},
+		{"initial offset", map[string]string{"KAFKA_INITIAL_OFFSET": "earliest"}, "KAFKA_INITIAL_OFFSET"},
+		{"session timeout", map[string]string{"KAFKA_SESSION_TIMEOUT": "1s"}, "KAFKA_SESSION_TIMEOUT"},
+		{"fetch default below min", map[string]string{
+			"KAFKA_FETCH_MIN_BYTES": "100", "KAFKA_FETCH_DEFAULT_BYTES": "10",
+		}, "KAFKA_FETCH_DEFAULT_BYTES"},
+		{"fetch max below default", map[string]string{"KAFKA_FETCH_MAX_BYTES": "10"}, "KAFKA_FETCH_MAX_BYTES"},
+		{"tls file without tls", map[string]string{"KAFKA_TLS_CA_FILE": cert}, "KAFKA_TLS_ENABLED"},
+		{"cert without key", map[string]string{
+			"KAFKA_TLS_ENABLED": "true", "KAFKA_TLS_CERT_FILE": cert,
+		}, "KAFKA_TLS_KEY_FILE"},
+		{"ca with skip verify", map[string]string{
+			"KAFKA_TLS_ENABLED": "true", "KAFKA_TLS_CA_FILE": cert, "KAFKA_TLS_INSECURE_SKIP_VERIFY": "true",
+		}, "KAFKA_TLS_INSECURE_SKIP_VERIFY"},
+		{"missing ca", map[string]string{
+			"KAFKA_TLS_ENABLED": "true", "KAFKA_TLS_CA_FILE": filepath.Join(dir, "missing.pem"),
+		}, "KAFKA_TLS_CA_FILE"},
+		{"mechanism", map[string]string{
+			"KAFKA_SASL_MECHANISM": "GSSAPI", "KAFKA_SASL_USERNAME": "u", "KAFKA_SASL_PASSWORD": "p",
+		}, "KAFKA_SASL_MECHANISM"},
+		{"mechanism without password", map[string]string{
+			"KAFKA_SASL_MECHANISM": "SCRAM-SHA-256", "KAFKA_SASL_USERNAME": "u",
+		}, "KAFKA_SASL_PASSWORD"},
+		{"credentials without mechanism", map[string]string{
+			"KAFKA_SASL_USERNAME": "u", "KAFKA_SASL_PASSWORD": "p",
+		}, "KAFKA_SASL_MECHANISM"},
+		{"missing password file", map[string]string{
+			"KAFKA_SASL_MECHANISM": "SCRAM-SHA-256", "KAFKA_SASL_USERNAME": "u",
+			"KAFKA_SASL_PASSWORD_FILE": filepath.Join(dir, "missing"),
+		}, "KAFKA_SASL_PASSWORD_FILE"},
+		{"plain without tls", map[string]string{
+			"KAFKA_SASL_MECHANISM": "PLAIN", "KAFKA_SASL_USERNAME": "u", "KAFKA_SASL_PASSWORD": "p",
+		}, "KAFKA_TLS_ENABLED"},
+		{"scram on an old cluster", map[string]string{
+			"KAFKA_VERSION": "0.10.0.0", "KAFKA_SASL_MECHANISM": "SCRAM-SHA-256",
+			"KAFKA_SASL_USERNAME": "u", "KAFKA_SASL_PASSWORD": "p",
+		}, "KAFKA_VERSION"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			clearKafkaClientEnv(t)
+			setEnvMap(t, tc.env)
+
+			_, err := loadKafkaClient()
+			require.ErrorContains(t, err, tc.want)
+		})
+	}
+}
+
+func TestParseOrdersBreaker(t *testing.T) {
+	setEnvEmpty(t,
+		"ORDER_GATEWAY_BREAKER_ENABLED", "ORDER_GATEWAY_BREAKER_WINDOW", "ORDER_GATEWAY_BREAKER_MIN_REQUESTS",
+		"ORDER_GATEWAY_BREAKER_FAILURE_RATIO", "ORDER_GATEWAY_BREAKER_COOLDOWN", "ORDER_GATEWAY_BREAKER_HALF_OPEN_REQUESTS",
+	)
+
+	cfg, err := parseOrdersBreaker()
+	require.NoError(t, err)
+	require.Equal(t, OrdersBreaker{
+		Enabled:          true,
+		Window:           30 * time.Second,
+		MinRequests:      10,
+		FailureRatio:     0.5,
+		CoolDown:         15 * time.Second,
+		HalfOpenRequests: 3,
+	}, cfg)
+
+	setEnvMap(t, map[string]string{
+		"ORDER_GATEWAY_BREAKER_ENABLED":            "false",
+		"ORDER_GATEWAY_BREAKER_WINDOW":             "1m",
+		"ORDER_GATEWAY_BREAKER_MIN_REQUESTS":       "20",
+		"ORDER_GATEWAY_BREAKER_FAILURE_RATIO":      "0.25",
+		"ORDER_GATEWAY_BREAKER_COOLDOWN":           "30s",
+		"ORDER_GATEWAY_BREAKER_HALF_OPEN_REQUESTS": "1",
+	})
+	cfg, err = parseOrdersBreaker()
+	require.NoError(t, err)
+	require.Equal(t, OrdersBreaker{
+		Window:           time.Minute,
+		MinRequests:      20,
+		FailureRatio:     0.25,
+		CoolDown:         30 * time.Second,
+		HalfOpenRequests: 1,
+	}, cfg)
+
+	t.Setenv("ORDER_GATEWAY_BREAKER_FAILURE_RATIO", "1.5")
+	_, err = parseOrdersBreaker()
+	require.ErrorContains(t, err, "ORDER_GATEWAY_BREAKER_FAILURE_RATIO")
+}
+
+func TestParseOrdersCache(t *testing.T) {
+	setEnvEmpty(t,
+		"ORDER_GATEWAY_CACHE_ENABLED", "ORDER_GATEWAY_CACHE_TTL", "ORDER_GATEWAY_CACHE_NEGATIVE_TTL", "ORDER_GATEWAY_CACHE_SIZE",
+	)
+
+	cfg, err := parseOrdersCache()
+	require.NoError(t, err)
+	require.Equal(t, OrdersCache{
+		Enabled:     true,
+		TTL:         10 * time.Second,
+		NegativeTTL: 2 * time.Second,
+		Size:        10000,
+	}, cfg)
+
+	setEnvMap(t, map[string]string{
+		"ORDER_GATEWAY_CACHE_ENABLED":      "false",
+		"ORDER_GATEWAY_CACHE_TTL":          "1m",
+		"ORDER_GATEWAY_CACHE_NEGATIVE_TTL": "0s",
+		"ORDER_GATEWAY_CACHE_SIZE":         "100",
+	})
+	cfg, err = parseOrdersCache()
+	require.NoError(t, err)
+	require.Equal(t, OrdersCache{TTL: time.Minute, Size: 100}, cfg)
+
+	t.Setenv("ORDER_GATEWAY_CACHE_SIZE", "0")
+	_, err = parseOrdersCache()
+	require.ErrorContains(t, err, "ORDER_GATEWAY_CACHE_SIZE")
+}
+
+func TestParseOrdersFallback(t *testing.T) {
+	setEnvEmpty(t,
+		"ORDER_GATEWAY_FALLBACK", "ORDER_GATEWAY_VERIFY_DELAY", "ORDER_GATEWAY_VERIFY_INTERVAL", "ORDER_GATEWAY_VERIFY_BATCH",
+	)
+
+	cfg, err := parseOrdersFallback()
+	require.NoError(t, err)
+	require.Equal(t, OrdersFallback{
+		Mode:           FallbackStrict,
+		VerifyDelay:    time.Minute,
+		VerifyInterval: 30 * time.Second,
+		VerifyBatch:    50,
+	}, cfg)
+
+	setEnvMap(t, map[string]string{
+		"ORDER_GATEWAY_FALLBACK":        "Trust-Payload",
+		"ORDER_GATEWAY_VERIFY_DELAY":    "5m",
+		"ORDER_GATEWAY_VERIFY_INTERVAL": "10s",
+		"ORDER_GATEWAY_VERIFY_BATCH":    "100",
+	})
+	cfg, err = parseOrdersFallback()
+	require.NoError(t, err)
+	require.Equal(t, OrdersFallback{
+		Mode:           FallbackTrustPayload,
+		VerifyDelay:    5 * time.Minute,
+		VerifyInterval: 10 * time.Second,
+		VerifyBatch:    100,
+	}, cfg)
+
+	t.Setenv("ORDER_GATEWAY_FALLBACK", "lenient")
+	_, err = parseOrdersFallback()
+	require.ErrorContains(t, err, "ORDER_GATEWAY_FALLBACK")
+
+	t.Setenv("ORDER_GATEWAY_FALLBACK", "strict")
+	t.Setenv("ORDER_GATEWAY_VERIFY_DELAY", "10ms")
+	_, err = parseOrdersFallback()
+	require.ErrorContains(t, err, "ORDER_GATEWAY_VERIFY_DELAY")
+}
+
+func TestParseOrdersReconcile(t *testing.T) {
+	setEnvEmpty(t,
+		"ORDER_RECONCILE_ENABLED", "ORDER_RECONCILE_INTERVAL", "ORDER_RECONCILE_LOOKBACK", "ORDER_RECONCILE_DRY_RUN",
+	)
+
+	cfg, err := parseOrdersReconcile()
+	require.NoError(t, err)
+	require.Equal(t, OrdersReconcile{
+		Interval: 5 * time.Minute,
+		Lookback: time.Hour,
+	}, cfg)
+
+	setEnvMap(t, map[string]string{
+		"ORDER_RECONCILE_ENABLED":  "true",
+		"ORDER_RECONCILE_INTERVAL": "1m",
+		"ORDER_RECONCILE_LOOKBACK": "24h",
+		"ORDER_RECONCILE_DRY_RUN":  "true",
+	})
+	cfg, err = parseOrdersReconcile()
+	require.NoError(t, err)
+	require.Equal(t, OrdersReconcile{
+		Enabled:  true,
+		Interval: time.Minute,
+		Lookback: 24 * time.Hour,
+		DryRun:   true,
+	}, cfg)
+
+	t.Setenv("ORDER_RECONCILE_INTERVAL", "10ms")
+	_, err = parseOrdersReconcile()
+	require.ErrorContains(t, err, "ORDER_RECONCILE_INTERVAL")
+}
+
+func clearOrdersClientEnv(t *testing.T) {
+	t.Helper()
+	setEnvEmpty(t,
+		"ORDER_SERVICE_TLS_ENABLED", "ORDER_SERVICE_TLS_CA_FILE", "ORDER_SERVICE_TLS_CERT_FILE",
+		"ORDER_SERVICE_TLS_KEY_FILE", "ORDER_SERVICE_TLS_SERVER_NAME",
+		"ORDER_SERVICE_TOKEN", "ORDER_SERVICE_TOKEN_FILE", "ORDER_SERVICE_API_KEY", "ORDER_SERVICE_API_KEY_FILE",
+		"ORDER_SERVICE_API_KEY_HEADER",
+		"ORDER_SERVICE_KEEPALIVE_TIME", "ORDER_SERVICE_KEEPALIVE_TIMEOUT", "ORDER_SERVICE_CALL_TIMEOUT",
+	)
+}
+
+func TestParseOrdersClient_Defaults(t *testing.T) {
+	clearOrdersClientEnv(t)
+
+	cfg, err := parseOrdersClient()
+	require.NoError(t, err)
+	require.Equal(t, OrdersClient{
+		APIKeyHeader:     "x-api-key",
+		KeepaliveTimeout: 20 * time.Second,
+		CallTimeout:      10 * time.Second,
+	}, cfg)
+}
+
+func TestParseOrdersClient_MTLSWithTokenFile(t *testing.T) {
+	clearOrdersClientEnv(t)
+	dir := t.TempDir()
+	ca := filepath.Join(dir, "ca.pem")
+	cert := filepath.Join(dir, "cert.pem")
+	key := filepath.Join(dir, "key.pem")
+	token := filepath.Join(dir, "orders_token")
+	for _, f := range []string{ca, cert, key} {
+		require.NoError(t, os.WriteFile(f, []byte("pem"), 0o600))
+	}
+	require.NoError(t, os.WriteFile(token, []byte("s3cret\n"), 0o600))
+
+	setEnvMap(t, map[string]string{
+		"ORDER_SERVICE_TLS_ENABLED":       "true",
+		"ORDER_SERVICE_TLS_CA_FILE":       ca,
+		"ORDER_SERVICE_TLS_CERT_FILE":     cert,
+		"ORDER_SERVICE_TLS_KEY_FILE":      key,
+		"ORDER_SERVICE_TLS_SERVER_NAME":   "orders.internal",
+		"ORDER_SERVICE_TOKEN":             "ignored",
+		"ORDER_SERVICE_TOKEN_FILE":        token,
+		"ORDER_SERVICE_KEEPALIVE_TIME":    "30s",
+		"ORDER_SERVICE_KEEPALIVE_TIMEOUT": "5s",
+		"ORDER_SERVICE_CALL_TIMEOUT":      "0s",
+	})
+
+	cfg, err := parseOrdersClient()
+	require.NoError(t, err)
+	require.Equal(t, OrdersClient{
+		TLS:              OrdersTLS{Enabled: true, CAFile: ca, CertFile: cert, KeyFile: key, ServerName: "orders.internal"},
+		Token:            "s3cret",
+		APIKeyHeader:     "x-api-key",
+		KeepaliveTime:    30 * time.Second,
+		KeepaliveTimeout: 5 * time.Second,
+	}, cfg)
+}
+
+func TestParseOrdersClient_RejectsInvalidCombinations(t *testing.T) {
+	dir := t.TempDir()
+	cert := filepath.Join(dir, "cert.pem")
+	require.NoError(t, os.WriteFile(cert, []byte("cert"), 0o600))
+
+	cases := []struct {
+		name string
+		env  map[string]string
+		want string
+	}{
+		{"tls file without tls", map[string]string{"ORDER_SERVICE_TLS_CA_FILE": cert}, "ORDER_SERVICE_TLS_ENABLED"},
+		{"cert without key", map[string]string{
+			"ORDER_SERVICE_TLS_ENABLED": "true", "ORDER_SERVICE_TLS_CERT_FILE": cert,
+		}, "ORDER_SERVICE_TLS_KEY_FILE"},
+		{"missing ca", map[string]string{
+			"ORDER_SERVICE_TLS_ENABLED": "true", "ORDER_SERVICE_TLS_CA_FILE": filepath.Join(dir, "missing.pem"),
+		}, "ORDER_SERVICE_TLS_CA_FILE"},
+		{"token and api key", map[string]string{
+			"ORDER_SERVICE_TLS_ENABLED": "true", "ORDER_SERVICE_TOKEN": "t", "ORDER_SERVICE_API_KEY": "k",
+		}, "ORDER_SERVICE_API_KEY"},
+		{"token without tls", map[string]string{"ORDER_SERVICE_TOKEN": "t"}, "ORDER_SERVICE_TLS_ENABLED"},
+		{"missing api key file", map[string]string{
+			"ORDER_SERVICE_API_KEY_FILE": filepath.Join(dir, "missing"),
+		}, "ORDER_SERVICE_API_KEY_FILE"},
+		{"keepalive below 10s", map[string]string{"ORDER_SERVICE_KEEPALIVE_TIME": "1s"}, "ORDER_SERVICE_KEEPALIVE_TIME"},
+		{"negative call timeout", map[string]string{"ORDER_SERVICE_CALL_TIMEOUT": "-1s"}, "ORDER_SERVICE_CALL_TIMEOUT"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			clearOrdersClientEnv(t)
+			setEnvMap(t, tc.env)
+			_, err := parseOrdersClient()
+			require.ErrorContains(t, err, tc.want)
+		})
+	}
+}
@@ -5,9 +5,38 @@ import "time"
 const defaultPort = 8080
 
 var defaultOrdersGateway = OrdersGateway{
+	Client: OrdersClient{
+		APIKeyHeader:     "x-api-key",
+		KeepaliveTimeout: 20 * time.Second,
+		CallTimeout:      10 * time.Second,
+	},
 	MaxAttempts: 4,
 	BaseDelay:   150 * time.Millisecond,
 	MaxDelay:    200 * time.Millisecond,
+	Breaker: OrdersBreaker{
+		Enabled:          true,
+		Window:           30 * time.Second,
+		MinRequests:      10,
+		FailureRatio:     0.5,
+		CoolDown:         15 * time.Second,
+		HalfOpenRequests: 3,
+	},
+	Cache: OrdersCache{
+		Enabled:     true,
+		TTL:         10 * time.Second,
+		NegativeTTL: 2 * time.Second,
+		Size:        10000,
+	},
+	Fallback: OrdersFallback{
+		Mode:           FallbackStrict,
+		VerifyDelay:    time.Minute,
+		VerifyInterval: 30 * time.Second,
+		VerifyBatch:    50,
+	},
+	Reconcile: OrdersReconcile{
+		Interval: 5 * time.Minute,
+		Lookback: time.Hour,
+	},
 }
 
 var defaultDB = DB{
@@ -21,7 +50,39 @@ var defaultDB = DB{
 const defaultOrderServiceHost = "localhost:50051"
 
 var defaultDelivery = Delivery{
-	AutoReleaseInterval: 10 * time.Second,
+	AutoReleaseInterval:   10 * time.Second,
+	AssignRadiusKm:        5,
+	AssignStrategy:        "nearest",
+	DispatchInterval:      5 * time.Second,
+	DispatchBatchSize:     50,
+	TransportTypesRefresh: 30 * time.Second,
+}
+
+var defaultKafkaRetry = KafkaRetry{
+	MaxAttempts: 5,
+	BaseDelay:   100 * time.Millisecond,
+	MaxDelay:    5 * time.Second,
+}
+
+const defaultKafkaConcurrency = 8
+
+var defaultKafkaClient = KafkaClient{
+	ClientID:          "service-courier",
+	Rebalance:         "range",
+	SessionTimeout:    10 * time.Second,
+	FetchMinBytes:     1,
+	FetchDefaultBytes: 1 << 20,
+	InitialOffset:     "oldest",
+}
+
+var defaultKafkaOutbox = KafkaOutbox{
+	Interval:  time.Second,
+	BatchSize: 100,
+}
+
+var defaultWorker = Worker{
+	HTTPAddr:     ":9091",
+	ReadyTimeout: 2 * time.Second,
 }
 
 var defaultRateLimit = rateLimit{
@@ -1,5 +1,7 @@
 package domain
 
+import "time"
+
 type (
 	// CourierStatus represents the status of a courier.
 	CourierStatus string
@@ -14,6 +16,41 @@ type Courier struct {
 	Phone         string
 	Status        CourierStatus
 	TransportType CourierTransportType
+	// Capacity overrides the transport capacity, nil means the transport default
+	Capacity *int
+}
+
+// MaxActiveDeliveries returns how many deliveries the courier may carry at once,
+// its transport default is taken from types.
+func (c Courier) MaxActiveDeliveries(types TransportTypes) int {
+	if c.Capacity != nil {
+		return *c.Capacity
+	}
+	return c.TransportType.Capacity(types)
+}
+
+// LoadStatus returns the status of the courier while it carries active deliveries: busy once all of its
+// slots are taken, available otherwise. A paused courier stays paused.
+func (c Courier) LoadStatus(active int, types TransportTypes) CourierStatus {
+	switch {
+	case c.Status == StatusPaused:
+		return StatusPaused
+	case active >= c.MaxActiveDeliveries(types):
+		return StatusBusy
+	default:
+		return StatusAvailable
+	}
+}
+
+// CourierCandidate is an available courier together with the data assignment strategies rank by.
+type CourierCandidate struct {
+	Courier Courier
+	// Location is the last reported courier location, nil if the courier never reported it
+	Location         *Location
+	ActiveDeliveries int
+	TotalDeliveries  int
+	// LastAssignedAt is the time of the latest delivery assigned to the courier, nil if there were none
+	LastAssignedAt *time.Time
 }
 
 // PartialCourierUpdate carries optional fields to update a courier.
@@ -23,4 +60,26 @@ type PartialCourierUpdate struct {
 	Phone         *string
 	Status        *CourierStatus
 	TransportType *CourierTransportType
+	Capacity      *int
+}
+
+// Apply returns c with the fields set in u.
+func (u PartialCourierUpdate) Apply(c Courier) Courier {
+	if u.Name != nil {
+		c.Name = *u.Name
+	}
+	if u.Phone != nil {
+		c.Phone = *u.Phone
+	}
+	if u.Status != nil {
+		c.Status = *u.Status
+	}
+	if u.TransportType != nil {
+		c.TransportType = *u.TransportType
+	}
+	if u.Capacity != nil {
+		capacity := *u.Capacity
+		c.Capacity = &capacity
+	}
+	return c
 }
@@ -2,13 +2,24 @@ package domain
 
 import "time"
 
+// DeliveryStatus represents the lifecycle state of a delivery.
+type DeliveryStatus string
+
 // Delivery - struct representing a delivery assignment.
 type Delivery struct {
-	ID         int64
-	CourierID  int64
-	OrderID    string
-	AssignedAt time.Time
-	Deadline   time.Time
+	ID          int64
+	CourierID   int64
+	OrderID     string
+	Status      DeliveryStatus
+	AssignedAt  time.Time
+	Deadline    time.Time
+	PickedUpAt  *time.Time
+	DeliveredAt *time.Time
+	CanceledAt  *time.Time
+	ExpiredAt   *time.Time
+	FailedAt    *time.Time
+	// Pickup is the pickup point the courier was chosen for, nil if the order came without one
+	Pickup *Location
 }
 
 // AssignResult - struct representing the result of assigning a delivery.
@@ -17,6 +28,8 @@ type AssignResult struct {
 	OrderID       string
 	TransportType CourierTransportType
 	Deadline      time.Time
+	// DeadlineExplanation lists the deadline rules applied, empty if the TimeFactory gives none
+	DeadlineExplanation string
 }
 
 // UnassignResult - struct representing the result of unassigning a delivery.
@@ -25,3 +38,32 @@ type UnassignResult struct {
 	OrderID   string
 	Status    string
 }
+
+// TransitionResult - struct representing the result of moving a delivery to a new state.
+type TransitionResult struct {
+	CourierID int64
+	OrderID   string
+	Status    DeliveryStatus
+	At        time.Time
+}
+
+// PendingOrder is an order waiting in the queue for a courier to become available.
+type PendingOrder struct {
+	OrderID string
+	Pickup  *Location
+	// Priority orders are dispatched first; orders of the same priority are dispatched FIFO
+	Priority   int
+	EnqueuedAt time.Time
+	// ExcludedCourierID is the courier whose delivery of the order expired, the order is not given back to them
+	ExcludedCourierID *int64
+}
+
+// Reassignment records an expired delivery handed over to another courier.
+// ToCourierID is nil when no other courier was available and the order was queued instead.
+type Reassignment struct {
+	OrderID           string
+	ExpiredDeliveryID int64
+	FromCourierID     int64
+	ToCourierID       *int64
+	At                time.Time
+}
@@ -0,0 +1,19 @@
+package domain
+
+import "fmt"
+
+// EventKey identifies a consumed message by its position in the log.
+type EventKey struct {
+	Topic     string
+	Partition int32
+	Offset    int64
+}
+
+// IsZero reports whether the key is unset, e.g. for events that did not come from Kafka.
+func (k EventKey) IsZero() bool {
+	return k.Topic == ""
+}
+
+func (k EventKey) String() string {
+	return fmt.Sprintf("%s/%d/%d", k.Topic, k.Partition, k.Offset)
+}
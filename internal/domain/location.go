@@ -0,0 +1,27 @@
+package domain
+
+import "math"
+
+const earthRadiusKm = 6371.0
+
+// Location is a point on the map in WGS84 degrees.
+type Location struct {
+	Lat float64
+	Lon float64
+}
+
+// Valid checks if the Location has coordinates within the allowed ranges
+func (l Location) Valid() bool {
+	return l.Lat >= -90 && l.Lat <= 90 && l.Lon >= -180 && l.Lon <= 180
+}
+
+// DistanceKm returns the great-circle (haversine) distance to another location in kilometers.
+func (l Location) DistanceKm(to Location) float64 {
+	lat1 := l.Lat * math.Pi / 180
+	lat2 := to.Lat * math.Pi / 180
+	dLat := lat2 - lat1
+	dLon := (to.Lon - l.Lon) * math.Pi / 180
+
+	h := math.Pow(math.Sin(dLat/2), 2) + math.Cos(lat1)*math.Cos(lat2)*math.Pow(math.Sin(dLon/2), 2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(math.Min(1, h)))
+}
@@ -0,0 +1,23 @@
+package domain
+
+import "time"
+
+// OrderVersion is the last order status the worker applied.
+type OrderVersion struct {
+	OrderID string
+	Status  string
+	// At is when the applied event was produced; zero if the event carried no time
+	At time.Time
+}
+
+// OrderVerification is an order event applied from its payload while the orders service was
+// unavailable, waiting to be checked against the orders service.
+type OrderVerification struct {
+	OrderID string
+	// Status is the status the event was applied with
+	Status string
+	// At is when the event was produced; zero if the event carried no time
+	At time.Time
+	// DueAt is when the order is checked
+	DueAt time.Time
+}
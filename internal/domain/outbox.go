@@ -0,0 +1,42 @@
+package domain
+
+import (
+	"strconv"
+	"time"
+)
+
+// OutboxEventType names a change the service announces to other services.
+type OutboxEventType string
+
+const (
+	// OutboxDeliveryAssigned - a courier got an order
+	OutboxDeliveryAssigned OutboxEventType = "delivery.assigned"
+	// OutboxDeliveryStatusChanged - a delivery was unassigned, picked up, completed, failed or expired
+	OutboxDeliveryStatusChanged OutboxEventType = "delivery.status_changed"
+	// OutboxCourierStatusChanged - a courier became busy or available
+	OutboxCourierStatusChanged OutboxEventType = "courier.status_changed"
+)
+
+// OutboxEvent is a change recorded in the transaction that made it and published afterwards.
+type OutboxEvent struct {
+	ID   int64
+	Type OutboxEventType
+	// OrderID is empty for courier events
+	OrderID   string
+	CourierID int64
+	// DeliveryStatus is set for delivery events
+	DeliveryStatus DeliveryStatus
+	// CourierStatus is set for courier events
+	CourierStatus CourierStatus
+	// Deadline is set for OutboxDeliveryAssigned
+	Deadline   *time.Time
+	OccurredAt time.Time
+}
+
+// Key returns the partitioning key of the event: events of one order, or of one courier, keep their order.
+func (e OutboxEvent) Key() string {
+	if e.OrderID != "" {
+		return e.OrderID
+	}
+	return "courier-" + strconv.FormatInt(e.CourierID, 10)
+}
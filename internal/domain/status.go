@@ -9,9 +9,10 @@ const (
 	StatusPaused    CourierStatus = "paused"
 )
 
-// List of possible courier transport types
+// Built-in courier transport types, more can be added to the transport_types table
 const (
 	TransportTypeFoot    CourierTransportType = "on_foot"
+	TransportTypeBike    CourierTransportType = "bike"
 	TransportTypeScooter CourierTransportType = "scooter"
 	TransportTypeCar     CourierTransportType = "car"
 )
@@ -21,8 +22,25 @@ var allowedStatuses = [...]CourierStatus{
 	StatusAvailable, StatusBusy, StatusPaused,
 }
 
-var allowedTransportTypes = [...]CourierTransportType{
-	TransportTypeFoot, TransportTypeScooter, TransportTypeCar,
+// List of possible delivery statuses
+const (
+	DeliveryStatusAssigned  DeliveryStatus = "assigned"
+	DeliveryStatusPickedUp  DeliveryStatus = "picked_up"
+	DeliveryStatusDelivered DeliveryStatus = "delivered"
+	DeliveryStatusCanceled  DeliveryStatus = "canceled"
+	DeliveryStatusExpired   DeliveryStatus = "expired"
+	DeliveryStatusFailed    DeliveryStatus = "failed"
+)
+
+// deliveryTransitions lists the states reachable from each non-terminal delivery state
+var deliveryTransitions = map[DeliveryStatus][]DeliveryStatus{
+	DeliveryStatusAssigned: {
+		DeliveryStatusPickedUp, DeliveryStatusDelivered, DeliveryStatusCanceled,
+		DeliveryStatusExpired, DeliveryStatusFailed,
+	},
+	DeliveryStatusPickedUp: {
+		DeliveryStatusDelivered, DeliveryStatusCanceled, DeliveryStatusExpired, DeliveryStatusFailed,
+	},
 }
 
 // Valid checks if the CourierStatus is valid
@@ -35,10 +53,41 @@ func (s CourierStatus) Valid() bool {
 	return false
 }
 
-// Valid checks if the CourierTransportType is valid
-func (t CourierTransportType) Valid() bool {
-	for _, v := range allowedTransportTypes {
-		if t == v {
+// Valid checks if the CourierTransportType is one of types
+func (t CourierTransportType) Valid(types TransportTypes) bool {
+	_, ok := types.Get(t)
+	return ok
+}
+
+// Capacity returns how many deliveries a courier on this transport carries at once by default,
+// one for a transport missing from types
+func (t CourierTransportType) Capacity(types TransportTypes) int {
+	if tt, ok := types.Get(t); ok {
+		return tt.MaxCapacity
+	}
+	return 1
+}
+
+// Valid checks if the DeliveryStatus is valid
+func (s DeliveryStatus) Valid() bool {
+	switch s {
+	case DeliveryStatusAssigned, DeliveryStatusPickedUp, DeliveryStatusDelivered,
+		DeliveryStatusCanceled, DeliveryStatusExpired, DeliveryStatusFailed:
+		return true
+	default:
+		return false
+	}
+}
+
+// Active reports whether the delivery is still in progress and holds a courier
+func (s DeliveryStatus) Active() bool {
+	return s == DeliveryStatusAssigned || s == DeliveryStatusPickedUp
+}
+
+// CanTransitionTo reports whether a delivery may move from s to next
+func (s DeliveryStatus) CanTransitionTo(next DeliveryStatus) bool {
+	for _, v := range deliveryTransitions[s] {
+		if v == next {
 			return true
 		}
 	}
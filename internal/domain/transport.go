@@ -0,0 +1,59 @@
+package domain
+
+import (
+	"regexp"
+	"time"
+)
+
+// TransportType describes a courier transport and the delivery rules that depend on it.
+type TransportType struct {
+	Code CourierTransportType
+	// SpeedKmh is the average speed of a courier on this transport
+	SpeedKmh float64
+	// MaxCapacity is how many deliveries a courier on this transport carries at once by default
+	MaxCapacity int
+	// BaseDeadline is how long a delivery on this transport may take
+	BaseDeadline time.Duration
+}
+
+var reTransportCode = regexp.MustCompile(`^[a-z][a-z0-9_]{0,31}$`)
+
+// Valid checks that the transport type has a well-formed code and positive rules
+func (t TransportType) Valid() bool {
+	return reTransportCode.MatchString(string(t.Code)) &&
+		t.SpeedKmh > 0 && t.MaxCapacity > 0 && t.BaseDeadline > 0
+}
+
+// DefaultTransportTypes returns the built-in transport types used until the catalog is loaded from storage,
+// they match the rows seeded by the transport_types migration
+func DefaultTransportTypes() []TransportType {
+	return []TransportType{
+		{Code: TransportTypeFoot, SpeedKmh: 5, MaxCapacity: 1, BaseDeadline: 30 * time.Minute},
+		{Code: TransportTypeBike, SpeedKmh: 15, MaxCapacity: 2, BaseDeadline: 20 * time.Minute},
+		{Code: TransportTypeScooter, SpeedKmh: 20, MaxCapacity: 2, BaseDeadline: 15 * time.Minute},
+		{Code: TransportTypeCar, SpeedKmh: 40, MaxCapacity: 4, BaseDeadline: 5 * time.Minute},
+	}
+}
+
+// TransportTypes resolves transport types by code.
+type TransportTypes interface {
+	Get(code CourierTransportType) (TransportType, bool)
+}
+
+// TransportTypeSet is a set of transport types indexed by code.
+type TransportTypeSet map[CourierTransportType]TransportType
+
+// NewTransportTypeSet indexes types by code.
+func NewTransportTypeSet(types []TransportType) TransportTypeSet {
+	s := make(TransportTypeSet, len(types))
+	for _, t := range types {
+		s[t.Code] = t
+	}
+	return s
+}
+
+// Get returns the transport type with the given code.
+func (s TransportTypeSet) Get(code CourierTransportType) (TransportType, bool) {
+	t, ok := s[code]
+	return t, ok
+}
@@ -0,0 +1,249 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"course-go-avito-Orurh/internal/logx"
+)
+
+// ErrCircuitOpen is returned without calling the orders service while the breaker is open
+var ErrCircuitOpen = errors.New("order gateway: circuit breaker is open")
+
+// BreakerState is the state of a BreakerGateway, its value is the one of the state gauge
+type BreakerState int
+
+// States of a BreakerGateway.
+const (
+	// BreakerClosed passes every call and counts failures
+	BreakerClosed BreakerState = iota
+	// BreakerOpen fails every call with ErrCircuitOpen until the cool-down is over
+	BreakerOpen
+	// BreakerHalfOpen passes a few trial calls that decide whether to close or open again
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// breakerBuckets is how many buckets the failure window is split into
+const breakerBuckets = 10
+
+// BreakerConfig is a configuration for BreakerGateway
+type BreakerConfig struct {
+	// Window is how far back calls are counted for the failure ratio
+	Window time.Duration
+	// MinRequests is how many calls the window needs before the breaker may open
+	MinRequests int
+	// FailureRatio opens the breaker when this share of the calls in the window failed
+	FailureRatio float64
+	// CoolDown is how long the breaker stays open before it lets trial calls through
+	CoolDown time.Duration
+	// HalfOpenRequests is how many trial calls must succeed in a row to close the breaker
+	HalfOpenRequests int
+}
+
+type gauge interface {
+	Set(float64)
+}
+
+type breakerBucket struct {
+	start           time.Time
+	total, failures int
+}
+
+// BreakerGateway is a gateway that stops calling the orders service while it keeps failing.
+// Only Unavailable, DeadlineExceeded and ResourceExhausted errors count as failures; a call
+// canceled by the caller is not counted at all.
+type BreakerGateway struct {
+	next   gateway
+	logger logx.Logger
+	gauge  gauge
+	cfg    BreakerConfig
+	now    func() time.Time
+
+	mu       sync.Mutex
+	state    BreakerState
+	openedAt time.Time
+	// generation changes with every transition, outcomes of calls started before it are dropped
+	generation uint64
+	buckets    [breakerBuckets]breakerBucket
+	// trials and successes count the calls let through and succeeded in the half-open state
+	trials    int
+	successes int
+}
+
+// NewBreakerGateway проверяет, что next не nil и возвращает BreakerGateway в состоянии closed
+func NewBreakerGateway(next gateway, logger logx.Logger, state gauge, cfg BreakerConfig) *BreakerGateway {
+	if next == nil {
+		return nil
+	}
+	cfg.MinRequests = max(cfg.MinRequests, 1)
+	cfg.HalfOpenRequests = max(cfg.HalfOpenRequests, 1)
+	g := &BreakerGateway{next: next, logger: logger, gauge: state, cfg: cfg, now: time.Now}
+	if g.gauge != nil {
+		g.gauge.Set(float64(BreakerClosed))
+	}
+	return g
+}
+
+// State returns the current state
+func (g *BreakerGateway) State() BreakerState {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.state
+}
+
+// GetByID реализует поведение BreakerGateway
+func (g *BreakerGateway) GetByID(ctx context.Context, id string) (*Order, error) {
+	gen, err := g.allow("GetByID")
+	if err != nil {
+		return nil, err
+	}
+	ord, err := g.next.GetByID(ctx, id)
+	g.done(ctx, gen, err)
+	return ord, err
+}
+
+// ListFrom реализует поведение BreakerGateway
+func (g *BreakerGateway) ListFrom(ctx context.Context, from time.Time) ([]Order, error) {
+	gen, err := g.allow("ListFrom")
+	if err != nil {
+		return nil, err
+	}
+	orders, err := g.next.ListFrom(ctx, from)
+	g.done(ctx, gen, err)
+	return orders, err
+}
+
+// allow reports whether a call may go to the orders service and returns the generation it belongs to
+func (g *BreakerGateway) allow(method string) (uint64, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := g.now()
+	if g.state == BreakerOpen && now.Sub(g.openedAt) >= g.cfg.CoolDown {
+		g.transition(BreakerHalfOpen, now, method)
+	}
+	switch g.state {
+	case BreakerOpen:
+		return 0, ErrCircuitOpen
+	case BreakerHalfOpen:
+		if g.trials >= g.cfg.HalfOpenRequests {
+			return 0, ErrCircuitOpen
+		}
+		g.trials++
+	}
+	return g.generation, nil
+}
+
+// done accounts the outcome of a call let through by allow. A call canceled by the caller
+// says nothing about the orders service and only gives its half-open trial back.
+func (g *BreakerGateway) done(ctx context.Context, gen uint64, err error) {
+	canceled := errors.Is(ctx.Err(), context.Canceled)
+	failed := isBreakerFailure(err)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if gen != g.generation {
+		return
+	}
+	now := g.now()
+
+	switch g.state {
+	case BreakerClosed:
+		if canceled {
+			return
+		}
+		total, failures := g.record(now, failed)
+		if total >= g.cfg.MinRequests && float64(failures) >= g.cfg.FailureRatio*float64(total) {
+			g.transition(BreakerOpen, now, "")
+		}
+	case BreakerHalfOpen:
+		switch {
+		case canceled:
+			g.trials--
+		case failed:
+			g.transition(BreakerOpen, now, "")
+		default:
+			g.successes++
+			if g.successes >= g.cfg.HalfOpenRequests {
+				g.transition(BreakerClosed, now, "")
+			}
+		}
+	}
+}
+
+// record counts a call in the window and returns the calls and failures of the window
+func (g *BreakerGateway) record(now time.Time, failed bool) (int, int) {
+	width := max(g.cfg.Window/breakerBuckets, time.Millisecond)
+	start := now.Truncate(width)
+	b := &g.buckets[int(start.UnixNano()/int64(width))%breakerBuckets]
+	if !b.start.Equal(start) {
+		*b = breakerBucket{start: start}
+	}
+	b.total++
+	if failed {
+		b.failures++
+	}
+
+	var total, failures int
+	for _, b := range g.buckets {
+		if now.Sub(b.start) < g.cfg.Window {
+			total += b.total
+			failures += b.failures
+		}
+	}
+	return total, failures
+}
+
+// transition moves the breaker to state, resetting what the previous state counted
+func (g *BreakerGateway) transition(to BreakerState, now time.Time, method string) {
+	from := g.state
+	g.state = to
+	g.generation++
+	g.trials, g.successes = 0, 0
+	switch to {
+	case BreakerOpen:
+		g.openedAt = now
+	case BreakerClosed:
+		g.buckets = [breakerBuckets]breakerBucket{}
+	}
+	if g.gauge != nil {
+		g.gauge.Set(float64(to))
+	}
+
+	fields := []logx.Field{logx.String("from", from.String()), logx.String("to", to.String())}
+	if method != "" {
+		fields = append(fields, logx.String("method", method))
+	}
+	if to == BreakerOpen {
+		fields = append(fields, logx.Duration("cool_down", g.cfg.CoolDown))
+		g.logger.Warn("orders gateway breaker state changed", fields...)
+		return
+	}
+	g.logger.Info("orders gateway breaker state changed", fields...)
+}
+
+// IsUnavailable reports whether err says the orders service can not answer now: the breaker
+// is open or the service is down, overloaded or too slow
+func IsUnavailable(err error) bool {
+	return errors.Is(err, ErrCircuitOpen) || isBreakerFailure(err)
+}
+
+// isBreakerFailure reports whether err says the orders service is down or overloaded
+func isBreakerFailure(err error) bool {
+	return err != nil && (isRetryable(err) || errors.Is(err, context.DeadlineExceeded))
+}
@@ -0,0 +1,189 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	testlog "course-go-avito-Orurh/internal/testutil"
+)
+
+type gaugeStub struct{ v float64 }
+
+func (g *gaugeStub) Set(v float64) { g.v = v }
+
+type breakerFixture struct {
+	g     *BreakerGateway
+	rec   *testlog.Recorder
+	gauge *gaugeStub
+	now   time.Time
+	err   error
+	calls int
+}
+
+func newBreakerFixture(cfg BreakerConfig) *breakerFixture {
+	f := &breakerFixture{rec: testlog.New(), gauge: &gaugeStub{v: -1}, now: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+	next := &fakeGateway{
+		getByIDFn: func(context.Context, string) (*Order, error) {
+			f.calls++
+			if f.err != nil {
+				return nil, f.err
+			}
+			return &Order{ID: "o1"}, nil
+		},
+		listFn: func(context.Context, time.Time) ([]Order, error) {
+			f.calls++
+			return nil, f.err
+		},
+	}
+	f.g = NewBreakerGateway(next, f.rec.Logger(), f.gauge, cfg)
+	f.g.now = func() time.Time { return f.now }
+	return f
+}
+
+func (f *breakerFixture) call(t *testing.T) error {
+	t.Helper()
+	_, err := f.g.GetByID(context.Background(), "o1")
+	return err
+}
+
+func (f *breakerFixture) transitions() []string {
+	var out []string
+	for _, e := range f.rec.Entries() {
+		if e.Msg != "orders gateway breaker state changed" {
+			continue
+		}
+		for _, fl := range e.Fields {
+			if fl.Key == "to" {
+				out = append(out, fl.Value.(string))
+			}
+		}
+	}
+	return out
+}
+
+var testBreakerConfig = BreakerConfig{
+	Window:           10 * time.Second,
+	MinRequests:      4,
+	FailureRatio:     0.5,
+	CoolDown:         5 * time.Second,
+	HalfOpenRequests: 2,
+}
+
+func TestBreakerGateway_OpensOnFailureRatio(t *testing.T) {
+	t.Parallel()
+
+	f := newBreakerFixture(testBreakerConfig)
+	require.Equal(t, float64(BreakerClosed), f.gauge.v)
+
+	require.NoError(t, f.call(t))
+	require.NoError(t, f.call(t))
+	f.err = status.Error(codes.Unavailable, "down")
+	require.Error(t, f.call(t))
+	require.Equal(t, BreakerClosed, f.g.State(), "3 calls are below MinRequests")
+	require.Error(t, f.call(t))
+	require.Equal(t, BreakerOpen, f.g.State(), "2 of 4 calls failed")
+	require.Equal(t, float64(BreakerOpen), f.gauge.v)
+
+	calls := f.calls
+	require.ErrorIs(t, f.call(t), ErrCircuitOpen)
+	_, err := f.g.ListFrom(context.Background(), f.now)
+	require.ErrorIs(t, err, ErrCircuitOpen)
+	require.Equal(t, calls, f.calls, "an open breaker does not call the orders service")
+	require.Equal(t, []string{"open"}, f.transitions())
+}
+
+func TestBreakerGateway_IgnoresOtherErrorsAndOldCalls(t *testing.T) {
+	t.Parallel()
+
+	f := newBreakerFixture(testBreakerConfig)
+	f.err = status.Error(codes.NotFound, "no order")
+	for range 5 {
+		require.Error(t, f.call(t))
+	}
+	require.Equal(t, BreakerClosed, f.g.State(), "NotFound says the service is up")
+
+	f.err = status.Error(codes.DeadlineExceeded, "slow")
+	require.Error(t, f.call(t))
+	require.Error(t, f.call(t))
+	require.Equal(t, BreakerClosed, f.g.State(), "2 of 7 calls failed")
+
+	f.now = f.now.Add(11 * time.Second)
+	f.err = errors.Join(errors.New("wrapped"), status.Error(codes.Unavailable, "down"))
+	for range 3 {
+		require.Error(t, f.call(t))
+	}
+	require.Equal(t, BreakerClosed, f.g.State(), "calls out of the window are forgotten")
+	require.Error(t, f.call(t))
+	require.Equal(t, BreakerOpen, f.g.State())
+}
+
+func TestBreakerGateway_HalfOpen(t *testing.T) {
+	t.Parallel()
+
+	f := newBreakerFixture(testBreakerConfig)
+	f.err = status.Error(codes.Unavailable, "down")
+	for range 4 {
+		require.Error(t, f.call(t))
+	}
+	require.Equal(t, BreakerOpen, f.g.State())
+
+	f.now = f.now.Add(5 * time.Second)
+	require.Error(t, f.call(t))
+	require.Equal(t, BreakerOpen, f.g.State(), "a failed trial opens the breaker again")
+	require.ErrorIs(t, f.call(t), ErrCircuitOpen)
+
+	f.now = f.now.Add(5 * time.Second)
+	f.err = nil
+	require.NoError(t, f.call(t))
+	require.Equal(t, BreakerHalfOpen, f.g.State())
+	require.Equal(t, float64(BreakerHalfOpen), f.gauge.v)
+	require.NoError(t, f.call(t))
+	require.Equal(t, BreakerClosed, f.g.State())
+	require.Equal(t, float64(BreakerClosed), f.gauge.v)
+
+	require.Equal(t, []string{"open", "half-open", "open", "half-open", "closed"}, f.transitions())
+}
+
+func TestBreakerGateway_HalfOpenLimitsTrials(t *testing.T) {
+	t.Parallel()
+
+	f := newBreakerFixture(BreakerConfig{Window: time.Second, MinRequests: 1, FailureRatio: 1, CoolDown: time.Second, HalfOpenRequests: 1})
+	f.err = status.Error(codes.Unavailable, "down")
+	require.Error(t, f.call(t))
+	f.now = f.now.Add(time.Second)
+
+	gen, err := f.g.allow("GetByID")
+	require.NoError(t, err)
+	_, err = f.g.allow("GetByID")
+	require.ErrorIs(t, err, ErrCircuitOpen, "the only trial is in flight")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	f.g.done(ctx, gen, context.Canceled)
+	require.Equal(t, BreakerHalfOpen, f.g.State(), "a canceled trial decides nothing")
+	_, err = f.g.allow("GetByID")
+	require.NoError(t, err, "the canceled trial is given back")
+}
+
+func TestNewBreakerGateway_NilNext(t *testing.T) {
+	t.Parallel()
+	require.Nil(t, NewBreakerGateway(nil, testlog.New().Logger(), nil, testBreakerConfig))
+}
+
+func TestIsUnavailable(t *testing.T) {
+	t.Parallel()
+
+	require.True(t, IsUnavailable(ErrCircuitOpen))
+	require.True(t, IsUnavailable(fmt.Errorf("get: %w", status.Error(codes.Unavailable, "down"))))
+	require.True(t, IsUnavailable(context.DeadlineExceeded))
+	require.False(t, IsUnavailable(status.Error(codes.NotFound, "no order")))
+	require.False(t, IsUnavailable(context.Canceled))
+	require.False(t, IsUnavailable(nil))
+}
@@ -0,0 +1,232 @@
+package order
+
+import (
+	"container/list"
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// CacheConfig is a configuration for CachingGateway
+type CacheConfig struct {
+	// TTL is how long a found order is served from the cache
+	TTL time.Duration
+	// NegativeTTL is how long an order the orders service does not know is served as not found;
+	// zero disables negative caching
+	NegativeTTL time.Duration
+	// Size is how many orders the cache holds at most, the least recently used are evicted first
+	Size int
+}
+
+type cacheEntry struct {
+	id string
+	// order is nil for an order the orders service does not know
+	order     *Order
+	fetchedAt time.Time
+	expiresAt time.Time
+}
+
+// CachingGateway is a read-through cache of GetByID. Concurrent lookups of the same order share
+// one call to next, and an order the orders service does not know is cached for NegativeTTL.
+// Errors are never cached. ListFrom always goes to next.
+type CachingGateway struct {
+	next   gateway
+	hits   counter
+	misses counter
+	cfg    CacheConfig
+	now    func() time.Time
+	group  singleflight.Group
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List
+	// lookups holds the token of the in-flight lookup of every order: a lookup whose order was
+	// invalidated meanwhile does not store its result, it may be older than the invalidation
+	lookups map[string]uint64
+	token   uint64
+}
+
+// NewCachingGateway проверяет, что next не nil и возвращает CachingGateway.
+// A lookup coalesced with a concurrent one is counted as a miss.
+func NewCachingGateway(next gateway, hits, misses counter, cfg CacheConfig) *CachingGateway {
+	if next == nil {
+		return nil
+	}
+	cfg.Size = max(cfg.Size, 1)
+	return &CachingGateway{
+		next:    next,
+		hits:    hits,
+		misses:  misses,
+		cfg:     cfg,
+		now:     time.Now,
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+		lookups: make(map[string]uint64),
+	}
+}
+
+// GetByID returns the order from the cache, or looks it up in next.
+// The shared lookup is not canceled with the caller that started it, but keeps its deadline:
+// a caller that gives up gets its context error, the others still get the order.
+func (g *CachingGateway) GetByID(ctx context.Context, id string) (*Order, error) {
+	if ord, ok := g.cached(id); ok {
+		inc(g.hits)
+		return ord, nil
+	}
+	inc(g.misses)
+
+	ch := g.group.DoChan(id, func() (any, error) {
+		return g.lookup(ctx, id)
+	})
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-ch:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		return copyOrder(res.Val.(*Order)), nil
+	}
+}
+
+// ListFrom реализует поведение CachingGateway
+func (g *CachingGateway) ListFrom(ctx context.Context, from time.Time) ([]Order, error) {
+	return g.next.ListFrom(ctx, from)
+}
+
+// Invalidate drops the cached order, a lookup in flight does not store its result.
+func (g *CachingGateway) Invalidate(id string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.invalidateLocked(id)
+}
+
+// ObserveStatus invalidates the cached order when an event with another status arrives,
+// unless the event was produced before the order was fetched. An event without a time
+// (zero at) is taken as newer. Any event of an order cached as not found invalidates it.
+func (g *CachingGateway) ObserveStatus(id, status string, at time.Time) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if _, inFlight := g.lookups[id]; inFlight {
+		g.invalidateLocked(id)
+		return
+	}
+	el, ok := g.entries[id]
+	if !ok {
+		return
+	}
+	e := el.Value.(*cacheEntry)
+	if !at.IsZero() && at.Before(e.fetchedAt) {
+		return
+	}
+	if e.order == nil || !strings.EqualFold(strings.TrimSpace(e.order.Status), strings.TrimSpace(status)) {
+		g.invalidateLocked(id)
+	}
+}
+
+// Len returns how many orders are cached, expired ones included
+func (g *CachingGateway) Len() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.lru.Len()
+}
+
+func (g *CachingGateway) cached(id string) (*Order, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	el, ok := g.entries[id]
+	if !ok {
+		return nil, false
+	}
+	e := el.Value.(*cacheEntry)
+	if !g.now().Before(e.expiresAt) {
+		g.removeLocked(el)
+		return nil, false
+	}
+	g.lru.MoveToFront(el)
+	return copyOrder(e.order), true
+}
+
+// lookup calls next once for all concurrent GetByID of id and caches the result
+func (g *CachingGateway) lookup(ctx context.Context, id string) (*Order, error) {
+	g.mu.Lock()
+	g.token++
+	token := g.token
+	g.lookups[id] = token
+	g.mu.Unlock()
+
+	callCtx := context.WithoutCancel(ctx)
+	if deadline, ok := ctx.Deadline(); ok {
+		var cancel context.CancelFunc
+		callCtx, cancel = context.WithDeadline(callCtx, deadline)
+		defer cancel()
+	}
+	fetchedAt := g.now()
+	ord, err := g.next.GetByID(callCtx, id)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.lookups[id] != token {
+		return ord, err
+	}
+	delete(g.lookups, id)
+	if err != nil {
+		return nil, err
+	}
+	g.storeLocked(id, ord, fetchedAt)
+	return ord, nil
+}
+
+func (g *CachingGateway) storeLocked(id string, ord *Order, fetchedAt time.Time) {
+	ttl := g.cfg.TTL
+	if ord == nil {
+		ttl = g.cfg.NegativeTTL
+	}
+	if ttl <= 0 {
+		return
+	}
+	e := &cacheEntry{id: id, order: copyOrder(ord), fetchedAt: fetchedAt, expiresAt: fetchedAt.Add(ttl)}
+	if el, ok := g.entries[id]; ok {
+		el.Value = e
+		g.lru.MoveToFront(el)
+		return
+	}
+	g.entries[id] = g.lru.PushFront(e)
+	for g.lru.Len() > g.cfg.Size {
+		g.removeLocked(g.lru.Back())
+	}
+}
+
+func (g *CachingGateway) invalidateLocked(id string) {
+	delete(g.lookups, id)
+	// later lookups of id must not join the one in flight
+	g.group.Forget(id)
+	if el, ok := g.entries[id]; ok {
+		g.removeLocked(el)
+	}
+}
+
+func (g *CachingGateway) removeLocked(el *list.Element) {
+	g.lru.Remove(el)
+	delete(g.entries, el.Value.(*cacheEntry).id)
+}
+
+// copyOrder keeps callers from changing cached orders
+func copyOrder(ord *Order) *Order {
+	if ord == nil {
+		return nil
+	}
+	c := *ord
+	return &c
+}
+
+func inc(c counter) {
+	if c != nil {
+		c.Inc()
+	}
+}
@@ -0,0 +1,264 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type cacheFixture struct {
+	g            *CachingGateway
+	hits, misses *counterStub
+	now          time.Time
+	calls        atomic.Int64
+	status       string
+	err          error
+}
+
+func newCacheFixture(cfg CacheConfig) *cacheFixture {
+	f := &cacheFixture{
+		hits:   &counterStub{},
+		misses: &counterStub{},
+		now:    time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		status: "created",
+	}
+	next := &fakeGateway{
+		getByIDFn: func(_ context.Context, id string) (*Order, error) {
+			f.calls.Add(1)
+			if f.err != nil {
+				return nil, f.err
+			}
+			if id == "unknown" {
+				return nil, nil
+			}
+			return &Order{ID: id, Status: f.status}, nil
+		},
+	}
+	f.g = NewCachingGateway(next, f.hits, f.misses, cfg)
+	f.g.now = func() time.Time { return f.now }
+	return f
+}
+
+func (f *cacheFixture) get(t *testing.T, id string) *Order {
+	t.Helper()
+	ord, err := f.g.GetByID(context.Background(), id)
+	require.NoError(t, err)
+	return ord
+}
+
+func TestCachingGateway_ServesFromCacheUntilTTL(t *testing.T) {
+	t.Parallel()
+
+	f := newCacheFixture(CacheConfig{TTL: time.Minute, NegativeTTL: 10 * time.Second, Size: 10})
+
+	require.Equal(t, "created", f.get(t, "o1").Status)
+	f.status = "canceled"
+	ord := f.get(t, "o1")
+	require.Equal(t, "created", ord.Status)
+	ord.Status = "changed by caller"
+	require.Equal(t, "created", f.get(t, "o1").Status, "callers get copies")
+	require.EqualValues(t, 1, f.calls.Load())
+
+	f.now = f.now.Add(time.Minute)
+	require.Equal(t, "canceled", f.get(t, "o1").Status)
+	require.EqualValues(t, 2, f.calls.Load())
+	require.EqualValues(t, 2, f.hits.Count())
+	require.EqualValues(t, 2, f.misses.Count())
+}
+
+func TestCachingGateway_NegativeCaching(t *testing.T) {
+	t.Parallel()
+
+	f := newCacheFixture(CacheConfig{TTL: time.Minute, NegativeTTL: 10 * time.Second, Size: 10})
+	require.Nil(t, f.get(t, "unknown"))
+	require.Nil(t, f.get(t, "unknown"))
+	require.EqualValues(t, 1, f.calls.Load())
+
+	f.now = f.now.Add(10 * time.Second)
+	require.Nil(t, f.get(t, "unknown"))
+	require.EqualValues(t, 2, f.calls.Load())
+
+	f = newCacheFixture(CacheConfig{TTL: time.Minute, Size: 10})
+	require.Nil(t, f.get(t, "unknown"))
+	require.Nil(t, f.get(t, "unknown"))
+	require.EqualValues(t, 2, f.calls.Load(), "zero NegativeTTL disables negative caching")
+}
+
+func TestCachingGateway_DoesNotCacheErrors(t *testing.T) {
+	t.Parallel()
+
+	f := newCacheFixture(CacheConfig{TTL: time.Minute, NegativeTTL: time.Minute, Size: 10})
+	f.err = errors.New("boom")
+	_, err := f.g.GetByID(context.Background(), "o1")
+	require.ErrorIs(t, err, f.err)
+
+	f.err = nil
+	require.NotNil(t, f.get(t, "o1"))
+	require.EqualValues(t, 2, f.calls.Load())
+}
+
+func TestCachingGateway_EvictsLeastRecentlyUsed(t *testing.T) {
+	t.Parallel()
+
+	f := newCacheFixture(CacheConfig{TTL: time.Minute, Size: 2})
+	f.get(t, "o1")
+	f.get(t, "o2")
+	f.get(t, "o1")
+	f.get(t, "o3")
+	require.Equal(t, 2, f.g.Len())
+
+	f.get(t, "o1")
+	f.get(t, "o3")
+	require.EqualValues(t, 3, f.calls.Load())
+	f.get(t, "o2")
+	require.EqualValues(t, 4, f.calls.Load(), "o2 was the least recently used")
+}
+
+func TestCachingGateway_ObserveStatus(t *testing.T) {
+	t.Parallel()
+
+	f := newCacheFixture(CacheConfig{TTL: time.Minute, NegativeTTL: time.Minute, Size: 10})
+	fetchedAt := f.now
+	f.get(t, "o1")
+
+	f.g.ObserveStatus("o1", " Created ", time.Time{})
+	f.g.ObserveStatus("o1", "canceled", fetchedAt.Add(-time.Second))
+	f.get(t, "o1")
+	require.EqualValues(t, 1, f.calls.Load(), "same status and older events keep the order")
+
+	f.g.ObserveStatus("o1", "canceled", fetchedAt.Add(time.Second))
+	f.get(t, "o1")
+	require.EqualValues(t, 2, f.calls.Load())
+
+	f.get(t, "unknown")
+	f.g.ObserveStatus("unknown", "created", time.Time{})
+	f.get(t, "unknown")
+	require.EqualValues(t, 4, f.calls.Load(), "an order cached as not found is invalidated by any event")
+
+	f.g.Invalidate("o1")
+	f.get(t, "o1")
+	require.EqualValues(t, 5, f.calls.Load())
+}
+
+// blockingGateway holds GetByID until release is closed
+type blockingGateway struct {
+	fakeGateway
+	calls   atomic.Int64
+	started chan struct{}
+	release chan struct{}
+	status  atomic.Value
+}
+
+func newBlockingGateway() *blockingGateway {
+	b := &blockingGateway{started: make(chan struct{}, 10), release: make(chan struct{})}
+	b.status.Store("created")
+	return b
+}
+
+func (b *blockingGateway) GetByID(ctx context.Context, id string) (*Order, error) {
+	b.calls.Add(1)
+	b.started <- struct{}{}
+	select {
+	case <-b.release:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return &Order{ID: id, Status: b.status.Load().(string)}, nil
+}
+
+func TestCachingGateway_CoalescesConcurrentLookups(t *testing.T) {
+	t.Parallel()
+
+	next := newBlockingGateway()
+	misses := &counterStub{}
+	g := NewCachingGateway(next, nil, misses, CacheConfig{TTL: time.Minute, Size: 10})
+
+	// the caller that starts the lookup gives up, the others still get the order
+	leaderCtx, cancelLeader := context.WithCancel(context.Background())
+	leaderErr := make(chan error, 1)
+	go func() {
+		_, err := g.GetByID(leaderCtx, "o1")
+		leaderErr <- err
+	}()
+	<-next.started
+
+	const n = 5
+	var wg sync.WaitGroup
+	results := make(chan *Order, n)
+	for range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ord, err := g.GetByID(context.Background(), "o1")
+			require.NoError(t, err)
+			results <- ord
+		}()
+	}
+	require.Eventually(t, func() bool { return misses.Count() == n+1 }, time.Second, time.Millisecond)
+	// let the callers past the cache join the lookup
+	time.Sleep(20 * time.Millisecond)
+
+	cancelLeader()
+	require.ErrorIs(t, <-leaderErr, context.Canceled)
+	close(next.release)
+	wg.Wait()
+	close(results)
+
+	for ord := range results {
+		require.Equal(t, "created", ord.Status)
+	}
+	require.EqualValues(t, 1, next.calls.Load())
+	require.Equal(t, 1, g.Len())
+}
+
+func TestCachingGateway_InvalidatedLookupIsNotStored(t *testing.T) {
+	t.Parallel()
+
+	next := newBlockingGateway()
+	g := NewCachingGateway(next, nil, nil, CacheConfig{TTL: time.Minute, Size: 10})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _ = g.GetByID(context.Background(), "o1")
+	}()
+	<-next.started
+
+	g.ObserveStatus("o1", "canceled", time.Time{})
+	next.status.Store("canceled")
+	close(next.release)
+	<-done
+	require.Zero(t, g.Len(), "the result may predate the event")
+
+	ord, err := g.GetByID(context.Background(), "o1")
+	require.NoError(t, err)
+	require.Equal(t, "canceled", ord.Status)
+	require.EqualValues(t, 2, next.calls.Load())
+}
+
+func TestCachingGateway_ListFromPassesThrough(t *testing.T) {
+	t.Parallel()
+
+	calls := 0
+	next := &fakeGateway{listFn: func(context.Context, time.Time) ([]Order, error) {
+		calls++
+		return []Order{{ID: "o1"}}, nil
+	}}
+	g := NewCachingGateway(next, nil, nil, CacheConfig{TTL: time.Minute, Size: 10})
+	for range 2 {
+		list, err := g.ListFrom(context.Background(), time.Time{})
+		require.NoError(t, err)
+		require.Len(t, list, 1)
+	}
+	require.Equal(t, 2, calls)
+}
+
+func TestNewCachingGateway_NilNext(t *testing.T) {
+	t.Parallel()
+	require.Nil(t, NewCachingGateway(nil, nil, nil, CacheConfig{}))
+}
@@ -0,0 +1,146 @@
+package order
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/metadata"
+)
+
+// DefaultAPIKeyHeader is the metadata key the API key is sent in unless another one is set
+const DefaultAPIKeyHeader = "x-api-key"
+
+// ClientConfig is how the gRPC client connects to the orders service.
+// Zero values keep the grpc defaults: plaintext, no auth, no keepalive pings, no deadline.
+type ClientConfig struct {
+	TLS  TLSConfig
+	Auth AuthConfig
+	// KeepaliveTime is how long an idle connection waits before a ping, zero disables pings
+	KeepaliveTime time.Duration
+	// KeepaliveTimeout is how long a ping waits for its ack before the connection is closed
+	KeepaliveTimeout time.Duration
+	// CallTimeout is the deadline of a call whose context has none, zero leaves such calls unbounded
+	CallTimeout time.Duration
+}
+
+// TLSConfig enables TLS to the orders service. CAFile replaces the system roots, CertFile and
+// KeyFile are the client certificate for mTLS, ServerName overrides the name the certificate is
+// checked against.
+type TLSConfig struct {
+	Enabled    bool
+	CAFile     string
+	CertFile   string
+	KeyFile    string
+	ServerName string
+}
+
+// AuthConfig is sent with every call: Token as "authorization: Bearer <token>", or APIKey
+// in the APIKeyHeader metadata key.
+type AuthConfig struct {
+	Token        string
+	APIKey       string
+	APIKeyHeader string
+}
+
+// DialOptions returns the grpc options of c
+func (c ClientConfig) DialOptions() ([]grpc.DialOption, error) {
+	creds := insecure.NewCredentials()
+	if c.TLS.Enabled {
+		tlsCfg, err := c.TLS.load()
+		if err != nil {
+			return nil, err
+		}
+		creds = credentials.NewTLS(tlsCfg)
+	}
+	auth, err := c.Auth.metadata()
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []grpc.DialOption{
+		grpc.WithTransportCredentials(creds),
+		grpc.WithChainUnaryInterceptor(unaryInterceptor(auth, c.CallTimeout)),
+	}
+	if c.KeepaliveTime > 0 {
+		opts = append(opts, grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:    c.KeepaliveTime,
+			Timeout: c.KeepaliveTimeout,
+		}))
+	}
+	return opts, nil
+}
+
+// unaryInterceptor attaches auth to the outgoing metadata and bounds calls without a deadline by timeout
+func unaryInterceptor(auth metadata.MD, timeout time.Duration) grpc.UnaryClientInterceptor {
+	return func(
+		ctx context.Context,
+		method string,
+		req, reply any,
+		cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker,
+		opts ...grpc.CallOption,
+	) error {
+		if _, ok := ctx.Deadline(); !ok && timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+		for k, vs := range auth {
+			for _, v := range vs {
+				ctx = metadata.AppendToOutgoingContext(ctx, k, v)
+			}
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+func (a AuthConfig) metadata() (metadata.MD, error) {
+	switch {
+	case a.Token != "" && a.APIKey != "":
+		return nil, errors.New("order gateway auth: set a token or an api key, not both")
+	case a.Token != "":
+		return metadata.Pairs("authorization", "Bearer "+a.Token), nil
+	case a.APIKey != "":
+		header := a.APIKeyHeader
+		if header == "" {
+			header = DefaultAPIKeyHeader
+		}
+		return metadata.Pairs(header, a.APIKey), nil
+	}
+	return nil, nil
+}
+
+func (t TLSConfig) load() (*tls.Config, error) {
+	cfg := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: t.ServerName,
+	}
+	if t.CAFile != "" {
+		pem, err := os.ReadFile(t.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("order gateway tls ca: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("order gateway tls ca: no certificates in %s", t.CAFile)
+		}
+		cfg.RootCAs = pool
+	}
+	if t.CertFile != "" || t.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("order gateway tls client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
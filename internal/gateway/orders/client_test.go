@@ -0,0 +1,195 @@
+package order
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/test/bufconn"
+
+	ordersproto "course-go-avito-Orurh/internal/proto"
+)
+
+const testServerName = "orders.internal"
+
+// writeCert writes a self-signed certificate for testServerName, usable by both sides, and its key to dir
+func writeCert(t *testing.T, dir string) (certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: testServerName},
+		DNSNames:              []string{testServerName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certFile = filepath.Join(dir, "cert.pem")
+	keyFile = filepath.Join(dir, "key.pem")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	return certFile, keyFile
+}
+
+type mdOrdersServer struct {
+	ordersproto.UnimplementedOrdersServiceServer
+	md       chan metadata.MD
+	deadline chan time.Time
+}
+
+func (s *mdOrdersServer) GetOrderByID(ctx context.Context, req *ordersproto.GetOrderByIDRequest) (*ordersproto.GetOrderByIDResponse, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	s.md <- md
+	deadline, _ := ctx.Deadline()
+	s.deadline <- deadline
+	return &ordersproto.GetOrderByIDResponse{Order: &ordersproto.Order{Id: req.GetId(), Status: "created"}}, nil
+}
+
+// startMTLSServer serves the orders service over bufconn, accepting only clients with a certificate signed by certFile
+func startMTLSServer(t *testing.T, certFile, keyFile string) (*bufconn.Listener, *mdOrdersServer) {
+	t.Helper()
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	require.NoError(t, err)
+	caPEM, err := os.ReadFile(certFile)
+	require.NoError(t, err)
+	pool := x509.NewCertPool()
+	require.True(t, pool.AppendCertsFromPEM(caPEM))
+
+	srv := grpc.NewServer(grpc.Creds(credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		MinVersion:   tls.VersionTLS12,
+	})))
+	impl := &mdOrdersServer{md: make(chan metadata.MD, 1), deadline: make(chan time.Time, 1)}
+	ordersproto.RegisterOrdersServiceServer(srv, impl)
+
+	lis := bufconn.Listen(1 << 20)
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+	return lis, impl
+}
+
+func dialBufconn(t *testing.T, lis *bufconn.Listener, cfg ClientConfig) *GRPCGateway {
+	t.Helper()
+	opts, err := cfg.DialOptions()
+	require.NoError(t, err)
+	opts = append(opts, grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+		return lis.DialContext(ctx)
+	}))
+	conn, err := grpc.NewClient("passthrough:///bufnet", opts...)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	return NewGRPCGateway(ordersproto.NewOrdersServiceClient(conn))
+}
+
+func TestClientConfig_MTLSWithToken(t *testing.T) {
+	t.Parallel()
+
+	certFile, keyFile := writeCert(t, t.TempDir())
+	lis, srv := startMTLSServer(t, certFile, keyFile)
+	gw := dialBufconn(t, lis, ClientConfig{
+		TLS:         TLSConfig{Enabled: true, CAFile: certFile, CertFile: certFile, KeyFile: keyFile, ServerName: testServerName},
+		Auth:        AuthConfig{Token: "secret"},
+		CallTimeout: time.Minute,
+	})
+
+	ord, err := gw.GetByID(context.Background(), "o1")
+	require.NoError(t, err)
+	require.Equal(t, "created", ord.Status)
+	require.Equal(t, []string{"Bearer secret"}, (<-srv.md).Get("authorization"))
+	require.WithinDuration(t, time.Now().Add(time.Minute), <-srv.deadline, 10*time.Second,
+		"a call without a deadline gets CallTimeout")
+}
+
+func TestClientConfig_MTLSRejectsClientWithoutCertificate(t *testing.T) {
+	t.Parallel()
+
+	certFile, keyFile := writeCert(t, t.TempDir())
+	lis, _ := startMTLSServer(t, certFile, keyFile)
+	gw := dialBufconn(t, lis, ClientConfig{
+		TLS: TLSConfig{Enabled: true, CAFile: certFile, ServerName: testServerName},
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err := gw.GetByID(ctx, "o1")
+	require.Error(t, err)
+}
+
+func TestUnaryInterceptor(t *testing.T) {
+	t.Parallel()
+
+	var (
+		gotMD       metadata.MD
+		gotDeadline time.Time
+	)
+	invoker := func(ctx context.Context, _ string, _, _ any, _ *grpc.ClientConn, _ ...grpc.CallOption) error {
+		gotMD, _ = metadata.FromOutgoingContext(ctx)
+		gotDeadline, _ = ctx.Deadline()
+		return nil
+	}
+
+	auth, err := AuthConfig{APIKey: "k"}.metadata()
+	require.NoError(t, err)
+	intercept := unaryInterceptor(auth, time.Minute)
+
+	require.NoError(t, intercept(context.Background(), "/m", nil, nil, nil, invoker))
+	require.Equal(t, []string{"k"}, gotMD.Get(DefaultAPIKeyHeader))
+	require.WithinDuration(t, time.Now().Add(time.Minute), gotDeadline, time.Second)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+	want, _ := ctx.Deadline()
+	require.NoError(t, intercept(ctx, "/m", nil, nil, nil, invoker))
+	require.Equal(t, want, gotDeadline, "the caller's deadline is kept")
+
+	auth, err = AuthConfig{APIKey: "k", APIKeyHeader: "x-orders-key"}.metadata()
+	require.NoError(t, err)
+	require.NoError(t, unaryInterceptor(auth, 0)(context.Background(), "/m", nil, nil, nil, invoker))
+	require.Equal(t, []string{"k"}, gotMD.Get("x-orders-key"))
+	require.True(t, gotDeadline.IsZero())
+}
+
+func TestClientConfig_Invalid(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	certFile, _ := writeCert(t, dir)
+	notPEM := filepath.Join(dir, "ca.txt")
+	require.NoError(t, os.WriteFile(notPEM, []byte("not a certificate"), 0o600))
+
+	for name, c := range map[string]ClientConfig{
+		"token and api key": {Auth: AuthConfig{Token: "t", APIKey: "k"}},
+		"missing ca":        {TLS: TLSConfig{Enabled: true, CAFile: filepath.Join(dir, "missing.pem")}},
+		"ca without pem":    {TLS: TLSConfig{Enabled: true, CAFile: notPEM}},
+		"cert, no key":      {TLS: TLSConfig{Enabled: true, CertFile: certFile}},
+	} {
+		_, err := c.DialOptions()
+		require.Error(t, err, name)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"course-go-avito-Orurh/internal/domain"
 	"course-go-avito-Orurh/internal/service/courier"
 	"course-go-avito-Orurh/internal/service/delivery"
+	"course-go-avito-Orurh/internal/service/transporttype"
 )
 
 type courierUsecase interface {
@@ -13,6 +14,7 @@ type courierUsecase interface {
 	List(ctx context.Context, limit, offset *int) ([]domain.Courier, error)
 	Create(ctx context.Context, c *domain.Courier) (int64, error)
 	UpdatePartial(ctx context.Context, u domain.PartialCourierUpdate) (bool, error)
+	UpdateLocation(ctx context.Context, id int64, loc domain.Location) error
 }
 
 // NewCourierUsecase wires a CourierService into a courierUsecase.
@@ -21,11 +23,30 @@ func NewCourierUsecase(service *courier.Service) courierUsecase {
 }
 
 type deliveryUsecase interface {
-	Assign(ctx context.Context, orderID string) (domain.AssignResult, error)
+	Assign(ctx context.Context, orderID string, pickup *domain.Location) (domain.AssignResult, error)
 	Unassign(ctx context.Context, orderID string) (domain.UnassignResult, error)
+	PickUp(ctx context.Context, orderID string) (domain.TransitionResult, error)
+	Complete(ctx context.Context, orderID string) (domain.TransitionResult, error)
+	Fail(ctx context.Context, orderID string) (domain.TransitionResult, error)
+	Get(ctx context.Context, orderID string) (*domain.Delivery, error)
+	ListByCourier(ctx context.Context, courierID int64, activeOnly bool, limit, offset *int) ([]domain.Delivery, error)
+	ListOverdue(ctx context.Context, limit, offset *int) ([]domain.Delivery, error)
 }
 
 // NewDeliveryUsecase wires a DeliveryService into a deliveryUsecase.
 func NewDeliveryUsecase(svc *delivery.Service) deliveryUsecase {
 	return svc
 }
+
+type transportTypeUsecase interface {
+	List(ctx context.Context) ([]domain.TransportType, error)
+	Get(ctx context.Context, code domain.CourierTransportType) (*domain.TransportType, error)
+	Create(ctx context.Context, t domain.TransportType) error
+	Update(ctx context.Context, t domain.TransportType) error
+	Delete(ctx context.Context, code domain.CourierTransportType) error
+}
+
+// NewTransportTypeUsecase wires a transport type Service into a transportTypeUsecase.
+func NewTransportTypeUsecase(svc *transporttype.Service) transportTypeUsecase {
+	return svc
+}
@@ -61,23 +61,9 @@ func (h *CourierHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 // @Failure 500 {object} ErrorResponse "internal error"
 // @Router /couriers [get]
 func (h *CourierHandler) List(w http.ResponseWriter, r *http.Request) {
-	q := r.URL.Query()
-	var limitPtr, offsetPtr *int
-	if s := q.Get("limit"); s != "" {
-		v, err := strconv.Atoi(s)
-		if err != nil || v < 0 {
-			writeError(h.logger, w, r, http.StatusBadRequest, "invalid limit")
-			return
-		}
-		limitPtr = &v
-	}
-	if s := q.Get("offset"); s != "" {
-		v, err := strconv.Atoi(s)
-		if err != nil || v < 0 {
-			writeError(h.logger, w, r, http.StatusBadRequest, "invalid offset")
-			return
-		}
-		offsetPtr = &v
+	limitPtr, offsetPtr, ok := pageFromQuery(h.logger, w, r)
+	if !ok {
+		return
 	}
 
 	list, err := h.usecase.List(r.Context(), limitPtr, offsetPtr)
@@ -152,3 +138,45 @@ func (h *CourierHandler) Update(w http.ResponseWriter, r *http.Request) {
 		writeError(h.logger, w, r, http.StatusInternalServerError, "internal error")
 	}
 }
+
+// UpdateLocation handles PUT /courier/{id}/location.
+// @Summary Обновить местоположение курьера
+// @Description Сохраняет последние координаты курьера, по ним выбирается ближайший курьер при назначении
+// @Tags couriers
+// @Accept json
+// @Produce json
+// @Param id path int true "Courier ID"
+// @Param request body locationDTO true "Courier coordinates"
+// @Success 200 {object} StatusResponse "status ok"
+// @Failure 400 {object} ErrorResponse "invalid input"
+// @Failure 404 {object} ErrorResponse "not found"
+// @Failure 500 {object} ErrorResponse "internal error"
+// @Router /courier/{id}/location [put]
+func (h *CourierHandler) UpdateLocation(w http.ResponseWriter, r *http.Request) {
+	id, err := idFromURL(r, "id")
+	if err != nil {
+		writeError(h.logger, w, r, http.StatusBadRequest, "invalid id")
+		return
+	}
+	var req locationDTO
+	if ok := decodeJSON(h.logger, w, r, &req); !ok {
+		return
+	}
+	loc, ok := req.toModel()
+	if !ok {
+		writeError(h.logger, w, r, http.StatusBadRequest, "invalid input")
+		return
+	}
+
+	err = h.usecase.UpdateLocation(r.Context(), id, loc)
+	switch {
+	case err == nil:
+		writeJSON(h.logger, w, r, http.StatusOK, map[string]string{"status": "ok"})
+	case errors.Is(err, apperr.ErrInvalid):
+		writeError(h.logger, w, r, http.StatusBadRequest, "invalid input")
+	case errors.Is(err, apperr.ErrNotFound):
+		writeError(h.logger, w, r, http.StatusNotFound, "not found")
+	default:
+		writeError(h.logger, w, r, http.StatusInternalServerError, "internal error")
+	}
+}
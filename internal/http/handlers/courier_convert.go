@@ -8,6 +8,7 @@ func (req createCourierRequest) toModel() *domain.Courier {
 		Phone:         req.Phone,
 		Status:        req.Status,
 		TransportType: req.TransportType,
+		Capacity:      req.Capacity,
 	}
 }
 
@@ -18,16 +19,31 @@ func (req updateCourierRequest) toModel() domain.PartialCourierUpdate {
 		Phone:         req.Phone,
 		Status:        req.Status,
 		TransportType: req.TransportType,
+		Capacity:      req.Capacity,
 	}
 }
 
+// toModel returns false if a coordinate is missing.
+func (l locationDTO) toModel() (domain.Location, bool) {
+	if l.Lat == nil || l.Lon == nil {
+		return domain.Location{}, false
+	}
+	return domain.Location{Lat: *l.Lat, Lon: *l.Lon}, true
+}
+
+// modelToResponse expects the capacity in effect to be resolved by the courier service.
 func modelToResponse(c domain.Courier) courierDTO {
+	var capacity int
+	if c.Capacity != nil {
+		capacity = *c.Capacity
+	}
 	return courierDTO{
 		ID:            c.ID,
 		Name:          c.Name,
 		Phone:         c.Phone,
 		Status:        c.Status,
 		TransportType: c.TransportType,
+		Capacity:      capacity,
 	}
 }
 
@@ -18,9 +18,10 @@ import (
 )
 
 type courierResponse struct {
-	ID    int64  `json:"id"`
-	Name  string `json:"name"`
-	Phone string `json:"phone"`
+	ID       int64  `json:"id"`
+	Name     string `json:"name"`
+	Phone    string `json:"phone"`
+	Capacity int    `json:"capacity"`
 }
 
 type stubCourierUsecase struct {
@@ -28,6 +29,7 @@ type stubCourierUsecase struct {
 	listFn          func(ctx context.Context, limit, offset *int) ([]domain.Courier, error)
 	createFn        func(ctx context.Context, c *domain.Courier) (int64, error)
 	updatePartialFn func(ctx context.Context, u domain.PartialCourierUpdate) (bool, error)
+	locationFn      func(ctx context.Context, id int64, loc domain.Location) error
 }
 
 func (s *stubCourierUsecase) Get(ctx context.Context, id int64) (*domain.Courier, error) {
@@ -46,13 +48,20 @@ func (s *stubCourierUsecase) UpdatePartial(ctx context.Context, u domain.Partial
 	return s.updatePartialFn(ctx, u)
 }
 
+func (s *stubCourierUsecase) UpdateLocation(ctx context.Context, id int64, loc domain.Location) error {
+	return s.locationFn(ctx, id, loc)
+}
+
 func TestCourierHandler_GetByID_OK(t *testing.T) {
 	t.Parallel()
 
+	capacity := 3
 	expected := &domain.Courier{
-		ID:    99,
-		Name:  "Artem",
-		Phone: "+70000000000",
+		ID:            99,
+		Name:          "Artem",
+		Phone:         "+70000000000",
+		TransportType: domain.TransportTypeCar,
+		Capacity:      &capacity,
 	}
 
 	uc := &stubCourierUsecase{
@@ -81,6 +90,7 @@ func TestCourierHandler_GetByID_OK(t *testing.T) {
 	require.Equal(t, expected.ID, resp.ID)
 	require.Equal(t, expected.Name, resp.Name)
 	require.Equal(t, expected.Phone, resp.Phone)
+	require.Equal(t, capacity, resp.Capacity)
 }
 
 func TestCourierHandler_GetByID_InvalidID(t *testing.T) {
@@ -457,3 +467,79 @@ func TestCourierHandler_Update_BadJSON(t *testing.T) {
 
 	require.Equal(t, http.StatusBadRequest, rr.Code)
 }
+
+func newLocationRequest(id, body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPut, "/courier/"+id+"/location", strings.NewReader(body))
+	routeCtx := chi.NewRouteContext()
+	routeCtx.URLParams.Add("id", id)
+	return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, routeCtx))
+}
+
+func TestCourierHandler_UpdateLocation_OK(t *testing.T) {
+	t.Parallel()
+
+	uc := &stubCourierUsecase{
+		locationFn: func(ctx context.Context, id int64, loc domain.Location) error {
+			require.Equal(t, int64(7), id)
+			require.Equal(t, domain.Location{Lat: 55.75, Lon: 37.61}, loc)
+			return nil
+		},
+	}
+	h := handlers.NewCourierHandler(testLogger(), uc)
+
+	rr := httptest.NewRecorder()
+	h.UpdateLocation(rr, newLocationRequest("7", `{"lat":55.75,"lon":37.61}`))
+
+	require.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestCourierHandler_UpdateLocation_MissingCoordinate(t *testing.T) {
+	t.Parallel()
+
+	h := handlers.NewCourierHandler(testLogger(), &stubCourierUsecase{})
+
+	rr := httptest.NewRecorder()
+	h.UpdateLocation(rr, newLocationRequest("7", `{"lat":55.75}`))
+
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestCourierHandler_UpdateLocation_InvalidID(t *testing.T) {
+	t.Parallel()
+
+	h := handlers.NewCourierHandler(testLogger(), &stubCourierUsecase{})
+
+	rr := httptest.NewRecorder()
+	h.UpdateLocation(rr, newLocationRequest("abc", `{"lat":1,"lon":1}`))
+
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestCourierHandler_UpdateLocation_Errors(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		err  error
+		code int
+	}{
+		{name: "invalid", err: apperr.ErrInvalid, code: http.StatusBadRequest},
+		{name: "not found", err: apperr.ErrNotFound, code: http.StatusNotFound},
+		{name: "internal", err: errors.New("db down"), code: http.StatusInternalServerError},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			uc := &stubCourierUsecase{
+				locationFn: func(context.Context, int64, domain.Location) error { return tc.err },
+			}
+			h := handlers.NewCourierHandler(testLogger(), uc)
+
+			rr := httptest.NewRecorder()
+			h.UpdateLocation(rr, newLocationRequest("7", `{"lat":91,"lon":1}`))
+
+			require.Equal(t, tc.code, rr.Code)
+		})
+	}
+}
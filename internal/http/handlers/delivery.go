@@ -1,10 +1,15 @@
 package handlers
 
 import (
+	"context"
 	"errors"
 	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
 
 	"course-go-avito-Orurh/internal/apperr"
+	"course-go-avito-Orurh/internal/domain"
 	"course-go-avito-Orurh/internal/logx"
 )
 
@@ -21,7 +26,8 @@ func NewDeliveryHandler(logger logx.Logger, uc deliveryUsecase) *DeliveryHandler
 
 // Assign handles POST /delivery/assign.
 // @Summary Назначить доставку
-// @Description Назначает курьера на заказ по order_id
+// @Description Назначает курьера на заказ по order_id. Если переданы координаты pickup,
+// @Description выбирается ближайший свободный курьер в пределах радиуса назначения
 // @Tags deliveries
 // @Accept json
 // @Produce json
@@ -37,7 +43,17 @@ func (h *DeliveryHandler) Assign(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	res, err := h.usecase.Assign(r.Context(), req.OrderID)
+	var pickup *domain.Location
+	if req.Pickup != nil {
+		loc, ok := req.Pickup.toModel()
+		if !ok {
+			writeError(h.logger, w, r, http.StatusBadRequest, "invalid input")
+			return
+		}
+		pickup = &loc
+	}
+
+	res, err := h.usecase.Assign(r.Context(), req.OrderID, pickup)
 	switch {
 	case err == nil:
 		writeJSON(h.logger, w, r, http.StatusOK, assignResultToResponse(res))
@@ -60,6 +76,7 @@ func (h *DeliveryHandler) Assign(w http.ResponseWriter, r *http.Request) {
 // @Success 200 {object} map[string]interface{}
 // @Failure 400 {object} ErrorResponse "invalid id"
 // @Failure 404 {object} ErrorResponse "delivery not found"
+// @Failure 409 {object} ErrorResponse "delivery is not active"
 // @Failure 500 {object} ErrorResponse "internal error"
 // @Router /delivery/unassign [post]
 func (h *DeliveryHandler) Unassign(w http.ResponseWriter, r *http.Request) {
@@ -76,6 +93,178 @@ func (h *DeliveryHandler) Unassign(w http.ResponseWriter, r *http.Request) {
 		writeError(h.logger, w, r, http.StatusBadRequest, "invalid input")
 	case errors.Is(err, apperr.ErrNotFound):
 		writeError(h.logger, w, r, http.StatusNotFound, "delivery not found")
+	case errors.Is(err, apperr.ErrConflict):
+		writeError(h.logger, w, r, http.StatusConflict, "delivery is not active")
+	default:
+		writeError(h.logger, w, r, http.StatusInternalServerError, "internal error")
+	}
+}
+
+// PickUp handles POST /delivery/pickup.
+// @Summary Отметить заказ как забранный
+// @Description Переводит доставку по order_id в статус picked_up
+// @Tags deliveries
+// @Accept json
+// @Produce json
+// @Param request body deliveryStatusRequest true "Delivery status payload"
+// @Success 200 {object} deliveryStatusResponse
+// @Failure 400 {object} ErrorResponse "invalid input"
+// @Failure 404 {object} ErrorResponse "delivery not found"
+// @Failure 409 {object} ErrorResponse "transition not allowed"
+// @Failure 500 {object} ErrorResponse "internal error"
+// @Router /delivery/pickup [post]
+func (h *DeliveryHandler) PickUp(w http.ResponseWriter, r *http.Request) {
+	h.transition(w, r, h.usecase.PickUp)
+}
+
+// Complete handles POST /delivery/complete.
+// @Summary Завершить доставку
+// @Description Переводит доставку по order_id в статус delivered и освобождает курьера
+// @Tags deliveries
+// @Accept json
+// @Produce json
+// @Param request body deliveryStatusRequest true "Delivery status payload"
+// @Success 200 {object} deliveryStatusResponse
+// @Failure 400 {object} ErrorResponse "invalid input"
+// @Failure 404 {object} ErrorResponse "delivery not found"
+// @Failure 409 {object} ErrorResponse "transition not allowed"
+// @Failure 500 {object} ErrorResponse "internal error"
+// @Router /delivery/complete [post]
+func (h *DeliveryHandler) Complete(w http.ResponseWriter, r *http.Request) {
+	h.transition(w, r, h.usecase.Complete)
+}
+
+// Fail handles POST /delivery/fail.
+// @Summary Отметить доставку как неудачную
+// @Description Переводит доставку по order_id в статус failed и освобождает курьера
+// @Tags deliveries
+// @Accept json
+// @Produce json
+// @Param request body deliveryStatusRequest true "Delivery status payload"
+// @Success 200 {object} deliveryStatusResponse
+// @Failure 400 {object} ErrorResponse "invalid input"
+// @Failure 404 {object} ErrorResponse "delivery not found"
+// @Failure 409 {object} ErrorResponse "transition not allowed"
+// @Failure 500 {object} ErrorResponse "internal error"
+// @Router /delivery/fail [post]
+func (h *DeliveryHandler) Fail(w http.ResponseWriter, r *http.Request) {
+	h.transition(w, r, h.usecase.Fail)
+}
+
+func (h *DeliveryHandler) transition(
+	w http.ResponseWriter,
+	r *http.Request,
+	fn func(ctx context.Context, orderID string) (domain.TransitionResult, error),
+) {
+	var req deliveryStatusRequest
+	if ok := decodeJSON(h.logger, w, r, &req); !ok {
+		return
+	}
+
+	res, err := fn(r.Context(), req.OrderID)
+	switch {
+	case err == nil:
+		writeJSON(h.logger, w, r, http.StatusOK, transitionResultToResponse(res))
+	case errors.Is(err, apperr.ErrInvalid):
+		writeError(h.logger, w, r, http.StatusBadRequest, "invalid input")
+	case errors.Is(err, apperr.ErrNotFound):
+		writeError(h.logger, w, r, http.StatusNotFound, "delivery not found")
+	case errors.Is(err, apperr.ErrConflict):
+		writeError(h.logger, w, r, http.StatusConflict, "transition not allowed")
+	default:
+		writeError(h.logger, w, r, http.StatusInternalServerError, "internal error")
+	}
+}
+
+// Get handles GET /delivery/{order_id}.
+// @Summary Получить доставку по заказу
+// @Description Возвращает последнюю доставку заказа по order_id
+// @Tags deliveries
+// @Produce json
+// @Param order_id path string true "Order ID"
+// @Success 200 {object} deliveryDTO
+// @Failure 400 {object} ErrorResponse "invalid input"
+// @Failure 404 {object} ErrorResponse "delivery not found"
+// @Failure 500 {object} ErrorResponse "internal error"
+// @Router /delivery/{order_id} [get]
+func (h *DeliveryHandler) Get(w http.ResponseWriter, r *http.Request) {
+	d, err := h.usecase.Get(r.Context(), chi.URLParam(r, "order_id"))
+	switch {
+	case err == nil:
+		writeJSON(h.logger, w, r, http.StatusOK, deliveryToResponse(*d))
+	case errors.Is(err, apperr.ErrInvalid):
+		writeError(h.logger, w, r, http.StatusBadRequest, "invalid input")
+	case errors.Is(err, apperr.ErrNotFound):
+		writeError(h.logger, w, r, http.StatusNotFound, "delivery not found")
+	default:
+		writeError(h.logger, w, r, http.StatusInternalServerError, "internal error")
+	}
+}
+
+// ListByCourier handles GET /courier/{id}/deliveries.
+// @Summary Доставки курьера
+// @Description Возвращает доставки курьера, начиная с последних, с опциональной пагинацией (limit/offset).
+// @Description С active=true возвращаются только незавершённые доставки
+// @Tags deliveries
+// @Produce json
+// @Param id path int true "Courier ID"
+// @Param active query bool false "Only active deliveries"
+// @Param limit query int false "Limit" minimum(1) maximum(500)
+// @Param offset query int false "Offset" minimum(0)
+// @Success 200 {array} deliveryDTO
+// @Failure 400 {object} ErrorResponse "invalid input"
+// @Failure 500 {object} ErrorResponse "internal error"
+// @Router /courier/{id}/deliveries [get]
+func (h *DeliveryHandler) ListByCourier(w http.ResponseWriter, r *http.Request) {
+	id, err := idFromURL(r, "id")
+	if err != nil {
+		writeError(h.logger, w, r, http.StatusBadRequest, "invalid id")
+		return
+	}
+	var activeOnly bool
+	if s := r.URL.Query().Get("active"); s != "" {
+		if activeOnly, err = strconv.ParseBool(s); err != nil {
+			writeError(h.logger, w, r, http.StatusBadRequest, "invalid active")
+			return
+		}
+	}
+	limit, offset, ok := pageFromQuery(h.logger, w, r)
+	if !ok {
+		return
+	}
+
+	list, err := h.usecase.ListByCourier(r.Context(), id, activeOnly, limit, offset)
+	h.writeList(w, r, list, err)
+}
+
+// ListOverdue handles GET /deliveries/overdue.
+// @Summary Просроченные доставки
+// @Description Возвращает активные доставки с истёкшим дедлайном, начиная с самых просроченных,
+// @Description с опциональной пагинацией (limit/offset)
+// @Tags deliveries
+// @Produce json
+// @Param limit query int false "Limit" minimum(1) maximum(500)
+// @Param offset query int false "Offset" minimum(0)
+// @Success 200 {array} deliveryDTO
+// @Failure 400 {object} ErrorResponse "invalid input"
+// @Failure 500 {object} ErrorResponse "internal error"
+// @Router /deliveries/overdue [get]
+func (h *DeliveryHandler) ListOverdue(w http.ResponseWriter, r *http.Request) {
+	limit, offset, ok := pageFromQuery(h.logger, w, r)
+	if !ok {
+		return
+	}
+
+	list, err := h.usecase.ListOverdue(r.Context(), limit, offset)
+	h.writeList(w, r, list, err)
+}
+
+func (h *DeliveryHandler) writeList(w http.ResponseWriter, r *http.Request, list []domain.Delivery, err error) {
+	switch {
+	case err == nil:
+		writeJSON(h.logger, w, r, http.StatusOK, deliveriesToResponse(list))
+	case errors.Is(err, apperr.ErrInvalid):
+		writeError(h.logger, w, r, http.StatusBadRequest, "invalid input")
 	default:
 		writeError(h.logger, w, r, http.StatusInternalServerError, "internal error")
 	}
@@ -18,3 +18,36 @@ func unassignResultToResponse(result domain.UnassignResult) unassignDeliveryResp
 		CourierID: result.CourierID,
 	}
 }
+
+func transitionResultToResponse(result domain.TransitionResult) deliveryStatusResponse {
+	return deliveryStatusResponse{
+		OrderID:   result.OrderID,
+		CourierID: result.CourierID,
+		Status:    string(result.Status),
+		ChangedAt: result.At,
+	}
+}
+
+func deliveryToResponse(d domain.Delivery) deliveryDTO {
+	return deliveryDTO{
+		ID:          d.ID,
+		OrderID:     d.OrderID,
+		CourierID:   d.CourierID,
+		Status:      string(d.Status),
+		AssignedAt:  d.AssignedAt,
+		Deadline:    d.Deadline,
+		PickedUpAt:  d.PickedUpAt,
+		DeliveredAt: d.DeliveredAt,
+		CanceledAt:  d.CanceledAt,
+		ExpiredAt:   d.ExpiredAt,
+		FailedAt:    d.FailedAt,
+	}
+}
+
+func deliveriesToResponse(list []domain.Delivery) []deliveryDTO {
+	out := make([]deliveryDTO, 0, len(list))
+	for _, d := range list {
+		out = append(out, deliveryToResponse(d))
+	}
+	return out
+}
@@ -5,7 +5,8 @@ import (
 )
 
 type assignDeliveryRequest struct {
-	OrderID string `json:"order_id"`
+	OrderID string       `json:"order_id"`
+	Pickup  *locationDTO `json:"pickup,omitempty"`
 }
 
 type assignDeliveryResponse struct {
@@ -24,3 +25,28 @@ type unassignDeliveryResponse struct {
 	Status    string `json:"status"`
 	CourierID int64  `json:"courier_id"`
 }
+
+type deliveryStatusRequest struct {
+	OrderID string `json:"order_id"`
+}
+
+type deliveryStatusResponse struct {
+	OrderID   string    `json:"order_id"`
+	CourierID int64     `json:"courier_id"`
+	Status    string    `json:"status"`
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+type deliveryDTO struct {
+	ID          int64      `json:"id"`
+	OrderID     string     `json:"order_id"`
+	CourierID   int64      `json:"courier_id"`
+	Status      string     `json:"status"`
+	AssignedAt  time.Time  `json:"assigned_at"`
+	Deadline    time.Time  `json:"deadline"`
+	PickedUpAt  *time.Time `json:"picked_up_at,omitempty"`
+	DeliveredAt *time.Time `json:"delivered_at,omitempty"`
+	CanceledAt  *time.Time `json:"canceled_at,omitempty"`
+	ExpiredAt   *time.Time `json:"expired_at,omitempty"`
+	FailedAt    *time.Time `json:"failed_at,omitempty"`
+}
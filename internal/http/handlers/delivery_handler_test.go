@@ -10,6 +10,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -19,17 +20,27 @@ import (
 )
 
 type stubDeliveryUsecase struct {
-	assignFn   func(ctx context.Context, orderID string) (domain.AssignResult, error)
+	assignFn   func(ctx context.Context, orderID string, pickup *domain.Location) (domain.AssignResult, error)
 	unassignFn func(ctx context.Context, orderID string) (domain.UnassignResult, error)
+	pickUpFn   func(ctx context.Context, orderID string) (domain.TransitionResult, error)
+	completeFn func(ctx context.Context, orderID string) (domain.TransitionResult, error)
+	failFn     func(ctx context.Context, orderID string) (domain.TransitionResult, error)
+	getFn      func(ctx context.Context, orderID string) (*domain.Delivery, error)
+	byCourier  func(ctx context.Context, courierID int64, activeOnly bool, limit, offset *int) ([]domain.Delivery, error)
+	overdueFn  func(ctx context.Context, limit, offset *int) ([]domain.Delivery, error)
 }
 
 func testLogger() logx.Logger { return logx.Nop() }
 
-func (s *stubDeliveryUsecase) Assign(ctx context.Context, orderID string) (domain.AssignResult, error) {
+func (s *stubDeliveryUsecase) Assign(
+	ctx context.Context,
+	orderID string,
+	pickup *domain.Location,
+) (domain.AssignResult, error) {
 	if s.assignFn == nil {
 		panic("Assign not expected in this test")
 	}
-	return s.assignFn(ctx, orderID)
+	return s.assignFn(ctx, orderID, pickup)
 }
 
 func (s *stubDeliveryUsecase) Unassign(ctx context.Context, orderID string) (domain.UnassignResult, error) {
@@ -39,6 +50,53 @@ func (s *stubDeliveryUsecase) Unassign(ctx context.Context, orderID string) (dom
 	return s.unassignFn(ctx, orderID)
 }
 
+func (s *stubDeliveryUsecase) PickUp(ctx context.Context, orderID string) (domain.TransitionResult, error) {
+	if s.pickUpFn == nil {
+		panic("PickUp not expected in this test")
+	}
+	return s.pickUpFn(ctx, orderID)
+}
+
+func (s *stubDeliveryUsecase) Complete(ctx context.Context, orderID string) (domain.TransitionResult, error) {
+	if s.completeFn == nil {
+		panic("Complete not expected in this test")
+	}
+	return s.completeFn(ctx, orderID)
+}
+
+func (s *stubDeliveryUsecase) Fail(ctx context.Context, orderID string) (domain.TransitionResult, error) {
+	if s.failFn == nil {
+		panic("Fail not expected in this test")
+	}
+	return s.failFn(ctx, orderID)
+}
+
+func (s *stubDeliveryUsecase) Get(ctx context.Context, orderID string) (*domain.Delivery, error) {
+	if s.getFn == nil {
+		panic("Get not expected in this test")
+	}
+	return s.getFn(ctx, orderID)
+}
+
+func (s *stubDeliveryUsecase) ListByCourier(
+	ctx context.Context,
+	courierID int64,
+	activeOnly bool,
+	limit, offset *int,
+) ([]domain.Delivery, error) {
+	if s.byCourier == nil {
+		panic("ListByCourier not expected in this test")
+	}
+	return s.byCourier(ctx, courierID, activeOnly, limit, offset)
+}
+
+func (s *stubDeliveryUsecase) ListOverdue(ctx context.Context, limit, offset *int) ([]domain.Delivery, error) {
+	if s.overdueFn == nil {
+		panic("ListOverdue not expected in this test")
+	}
+	return s.overdueFn(ctx, limit, offset)
+}
+
 func TestDeliveryHandler_Assign_OK(t *testing.T) {
 	t.Parallel()
 
@@ -51,7 +109,7 @@ func TestDeliveryHandler_Assign_OK(t *testing.T) {
 	deadline := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
 
 	uc := &stubDeliveryUsecase{
-		assignFn: func(ctx context.Context, orderID string) (domain.AssignResult, error) {
+		assignFn: func(ctx context.Context, orderID string, _ *domain.Location) (domain.AssignResult, error) {
 			require.Equal(t, "order-123", orderID)
 			return domain.AssignResult{
 				CourierID:     42,
@@ -76,6 +134,44 @@ func TestDeliveryHandler_Assign_OK(t *testing.T) {
 	assert.JSONEq(t, expectedJSON, rr.Body.String())
 }
 
+func TestDeliveryHandler_Assign_WithPickup(t *testing.T) {
+	t.Parallel()
+
+	body := `{"order_id":"order-123","pickup":{"lat":55.7558,"lon":37.6173}}`
+	req := httptest.NewRequest(http.MethodPost, "/delivery/assign", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	rr := httptest.NewRecorder()
+
+	uc := &stubDeliveryUsecase{
+		assignFn: func(ctx context.Context, orderID string, pickup *domain.Location) (domain.AssignResult, error) {
+			require.Equal(t, &domain.Location{Lat: 55.7558, Lon: 37.6173}, pickup)
+			return domain.AssignResult{CourierID: 1, OrderID: orderID}, nil
+		},
+	}
+
+	h := NewDeliveryHandler(testLogger(), uc)
+	h.Assign(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestDeliveryHandler_Assign_IncompletePickup(t *testing.T) {
+	t.Parallel()
+
+	body := `{"order_id":"order-123","pickup":{"lat":55.7558}}`
+	req := httptest.NewRequest(http.MethodPost, "/delivery/assign", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	rr := httptest.NewRecorder()
+
+	h := NewDeliveryHandler(testLogger(), &stubDeliveryUsecase{})
+	h.Assign(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.JSONEq(t, `{"error": "invalid input"}`, rr.Body.String())
+}
+
 func TestDeliveryHandler_Assign_Invalid(t *testing.T) {
 	t.Parallel()
 
@@ -86,7 +182,7 @@ func TestDeliveryHandler_Assign_Invalid(t *testing.T) {
 	rr := httptest.NewRecorder()
 
 	uc := &stubDeliveryUsecase{
-		assignFn: func(ctx context.Context, orderID string) (domain.AssignResult, error) {
+		assignFn: func(ctx context.Context, orderID string, _ *domain.Location) (domain.AssignResult, error) {
 			return domain.AssignResult{}, apperr.ErrInvalid
 		},
 	}
@@ -107,7 +203,7 @@ func TestDeliveryHandler_Assign_Conflict(t *testing.T) {
 	rr := httptest.NewRecorder()
 
 	uc := &stubDeliveryUsecase{
-		assignFn: func(ctx context.Context, orderID string) (domain.AssignResult, error) {
+		assignFn: func(ctx context.Context, orderID string, _ *domain.Location) (domain.AssignResult, error) {
 			require.Equal(t, "order-123", orderID)
 			return domain.AssignResult{}, apperr.ErrConflict
 		},
@@ -136,7 +232,7 @@ func TestDeliveryHandler_Assign_InternalError(t *testing.T) {
 	rr := httptest.NewRecorder()
 
 	uc := &stubDeliveryUsecase{
-		assignFn: func(ctx context.Context, orderID string) (domain.AssignResult, error) {
+		assignFn: func(ctx context.Context, orderID string, _ *domain.Location) (domain.AssignResult, error) {
 			return domain.AssignResult{}, errors.New("boom")
 		},
 	}
@@ -163,7 +259,7 @@ func TestDeliveryHandler_Assign_InvalidJSON(t *testing.T) {
 	rr := httptest.NewRecorder()
 
 	uc := &stubDeliveryUsecase{
-		assignFn: func(ctx context.Context, orderID string) (domain.AssignResult, error) {
+		assignFn: func(ctx context.Context, orderID string, _ *domain.Location) (domain.AssignResult, error) {
 			require.FailNow(t, "usecase.Assign must not be called on invalid json")
 			return domain.AssignResult{}, nil
 		},
@@ -319,3 +415,248 @@ func TestDeliveryHandler_Unassign_InvalidJSON(t *testing.T) {
 	require.Equal(t, http.StatusBadRequest, rr.Code)
 	assert.JSONEq(t, `{"error": "invalid json"}`, rr.Body.String())
 }
+
+func TestDeliveryHandler_Unassign_Conflict(t *testing.T) {
+	t.Parallel()
+
+	body := `{"order_id":"order-123"}`
+	req := httptest.NewRequest(http.MethodPost, "/delivery/unassign", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	rr := httptest.NewRecorder()
+
+	uc := &stubDeliveryUsecase{
+		unassignFn: func(ctx context.Context, orderID string) (domain.UnassignResult, error) {
+			return domain.UnassignResult{}, apperr.ErrConflict
+		},
+	}
+
+	h := NewDeliveryHandler(testLogger(), uc)
+	h.Unassign(rr, req)
+
+	require.Equal(t, http.StatusConflict, rr.Code)
+}
+
+func TestDeliveryHandler_PickUp_OK(t *testing.T) {
+	t.Parallel()
+
+	body := `{"order_id":"order-123"}`
+	req := httptest.NewRequest(http.MethodPost, "/delivery/pickup", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	rr := httptest.NewRecorder()
+
+	at := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	uc := &stubDeliveryUsecase{
+		pickUpFn: func(ctx context.Context, orderID string) (domain.TransitionResult, error) {
+			require.Equal(t, "order-123", orderID)
+			return domain.TransitionResult{
+				CourierID: 10,
+				OrderID:   orderID,
+				Status:    domain.DeliveryStatusPickedUp,
+				At:        at,
+			}, nil
+		},
+	}
+
+	h := NewDeliveryHandler(testLogger(), uc)
+	h.PickUp(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	expectedJSON := `{
+        "order_id": "order-123",
+        "courier_id": 10,
+        "status": "picked_up",
+        "changed_at": "2025-01-02T03:04:05Z"
+    }`
+	assert.JSONEq(t, expectedJSON, rr.Body.String())
+}
+
+func TestDeliveryHandler_Complete_Conflict(t *testing.T) {
+	t.Parallel()
+
+	body := `{"order_id":"order-123"}`
+	req := httptest.NewRequest(http.MethodPost, "/delivery/complete", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	rr := httptest.NewRecorder()
+
+	uc := &stubDeliveryUsecase{
+		completeFn: func(ctx context.Context, orderID string) (domain.TransitionResult, error) {
+			return domain.TransitionResult{}, apperr.ErrConflict
+		},
+	}
+
+	h := NewDeliveryHandler(testLogger(), uc)
+	h.Complete(rr, req)
+
+	require.Equal(t, http.StatusConflict, rr.Code)
+}
+
+func TestDeliveryHandler_Fail_NotFound(t *testing.T) {
+	t.Parallel()
+
+	body := `{"order_id":"order-404"}`
+	req := httptest.NewRequest(http.MethodPost, "/delivery/fail", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	rr := httptest.NewRecorder()
+
+	uc := &stubDeliveryUsecase{
+		failFn: func(ctx context.Context, orderID string) (domain.TransitionResult, error) {
+			return domain.TransitionResult{}, apperr.ErrNotFound
+		},
+	}
+
+	h := NewDeliveryHandler(testLogger(), uc)
+	h.Fail(rr, req)
+
+	require.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func withURLParam(req *http.Request, name, value string) *http.Request {
+	routeCtx := chi.NewRouteContext()
+	routeCtx.URLParams.Add(name, value)
+	return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, routeCtx))
+}
+
+func TestDeliveryHandler_Get_OK(t *testing.T) {
+	t.Parallel()
+
+	assigned := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	pickedUp := assigned.Add(5 * time.Minute)
+	uc := &stubDeliveryUsecase{
+		getFn: func(_ context.Context, orderID string) (*domain.Delivery, error) {
+			require.Equal(t, "order-123", orderID)
+			return &domain.Delivery{
+				ID:         7,
+				OrderID:    orderID,
+				CourierID:  42,
+				Status:     domain.DeliveryStatusPickedUp,
+				AssignedAt: assigned,
+				Deadline:   assigned.Add(30 * time.Minute),
+				PickedUpAt: &pickedUp,
+			}, nil
+		},
+	}
+
+	req := withURLParam(httptest.NewRequest(http.MethodGet, "/delivery/order-123", nil), "order_id", "order-123")
+	rr := httptest.NewRecorder()
+	NewDeliveryHandler(testLogger(), uc).Get(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{
+        "id": 7,
+        "order_id": "order-123",
+        "courier_id": 42,
+        "status": "picked_up",
+        "assigned_at": "2025-01-02T03:04:05Z",
+        "deadline": "2025-01-02T03:34:05Z",
+        "picked_up_at": "2025-01-02T03:09:05Z"
+    }`, rr.Body.String())
+}
+
+func TestDeliveryHandler_Get_NotFound(t *testing.T) {
+	t.Parallel()
+
+	uc := &stubDeliveryUsecase{
+		getFn: func(context.Context, string) (*domain.Delivery, error) {
+			return nil, apperr.ErrNotFound
+		},
+	}
+
+	req := withURLParam(httptest.NewRequest(http.MethodGet, "/delivery/nope", nil), "order_id", "nope")
+	rr := httptest.NewRecorder()
+	NewDeliveryHandler(testLogger(), uc).Get(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.JSONEq(t, `{"error":"delivery not found"}`, rr.Body.String())
+}
+
+func TestDeliveryHandler_ListByCourier_OK(t *testing.T) {
+	t.Parallel()
+
+	uc := &stubDeliveryUsecase{
+		byCourier: func(_ context.Context, courierID int64, activeOnly bool, limit, offset *int) ([]domain.Delivery, error) {
+			require.Equal(t, int64(10), courierID)
+			require.True(t, activeOnly)
+			require.Equal(t, 5, *limit)
+			require.Nil(t, offset)
+			return nil, nil
+		},
+	}
+
+	req := withURLParam(httptest.NewRequest(http.MethodGet, "/courier/10/deliveries?active=true&limit=5", nil), "id", "10")
+	rr := httptest.NewRecorder()
+	NewDeliveryHandler(testLogger(), uc).ListByCourier(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `[]`, rr.Body.String())
+}
+
+func TestDeliveryHandler_ListByCourier_BadInput(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		id, query, msg string
+	}{
+		"bad id":     {id: "abc", query: "", msg: "invalid id"},
+		"bad active": {id: "10", query: "?active=maybe", msg: "invalid active"},
+		"bad limit":  {id: "10", query: "?limit=-1", msg: "invalid limit"},
+		"bad offset": {id: "10", query: "?offset=x", msg: "invalid offset"},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			req := withURLParam(httptest.NewRequest(http.MethodGet, "/courier/"+tc.id+"/deliveries"+tc.query, nil), "id", tc.id)
+			rr := httptest.NewRecorder()
+			NewDeliveryHandler(testLogger(), &stubDeliveryUsecase{}).ListByCourier(rr, req)
+
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+			assert.JSONEq(t, `{"error":"`+tc.msg+`"}`, rr.Body.String())
+		})
+	}
+}
+
+func TestDeliveryHandler_ListOverdue(t *testing.T) {
+	t.Parallel()
+
+	assigned := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	uc := &stubDeliveryUsecase{
+		overdueFn: func(_ context.Context, limit, offset *int) ([]domain.Delivery, error) {
+			if limit != nil && *limit > 500 {
+				return nil, apperr.ErrInvalid
+			}
+			return []domain.Delivery{{
+				ID: 1, OrderID: "order-1", CourierID: 2, Status: domain.DeliveryStatusAssigned,
+				AssignedAt: assigned, Deadline: assigned.Add(time.Minute),
+			}}, nil
+		},
+	}
+	h := NewDeliveryHandler(testLogger(), uc)
+
+	rr := httptest.NewRecorder()
+	h.ListOverdue(rr, httptest.NewRequest(http.MethodGet, "/deliveries/overdue", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `[{
+        "id": 1,
+        "order_id": "order-1",
+        "courier_id": 2,
+        "status": "assigned",
+        "assigned_at": "2025-01-02T03:04:05Z",
+        "deadline": "2025-01-02T03:05:05Z"
+    }]`, rr.Body.String())
+
+	rr = httptest.NewRecorder()
+	h.ListOverdue(rr, httptest.NewRequest(http.MethodGet, "/deliveries/overdue?limit=1000", nil))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	uc.overdueFn = func(context.Context, *int, *int) ([]domain.Delivery, error) {
+		return nil, errors.New("db down")
+	}
+	rr = httptest.NewRecorder()
+	h.ListOverdue(rr, httptest.NewRequest(http.MethodGet, "/deliveries/overdue", nil))
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+}
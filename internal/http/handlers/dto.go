@@ -8,6 +8,7 @@ type courierDTO struct {
 	Phone         string                      `json:"phone" example:"+79991234567"`
 	Status        domain.CourierStatus        `json:"status" example:"active"`
 	TransportType domain.CourierTransportType `json:"transport_type" example:"bike"`
+	Capacity      int                         `json:"capacity" example:"1"`
 }
 
 type createCourierRequest struct {
@@ -15,6 +16,7 @@ type createCourierRequest struct {
 	Phone         string                      `json:"phone" example:"+79991234567"`
 	Status        domain.CourierStatus        `json:"status" example:"active"`
 	TransportType domain.CourierTransportType `json:"transport_type" example:"bike"`
+	Capacity      *int                        `json:"capacity,omitempty" example:"2"`
 }
 
 type updateCourierRequest struct {
@@ -23,6 +25,12 @@ type updateCourierRequest struct {
 	Phone         *string                      `json:"phone,omitempty" example:"+79991234567"`
 	Status        *domain.CourierStatus        `json:"status,omitempty" example:"active"`
 	TransportType *domain.CourierTransportType `json:"transport_type,omitempty" example:"bike"`
+	Capacity      *int                         `json:"capacity,omitempty" example:"2"`
+}
+
+type locationDTO struct {
+	Lat *float64 `json:"lat" example:"55.7558"`
+	Lon *float64 `json:"lon" example:"37.6173"`
 }
 
 // IDResponse contains created/returned entity identifier.
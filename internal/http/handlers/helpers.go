@@ -92,3 +92,26 @@ func idFromURL(r *http.Request, name string) (int64, error) {
 	}
 	return id, nil
 }
+
+// pageFromQuery reads the optional limit/offset query parameters.
+// On a malformed value it writes a 400 response and returns false.
+func pageFromQuery(logger logx.Logger, w http.ResponseWriter, r *http.Request) (limit, offset *int, ok bool) {
+	q := r.URL.Query()
+	if s := q.Get("limit"); s != "" {
+		v, err := strconv.Atoi(s)
+		if err != nil || v < 0 {
+			writeError(logger, w, r, http.StatusBadRequest, "invalid limit")
+			return nil, nil, false
+		}
+		limit = &v
+	}
+	if s := q.Get("offset"); s != "" {
+		v, err := strconv.Atoi(s)
+		if err != nil || v < 0 {
+			writeError(logger, w, r, http.StatusBadRequest, "invalid offset")
+			return nil, nil, false
+		}
+		offset = &v
+	}
+	return limit, offset, true
+}
@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"course-go-avito-Orurh/internal/apperr"
+	"course-go-avito-Orurh/internal/domain"
+	"course-go-avito-Orurh/internal/logx"
+)
+
+// TransportTypeHandler serves admin HTTP endpoints for transport types.
+type TransportTypeHandler struct {
+	usecase transportTypeUsecase
+	logger  logx.Logger
+}
+
+// NewTransportTypeHandler wires a transportTypeUsecase into HTTP handlers.
+func NewTransportTypeHandler(logger logx.Logger, uc transportTypeUsecase) *TransportTypeHandler {
+	return &TransportTypeHandler{usecase: uc, logger: logger}
+}
+
+func codeFromURL(r *http.Request) domain.CourierTransportType {
+	return domain.CourierTransportType(chi.URLParam(r, "code"))
+}
+
+// List handles GET /admin/transport-types.
+// @Summary Список типов транспорта
+// @Description Возвращает типы транспорта со средней скоростью, вместимостью и базовым дедлайном
+// @Tags transport-types
+// @Produce json
+// @Success 200 {array} transportTypeDTO
+// @Failure 500 {object} ErrorResponse "internal error"
+// @Router /admin/transport-types [get]
+func (h *TransportTypeHandler) List(w http.ResponseWriter, r *http.Request) {
+	list, err := h.usecase.List(r.Context())
+	if err != nil {
+		writeError(h.logger, w, r, http.StatusInternalServerError, "internal error")
+		return
+	}
+	writeJSON(h.logger, w, r, http.StatusOK, transportTypesToResponse(list))
+}
+
+// Get handles GET /admin/transport-types/{code}.
+// @Summary Получить тип транспорта
+// @Description Возвращает тип транспорта по коду
+// @Tags transport-types
+// @Produce json
+// @Param code path string true "Transport type code"
+// @Success 200 {object} transportTypeDTO
+// @Failure 404 {object} ErrorResponse "not found"
+// @Failure 500 {object} ErrorResponse "internal error"
+// @Router /admin/transport-types/{code} [get]
+func (h *TransportTypeHandler) Get(w http.ResponseWriter, r *http.Request) {
+	t, err := h.usecase.Get(r.Context(), codeFromURL(r))
+	switch {
+	case err == nil:
+		writeJSON(h.logger, w, r, http.StatusOK, transportTypeToResponse(*t))
+	case errors.Is(err, apperr.ErrNotFound):
+		writeError(h.logger, w, r, http.StatusNotFound, "not found")
+	default:
+		writeError(h.logger, w, r, http.StatusInternalServerError, "internal error")
+	}
+}
+
+// Create handles POST /admin/transport-types.
+// @Summary Создать тип транспорта
+// @Description Добавляет тип транспорта, курьеров с ним можно создавать без передеплоя
+// @Tags transport-types
+// @Accept json
+// @Produce json
+// @Param request body transportTypeDTO true "Transport type"
+// @Success 201 {object} transportTypeDTO
+// @Header 201 {string} Location "URL созданного ресурса"
+// @Failure 400 {object} ErrorResponse "invalid input"
+// @Failure 409 {object} ErrorResponse "transport type already exists"
+// @Failure 500 {object} ErrorResponse "internal error"
+// @Router /admin/transport-types [post]
+func (h *TransportTypeHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req transportTypeDTO
+	if ok := decodeJSON(h.logger, w, r, &req); !ok {
+		return
+	}
+	t := req.toModel()
+	err := h.usecase.Create(r.Context(), t)
+	switch {
+	case err == nil:
+		w.Header().Set("Location", "/admin/transport-types/"+string(t.Code))
+		writeJSON(h.logger, w, r, http.StatusCreated, transportTypeToResponse(t))
+	case errors.Is(err, apperr.ErrInvalid):
+		writeError(h.logger, w, r, http.StatusBadRequest, "invalid input")
+	case errors.Is(err, apperr.ErrConflict):
+		writeError(h.logger, w, r, http.StatusConflict, "transport type already exists")
+	default:
+		writeError(h.logger, w, r, http.StatusInternalServerError, "internal error")
+	}
+}
+
+// Update handles PUT /admin/transport-types/{code}.
+// @Summary Обновить тип транспорта
+// @Description Заменяет скорость, вместимость и базовый дедлайн типа транспорта
+// @Tags transport-types
+// @Accept json
+// @Produce json
+// @Param code path string true "Transport type code"
+// @Param request body transportTypeRulesRequest true "Transport type rules"
+// @Success 200 {object} transportTypeDTO
+// @Failure 400 {object} ErrorResponse "invalid input"
+// @Failure 404 {object} ErrorResponse "not found"
+// @Failure 500 {object} ErrorResponse "internal error"
+// @Router /admin/transport-types/{code} [put]
+func (h *TransportTypeHandler) Update(w http.ResponseWriter, r *http.Request) {
+	var req transportTypeRulesRequest
+	if ok := decodeJSON(h.logger, w, r, &req); !ok {
+		return
+	}
+	t := req.toModel(codeFromURL(r))
+	err := h.usecase.Update(r.Context(), t)
+	switch {
+	case err == nil:
+		writeJSON(h.logger, w, r, http.StatusOK, transportTypeToResponse(t))
+	case errors.Is(err, apperr.ErrInvalid):
+		writeError(h.logger, w, r, http.StatusBadRequest, "invalid input")
+	case errors.Is(err, apperr.ErrNotFound):
+		writeError(h.logger, w, r, http.StatusNotFound, "not found")
+	default:
+		writeError(h.logger, w, r, http.StatusInternalServerError, "internal error")
+	}
+}
+
+// Delete handles DELETE /admin/transport-types/{code}.
+// @Summary Удалить тип транспорта
+// @Description Удаляет тип транспорта, если на нём нет ни одного курьера
+// @Tags transport-types
+// @Produce json
+// @Param code path string true "Transport type code"
+// @Success 200 {object} StatusResponse "status ok"
+// @Failure 404 {object} ErrorResponse "not found"
+// @Failure 409 {object} ErrorResponse "transport type is in use"
+// @Failure 500 {object} ErrorResponse "internal error"
+// @Router /admin/transport-types/{code} [delete]
+func (h *TransportTypeHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	err := h.usecase.Delete(r.Context(), codeFromURL(r))
+	switch {
+	case err == nil:
+		writeJSON(h.logger, w, r, http.StatusOK, map[string]string{"status": "ok"})
+	case errors.Is(err, apperr.ErrNotFound):
+		writeError(h.logger, w, r, http.StatusNotFound, "not found")
+	case errors.Is(err, apperr.ErrConflict):
+		writeError(h.logger, w, r, http.StatusConflict, "transport type is in use")
+	default:
+		writeError(h.logger, w, r, http.StatusInternalServerError, "internal error")
+	}
+}
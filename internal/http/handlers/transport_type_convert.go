@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"time"
+
+	"course-go-avito-Orurh/internal/domain"
+)
+
+func (req transportTypeDTO) toModel() domain.TransportType {
+	return domain.TransportType{
+		Code:         req.Code,
+		SpeedKmh:     req.SpeedKmh,
+		MaxCapacity:  req.MaxCapacity,
+		BaseDeadline: time.Duration(req.BaseDeadlineSeconds) * time.Second,
+	}
+}
+
+func (req transportTypeRulesRequest) toModel(code domain.CourierTransportType) domain.TransportType {
+	return transportTypeDTO{
+		Code:                code,
+		SpeedKmh:            req.SpeedKmh,
+		MaxCapacity:         req.MaxCapacity,
+		BaseDeadlineSeconds: req.BaseDeadlineSeconds,
+	}.toModel()
+}
+
+func transportTypeToResponse(t domain.TransportType) transportTypeDTO {
+	return transportTypeDTO{
+		Code:                t.Code,
+		SpeedKmh:            t.SpeedKmh,
+		MaxCapacity:         t.MaxCapacity,
+		BaseDeadlineSeconds: int(t.BaseDeadline / time.Second),
+	}
+}
+
+func transportTypesToResponse(list []domain.TransportType) []transportTypeDTO {
+	out := make([]transportTypeDTO, 0, len(list))
+	for _, t := range list {
+		out = append(out, transportTypeToResponse(t))
+	}
+	return out
+}
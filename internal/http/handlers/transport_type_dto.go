@@ -0,0 +1,16 @@
+package handlers
+
+import "course-go-avito-Orurh/internal/domain"
+
+type transportTypeDTO struct {
+	Code                domain.CourierTransportType `json:"code" example:"bike"`
+	SpeedKmh            float64                     `json:"speed_kmh" example:"15"`
+	MaxCapacity         int                         `json:"max_capacity" example:"2"`
+	BaseDeadlineSeconds int                         `json:"base_deadline_seconds" example:"1200"`
+}
+
+type transportTypeRulesRequest struct {
+	SpeedKmh            float64 `json:"speed_kmh" example:"15"`
+	MaxCapacity         int     `json:"max_capacity" example:"2"`
+	BaseDeadlineSeconds int     `json:"base_deadline_seconds" example:"1200"`
+}
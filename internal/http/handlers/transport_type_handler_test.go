@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+
+	"course-go-avito-Orurh/internal/apperr"
+	"course-go-avito-Orurh/internal/domain"
+)
+
+type stubTransportTypeUsecase struct {
+	listFn   func(ctx context.Context) ([]domain.TransportType, error)
+	getFn    func(ctx context.Context, code domain.CourierTransportType) (*domain.TransportType, error)
+	createFn func(ctx context.Context, t domain.TransportType) error
+	updateFn func(ctx context.Context, t domain.TransportType) error
+	deleteFn func(ctx context.Context, code domain.CourierTransportType) error
+}
+
+func (s *stubTransportTypeUsecase) List(ctx context.Context) ([]domain.TransportType, error) {
+	if s.listFn == nil {
+		panic("List not expected in this test")
+	}
+	return s.listFn(ctx)
+}
+
+func (s *stubTransportTypeUsecase) Get(ctx context.Context, code domain.CourierTransportType) (*domain.TransportType, error) {
+	if s.getFn == nil {
+		panic("Get not expected in this test")
+	}
+	return s.getFn(ctx, code)
+}
+
+func (s *stubTransportTypeUsecase) Create(ctx context.Context, t domain.TransportType) error {
+	if s.createFn == nil {
+		panic("Create not expected in this test")
+	}
+	return s.createFn(ctx, t)
+}
+
+func (s *stubTransportTypeUsecase) Update(ctx context.Context, t domain.TransportType) error {
+	if s.updateFn == nil {
+		panic("Update not expected in this test")
+	}
+	return s.updateFn(ctx, t)
+}
+
+func (s *stubTransportTypeUsecase) Delete(ctx context.Context, code domain.CourierTransportType) error {
+	if s.deleteFn == nil {
+		panic("Delete not expected in this test")
+	}
+	return s.deleteFn(ctx, code)
+}
+
+func withCode(req *http.Request, code string) *http.Request {
+	routeCtx := chi.NewRouteContext()
+	routeCtx.URLParams.Add("code", code)
+	return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, routeCtx))
+}
+
+func TestTransportTypeHandler_Create_OK(t *testing.T) {
+	t.Parallel()
+
+	var got domain.TransportType
+	h := NewTransportTypeHandler(testLogger(), &stubTransportTypeUsecase{
+		createFn: func(_ context.Context, tt domain.TransportType) error {
+			got = tt
+			return nil
+		},
+	})
+
+	body := `{"code":"bike","speed_kmh":15,"max_capacity":2,"base_deadline_seconds":1200}`
+	req := httptest.NewRequest(http.MethodPost, "/admin/transport-types", strings.NewReader(body))
+	rr := httptest.NewRecorder()
+
+	h.Create(rr, req)
+
+	require.Equal(t, http.StatusCreated, rr.Code)
+	require.Equal(t, "/admin/transport-types/bike", rr.Header().Get("Location"))
+	require.Equal(t, domain.TransportType{
+		Code: "bike", SpeedKmh: 15, MaxCapacity: 2, BaseDeadline: 20 * time.Minute,
+	}, got)
+}
+
+func TestTransportTypeHandler_Create_Errors(t *testing.T) {
+	t.Parallel()
+
+	cases := map[error]int{
+		apperr.ErrInvalid:  http.StatusBadRequest,
+		apperr.ErrConflict: http.StatusConflict,
+		context.Canceled:   http.StatusInternalServerError,
+	}
+	for err, want := range cases {
+		h := NewTransportTypeHandler(testLogger(), &stubTransportTypeUsecase{
+			createFn: func(context.Context, domain.TransportType) error { return err },
+		})
+		req := httptest.NewRequest(http.MethodPost, "/admin/transport-types", strings.NewReader(`{"code":"bike"}`))
+		rr := httptest.NewRecorder()
+
+		h.Create(rr, req)
+		require.Equal(t, want, rr.Code, err.Error())
+	}
+}
+
+func TestTransportTypeHandler_Update_UsesCodeFromPath(t *testing.T) {
+	t.Parallel()
+
+	var got domain.TransportType
+	h := NewTransportTypeHandler(testLogger(), &stubTransportTypeUsecase{
+		updateFn: func(_ context.Context, tt domain.TransportType) error {
+			got = tt
+			return nil
+		},
+	})
+
+	body := `{"speed_kmh":18,"max_capacity":3,"base_deadline_seconds":900}`
+	req := withCode(httptest.NewRequest(http.MethodPut, "/admin/transport-types/bike", strings.NewReader(body)), "bike")
+	rr := httptest.NewRecorder()
+
+	h.Update(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Equal(t, domain.CourierTransportType("bike"), got.Code)
+	require.Equal(t, 15*time.Minute, got.BaseDeadline)
+
+	var resp transportTypeDTO
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+	require.Equal(t, 900, resp.BaseDeadlineSeconds)
+}
+
+func TestTransportTypeHandler_Get(t *testing.T) {
+	t.Parallel()
+
+	h := NewTransportTypeHandler(testLogger(), &stubTransportTypeUsecase{
+		getFn: func(_ context.Context, code domain.CourierTransportType) (*domain.TransportType, error) {
+			if code != domain.TransportTypeCar {
+				return nil, apperr.ErrNotFound
+			}
+			return &domain.TransportType{Code: code, SpeedKmh: 40, MaxCapacity: 4, BaseDeadline: 5 * time.Minute}, nil
+		},
+	})
+
+	rr := httptest.NewRecorder()
+	h.Get(rr, withCode(httptest.NewRequest(http.MethodGet, "/admin/transport-types/car", nil), "car"))
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var resp transportTypeDTO
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+	require.Equal(t, transportTypeDTO{Code: "car", SpeedKmh: 40, MaxCapacity: 4, BaseDeadlineSeconds: 300}, resp)
+
+	rr = httptest.NewRecorder()
+	h.Get(rr, withCode(httptest.NewRequest(http.MethodGet, "/admin/transport-types/boat", nil), "boat"))
+	require.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestTransportTypeHandler_Delete_InUse(t *testing.T) {
+	t.Parallel()
+
+	h := NewTransportTypeHandler(testLogger(), &stubTransportTypeUsecase{
+		deleteFn: func(context.Context, domain.CourierTransportType) error { return apperr.ErrConflict },
+	})
+
+	rr := httptest.NewRecorder()
+	h.Delete(rr, withCode(httptest.NewRequest(http.MethodDelete, "/admin/transport-types/car", nil), "car"))
+	require.Equal(t, http.StatusConflict, rr.Code)
+}
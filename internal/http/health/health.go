@@ -0,0 +1,64 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// Check reports whether a dependency is usable, a nil error means it is
+type Check struct {
+	Name string
+	Fn   func(ctx context.Context) error
+}
+
+// Report is the JSON body of /readyz
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks"`
+}
+
+const (
+	statusOK          = "ok"
+	statusUnavailable = "unavailable"
+)
+
+// Liveness answers 200 as long as the process serves HTTP.
+func Liveness() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": statusOK})
+	})
+}
+
+// Readiness runs every check with the given timeout and answers 200 if all of them pass, 503 otherwise.
+// The report lists the result of each check.
+func Readiness(timeout time.Duration, checks ...Check) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		rep := Report{Status: statusOK, Checks: make(map[string]string, len(checks))}
+		for _, c := range checks {
+			if err := c.Fn(ctx); err != nil {
+				rep.Status = statusUnavailable
+				rep.Checks[c.Name] = err.Error()
+				continue
+			}
+			rep.Checks[c.Name] = statusOK
+		}
+
+		code := http.StatusOK
+		if rep.Status != statusOK {
+			code = http.StatusServiceUnavailable
+		}
+		writeJSON(w, code, rep)
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
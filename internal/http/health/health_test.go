@@ -0,0 +1,60 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLiveness_OK(t *testing.T) {
+	t.Parallel()
+
+	rr := httptest.NewRecorder()
+	Liveness().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestReadiness_AllChecksPass(t *testing.T) {
+	t.Parallel()
+
+	h := Readiness(time.Second,
+		Check{Name: "db", Fn: func(context.Context) error { return nil }},
+		Check{Name: "kafka", Fn: func(context.Context) error { return nil }},
+	)
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	var rep Report
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&rep))
+	require.Equal(t, Report{Status: "ok", Checks: map[string]string{"db": "ok", "kafka": "ok"}}, rep)
+}
+
+func TestReadiness_FailingCheck_Unavailable(t *testing.T) {
+	t.Parallel()
+
+	var deadline bool
+	h := Readiness(time.Second,
+		Check{Name: "db", Fn: func(ctx context.Context) error {
+			_, deadline = ctx.Deadline()
+			return errors.New("connection refused")
+		}},
+		Check{Name: "kafka", Fn: func(context.Context) error { return nil }},
+	)
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	require.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	require.True(t, deadline, "checks run with a timeout")
+	var rep Report
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&rep))
+	require.Equal(t, "unavailable", rep.Status)
+	require.Equal(t, "connection refused", rep.Checks["db"])
+	require.Equal(t, "ok", rep.Checks["kafka"])
+}
@@ -15,7 +15,13 @@ import (
 )
 
 // New constructs a chi-based http.Handler with base middleware and routes.
-func New(base *handlers.Handlers, cour *handlers.CourierHandler, delivery *handlers.DeliveryHandler, rl *ratelimit.Middleware) http.Handler {
+func New(
+	base *handlers.Handlers,
+	cour *handlers.CourierHandler,
+	delivery *handlers.DeliveryHandler,
+	transport *handlers.TransportTypeHandler,
+	rl *ratelimit.Middleware,
+) http.Handler {
 	r := chi.NewRouter()
 	r.Use(middleware.RequestID)
 	r.Use(middleware.RealIP)
@@ -40,9 +46,22 @@ func New(base *handlers.Handlers, cour *handlers.CourierHandler, delivery *handl
 		api.Get("/couriers", cour.List)
 		api.Post("/courier", cour.Create)
 		api.Put("/courier", cour.Update)
+		api.Put("/courier/{id}/location", cour.UpdateLocation)
+		api.Get("/courier/{id}/deliveries", delivery.ListByCourier)
 
 		api.Post("/delivery/assign", delivery.Assign)
 		api.Post("/delivery/unassign", delivery.Unassign)
+		api.Post("/delivery/pickup", delivery.PickUp)
+		api.Post("/delivery/complete", delivery.Complete)
+		api.Post("/delivery/fail", delivery.Fail)
+		api.Get("/delivery/{order_id}", delivery.Get)
+		api.Get("/deliveries/overdue", delivery.ListOverdue)
+
+		api.Get("/admin/transport-types", transport.List)
+		api.Post("/admin/transport-types", transport.Create)
+		api.Get("/admin/transport-types/{code}", transport.Get)
+		api.Put("/admin/transport-types/{code}", transport.Update)
+		api.Delete("/admin/transport-types/{code}", transport.Delete)
 	})
 	return r
 }
@@ -2,17 +2,30 @@ package deliverytx
 
 import (
 	"context"
+	"time"
 
 	"course-go-avito-Orurh/internal/domain"
 )
 
 // Repository is a delivery repository
 type Repository interface {
-	FindAvailableCourierForUpdate(ctx context.Context) (*domain.Courier, error)
+	ListAvailableCouriers(ctx context.Context) ([]domain.CourierCandidate, error)
+	LockAvailableCourier(ctx context.Context, id int64) (*domain.Courier, error)
+	LockCourier(ctx context.Context, id int64) (*domain.Courier, error)
+	CountActiveDeliveries(ctx context.Context, courierID int64) (int, error)
 	GetByOrderID(ctx context.Context, orderID string) (*domain.Delivery, error)
 	InsertDelivery(ctx context.Context, d *domain.Delivery) error
-	DeleteByOrderID(ctx context.Context, orderID string) error
+	RecordAssignment(ctx context.Context, courierID int64, at time.Time) error
+	UpdateDeliveryStatus(ctx context.Context, id int64, from, to domain.DeliveryStatus, at time.Time) error
 	UpdateCourierStatus(ctx context.Context, id int64, status domain.CourierStatus) error
+	UpdateCourier(ctx context.Context, u domain.PartialCourierUpdate) (bool, error)
+	ListPendingOrdersForUpdate(ctx context.Context, limit int) ([]domain.PendingOrder, error)
+	DeletePendingOrder(ctx context.Context, orderID string) error
+	EnqueuePendingOrder(ctx context.Context, p domain.PendingOrder) error
+	ExpireOverdueDeliveries(ctx context.Context, now time.Time, limit int) ([]domain.Delivery, error)
+	ListOverduePickedUp(ctx context.Context, now time.Time, limit int) ([]domain.Delivery, error)
+	InsertReassignment(ctx context.Context, r domain.Reassignment) error
+	AppendOutbox(ctx context.Context, ev domain.OutboxEvent) error
 }
 
 // Runner is a transaction runner
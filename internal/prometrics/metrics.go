@@ -17,3 +17,148 @@ func NewGatewayRetriesTotal() prometheus.Counter {
 		Help: "Total number of retry attempts performed by gateways",
 	})
 }
+
+// NewDeliveryReassignmentsTotal returns a Prometheus counter for the number of expired deliveries reassigned to another courier
+func NewDeliveryReassignmentsTotal() prometheus.Counter {
+	return prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "delivery_reassignments_total",
+		Help: "Total number of expired deliveries reassigned to another courier",
+	})
+}
+
+// NewDeliveryOverduePickedUp returns a Prometheus gauge for the number of picked up deliveries past their deadline
+func NewDeliveryOverduePickedUp() prometheus.Gauge {
+	return prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "delivery_overdue_picked_up",
+		Help: "Number of picked up deliveries past their deadline as of the last expiry run, at most 100 are counted",
+	})
+}
+
+// NewKafkaConsumerRetriesTotal returns a Prometheus counter vector for the number of retried order events, by event status
+func NewKafkaConsumerRetriesTotal() *prometheus.CounterVec {
+	return prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "kafka_consumer_retries_total",
+		Help: "Total number of order event handler retries after a transient error, by event status",
+	}, []string{"status"})
+}
+
+// NewKafkaConsumerGiveUpsTotal returns a Prometheus counter vector for the number of order events given up after all retries, by event status
+func NewKafkaConsumerGiveUpsTotal() *prometheus.CounterVec {
+	return prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "kafka_consumer_give_ups_total",
+		Help: "Total number of order events dead-lettered after exhausting retries, by event status",
+	}, []string{"status"})
+}
+
+// NewOrdersOutOfOrderEventsTotal returns a Prometheus counter vector for the number of order events skipped as out of order, by reason
+func NewOrdersOutOfOrderEventsTotal() *prometheus.CounterVec {
+	return prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "orders_out_of_order_events_total",
+		Help: "Total number of order events skipped because they were stale or moved the order back, by reason",
+	}, []string{"reason"})
+}
+
+// NewKafkaConsumerMessagesConsumedTotal returns a Prometheus counter vector for the number of messages read by the consumer, by topic
+func NewKafkaConsumerMessagesConsumedTotal() *prometheus.CounterVec {
+	return prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "kafka_consumer_messages_consumed_total",
+		Help: "Total number of messages read from Kafka by the order events consumer, by topic",
+	}, []string{"topic"})
+}
+
+// NewKafkaConsumerMessagesHandledTotal returns a Prometheus counter vector for the number of order events handled successfully, by event status
+func NewKafkaConsumerMessagesHandledTotal() *prometheus.CounterVec {
+	return prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "kafka_consumer_messages_handled_total",
+		Help: "Total number of order events handled successfully, by event status",
+	}, []string{"status"})
+}
+
+// NewKafkaConsumerMessagesSkippedTotal returns a Prometheus counter vector for the number of messages acked without being applied, by reason
+func NewKafkaConsumerMessagesSkippedTotal() *prometheus.CounterVec {
+	return prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "kafka_consumer_messages_skipped_total",
+		Help: "Total number of messages acked without being applied (bad_json, empty_order_id, unknown_status, permanent_error), by reason",
+	}, []string{"reason"})
+}
+
+// NewKafkaConsumerMessagesFailedTotal returns a Prometheus counter vector for the number of failed handler calls, by event status
+func NewKafkaConsumerMessagesFailedTotal() *prometheus.CounterVec {
+	return prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "kafka_consumer_messages_failed_total",
+		Help: "Total number of order event handler calls that failed with a transient error, by event status",
+	}, []string{"status"})
+}
+
+// NewKafkaConsumerHandlerDurationSeconds returns a Prometheus histogram vector for the duration of order event handler calls, by event status
+func NewKafkaConsumerHandlerDurationSeconds() *prometheus.HistogramVec {
+	return prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "kafka_consumer_handler_duration_seconds",
+		Help:    "Duration of order event handler calls, including the orders gateway lookup, by event status",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"status"})
+}
+
+// NewKafkaConsumerLag returns a Prometheus gauge vector for the consumer lag, by topic and partition
+func NewKafkaConsumerLag() *prometheus.GaugeVec {
+	return prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "kafka_consumer_lag",
+		Help: "Messages between the partition high-water mark and the next offset the consumer will commit, by topic and partition",
+	}, []string{"topic", "partition"})
+}
+
+// NewGatewayCacheHitsTotal returns a Prometheus counter for the number of orders lookups served from the cache
+func NewGatewayCacheHitsTotal() prometheus.Counter {
+	return prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "gateway_cache_hits_total",
+		Help: "Total number of orders gateway lookups served from the cache, not-found answers included",
+	})
+}
+
+// NewGatewayCacheMissesTotal returns a Prometheus counter for the number of orders lookups the cache could not serve
+func NewGatewayCacheMissesTotal() prometheus.Counter {
+	return prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "gateway_cache_misses_total",
+		Help: "Total number of orders gateway lookups not served from the cache, including the ones that joined a concurrent lookup of the same order",
+	})
+}
+
+// NewGatewayBreakerState returns a Prometheus gauge for the state of the orders gateway circuit breaker
+func NewGatewayBreakerState() prometheus.Gauge {
+	return prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "gateway_breaker_state",
+		Help: "State of the orders gateway circuit breaker: 0 closed, 1 open, 2 half-open",
+	})
+}
+
+// NewOrdersPayloadFallbacksTotal returns a Prometheus counter vector for the number of order events applied from their payload, by event status
+func NewOrdersPayloadFallbacksTotal() *prometheus.CounterVec {
+	return prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "orders_payload_fallbacks_total",
+		Help: "Total number of order events applied from their payload because the orders service was unavailable, by event status",
+	}, []string{"status"})
+}
+
+// NewOrdersVerificationsTotal returns a Prometheus counter vector for the number of checks of payload-applied orders, by result
+func NewOrdersVerificationsTotal() *prometheus.CounterVec {
+	return prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "orders_verifications_total",
+		Help: "Total number of checks of orders applied from event payloads against the orders service (confirmed, corrected, failed), by result",
+	}, []string{"result"})
+}
+
+// NewOrdersReconcileDriftFoundTotal returns a Prometheus counter vector for the number of orders the reconciliation found drifted, by kind
+func NewOrdersReconcileDriftFoundTotal() *prometheus.CounterVec {
+	return prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "orders_reconcile_drift_found_total",
+		Help: "Total number of orders the delivery table disagrees with, found by the reconciliation job (missing, not_released, not_completed), by kind",
+	}, []string{"kind"})
+}
+
+// NewOrdersReconcileDriftFixedTotal returns a Prometheus counter vector for the number of drifted orders the reconciliation fixed, by kind
+func NewOrdersReconcileDriftFixedTotal() *prometheus.CounterVec {
+	return prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "orders_reconcile_drift_fixed_total",
+		Help: "Total number of drifted orders fixed by the reconciliation job, by kind",
+	}, []string{"kind"})
+}
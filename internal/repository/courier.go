@@ -20,8 +20,8 @@ func NewCourierRepo(db *pgxpool.Pool) *CourierRepo { return &CourierRepo{db: db}
 func (r *CourierRepo) Get(ctx context.Context, id int64) (*domain.Courier, error) {
 	var c domain.Courier
 	err := r.db.QueryRow(ctx,
-		`SELECT id, name, phone, status, transport_type FROM couriers WHERE id=$1`, id,
-	).Scan(&c.ID, &c.Name, &c.Phone, &c.Status, &c.TransportType)
+		`SELECT id, name, phone, status, transport_type, capacity FROM couriers WHERE id=$1`, id,
+	).Scan(&c.ID, &c.Name, &c.Phone, &c.Status, &c.TransportType, &c.Capacity)
 	if err != nil {
 		if IsNotFound(err) {
 			return nil, nil
@@ -33,7 +33,7 @@ func (r *CourierRepo) Get(ctx context.Context, id int64) (*domain.Courier, error
 
 // List returns couriers ordered by id. If limit/offset are nil, returns the full list.
 func (r *CourierRepo) List(ctx context.Context, limit, offset *int) ([]domain.Courier, error) {
-	q := `SELECT id, name, phone, status, transport_type FROM couriers ORDER BY id`
+	q := `SELECT id, name, phone, status, transport_type, capacity FROM couriers ORDER BY id`
 	args := make([]any, 0, 2)
 	if limit != nil {
 		q += fmt.Sprintf(" LIMIT $%d", len(args)+1)
@@ -56,7 +56,7 @@ func (r *CourierRepo) List(ctx context.Context, limit, offset *int) ([]domain.Co
 	out := make([]domain.Courier, 0, capacity)
 	for rows.Next() {
 		var c domain.Courier
-		if err := rows.Scan(&c.ID, &c.Name, &c.Phone, &c.Status, &c.TransportType); err != nil {
+		if err := rows.Scan(&c.ID, &c.Name, &c.Phone, &c.Status, &c.TransportType, &c.Capacity); err != nil {
 			return nil, err
 		}
 		out = append(out, c)
@@ -68,8 +68,8 @@ func (r *CourierRepo) List(ctx context.Context, limit, offset *int) ([]domain.Co
 func (r *CourierRepo) Create(ctx context.Context, c *domain.Courier) (int64, error) {
 	var id int64
 	err := r.db.QueryRow(ctx,
-		`INSERT INTO couriers(name,phone,status,transport_type) VALUES($1,$2,$3,$4) RETURNING id`,
-		c.Name, c.Phone, c.Status, c.TransportType).Scan(&id)
+		`INSERT INTO couriers(name,phone,status,transport_type,capacity) VALUES($1,$2,$3,$4,$5) RETURNING id`,
+		c.Name, c.Phone, c.Status, c.TransportType, c.Capacity).Scan(&id)
 	if err != nil {
 		if IsDuplicate(err) {
 			return 0, apperr.ErrConflict
@@ -81,16 +81,22 @@ func (r *CourierRepo) Create(ctx context.Context, c *domain.Courier) (int64, err
 
 // UpdatePartial applies a partial update to a courier and returns true if a row was affected.
 func (r *CourierRepo) UpdatePartial(ctx context.Context, u domain.PartialCourierUpdate) (bool, error) {
-	ct, err := r.db.Exec(ctx, `
+	return updateCourier(ctx, r.db, u)
+}
+
+// updateCourier applies a partial update to a courier through db and returns true if a row was affected.
+func updateCourier(ctx context.Context, db execer, u domain.PartialCourierUpdate) (bool, error) {
+	ct, err := db.Exec(ctx, `
         UPDATE couriers
         SET
             name           = COALESCE($2, name),
             phone          = COALESCE($3, phone),
             status         = COALESCE($4, status),
             transport_type = COALESCE($5, transport_type),
+            capacity       = COALESCE($6, capacity),
             updated_at     = now()
         WHERE id = $1
-    `, u.ID, u.Name, u.Phone, u.Status, u.TransportType)
+    `, u.ID, u.Name, u.Phone, u.Status, u.TransportType, u.Capacity)
 	if err != nil {
 		if IsDuplicate(err) {
 			return false, apperr.ErrConflict
@@ -99,3 +105,21 @@ func (r *CourierRepo) UpdatePartial(ctx context.Context, u domain.PartialCourier
 	}
 	return ct.RowsAffected() > 0, nil
 }
+
+// UpdateLocation stores the last reported courier location and returns false if the courier does not exist.
+func (r *CourierRepo) UpdateLocation(ctx context.Context, id int64, loc domain.Location) (bool, error) {
+	ct, err := r.db.Exec(ctx, `
+        INSERT INTO courier_locations (courier_id, lat, lon, updated_at)
+        SELECT c.id, $2, $3, now()
+        FROM couriers c
+        WHERE c.id = $1
+        ON CONFLICT (courier_id) DO UPDATE
+        SET lat        = EXCLUDED.lat,
+            lon        = EXCLUDED.lon,
+            updated_at = EXCLUDED.updated_at
+    `, id, loc.Lat, loc.Lon)
+	if err != nil {
+		return false, fmt.Errorf("update courier location %d: %w", id, err)
+	}
+	return ct.RowsAffected() > 0, nil
+}
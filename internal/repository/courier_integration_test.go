@@ -139,6 +139,64 @@ func (s *CourierRepositorySuite) TestUpdatePartial() {
 	s.Equal("+70000000000", got.Phone)
 }
 
+func (s *CourierRepositorySuite) TestCapacity_RoundTrip() {
+	ctx := context.Background()
+
+	id, err := s.repo.Create(ctx, &domain.Courier{
+		Name:          "Artem",
+		Phone:         "+70000000000",
+		Status:        domain.StatusAvailable,
+		TransportType: domain.TransportTypeCar,
+	})
+	s.Require().NoError(err)
+
+	got, err := s.repo.Get(ctx, id)
+	s.Require().NoError(err)
+	s.Nil(got.Capacity, "the transport default applies")
+
+	capacity := 2
+	ok, err := s.repo.UpdatePartial(ctx, domain.PartialCourierUpdate{ID: id, Capacity: &capacity})
+	s.Require().NoError(err)
+	s.True(ok)
+
+	got, err = s.repo.Get(ctx, id)
+	s.Require().NoError(err)
+	s.Require().NotNil(got.Capacity)
+	s.Equal(2, *got.Capacity)
+}
+
+func (s *CourierRepositorySuite) TestUpdateLocation_Upserts() {
+	ctx := context.Background()
+
+	id, err := s.repo.Create(ctx, &domain.Courier{
+		Name:          "Artem",
+		Phone:         "+70000000000",
+		Status:        domain.StatusAvailable,
+		TransportType: domain.TransportTypeFoot,
+	})
+	s.Require().NoError(err)
+
+	ok, err := s.repo.UpdateLocation(ctx, id, domain.Location{Lat: 1, Lon: 2})
+	s.Require().NoError(err)
+	s.True(ok)
+
+	ok, err = s.repo.UpdateLocation(ctx, id, domain.Location{Lat: 3, Lon: 4})
+	s.Require().NoError(err)
+	s.True(ok)
+
+	var lat, lon float64
+	err = s.pool.QueryRow(ctx, `SELECT lat, lon FROM courier_locations WHERE courier_id = $1`, id).Scan(&lat, &lon)
+	s.Require().NoError(err)
+	s.Equal(3.0, lat)
+	s.Equal(4.0, lon)
+}
+
+func (s *CourierRepositorySuite) TestUpdateLocation_UnknownCourier() {
+	ok, err := s.repo.UpdateLocation(context.Background(), 404, domain.Location{Lat: 1, Lon: 2})
+	s.Require().NoError(err)
+	s.False(ok)
+}
+
 func (s *CourierRepositorySuite) TestUpdatePartial_IsDublicate() {
 	ctx := context.Background()
 
@@ -6,13 +6,25 @@ import (
 	"time"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 
+	"course-go-avito-Orurh/internal/apperr"
 	"course-go-avito-Orurh/internal/domain"
 	"course-go-avito-Orurh/internal/logx"
 	"course-go-avito-Orurh/internal/ports/deliverytx"
 )
 
+// execer is implemented by both the pool and a transaction.
+type execer interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+}
+
+// querier is implemented by both the pool and a transaction.
+type querier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
 // DeliveryRepo represents delivery repository.
 type DeliveryRepo struct {
 	db  *pgxpool.Pool
@@ -25,8 +37,9 @@ func NewDeliveryRepo(db *pgxpool.Pool, log logx.Logger) *DeliveryRepo {
 }
 
 // WithTx opens a transaction and executes fn within it.
+// Inside an ambient transaction (see ProcessedEventRepo.Once) fn runs in a savepoint of it.
 func (r *DeliveryRepo) WithTx(ctx context.Context, fn func(tx deliverytx.Repository) error) (err error) {
-	tx, err := r.db.BeginTx(ctx, pgx.TxOptions{})
+	tx, err := beginTx(ctx, r.db)
 	if err != nil {
 		return fmt.Errorf("begin tx: %w", err)
 	}
@@ -62,29 +75,126 @@ type TxRepo struct {
 	tx pgx.Tx
 }
 
-// FindAvailableCourierForUpdate - find available courier for update.
-func (r *TxRepo) FindAvailableCourierForUpdate(ctx context.Context) (*domain.Courier, error) {
-	row := r.tx.QueryRow(ctx, `
-        SELECT c.id, c.name, c.phone, c.status, c.transport_type
+// ListAvailableCouriers - list available couriers with their location and workload.
+// Only active deliveries are counted, the lifetime workload is kept on the courier by RecordAssignment.
+// Rows are not locked: the caller picks a candidate and locks it with LockAvailableCourier.
+func (r *TxRepo) ListAvailableCouriers(ctx context.Context) ([]domain.CourierCandidate, error) {
+	rows, err := r.tx.Query(ctx, `
+        SELECT c.id, c.name, c.phone, c.status, c.transport_type, c.capacity,
+               l.lat, l.lon,
+               COALESCE(a.active, 0), c.total_deliveries, c.last_assigned_at
         FROM couriers c
+        LEFT JOIN courier_locations l ON l.courier_id = c.id
+        LEFT JOIN (
+            SELECT d.courier_id, COUNT(*) AS active
+            FROM delivery d
+            WHERE d.status IN ($1, $2)
+            GROUP BY d.courier_id
+        ) a ON a.courier_id = c.id
         WHERE c.status = 'available'
-        ORDER BY
-            (SELECT COUNT(*) FROM delivery d WHERE d.courier_id = c.id) ASC,
-            c.id ASC
+        ORDER BY c.id
+    `, string(domain.DeliveryStatusAssigned), string(domain.DeliveryStatusPickedUp))
+	if err != nil {
+		return nil, fmt.Errorf("list available couriers: %w", err)
+	}
+	defer rows.Close()
+
+	var out []domain.CourierCandidate
+	for rows.Next() {
+		var (
+			c        domain.CourierCandidate
+			lat, lon *float64
+		)
+		if err := rows.Scan(
+			&c.Courier.ID, &c.Courier.Name, &c.Courier.Phone, &c.Courier.Status, &c.Courier.TransportType,
+			&c.Courier.Capacity,
+			&lat, &lon,
+			&c.ActiveDeliveries, &c.TotalDeliveries, &c.LastAssignedAt,
+		); err != nil {
+			return nil, fmt.Errorf("scan available courier: %w", err)
+		}
+		if lat != nil && lon != nil {
+			c.Location = &domain.Location{Lat: *lat, Lon: *lon}
+		}
+		out = append(out, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list available couriers: %w", err)
+	}
+	return out, nil
+}
+
+// LockAvailableCourier - lock the courier row if it is still available.
+// It returns nil if the courier is gone, no longer available or locked by another transaction.
+func (r *TxRepo) LockAvailableCourier(ctx context.Context, id int64) (*domain.Courier, error) {
+	row := r.tx.QueryRow(ctx, `
+        SELECT id, name, phone, status, transport_type, capacity
+        FROM couriers
+        WHERE id = $1 AND status = 'available'
+        FOR UPDATE SKIP LOCKED
+    `, id)
+
+	var c domain.Courier
+	if err := row.Scan(&c.ID, &c.Name, &c.Phone, &c.Status, &c.TransportType, &c.Capacity); err != nil {
+		if IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("lock available courier %d: %w", id, err)
+	}
+	return &c, nil
+}
+
+// LockCourier - lock the courier row whatever its status, waiting for concurrent transactions.
+// It returns nil if the courier does not exist.
+func (r *TxRepo) LockCourier(ctx context.Context, id int64) (*domain.Courier, error) {
+	row := r.tx.QueryRow(ctx, `
+        SELECT id, name, phone, status, transport_type, capacity
+        FROM couriers
+        WHERE id = $1
         FOR UPDATE
-        LIMIT 1
-    `)
+    `, id)
 
 	var c domain.Courier
-	if err := row.Scan(&c.ID, &c.Name, &c.Phone, &c.Status, &c.TransportType); err != nil {
+	if err := row.Scan(&c.ID, &c.Name, &c.Phone, &c.Status, &c.TransportType, &c.Capacity); err != nil {
 		if IsNotFound(err) {
 			return nil, nil
 		}
-		return nil, fmt.Errorf("find available courier: %w", err)
+		return nil, fmt.Errorf("lock courier %d: %w", id, err)
 	}
 	return &c, nil
 }
 
+// CountActiveDeliveries - count assigned and picked up deliveries of the courier.
+func (r *TxRepo) CountActiveDeliveries(ctx context.Context, courierID int64) (int, error) {
+	var n int
+	err := r.tx.QueryRow(ctx, `
+        SELECT COUNT(*)
+        FROM delivery
+        WHERE courier_id = $1 AND status IN ($2, $3)
+    `, courierID, string(domain.DeliveryStatusAssigned), string(domain.DeliveryStatusPickedUp)).Scan(&n)
+	if err != nil {
+		return 0, fmt.Errorf("count active deliveries of courier %d: %w", courierID, err)
+	}
+	return n, nil
+}
+
+// RecordAssignment - count a delivery assigned to the courier at the given time in its lifetime workload.
+func (r *TxRepo) RecordAssignment(ctx context.Context, courierID int64, at time.Time) error {
+	ct, err := r.tx.Exec(ctx, `
+        UPDATE couriers
+        SET total_deliveries = total_deliveries + 1,
+            last_assigned_at = GREATEST(last_assigned_at, $2)
+        WHERE id = $1
+    `, courierID, at)
+	if err != nil {
+		return fmt.Errorf("record assignment of courier %d: %w", courierID, err)
+	}
+	if ct.RowsAffected() == 0 {
+		return fmt.Errorf("courier %d not found", courierID)
+	}
+	return nil
+}
+
 // UpdateCourierStatus - update courier status.
 func (r *TxRepo) UpdateCourierStatus(ctx context.Context, id int64, status domain.CourierStatus) error {
 	ct, err := r.tx.Exec(ctx, `
@@ -101,29 +211,73 @@ func (r *TxRepo) UpdateCourierStatus(ctx context.Context, id int64, status domai
 	return nil
 }
 
+// UpdateCourier - apply a partial update to a courier, false if it does not exist.
+func (r *TxRepo) UpdateCourier(ctx context.Context, u domain.PartialCourierUpdate) (bool, error) {
+	return updateCourier(ctx, r.tx, u)
+}
+
 // InsertDelivery - insert a new delivery.
 func (r *TxRepo) InsertDelivery(ctx context.Context, d *domain.Delivery) error {
+	if d.Status == "" {
+		d.Status = domain.DeliveryStatusAssigned
+	}
+	var lat, lon *float64
+	if d.Pickup != nil {
+		lat, lon = &d.Pickup.Lat, &d.Pickup.Lon
+	}
 	err := r.tx.QueryRow(ctx, `
-        INSERT INTO delivery (courier_id, order_id, assigned_at, deadline)
-        VALUES ($1, $2, $3, $4)
+        INSERT INTO delivery (courier_id, order_id, status, assigned_at, deadline, pickup_lat, pickup_lon)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
         RETURNING id
-    `, d.CourierID, d.OrderID, d.AssignedAt, d.Deadline).Scan(&d.ID)
+    `, d.CourierID, d.OrderID, string(d.Status), d.AssignedAt, d.Deadline, lat, lon).Scan(&d.ID)
 	if err != nil {
+		if IsDuplicate(err) {
+			return apperr.ErrConflict
+		}
 		return fmt.Errorf("insert delivery: %w", err)
 	}
 	return nil
 }
 
-// GetByOrderID - get delivery by order ID.
+const deliveryColumns = `id, courier_id, order_id, status, assigned_at, deadline,
+            picked_up_at, delivered_at, canceled_at, expired_at, failed_at, pickup_lat, pickup_lon`
+
+func scanDelivery(row pgx.Row, d *domain.Delivery) error {
+	var lat, lon *float64
+	err := row.Scan(
+		&d.ID, &d.CourierID, &d.OrderID, &d.Status, &d.AssignedAt, &d.Deadline,
+		&d.PickedUpAt, &d.DeliveredAt, &d.CanceledAt, &d.ExpiredAt, &d.FailedAt, &lat, &lon,
+	)
+	if err != nil {
+		return err
+	}
+	if lat != nil && lon != nil {
+		d.Pickup = &domain.Location{Lat: *lat, Lon: *lon}
+	}
+	return nil
+}
+
+// GetByOrderID - get the latest delivery by order ID.
 func (r *TxRepo) GetByOrderID(ctx context.Context, orderID string) (*domain.Delivery, error) {
-	row := r.tx.QueryRow(ctx, `
-        SELECT id, courier_id, order_id, assigned_at, deadline
+	return getByOrderID(ctx, r.tx, orderID)
+}
+
+// GetByOrderID - get the latest delivery by order ID outside of a transaction.
+func (r *DeliveryRepo) GetByOrderID(ctx context.Context, orderID string) (*domain.Delivery, error) {
+	return getByOrderID(ctx, connFrom(ctx, r.db), orderID)
+}
+
+func getByOrderID(ctx context.Context, db querier, orderID string) (*domain.Delivery, error) {
+	row := db.QueryRow(ctx, `
+        SELECT `+deliveryColumns+`
         FROM delivery
         WHERE order_id = $1
+        ORDER BY id DESC
+        LIMIT 1
     `, orderID)
 
 	var d domain.Delivery
-	if err := row.Scan(&d.ID, &d.CourierID, &d.OrderID, &d.AssignedAt, &d.Deadline); err != nil {
+	if err := scanDelivery(row, &d); err != nil {
 		if IsNotFound(err) {
 			return nil, nil
 		}
@@ -132,33 +286,261 @@ func (r *TxRepo) GetByOrderID(ctx context.Context, orderID string) (*domain.Deli
 	return &d, nil
 }
 
-// DeleteByOrderID - delete delivery by order ID.
-func (r *TxRepo) DeleteByOrderID(ctx context.Context, orderID string) error {
-	ct, err := r.tx.Exec(ctx, `DELETE FROM delivery WHERE order_id = $1`, orderID)
+// ListByCourier - list deliveries of a courier, newest first.
+// If activeOnly is set, only deliveries still in progress are returned.
+func (r *DeliveryRepo) ListByCourier(
+	ctx context.Context,
+	courierID int64,
+	activeOnly bool,
+	limit, offset int,
+) ([]domain.Delivery, error) {
+	rows, err := connFrom(ctx, r.db).Query(ctx, `
+        SELECT `+deliveryColumns+`
+        FROM delivery
+        WHERE courier_id = $1
+          AND (NOT $2 OR status IN ($3, $4))
+        ORDER BY assigned_at DESC, id DESC
+        LIMIT $5 OFFSET $6
+    `, courierID, activeOnly,
+		string(domain.DeliveryStatusAssigned), string(domain.DeliveryStatusPickedUp), limit, offset,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list deliveries of courier %d: %w", courierID, err)
+	}
+	return collectDeliveries(rows)
+}
+
+// ListOverdue - list active deliveries whose deadline passed before now, the most overdue first.
+func (r *DeliveryRepo) ListOverdue(ctx context.Context, now time.Time, limit, offset int) ([]domain.Delivery, error) {
+	rows, err := connFrom(ctx, r.db).Query(ctx, `
+        SELECT `+deliveryColumns+`
+        FROM delivery
+        WHERE status IN ($1, $2)
+          AND deadline < $3
+        ORDER BY deadline, id
+        LIMIT $4 OFFSET $5
+    `, string(domain.DeliveryStatusAssigned), string(domain.DeliveryStatusPickedUp), now, limit, offset)
 	if err != nil {
-		return fmt.Errorf("delete delivery by order %q: %w", orderID, err)
+		return nil, fmt.Errorf("list overdue deliveries: %w", err)
+	}
+	return collectDeliveries(rows)
+}
+
+func collectDeliveries(rows pgx.Rows) ([]domain.Delivery, error) {
+	defer rows.Close()
+
+	out := make([]domain.Delivery, 0)
+	for rows.Next() {
+		var d domain.Delivery
+		if err := scanDelivery(rows, &d); err != nil {
+			return nil, fmt.Errorf("scan delivery: %w", err)
+		}
+		out = append(out, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("read deliveries: %w", err)
+	}
+	return out, nil
+}
+
+// deliveryStatusColumn maps a delivery status to the column storing its transition time.
+func deliveryStatusColumn(status domain.DeliveryStatus) (string, error) {
+	switch status {
+	case domain.DeliveryStatusPickedUp:
+		return "picked_up_at", nil
+	case domain.DeliveryStatusDelivered:
+		return "delivered_at", nil
+	case domain.DeliveryStatusCanceled:
+		return "canceled_at", nil
+	case domain.DeliveryStatusExpired:
+		return "expired_at", nil
+	case domain.DeliveryStatusFailed:
+		return "failed_at", nil
+	default:
+		return "", fmt.Errorf("no transition column for delivery status %q", status)
+	}
+}
+
+// UpdateDeliveryStatus - atomically move a delivery from one status to another.
+// It returns apperr.ErrConflict if the delivery is no longer in the expected status.
+func (r *TxRepo) UpdateDeliveryStatus(
+	ctx context.Context,
+	id int64,
+	from, to domain.DeliveryStatus,
+	at time.Time,
+) error {
+	column, err := deliveryStatusColumn(to)
+	if err != nil {
+		return err
+	}
+	ct, err := r.tx.Exec(ctx, `
+        UPDATE delivery
+        SET status = $3, `+column+` = $4, updated_at = now()
+        WHERE id = $1 AND status = $2
+    `, id, string(from), string(to), at)
+	if err != nil {
+		return fmt.Errorf("update delivery status %d: %w", id, err)
 	}
 	if ct.RowsAffected() == 0 {
-		return fmt.Errorf("delivery for order %q not found", orderID)
+		return apperr.ErrConflict
 	}
 	return nil
 }
 
-// ReleaseCouriers - release expired couriers.
-func (r *DeliveryRepo) ReleaseCouriers(ctx context.Context, now time.Time) (int64, error) {
-	cmd, err := r.db.Exec(ctx, `
-        UPDATE couriers c
+// ExpireOverdueDeliveries - mark up to limit overdue assigned deliveries as expired and return them.
+// Picked up deliveries are never expired, their courier already has the goods.
+// Deliveries locked by a concurrent transaction are skipped.
+func (r *TxRepo) ExpireOverdueDeliveries(ctx context.Context, now time.Time, limit int) ([]domain.Delivery, error) {
+	rows, err := r.tx.Query(ctx, `
+        UPDATE delivery
         SET status = $1,
+            expired_at = $2,
             updated_at = now()
-        WHERE c.status = $2
-          AND c.id IN (
-              SELECT d.courier_id
-              FROM delivery d
-              WHERE d.deadline < $3
-          )
-    `, string(domain.StatusAvailable), string(domain.StatusBusy), now)
+        WHERE id IN (
+            SELECT id
+            FROM delivery
+            WHERE status = $3
+              AND deadline < $2
+            ORDER BY deadline, id
+            LIMIT $4
+            FOR UPDATE SKIP LOCKED
+        )
+        RETURNING `+deliveryColumns+`
+    `, string(domain.DeliveryStatusExpired), now, string(domain.DeliveryStatusAssigned), limit)
+	if err != nil {
+		return nil, fmt.Errorf("expire overdue deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var out []domain.Delivery
+	for rows.Next() {
+		var d domain.Delivery
+		if err := scanDelivery(rows, &d); err != nil {
+			return nil, fmt.Errorf("scan expired delivery: %w", err)
+		}
+		out = append(out, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("expire overdue deliveries: %w", err)
+	}
+	return out, nil
+}
+
+// ListOverduePickedUp - list up to limit picked up deliveries whose deadline passed before now,
+// the most overdue first.
+func (r *TxRepo) ListOverduePickedUp(ctx context.Context, now time.Time, limit int) ([]domain.Delivery, error) {
+	rows, err := r.tx.Query(ctx, `
+        SELECT `+deliveryColumns+`
+        FROM delivery
+        WHERE status = $1
+          AND deadline < $2
+        ORDER BY deadline, id
+        LIMIT $3
+    `, string(domain.DeliveryStatusPickedUp), now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list overdue picked up deliveries: %w", err)
+	}
+	return collectDeliveries(rows)
+}
+
+// InsertReassignment - record that an expired delivery was handed over to another courier.
+func (r *TxRepo) InsertReassignment(ctx context.Context, ra domain.Reassignment) error {
+	_, err := r.tx.Exec(ctx, `
+        INSERT INTO delivery_reassignments (order_id, expired_delivery_id, from_courier_id, to_courier_id, created_at)
+        VALUES ($1, $2, $3, $4, $5)
+    `, ra.OrderID, ra.ExpiredDeliveryID, ra.FromCourierID, ra.ToCourierID, ra.At)
 	if err != nil {
-		return 0, fmt.Errorf("release expired couriers: %w", err)
+		return fmt.Errorf("insert reassignment for order %q: %w", ra.OrderID, err)
 	}
-	return cmd.RowsAffected(), nil
+	return nil
+}
+
+// EnqueuePendingOrder - put an order into the pending queue.
+// Re-enqueueing an already queued order keeps its original place in the queue.
+func (r *DeliveryRepo) EnqueuePendingOrder(ctx context.Context, p domain.PendingOrder) error {
+	return enqueuePendingOrder(ctx, connFrom(ctx, r.db), p)
+}
+
+func enqueuePendingOrder(ctx context.Context, db execer, p domain.PendingOrder) error {
+	var lat, lon *float64
+	if p.Pickup != nil {
+		lat, lon = &p.Pickup.Lat, &p.Pickup.Lon
+	}
+	_, err := db.Exec(ctx, `
+        INSERT INTO pending_orders (order_id, pickup_lat, pickup_lon, priority, enqueued_at, excluded_courier_id)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        ON CONFLICT (order_id) DO NOTHING
+    `, p.OrderID, lat, lon, p.Priority, p.EnqueuedAt, p.ExcludedCourierID)
+	if err != nil {
+		return fmt.Errorf("enqueue pending order %q: %w", p.OrderID, err)
+	}
+	return nil
+}
+
+// DeletePendingOrder - remove an order from the pending queue, returns false if it was not queued.
+func (r *DeliveryRepo) DeletePendingOrder(ctx context.Context, orderID string) (bool, error) {
+	ct, err := connFrom(ctx, r.db).Exec(ctx, `DELETE FROM pending_orders WHERE order_id = $1`, orderID)
+	if err != nil {
+		return false, fmt.Errorf("delete pending order %q: %w", orderID, err)
+	}
+	return ct.RowsAffected() > 0, nil
+}
+
+// IsPendingOrder - report whether the order waits in the pending queue.
+func (r *DeliveryRepo) IsPendingOrder(ctx context.Context, orderID string) (bool, error) {
+	var ok bool
+	err := connFrom(ctx, r.db).QueryRow(ctx,
+		`SELECT EXISTS (SELECT 1 FROM pending_orders WHERE order_id = $1)`, orderID).Scan(&ok)
+	if err != nil {
+		return false, fmt.Errorf("check pending order %q: %w", orderID, err)
+	}
+	return ok, nil
+}
+
+// ListPendingOrdersForUpdate - lock up to limit pending orders, highest priority and oldest first.
+// Orders locked by a concurrent dispatcher are skipped.
+func (r *TxRepo) ListPendingOrdersForUpdate(ctx context.Context, limit int) ([]domain.PendingOrder, error) {
+	rows, err := r.tx.Query(ctx, `
+        SELECT order_id, pickup_lat, pickup_lon, priority, enqueued_at, excluded_courier_id
+        FROM pending_orders
+        ORDER BY priority DESC, enqueued_at, order_id
+        LIMIT $1
+        FOR UPDATE SKIP LOCKED
+    `, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list pending orders: %w", err)
+	}
+	defer rows.Close()
+
+	var out []domain.PendingOrder
+	for rows.Next() {
+		var (
+			p        domain.PendingOrder
+			lat, lon *float64
+		)
+		if err := rows.Scan(&p.OrderID, &lat, &lon, &p.Priority, &p.EnqueuedAt, &p.ExcludedCourierID); err != nil {
+			return nil, fmt.Errorf("scan pending order: %w", err)
+		}
+		if lat != nil && lon != nil {
+			p.Pickup = &domain.Location{Lat: *lat, Lon: *lon}
+		}
+		out = append(out, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list pending orders: %w", err)
+	}
+	return out, nil
+}
+
+// EnqueuePendingOrder - put an order into the pending queue within the transaction.
+func (r *TxRepo) EnqueuePendingOrder(ctx context.Context, p domain.PendingOrder) error {
+	return enqueuePendingOrder(ctx, r.tx, p)
+}
+
+// DeletePendingOrder - remove a dispatched order from the pending queue.
+func (r *TxRepo) DeletePendingOrder(ctx context.Context, orderID string) error {
+	if _, err := r.tx.Exec(ctx, `DELETE FROM pending_orders WHERE order_id = $1`, orderID); err != nil {
+		return fmt.Errorf("delete pending order %q: %w", orderID, err)
+	}
+	return nil
 }
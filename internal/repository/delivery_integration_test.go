@@ -10,6 +10,7 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/stretchr/testify/suite"
 
+	"course-go-avito-Orurh/internal/apperr"
 	"course-go-avito-Orurh/internal/domain"
 	"course-go-avito-Orurh/internal/logx"
 	"course-go-avito-Orurh/internal/repository"
@@ -45,6 +46,8 @@ func (s *DeliveryRepositorySuite) SetupTest() {
 	s.Require().NoError(err)
 	_, err = s.pool.Exec(ctx, `TRUNCATE couriers RESTART IDENTITY CASCADE`)
 	s.Require().NoError(err)
+	_, err = s.pool.Exec(ctx, `TRUNCATE pending_orders, delivery_reassignments`)
+	s.Require().NoError(err)
 }
 
 func (s *DeliveryRepositorySuite) createCourier(name, phone string, status domain.CourierStatus) int64 {
@@ -75,6 +78,7 @@ func (s *DeliveryRepositorySuite) TestInsertDeliveryAndGetByOrderID() {
 			OrderID:    "order-1",
 			AssignedAt: assignedAt,
 			Deadline:   deadline,
+			Pickup:     &domain.Location{Lat: 55.75, Lon: 37.61},
 		}
 
 		if err := tx.InsertDelivery(ctx, d); err != nil {
@@ -90,6 +94,7 @@ func (s *DeliveryRepositorySuite) TestInsertDeliveryAndGetByOrderID() {
 		s.Require().NotNil(got)
 		s.Equal(d.ID, got.ID)
 		s.Equal(courierID, got.CourierID)
+		s.Equal(d.Pickup, got.Pickup)
 
 		return nil
 	})
@@ -106,24 +111,25 @@ func (s *DeliveryRepositorySuite) TestInsertDeliveryAndGetByOrderID() {
 	s.Equal(insertedID, got2.ID)
 }
 
-func (s *DeliveryRepositorySuite) TestDeleteByOrderID() {
+func (s *DeliveryRepositorySuite) TestUpdateDeliveryStatus_MovesStateAndStampsTime() {
 	ctx := context.Background()
-
 	courierID := s.createCourier("Artem", "+70000000000", domain.StatusAvailable)
 
+	d := &domain.Delivery{
+		CourierID:  courierID,
+		OrderID:    "order-2",
+		AssignedAt: time.Now(),
+		Deadline:   time.Now().Add(10 * time.Minute),
+	}
 	err := withTxDelivery(ctx, s.deliveryRepo, func(tx delivery.TxRepository) error {
-		d := &domain.Delivery{
-			CourierID:  courierID,
-			OrderID:    "order-2",
-			AssignedAt: time.Now(),
-			Deadline:   time.Now().Add(10 * time.Minute),
-		}
 		return tx.InsertDelivery(ctx, d)
 	})
 	s.Require().NoError(err)
+	s.Equal(domain.DeliveryStatusAssigned, d.Status)
 
+	pickedAt := time.Now().UTC().Truncate(time.Microsecond)
 	err = withTxDelivery(ctx, s.deliveryRepo, func(tx delivery.TxRepository) error {
-		return tx.DeleteByOrderID(ctx, "order-2")
+		return tx.UpdateDeliveryStatus(ctx, d.ID, domain.DeliveryStatusAssigned, domain.DeliveryStatusPickedUp, pickedAt)
 	})
 	s.Require().NoError(err)
 
@@ -132,44 +138,197 @@ func (s *DeliveryRepositorySuite) TestDeleteByOrderID() {
 		if err != nil {
 			return err
 		}
-		s.Nil(got)
+		s.Require().NotNil(got)
+		s.Equal(domain.DeliveryStatusPickedUp, got.Status)
+		s.Require().NotNil(got.PickedUpAt)
+		s.True(got.PickedUpAt.Equal(pickedAt))
+		s.Nil(got.DeliveredAt)
 		return nil
 	})
 	s.Require().NoError(err)
 }
 
-func (s *DeliveryRepositorySuite) TestFindAvailableCourierForUpdate_PicksLeastLoaded() {
+func (s *DeliveryRepositorySuite) TestUpdateDeliveryStatus_StaleFromStatus_Conflict() {
+	ctx := context.Background()
+	courierID := s.createCourier("Artem", "+70000000000", domain.StatusAvailable)
+
+	d := &domain.Delivery{
+		CourierID:  courierID,
+		OrderID:    "order-3",
+		AssignedAt: time.Now(),
+		Deadline:   time.Now().Add(10 * time.Minute),
+	}
+	err := withTxDelivery(ctx, s.deliveryRepo, func(tx delivery.TxRepository) error {
+		return tx.InsertDelivery(ctx, d)
+	})
+	s.Require().NoError(err)
+
+	err = withTxDelivery(ctx, s.deliveryRepo, func(tx delivery.TxRepository) error {
+		return tx.UpdateDeliveryStatus(ctx, d.ID, domain.DeliveryStatusPickedUp, domain.DeliveryStatusDelivered, time.Now())
+	})
+	s.Require().ErrorIs(err, apperr.ErrConflict)
+}
+
+func (s *DeliveryRepositorySuite) TestInsertDelivery_SecondActiveForOrder_Conflict() {
+	ctx := context.Background()
+	courierID := s.createCourier("Artem", "+70000000000", domain.StatusAvailable)
+	now := time.Now()
+
+	first := &domain.Delivery{CourierID: courierID, OrderID: "order-dup", AssignedAt: now, Deadline: now.Add(time.Minute)}
+	err := withTxDelivery(ctx, s.deliveryRepo, func(tx delivery.TxRepository) error {
+		return tx.InsertDelivery(ctx, first)
+	})
+	s.Require().NoError(err)
+
+	err = withTxDelivery(ctx, s.deliveryRepo, func(tx delivery.TxRepository) error {
+		return tx.InsertDelivery(ctx, &domain.Delivery{CourierID: courierID, OrderID: "order-dup", AssignedAt: now, Deadline: now.Add(time.Minute)})
+	})
+	s.Require().ErrorIs(err, apperr.ErrConflict)
+
+	err = withTxDelivery(ctx, s.deliveryRepo, func(tx delivery.TxRepository) error {
+		if err := tx.UpdateDeliveryStatus(ctx, first.ID, domain.DeliveryStatusAssigned, domain.DeliveryStatusCanceled, now); err != nil {
+			return err
+		}
+		return tx.InsertDelivery(ctx, &domain.Delivery{CourierID: courierID, OrderID: "order-dup", AssignedAt: now, Deadline: now.Add(time.Minute)})
+	})
+	s.Require().NoError(err)
+}
+
+func (s *DeliveryRepositorySuite) TestListAvailableCouriers_ReturnsWorkloadAndLocation() {
 	ctx := context.Background()
 
 	id1 := s.createCourier("C1", "+70000000001", domain.StatusAvailable)
 	id2 := s.createCourier("C2", "+70000000002", domain.StatusAvailable)
-	id3 := s.createCourier("C3", "+70000000003", domain.StatusAvailable)
+	_ = s.createCourier("Busy", "+70000000003", domain.StatusBusy)
 
-	now := time.Now()
-	insertDeliveryRaw := func(courierID int64) {
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	insertDeliveryRaw := func(courierID int64, status domain.DeliveryStatus, assignedAt time.Time) {
 		_, err := s.pool.Exec(ctx, `
-			INSERT INTO delivery (courier_id, order_id, assigned_at, deadline)
-			VALUES ($1, gen_random_uuid()::text, $2, $3)
-		`, courierID, now, now.Add(10*time.Minute))
+			INSERT INTO delivery (courier_id, order_id, status, assigned_at, deadline)
+			VALUES ($1, gen_random_uuid()::text, $2, $3, $4)
+		`, courierID, string(status), assignedAt, assignedAt.Add(10*time.Minute))
 		s.Require().NoError(err)
+		s.Require().NoError(withTxDelivery(ctx, s.deliveryRepo, func(tx delivery.TxRepository) error {
+			return tx.RecordAssignment(ctx, courierID, assignedAt)
+		}))
 	}
-	insertDeliveryRaw(id1)
-	insertDeliveryRaw(id1)
-	insertDeliveryRaw(id2)
+	insertDeliveryRaw(id1, domain.DeliveryStatusAssigned, now)
+	insertDeliveryRaw(id1, domain.DeliveryStatusDelivered, now.Add(-time.Hour))
 
-	var courier *domain.Courier
+	ok, err := s.courierRepo.UpdateLocation(ctx, id2, domain.Location{Lat: 55.75, Lon: 37.61})
+	s.Require().NoError(err)
+	s.Require().True(ok)
 
-	err := withTxDelivery(ctx, s.deliveryRepo, func(tx delivery.TxRepository) error {
+	var got []domain.CourierCandidate
+	err = withTxDelivery(ctx, s.deliveryRepo, func(tx delivery.TxRepository) error {
 		var err error
-		courier, err = tx.FindAvailableCourierForUpdate(ctx)
+		got, err = tx.ListAvailableCouriers(ctx)
 		return err
 	})
 	s.Require().NoError(err)
-	s.Require().NotNil(courier)
-	s.Equal(id3, courier.ID)
+	s.Require().Len(got, 2)
+
+	s.Equal(id1, got[0].Courier.ID)
+	s.Equal(2, got[0].TotalDeliveries)
+	s.Equal(1, got[0].ActiveDeliveries)
+	s.Require().NotNil(got[0].LastAssignedAt)
+	s.True(now.Equal(*got[0].LastAssignedAt), "an older assignment recorded later does not move it back")
+	s.Nil(got[0].Location)
+
+	s.Equal(id2, got[1].Courier.ID)
+	s.Zero(got[1].TotalDeliveries)
+	s.Nil(got[1].LastAssignedAt)
+	s.Equal(&domain.Location{Lat: 55.75, Lon: 37.61}, got[1].Location)
 }
 
-func (s *DeliveryRepositorySuite) TestReleaseCouriers() {
+func (s *DeliveryRepositorySuite) TestLockAvailableCourier() {
+	ctx := context.Background()
+
+	free := s.createCourier("Free", "+70000000001", domain.StatusAvailable)
+	busy := s.createCourier("Busy", "+70000000002", domain.StatusBusy)
+
+	err := withTxDelivery(ctx, s.deliveryRepo, func(tx delivery.TxRepository) error {
+		c, err := tx.LockAvailableCourier(ctx, free)
+		s.Require().NoError(err)
+		s.Require().NotNil(c)
+		s.Equal(free, c.ID)
+
+		// a concurrent transaction must skip the locked row instead of waiting for it
+		return withTxDelivery(ctx, s.deliveryRepo, func(other delivery.TxRepository) error {
+			c, err := other.LockAvailableCourier(ctx, free)
+			s.Require().NoError(err)
+			s.Nil(c)
+			return nil
+		})
+	})
+	s.Require().NoError(err)
+
+	err = withTxDelivery(ctx, s.deliveryRepo, func(tx delivery.TxRepository) error {
+		c, err := tx.LockAvailableCourier(ctx, busy)
+		s.Require().NoError(err)
+		s.Nil(c)
+		return nil
+	})
+	s.Require().NoError(err)
+}
+
+func (s *DeliveryRepositorySuite) TestPendingOrders_QueueOrderAndDelete() {
+	ctx := context.Background()
+	base := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	pickup := &domain.Location{Lat: 55.75, Lon: 37.61}
+	excluded := s.createCourier("Expired", "+70000000020", domain.StatusAvailable)
+	s.Require().NoError(s.deliveryRepo.EnqueuePendingOrder(ctx, domain.PendingOrder{
+		OrderID: "late", EnqueuedAt: base.Add(time.Minute), ExcludedCourierID: &excluded,
+	}))
+	s.Require().NoError(s.deliveryRepo.EnqueuePendingOrder(ctx, domain.PendingOrder{OrderID: "early", Pickup: pickup, EnqueuedAt: base}))
+	s.Require().NoError(s.deliveryRepo.EnqueuePendingOrder(ctx, domain.PendingOrder{OrderID: "urgent", Priority: 1, EnqueuedAt: base.Add(time.Hour)}))
+	// enqueueing the same order again keeps its original position
+	s.Require().NoError(s.deliveryRepo.EnqueuePendingOrder(ctx, domain.PendingOrder{OrderID: "early", EnqueuedAt: base.Add(2 * time.Hour)}))
+
+	err := withTxDelivery(ctx, s.deliveryRepo, func(tx delivery.TxRepository) error {
+		pending, err := tx.ListPendingOrdersForUpdate(ctx, 10)
+		s.Require().NoError(err)
+		s.Require().Len(pending, 3)
+		s.Equal("urgent", pending[0].OrderID)
+		s.Equal("early", pending[1].OrderID)
+		s.Require().NotNil(pending[1].Pickup)
+		s.InDelta(pickup.Lat, pending[1].Pickup.Lat, 1e-9)
+		s.Nil(pending[1].ExcludedCourierID)
+		s.Equal("late", pending[2].OrderID)
+		s.Nil(pending[2].Pickup)
+		s.Equal(&excluded, pending[2].ExcludedCourierID)
+
+		// a concurrent dispatcher skips the rows locked by this one
+		err = withTxDelivery(ctx, s.deliveryRepo, func(other delivery.TxRepository) error {
+			locked, err := other.ListPendingOrdersForUpdate(ctx, 10)
+			s.Require().NoError(err)
+			s.Empty(locked)
+			return nil
+		})
+		s.Require().NoError(err)
+
+		return tx.DeletePendingOrder(ctx, "urgent")
+	})
+	s.Require().NoError(err)
+
+	pending, err := s.deliveryRepo.IsPendingOrder(ctx, "late")
+	s.Require().NoError(err)
+	s.True(pending)
+
+	ok, err := s.deliveryRepo.DeletePendingOrder(ctx, "late")
+	s.Require().NoError(err)
+	s.True(ok)
+	ok, err = s.deliveryRepo.DeletePendingOrder(ctx, "urgent")
+	s.Require().NoError(err)
+	s.False(ok)
+
+	pending, err = s.deliveryRepo.IsPendingOrder(ctx, "late")
+	s.Require().NoError(err)
+	s.False(pending)
+}
+
+func (s *DeliveryRepositorySuite) TestExpireOverdueDeliveries_LeavesFreshDeliveries() {
 	ctx := context.Background()
 
 	id1 := s.createCourier("Busy1", "+70000000010", domain.StatusBusy)
@@ -179,29 +338,68 @@ func (s *DeliveryRepositorySuite) TestReleaseCouriers() {
 	past := now.Add(-time.Hour)
 	future := now.Add(time.Hour)
 
+	// id1 has only an overdue delivery, id2 has an overdue one, a fresh one and an overdue picked up one
 	_, err := s.pool.Exec(ctx, `
-		INSERT INTO delivery (courier_id, order_id, assigned_at, deadline)
-		VALUES ($1, 'o1', $2, $3)
-	`, id1, past, past)
+		INSERT INTO delivery (courier_id, order_id, assigned_at, deadline, pickup_lat, pickup_lon)
+		VALUES ($1, 'o1', $3, $3, 55.75, 37.61), ($2, 'o2', $3, $3, NULL, NULL), ($2, 'o3', $4, $5, NULL, NULL)
+	`, id1, id2, past, now, future)
 	s.Require().NoError(err)
-
 	_, err = s.pool.Exec(ctx, `
-		INSERT INTO delivery (courier_id, order_id, assigned_at, deadline)
-		VALUES ($1, 'o2', $2, $3)
-	`, id2, now, future)
+		INSERT INTO delivery (courier_id, order_id, status, assigned_at, deadline, picked_up_at)
+		VALUES ($1, 'o4', 'picked_up', $2, $2, $2)
+	`, id2, past)
 	s.Require().NoError(err)
 
-	affected, err := s.deliveryRepo.ReleaseCouriers(ctx, now)
-	s.Require().NoError(err)
-	s.Equal(int64(1), affected)
+	err = withTxDelivery(ctx, s.deliveryRepo, func(tx delivery.TxRepository) error {
+		expired, err := tx.ExpireOverdueDeliveries(ctx, now, 10)
+		s.Require().NoError(err)
+		s.Require().Len(expired, 2)
+		for _, d := range expired {
+			s.Equal(domain.DeliveryStatusExpired, d.Status)
+			s.NotNil(d.ExpiredAt)
+			if d.OrderID == "o1" {
+				s.Equal(&domain.Location{Lat: 55.75, Lon: 37.61}, d.Pickup, "the pickup point is kept for the reassignment")
+			} else {
+				s.Nil(d.Pickup)
+			}
+		}
 
-	c1, err := s.courierRepo.Get(ctx, id1)
-	s.Require().NoError(err)
-	s.Equal(domain.StatusAvailable, c1.Status)
+		c1, err := tx.LockCourier(ctx, id1)
+		s.Require().NoError(err)
+		s.Require().NotNil(c1)
+		active, err := tx.CountActiveDeliveries(ctx, id1)
+		s.Require().NoError(err)
+		s.Equal(0, active)
+
+		active, err = tx.CountActiveDeliveries(ctx, id2)
+		s.Require().NoError(err)
+		s.Equal(2, active, "a fresh delivery and a picked up one stay active")
 
-	c2, err := s.courierRepo.Get(ctx, id2)
+		late, err := tx.ListOverduePickedUp(ctx, now, 10)
+		s.Require().NoError(err)
+		s.Require().Len(late, 1)
+		s.Equal("o4", late[0].OrderID)
+
+		missing, err := tx.LockCourier(ctx, 9999)
+		s.Require().NoError(err)
+		s.Nil(missing)
+
+		return tx.InsertReassignment(ctx, domain.Reassignment{
+			OrderID:           expired[0].OrderID,
+			ExpiredDeliveryID: expired[0].ID,
+			FromCourierID:     expired[0].CourierID,
+			At:                now,
+		})
+	})
 	s.Require().NoError(err)
-	s.Equal(domain.StatusBusy, c2.Status)
+
+	var st3 string
+	s.Require().NoError(s.pool.QueryRow(ctx, `SELECT status FROM delivery WHERE order_id = 'o3'`).Scan(&st3))
+	s.Equal(string(domain.DeliveryStatusAssigned), st3)
+
+	var records int
+	s.Require().NoError(s.pool.QueryRow(ctx, `SELECT count(*) FROM delivery_reassignments`).Scan(&records))
+	s.Equal(1, records)
 }
 
 func (s *DeliveryRepositorySuite) TestUpdateCourierStatus_Success() {
@@ -232,33 +430,48 @@ func (s *DeliveryRepositorySuite) TestUpdateCourierStatus_NotFound() {
 	s.Contains(err.Error(), "not found")
 }
 
-func (s *DeliveryRepositorySuite) TestFindAvailableCourierForUpdate_NoAvailableCouriers() {
+func (s *DeliveryRepositorySuite) TestUpdateCourier_AppliesSetFields() {
 	ctx := context.Background()
 
-	_ = s.createCourier("Busy1", "+70000000030", domain.StatusBusy)
-	_ = s.createCourier("Busy2", "+70000000031", domain.StatusBusy)
-
-	var courier *domain.Courier
+	id := s.createCourier("Artem", "+70000000021", domain.StatusAvailable)
+	paused, capacity := domain.StatusPaused, 3
 
+	var updated, missing bool
 	err := withTxDelivery(ctx, s.deliveryRepo, func(tx delivery.TxRepository) error {
 		var err error
-		courier, err = tx.FindAvailableCourierForUpdate(ctx)
+		if updated, err = tx.UpdateCourier(ctx, domain.PartialCourierUpdate{ID: id, Status: &paused, Capacity: &capacity}); err != nil {
+			return err
+		}
+		missing, err = tx.UpdateCourier(ctx, domain.PartialCourierUpdate{ID: 999999, Status: &paused})
 		return err
 	})
 	s.Require().NoError(err)
-	s.Nil(courier)
+	s.True(updated)
+	s.False(missing)
+
+	got, err := s.courierRepo.Get(ctx, id)
+	s.Require().NoError(err)
+	s.Equal("Artem", got.Name)
+	s.Equal(domain.StatusPaused, got.Status)
+	s.Require().NotNil(got.Capacity)
+	s.Equal(3, *got.Capacity)
 }
 
-func (s *DeliveryRepositorySuite) TestDeleteByOrderID_NotFound() {
+func (s *DeliveryRepositorySuite) TestListAvailableCouriers_NoAvailableCouriers() {
 	ctx := context.Background()
 
-	const orderID = "no-order"
+	_ = s.createCourier("Busy1", "+70000000030", domain.StatusBusy)
+	_ = s.createCourier("Busy2", "+70000000031", domain.StatusBusy)
+
+	var got []domain.CourierCandidate
 
 	err := withTxDelivery(ctx, s.deliveryRepo, func(tx delivery.TxRepository) error {
-		return tx.DeleteByOrderID(ctx, orderID)
+		var err error
+		got, err = tx.ListAvailableCouriers(ctx)
+		return err
 	})
-	s.Require().Error(err)
-	s.Contains(err.Error(), "not found")
+	s.Require().NoError(err)
+	s.Empty(got)
 }
 
 func (s *DeliveryRepositorySuite) TestWithTx_BeginTx_ContextCanceled() {
@@ -325,33 +538,79 @@ func (s *DeliveryRepositorySuite) TestGetByOrderID_ContextCanceled_CoversErrorBr
 	s.Contains(err.Error(), "get delivery by order")
 }
 
-func (s *DeliveryRepositorySuite) TestDeleteByOrderID_ContextCanceled_CoversErrorBranch() {
+func (s *DeliveryRepositorySuite) TestUpdateDeliveryStatus_ContextCanceled_CoversErrorBranch() {
 	ctx := context.Background()
-
 	courierID := s.createCourier("ArtemY", "+70000000112", domain.StatusAvailable)
 	now := time.Now()
 
+	d := &domain.Delivery{
+		CourierID:  courierID,
+		OrderID:    "order-cancel-upd",
+		AssignedAt: now,
+		Deadline:   now.Add(10 * time.Minute),
+	}
 	err := withTxDelivery(ctx, s.deliveryRepo, func(tx delivery.TxRepository) error {
-		return tx.InsertDelivery(ctx, &domain.Delivery{
-			CourierID:  courierID,
-			OrderID:    "order-cancel-del",
-			AssignedAt: now,
-			Deadline:   now.Add(10 * time.Minute),
-		})
+		return tx.InsertDelivery(ctx, d)
 	})
 	s.Require().NoError(err)
 
 	err = withTxDelivery(ctx, s.deliveryRepo, func(tx delivery.TxRepository) error {
 		cctx, cancel := context.WithCancel(ctx)
 		cancel()
-
-		return tx.DeleteByOrderID(cctx, "order-cancel-del")
+		return tx.UpdateDeliveryStatus(cctx, d.ID, domain.DeliveryStatusAssigned, domain.DeliveryStatusCanceled, now)
 	})
 	s.Require().Error(err)
 	s.ErrorIs(err, context.Canceled)
-	s.Contains(err.Error(), "delete delivery by order")
+	s.Contains(err.Error(), "update delivery status")
 }
 
 func TestDeliveryRepositorySuite(t *testing.T) {
 	suite.Run(t, new(DeliveryRepositorySuite))
 }
+
+func (s *DeliveryRepositorySuite) TestQueries_ByOrderByCourierAndOverdue() {
+	ctx := context.Background()
+
+	id1 := s.createCourier("Busy1", "+70000000020", domain.StatusBusy)
+	id2 := s.createCourier("Busy2", "+70000000021", domain.StatusBusy)
+
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	_, err := s.pool.Exec(ctx, `
+		INSERT INTO delivery (courier_id, order_id, status, assigned_at, deadline)
+		VALUES ($1, 'q1', 'delivered', $3, $3),
+		       ($1, 'q2', 'assigned',  $4, $5),
+		       ($1, 'q3', 'picked_up', $5, $6),
+		       ($2, 'q4', 'assigned',  $4, $4)
+	`, id1, id2, now.Add(-3*time.Hour), now.Add(-2*time.Hour), now.Add(-time.Hour), now.Add(time.Hour))
+	s.Require().NoError(err)
+
+	got, err := s.deliveryRepo.GetByOrderID(ctx, "q3")
+	s.Require().NoError(err)
+	s.Require().NotNil(got)
+	s.Equal(domain.DeliveryStatusPickedUp, got.Status)
+
+	missing, err := s.deliveryRepo.GetByOrderID(ctx, "nope")
+	s.Require().NoError(err)
+	s.Nil(missing)
+
+	all, err := s.deliveryRepo.ListByCourier(ctx, id1, false, 10, 0)
+	s.Require().NoError(err)
+	s.Require().Len(all, 3)
+	s.Equal([]string{"q3", "q2", "q1"}, []string{all[0].OrderID, all[1].OrderID, all[2].OrderID}, "newest first")
+
+	active, err := s.deliveryRepo.ListByCourier(ctx, id1, true, 1, 1)
+	s.Require().NoError(err)
+	s.Require().Len(active, 1)
+	s.Equal("q2", active[0].OrderID)
+
+	none, err := s.deliveryRepo.ListByCourier(ctx, 9999, false, 10, 0)
+	s.Require().NoError(err)
+	s.NotNil(none)
+	s.Empty(none)
+
+	overdue, err := s.deliveryRepo.ListOverdue(ctx, now, 10, 0)
+	s.Require().NoError(err)
+	s.Require().Len(overdue, 2, "finished and fresh deliveries are not overdue")
+	s.Equal("q4", overdue[0].OrderID, "the most overdue first")
+	s.Equal("q2", overdue[1].OrderID)
+}
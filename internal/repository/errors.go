@@ -17,3 +17,9 @@ func IsDuplicate(err error) bool {
 func IsNotFound(err error) bool {
 	return errors.Is(err, pgx.ErrNoRows)
 }
+
+// IsForeignKeyViolation - signals that the error is a foreign key violation.
+func IsForeignKeyViolation(err error) bool {
+	var pgerr *pgconn.PgError
+	return errors.As(err, &pgerr) && pgerr.Code == "23503"
+}
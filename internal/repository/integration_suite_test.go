@@ -92,14 +92,34 @@ func setupPostgres(ctx context.Context) (string, *pgxpool.Pool, func(), error) {
 
 func createTables(ctx context.Context, pool *pgxpool.Pool) error {
 	_, err := pool.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS transport_types (
+			code                  TEXT PRIMARY KEY,
+			speed_kmh             DOUBLE PRECISION NOT NULL CHECK (speed_kmh > 0),
+			max_capacity          INT NOT NULL CHECK (max_capacity > 0),
+			base_deadline_seconds INT NOT NULL CHECK (base_deadline_seconds > 0),
+			created_at            TIMESTAMP WITHOUT TIME ZONE DEFAULT now() NOT NULL,
+			updated_at            TIMESTAMP WITHOUT TIME ZONE DEFAULT now() NOT NULL
+		);
+		INSERT INTO transport_types (code, speed_kmh, max_capacity, base_deadline_seconds)
+		VALUES ('on_foot', 5, 1, 1800), ('scooter', 20, 2, 900), ('car', 40, 4, 300)
+		ON CONFLICT (code) DO NOTHING;
+	`)
+	if err != nil {
+		return fmt.Errorf("create transport_types table: %w", err)
+	}
+
+	_, err = pool.Exec(ctx, `
 		CREATE TABLE IF NOT EXISTS couriers (
-			id             BIGSERIAL PRIMARY KEY,
-			name           TEXT NOT NULL,
-			phone          TEXT NOT NULL UNIQUE,
-			status         TEXT NOT NULL,
-			transport_type TEXT NOT NULL,
-			created_at     TIMESTAMP WITHOUT TIME ZONE DEFAULT now() NOT NULL,
-			updated_at     TIMESTAMP WITHOUT TIME ZONE DEFAULT now() NOT NULL
+			id               BIGSERIAL PRIMARY KEY,
+			name             TEXT NOT NULL,
+			phone            TEXT NOT NULL UNIQUE,
+			status           TEXT NOT NULL,
+			transport_type   TEXT NOT NULL REFERENCES transport_types(code),
+			capacity         INT CHECK (capacity > 0),
+			total_deliveries INT NOT NULL DEFAULT 0,
+			last_assigned_at TIMESTAMP WITHOUT TIME ZONE,
+			created_at       TIMESTAMP WITHOUT TIME ZONE DEFAULT now() NOT NULL,
+			updated_at       TIMESTAMP WITHOUT TIME ZONE DEFAULT now() NOT NULL
 		);
 	`)
 	if err != nil {
@@ -108,16 +128,138 @@ func createTables(ctx context.Context, pool *pgxpool.Pool) error {
 
 	_, err = pool.Exec(ctx, `
 		CREATE TABLE IF NOT EXISTS delivery (
-			id          BIGSERIAL PRIMARY KEY,
-			courier_id  BIGINT NOT NULL REFERENCES couriers(id) ON DELETE CASCADE,
-			order_id    TEXT NOT NULL UNIQUE,
-			assigned_at TIMESTAMP WITHOUT TIME ZONE NOT NULL,
-			deadline    TIMESTAMP WITHOUT TIME ZONE NOT NULL
+			id           BIGSERIAL PRIMARY KEY,
+			courier_id   BIGINT NOT NULL REFERENCES couriers(id) ON DELETE CASCADE,
+			order_id     TEXT NOT NULL,
+			status       TEXT NOT NULL DEFAULT 'assigned',
+			assigned_at  TIMESTAMP WITHOUT TIME ZONE NOT NULL,
+			deadline     TIMESTAMP WITHOUT TIME ZONE NOT NULL,
+			picked_up_at TIMESTAMP WITHOUT TIME ZONE,
+			delivered_at TIMESTAMP WITHOUT TIME ZONE,
+			canceled_at  TIMESTAMP WITHOUT TIME ZONE,
+			expired_at   TIMESTAMP WITHOUT TIME ZONE,
+			failed_at    TIMESTAMP WITHOUT TIME ZONE,
+			updated_at   TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT now(),
+			pickup_lat   DOUBLE PRECISION,
+			pickup_lon   DOUBLE PRECISION,
+			CHECK ((pickup_lat IS NULL) = (pickup_lon IS NULL))
 		);
 	`)
 	if err != nil {
 		return fmt.Errorf("create delivery table: %w", err)
 	}
+	_, err = pool.Exec(ctx, `
+		CREATE UNIQUE INDEX IF NOT EXISTS ux_delivery_order_id_active
+			ON delivery (order_id)
+			WHERE status IN ('assigned','picked_up');
+	`)
+	if err != nil {
+		return fmt.Errorf("create delivery active index: %w", err)
+	}
+
+	_, err = pool.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS courier_locations (
+			courier_id BIGINT PRIMARY KEY REFERENCES couriers(id) ON DELETE CASCADE,
+			lat        DOUBLE PRECISION NOT NULL,
+			lon        DOUBLE PRECISION NOT NULL,
+			updated_at TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT now()
+		);
+	`)
+	if err != nil {
+		return fmt.Errorf("create courier_locations table: %w", err)
+	}
+
+	_, err = pool.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS pending_orders (
+			order_id            TEXT PRIMARY KEY,
+			pickup_lat          DOUBLE PRECISION NULL,
+			pickup_lon          DOUBLE PRECISION NULL,
+			priority            INT NOT NULL DEFAULT 0,
+			enqueued_at         TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT now(),
+			excluded_courier_id BIGINT REFERENCES couriers(id) ON DELETE SET NULL
+		);
+	`)
+	if err != nil {
+		return fmt.Errorf("create pending_orders table: %w", err)
+	}
+
+	_, err = pool.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS delivery_reassignments (
+			id                  BIGSERIAL PRIMARY KEY,
+			order_id            TEXT NOT NULL,
+			expired_delivery_id BIGINT NOT NULL REFERENCES delivery(id) ON DELETE CASCADE,
+			from_courier_id     BIGINT NOT NULL,
+			to_courier_id       BIGINT,
+			created_at          TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT now()
+		);
+	`)
+	if err != nil {
+		return fmt.Errorf("create delivery_reassignments table: %w", err)
+	}
+
+	_, err = pool.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS processed_events (
+			topic        TEXT NOT NULL,
+			partition    INT NOT NULL,
+			"offset"     BIGINT NOT NULL,
+			processed_at TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT now(),
+			PRIMARY KEY (topic, partition, "offset")
+		);
+	`)
+	if err != nil {
+		return fmt.Errorf("create processed_events table: %w", err)
+	}
+
+	_, err = pool.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS outbox (
+			id         BIGSERIAL PRIMARY KEY,
+			event_type TEXT NOT NULL,
+			event_key  TEXT NOT NULL,
+			payload    JSONB NOT NULL,
+			created_at TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT now(),
+			sent_at    TIMESTAMP WITHOUT TIME ZONE
+		);
+	`)
+	if err != nil {
+		return fmt.Errorf("create outbox table: %w", err)
+	}
+
+	_, err = pool.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS order_versions (
+			order_id   VARCHAR(255) PRIMARY KEY,
+			status     TEXT NOT NULL,
+			event_at   TIMESTAMP WITHOUT TIME ZONE,
+			updated_at TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT now()
+		);
+	`)
+	if err != nil {
+		return fmt.Errorf("create order_versions table: %w", err)
+	}
+
+	_, err = pool.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS order_verifications (
+			order_id   VARCHAR(255) PRIMARY KEY,
+			status     TEXT NOT NULL,
+			event_at   TIMESTAMP WITHOUT TIME ZONE,
+			due_at     TIMESTAMP WITHOUT TIME ZONE NOT NULL,
+			attempts   INT NOT NULL DEFAULT 0,
+			created_at TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT now()
+		);
+	`)
+	if err != nil {
+		return fmt.Errorf("create order_verifications table: %w", err)
+	}
+
+	_, err = pool.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS sync_watermarks (
+			name       TEXT PRIMARY KEY,
+			watermark  TIMESTAMP WITHOUT TIME ZONE NOT NULL,
+			updated_at TIMESTAMP WITHOUT TIME ZONE NOT NULL DEFAULT now()
+		);
+	`)
+	if err != nil {
+		return fmt.Errorf("create sync_watermarks table: %w", err)
+	}
 
 	return nil
 }
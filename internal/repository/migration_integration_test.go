@@ -0,0 +1,113 @@
+//go:build integration
+
+package repository_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/require"
+)
+
+const migrationsDir = "../../db/migrations"
+
+// baselineMigrations are the migrations of the schema that deleted nothing on completion
+var baselineMigrations = []string{
+	"20251103171816_init_couriers.sql",
+	"20251116113651_add_courier_transport_and_delivery.sql",
+	"20251129175325_create_delivery_table.sql",
+	"20260125154910_add_indexes.sql",
+}
+
+// migrateUp runs the Up section of a goose migration
+func migrateUp(ctx context.Context, t *testing.T, conn *pgx.Conn, name string) {
+	t.Helper()
+	raw, err := os.ReadFile(filepath.Join(migrationsDir, name))
+	require.NoError(t, err)
+	up, _, _ := strings.Cut(string(raw), "-- +goose Down")
+	_, err = conn.Exec(ctx, up)
+	require.NoError(t, err, name)
+}
+
+// freshDatabase creates an empty database in the test container and connects to it
+func freshDatabase(ctx context.Context, t *testing.T, name string) *pgx.Conn {
+	t.Helper()
+	_, err := tcPool.Exec(ctx, `DROP DATABASE IF EXISTS `+name)
+	require.NoError(t, err)
+	_, err = tcPool.Exec(ctx, `CREATE DATABASE `+name)
+	require.NoError(t, err)
+
+	cfg, err := pgx.ParseConfig(tcDSN)
+	require.NoError(t, err)
+	cfg.Database = name
+	conn, err := pgx.ConnectConfig(ctx, cfg)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close(context.Background()) })
+	return conn
+}
+
+func TestMigration_DeliveryStatus_ClosesOutBaselineRows(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	conn := freshDatabase(ctx, t, "delivery_status_migration")
+	for _, name := range baselineMigrations {
+		migrateUp(ctx, t, conn, name)
+	}
+
+	_, err := conn.Exec(ctx, `
+		INSERT INTO couriers (id, name, phone, status)
+		VALUES (1, 'on the way', '+70000000001', 'busy'),
+		       (2, 'done early', '+70000000002', 'available'),
+		       (3, 'overdue',    '+70000000003', 'busy');
+
+		INSERT INTO delivery (courier_id, order_id, assigned_at, deadline)
+		VALUES (1, 'old',     now() - interval '2 days', now() - interval '1 day'),
+		       (1, 'current', now() - interval '5 minutes', now() + interval '25 minutes'),
+		       (2, 'early',   now() - interval '5 minutes', now() + interval '25 minutes'),
+		       (3, 'late',    now() - interval '1 hour', now() - interval '30 minutes');
+	`)
+	require.NoError(t, err)
+
+	migrateUp(ctx, t, conn, "20261016100000_add_delivery_status.sql")
+
+	rows, err := conn.Query(ctx, `
+		SELECT order_id, status, delivered_at IS NOT NULL, delivered_at <= deadline
+		FROM delivery
+		ORDER BY order_id`)
+	require.NoError(t, err)
+	type row struct {
+		orderID, status string
+		delivered       bool
+		byDeadline      *bool
+	}
+	got, err := pgx.CollectRows(rows, func(r pgx.CollectableRow) (row, error) {
+		var x row
+		err := r.Scan(&x.orderID, &x.status, &x.delivered, &x.byDeadline)
+		return x, err
+	})
+	require.NoError(t, err)
+
+	yes := true
+	require.Equal(t, []row{
+		{orderID: "current", status: "assigned"},
+		{orderID: "early", status: "delivered", delivered: true, byDeadline: &yes},
+		{orderID: "late", status: "delivered", delivered: true, byDeadline: &yes},
+		{orderID: "old", status: "delivered", delivered: true, byDeadline: &yes},
+	}, got)
+
+	var lateAt, lateDeadline time.Time
+	require.NoError(t, conn.QueryRow(ctx,
+		`SELECT delivered_at, deadline FROM delivery WHERE order_id = 'late'`).Scan(&lateAt, &lateDeadline))
+	require.Equal(t, lateDeadline, lateAt, "an overdue delivery is closed at its deadline")
+
+	_, err = conn.Exec(ctx, `
+		INSERT INTO delivery (courier_id, order_id, assigned_at, deadline)
+		VALUES (2, 'old', now(), now() + interval '30 minutes')`)
+	require.NoError(t, err, "a closed delivery does not block a new one of the same order")
+}
@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"course-go-avito-Orurh/internal/domain"
+)
+
+// OrderVerificationRepo stores order events applied from their payload until they are checked
+// against the orders service.
+type OrderVerificationRepo struct{ db *pgxpool.Pool }
+
+// NewOrderVerificationRepo creates a new OrderVerificationRepo.
+func NewOrderVerificationRepo(db *pgxpool.Pool) *OrderVerificationRepo {
+	return &OrderVerificationRepo{db: db}
+}
+
+// Schedule records v, replacing the pending verification of the order unless v was produced before it.
+func (r *OrderVerificationRepo) Schedule(ctx context.Context, v domain.OrderVerification) error {
+	var at *time.Time
+	if !v.At.IsZero() {
+		at = &v.At
+	}
+	_, err := connFrom(ctx, r.db).Exec(ctx, `
+        INSERT INTO order_verifications (order_id, status, event_at, due_at)
+        VALUES ($1, $2, $3, $4)
+        ON CONFLICT (order_id) DO UPDATE
+        SET status   = EXCLUDED.status,
+            event_at = EXCLUDED.event_at,
+            due_at   = EXCLUDED.due_at,
+            attempts = 0
+        WHERE order_verifications.event_at IS NULL
+           OR EXCLUDED.event_at IS NULL
+           OR EXCLUDED.event_at >= order_verifications.event_at
+    `, v.OrderID, v.Status, at, v.DueAt.UTC())
+	if err != nil {
+		return fmt.Errorf("schedule order verification %q: %w", v.OrderID, err)
+	}
+	return nil
+}
+
+// Claim returns up to limit verifications due at now, earliest first, and postpones them
+// by lease: a verification that is not resolved is returned again once the lease is over.
+// Concurrent Claims never return the same verification.
+func (r *OrderVerificationRepo) Claim(
+	ctx context.Context,
+	now time.Time,
+	limit int,
+	lease time.Duration,
+) ([]domain.OrderVerification, error) {
+	rows, err := connFrom(ctx, r.db).Query(ctx, `
+        UPDATE order_verifications v
+        SET due_at   = $3,
+            attempts = v.attempts + 1
+        FROM (
+            SELECT order_id
+            FROM order_verifications
+            WHERE due_at <= $1
+            ORDER BY due_at, order_id
+            LIMIT $2
+            FOR UPDATE SKIP LOCKED
+        ) due
+        WHERE v.order_id = due.order_id
+        RETURNING v.order_id, v.status, v.event_at, v.due_at
+    `, now.UTC(), limit, now.Add(lease).UTC())
+	if err != nil {
+		return nil, fmt.Errorf("claim order verifications: %w", err)
+	}
+	defer rows.Close()
+
+	var out []domain.OrderVerification
+	for rows.Next() {
+		var (
+			v  domain.OrderVerification
+			at *time.Time
+		)
+		if err := rows.Scan(&v.OrderID, &v.Status, &at, &v.DueAt); err != nil {
+			return nil, fmt.Errorf("scan order verification: %w", err)
+		}
+		if at != nil {
+			v.At = at.UTC()
+		}
+		v.DueAt = v.DueAt.UTC()
+		out = append(out, v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("claim order verifications: %w", err)
+	}
+	return out, nil
+}
+
+// Resolve removes the verification of v.OrderID unless a later event has changed its status.
+func (r *OrderVerificationRepo) Resolve(ctx context.Context, v domain.OrderVerification) error {
+	_, err := connFrom(ctx, r.db).Exec(ctx, `
+        DELETE FROM order_verifications
+        WHERE order_id = $1 AND status = $2
+    `, v.OrderID, v.Status)
+	if err != nil {
+		return fmt.Errorf("resolve order verification %q: %w", v.OrderID, err)
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+//go:build integration
+
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/suite"
+
+	"course-go-avito-Orurh/internal/domain"
+	"course-go-avito-Orurh/internal/repository"
+)
+
+type OrderVerificationRepositorySuite struct {
+	suite.Suite
+	pool  *pgxpool.Pool
+	queue *repository.OrderVerificationRepo
+	now   time.Time
+}
+
+func (s *OrderVerificationRepositorySuite) SetupSuite() {
+	s.Require().NotNil(tcPool, "tcPool must be initialized in TestMain")
+
+	s.pool = tcPool
+	s.queue = repository.NewOrderVerificationRepo(tcPool)
+	s.now = time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+}
+
+func (s *OrderVerificationRepositorySuite) SetupTest() {
+	_, err := s.pool.Exec(context.Background(), `TRUNCATE order_verifications`)
+	s.Require().NoError(err)
+}
+
+func (s *OrderVerificationRepositorySuite) TestClaim_DueOnlyAndLeased() {
+	ctx := context.Background()
+	s.Require().NoError(s.queue.Schedule(ctx, domain.OrderVerification{
+		OrderID: "order-1", Status: "created", At: s.now.Add(-time.Minute), DueAt: s.now.Add(-time.Second),
+	}))
+	s.Require().NoError(s.queue.Schedule(ctx, domain.OrderVerification{
+		OrderID: "order-2", Status: "created", DueAt: s.now.Add(time.Minute),
+	}))
+
+	got, err := s.queue.Claim(ctx, s.now, 10, time.Minute)
+	s.Require().NoError(err)
+	s.Require().Len(got, 1)
+	s.Equal("order-1", got[0].OrderID)
+	s.Equal("created", got[0].Status)
+	s.True(s.now.Add(-time.Minute).Equal(got[0].At))
+
+	got, err = s.queue.Claim(ctx, s.now, 10, time.Minute)
+	s.Require().NoError(err)
+	s.Empty(got, "a claimed verification is leased")
+
+	got, err = s.queue.Claim(ctx, s.now.Add(time.Minute), 10, time.Minute)
+	s.Require().NoError(err)
+	s.Len(got, 2, "an unresolved verification comes back after the lease")
+}
+
+func (s *OrderVerificationRepositorySuite) TestSchedule_KeepsLatestEvent() {
+	ctx := context.Background()
+	s.Require().NoError(s.queue.Schedule(ctx, domain.OrderVerification{
+		OrderID: "order-1", Status: "canceled", At: s.now, DueAt: s.now,
+	}))
+	s.Require().NoError(s.queue.Schedule(ctx, domain.OrderVerification{
+		OrderID: "order-1", Status: "created", At: s.now.Add(-time.Minute), DueAt: s.now,
+	}))
+
+	got, err := s.queue.Claim(ctx, s.now, 10, time.Minute)
+	s.Require().NoError(err)
+	s.Require().Len(got, 1)
+	s.Equal("canceled", got[0].Status, "an older event does not replace a newer one")
+}
+
+func (s *OrderVerificationRepositorySuite) TestResolve_KeepsRescheduled() {
+	ctx := context.Background()
+	v := domain.OrderVerification{OrderID: "order-1", Status: "created", DueAt: s.now}
+	s.Require().NoError(s.queue.Schedule(ctx, v))
+	s.Require().NoError(s.queue.Schedule(ctx, domain.OrderVerification{OrderID: "order-1", Status: "canceled", DueAt: s.now}))
+
+	s.Require().NoError(s.queue.Resolve(ctx, v))
+	got, err := s.queue.Claim(ctx, s.now, 10, time.Minute)
+	s.Require().NoError(err)
+	s.Require().Len(got, 1, "a later event of the order still needs its check")
+
+	s.Require().NoError(s.queue.Resolve(ctx, got[0]))
+	got, err = s.queue.Claim(ctx, s.now.Add(time.Hour), 10, time.Minute)
+	s.Require().NoError(err)
+	s.Empty(got)
+}
+
+func TestOrderVerificationRepositorySuite(t *testing.T) {
+	suite.Run(t, new(OrderVerificationRepositorySuite))
+}
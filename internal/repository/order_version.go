@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"course-go-avito-Orurh/internal/domain"
+)
+
+// OrderVersionRepo stores the last applied status of every order.
+type OrderVersionRepo struct{ db *pgxpool.Pool }
+
+// NewOrderVersionRepo creates a new OrderVersionRepo.
+func NewOrderVersionRepo(db *pgxpool.Pool) *OrderVersionRepo { return &OrderVersionRepo{db: db} }
+
+// Last returns the last applied version of the order, or nil if none was applied yet.
+// Inside an ambient transaction the row stays locked until the transaction ends.
+func (r *OrderVersionRepo) Last(ctx context.Context, orderID string) (*domain.OrderVersion, error) {
+	var (
+		v  = domain.OrderVersion{OrderID: orderID}
+		at *time.Time
+	)
+	err := connFrom(ctx, r.db).QueryRow(ctx, `
+        SELECT status, event_at
+        FROM order_versions
+        WHERE order_id = $1
+        FOR UPDATE
+    `, orderID).Scan(&v.Status, &at)
+	if err != nil {
+		if IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get order version %q: %w", orderID, err)
+	}
+	if at != nil {
+		v.At = at.UTC()
+	}
+	return &v, nil
+}
+
+// Save records v as the last applied version of its order.
+func (r *OrderVersionRepo) Save(ctx context.Context, v domain.OrderVersion) error {
+	var at *time.Time
+	if !v.At.IsZero() {
+		at = &v.At
+	}
+	_, err := connFrom(ctx, r.db).Exec(ctx, `
+        INSERT INTO order_versions (order_id, status, event_at)
+        VALUES ($1, $2, $3)
+        ON CONFLICT (order_id) DO UPDATE
+        SET status     = EXCLUDED.status,
+            event_at   = EXCLUDED.event_at,
+            updated_at = now()
+    `, v.OrderID, v.Status, at)
+	if err != nil {
+		return fmt.Errorf("save order version %q: %w", v.OrderID, err)
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+//go:build integration
+
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/suite"
+
+	"course-go-avito-Orurh/internal/domain"
+	"course-go-avito-Orurh/internal/repository"
+)
+
+type OrderVersionRepositorySuite struct {
+	suite.Suite
+	pool     *pgxpool.Pool
+	versions *repository.OrderVersionRepo
+}
+
+func (s *OrderVersionRepositorySuite) SetupSuite() {
+	s.Require().NotNil(tcPool, "tcPool must be initialized in TestMain")
+
+	s.pool = tcPool
+	s.versions = repository.NewOrderVersionRepo(tcPool)
+}
+
+func (s *OrderVersionRepositorySuite) SetupTest() {
+	_, err := s.pool.Exec(context.Background(), `TRUNCATE order_versions`)
+	s.Require().NoError(err)
+}
+
+func (s *OrderVersionRepositorySuite) TestLast_NoneApplied() {
+	v, err := s.versions.Last(context.Background(), "order-1")
+	s.Require().NoError(err)
+	s.Nil(v)
+}
+
+func (s *OrderVersionRepositorySuite) TestSave_OverwritesLastVersion() {
+	ctx := context.Background()
+	at := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	s.Require().NoError(s.versions.Save(ctx, domain.OrderVersion{OrderID: "order-1", Status: "created", At: at}))
+	s.Require().NoError(s.versions.Save(ctx, domain.OrderVersion{OrderID: "order-1", Status: "canceled"}))
+
+	v, err := s.versions.Last(ctx, "order-1")
+	s.Require().NoError(err)
+	s.Equal(&domain.OrderVersion{OrderID: "order-1", Status: "canceled"}, v, "a zero time is stored as unknown")
+
+	s.Require().NoError(s.versions.Save(ctx, domain.OrderVersion{OrderID: "order-1", Status: "completed", At: at}))
+	v, err = s.versions.Last(ctx, "order-1")
+	s.Require().NoError(err)
+	s.Equal("completed", v.Status)
+	s.True(at.Equal(v.At))
+}
+
+func TestOrderVersionRepositorySuite(t *testing.T) {
+	suite.Run(t, new(OrderVersionRepositorySuite))
+}
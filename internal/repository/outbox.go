@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"course-go-avito-Orurh/internal/domain"
+	"course-go-avito-Orurh/internal/logx"
+)
+
+// outboxPayload is how an outbox event is stored in outbox.payload.
+type outboxPayload struct {
+	OrderID        string     `json:"order_id,omitempty"`
+	CourierID      int64      `json:"courier_id"`
+	DeliveryStatus string     `json:"delivery_status,omitempty"`
+	CourierStatus  string     `json:"courier_status,omitempty"`
+	Deadline       *time.Time `json:"deadline,omitempty"`
+	OccurredAt     time.Time  `json:"occurred_at"`
+}
+
+// AppendOutbox - record an event to be published once the transaction commits.
+func (r *TxRepo) AppendOutbox(ctx context.Context, ev domain.OutboxEvent) error {
+	payload, err := json.Marshal(outboxPayload{
+		OrderID:        ev.OrderID,
+		CourierID:      ev.CourierID,
+		DeliveryStatus: string(ev.DeliveryStatus),
+		CourierStatus:  string(ev.CourierStatus),
+		Deadline:       ev.Deadline,
+		OccurredAt:     ev.OccurredAt,
+	})
+	if err != nil {
+		return fmt.Errorf("encode outbox event %s: %w", ev.Type, err)
+	}
+	_, err = r.tx.Exec(ctx, `
+        INSERT INTO outbox (event_type, event_key, payload)
+        VALUES ($1, $2, $3)
+    `, string(ev.Type), ev.Key(), payload)
+	if err != nil {
+		return fmt.Errorf("append outbox event %s: %w", ev.Type, err)
+	}
+	return nil
+}
+
+// OutboxRepo reads the outbox for the relay.
+type OutboxRepo struct {
+	db  *pgxpool.Pool
+	log logx.Logger
+}
+
+// NewOutboxRepo creates a new OutboxRepo.
+func NewOutboxRepo(db *pgxpool.Pool, log logx.Logger) *OutboxRepo {
+	return &OutboxRepo{db: db, log: log}
+}
+
+// Relay passes up to limit unsent events to publish, oldest first, and marks the published ones sent.
+// It stops at the first publish error, so an event is never marked sent after one that failed.
+// The batch stays locked until it is marked, a concurrent Relay waits for it and keeps the order.
+func (r *OutboxRepo) Relay(
+	ctx context.Context,
+	limit int,
+	publish func(ctx context.Context, ev domain.OutboxEvent) error,
+) (n int, err error) {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return 0, fmt.Errorf("begin tx: %w", err)
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			if rbErr := tx.Rollback(ctx); rbErr != nil {
+				r.log.Error("tx rollback failed after panic", logx.Any("err", rbErr))
+			}
+			panic(p)
+		}
+	}()
+
+	events, err := listUnsent(ctx, tx, limit)
+	if err != nil {
+		_ = tx.Rollback(ctx)
+		return 0, err
+	}
+
+	sent := make([]int64, 0, len(events))
+	var publishErr error
+	for _, ev := range events {
+		if publishErr = publish(ctx, ev); publishErr != nil {
+			break
+		}
+		sent = append(sent, ev.ID)
+	}
+
+	if len(sent) > 0 {
+		if _, err := tx.Exec(ctx, `UPDATE outbox SET sent_at = now() WHERE id = ANY($1)`, sent); err != nil {
+			_ = tx.Rollback(ctx)
+			return 0, fmt.Errorf("mark outbox events sent: %w", err)
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("commit tx: %w", err)
+	}
+	return len(sent), publishErr
+}
+
+func listUnsent(ctx context.Context, db conn, limit int) ([]domain.OutboxEvent, error) {
+	rows, err := db.Query(ctx, `
+        SELECT id, event_type, payload
+        FROM outbox
+        WHERE sent_at IS NULL
+        ORDER BY id
+        LIMIT $1
+        FOR UPDATE
+    `, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list unsent outbox events: %w", err)
+	}
+	defer rows.Close()
+
+	var out []domain.OutboxEvent
+	for rows.Next() {
+		var (
+			ev  domain.OutboxEvent
+			raw []byte
+			p   outboxPayload
+		)
+		if err := rows.Scan(&ev.ID, &ev.Type, &raw); err != nil {
+			return nil, fmt.Errorf("scan outbox event: %w", err)
+		}
+		if err := json.Unmarshal(raw, &p); err != nil {
+			return nil, fmt.Errorf("decode outbox event %d: %w", ev.ID, err)
+		}
+		ev.OrderID = p.OrderID
+		ev.CourierID = p.CourierID
+		ev.DeliveryStatus = domain.DeliveryStatus(p.DeliveryStatus)
+		ev.CourierStatus = domain.CourierStatus(p.CourierStatus)
+		ev.Deadline = p.Deadline
+		ev.OccurredAt = p.OccurredAt
+		out = append(out, ev)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list unsent outbox events: %w", err)
+	}
+	return out, nil
+}
@@ -0,0 +1,122 @@
+//go:build integration
+
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/suite"
+
+	"course-go-avito-Orurh/internal/domain"
+	"course-go-avito-Orurh/internal/logx"
+	"course-go-avito-Orurh/internal/ports/deliverytx"
+	"course-go-avito-Orurh/internal/repository"
+)
+
+type OutboxRepositorySuite struct {
+	suite.Suite
+	pool         *pgxpool.Pool
+	outbox       *repository.OutboxRepo
+	deliveryRepo *repository.DeliveryRepo
+}
+
+func (s *OutboxRepositorySuite) SetupSuite() {
+	s.Require().NotNil(tcPool, "tcPool must be initialized in TestMain")
+
+	s.pool = tcPool
+	s.outbox = repository.NewOutboxRepo(tcPool, logx.Nop())
+	s.deliveryRepo = repository.NewDeliveryRepo(tcPool, logx.Nop())
+}
+
+func (s *OutboxRepositorySuite) SetupTest() {
+	_, err := s.pool.Exec(context.Background(), `TRUNCATE outbox RESTART IDENTITY`)
+	s.Require().NoError(err)
+}
+
+func (s *OutboxRepositorySuite) appendEvents(ctx context.Context, orderIDs ...string) error {
+	return s.deliveryRepo.WithTx(ctx, func(tx deliverytx.Repository) error {
+		for _, id := range orderIDs {
+			err := tx.AppendOutbox(ctx, domain.OutboxEvent{
+				Type:           domain.OutboxDeliveryStatusChanged,
+				OrderID:        id,
+				CourierID:      1,
+				DeliveryStatus: domain.DeliveryStatusDelivered,
+				OccurredAt:     time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *OutboxRepositorySuite) TestAppendOutbox_RolledBackWithTransaction() {
+	ctx := context.Background()
+	boom := errors.New("boom")
+
+	err := s.deliveryRepo.WithTx(ctx, func(tx deliverytx.Repository) error {
+		if err := tx.AppendOutbox(ctx, domain.OutboxEvent{Type: domain.OutboxCourierStatusChanged, CourierID: 1}); err != nil {
+			return err
+		}
+		return boom
+	})
+	s.ErrorIs(err, boom)
+
+	n, err := s.outbox.Relay(ctx, 10, func(context.Context, domain.OutboxEvent) error { return nil })
+	s.Require().NoError(err)
+	s.Zero(n)
+}
+
+func (s *OutboxRepositorySuite) TestRelay_PublishesInOrderAndMarksSent() {
+	ctx := context.Background()
+	s.Require().NoError(s.appendEvents(ctx, "order-1", "order-2", "order-3"))
+
+	var got []domain.OutboxEvent
+	n, err := s.outbox.Relay(ctx, 10, func(_ context.Context, ev domain.OutboxEvent) error {
+		got = append(got, ev)
+		return nil
+	})
+	s.Require().NoError(err)
+	s.Equal(3, n)
+	s.Require().Len(got, 3)
+	s.Equal([]string{"order-1", "order-2", "order-3"}, []string{got[0].OrderID, got[1].OrderID, got[2].OrderID})
+	s.Equal(domain.OutboxDeliveryStatusChanged, got[0].Type)
+	s.Equal(domain.DeliveryStatusDelivered, got[0].DeliveryStatus)
+	s.True(got[0].OccurredAt.Equal(time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)))
+
+	n, err = s.outbox.Relay(ctx, 10, func(context.Context, domain.OutboxEvent) error { return nil })
+	s.Require().NoError(err)
+	s.Zero(n, "sent events are not published again")
+}
+
+func (s *OutboxRepositorySuite) TestRelay_StopsAtFirstFailure() {
+	ctx := context.Background()
+	s.Require().NoError(s.appendEvents(ctx, "order-1", "order-2", "order-3"))
+	boom := errors.New("broker down")
+
+	n, err := s.outbox.Relay(ctx, 10, func(_ context.Context, ev domain.OutboxEvent) error {
+		if ev.OrderID == "order-2" {
+			return boom
+		}
+		return nil
+	})
+	s.ErrorIs(err, boom)
+	s.Equal(1, n)
+
+	var next []string
+	_, err = s.outbox.Relay(ctx, 10, func(_ context.Context, ev domain.OutboxEvent) error {
+		next = append(next, ev.OrderID)
+		return nil
+	})
+	s.Require().NoError(err)
+	s.Equal([]string{"order-2", "order-3"}, next)
+}
+
+func TestOutboxRepositorySuite(t *testing.T) {
+	suite.Run(t, new(OutboxRepositorySuite))
+}
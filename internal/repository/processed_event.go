@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"course-go-avito-Orurh/internal/domain"
+	"course-go-avito-Orurh/internal/logx"
+)
+
+// ProcessedEventRepo is the ledger of consumed events that were already applied.
+type ProcessedEventRepo struct {
+	db  *pgxpool.Pool
+	log logx.Logger
+}
+
+// NewProcessedEventRepo creates a new ProcessedEventRepo.
+func NewProcessedEventRepo(db *pgxpool.Pool, log logx.Logger) *ProcessedEventRepo {
+	return &ProcessedEventRepo{db: db, log: log}
+}
+
+// Once records key and runs fn in the same transaction, so the changes fn makes through
+// repositories and the ledger entry are committed together. If key is already recorded,
+// fn is not called and Once returns false. If fn fails, nothing is recorded.
+//
+// A concurrent Once with the same key waits for the first one to finish and then skips.
+func (r *ProcessedEventRepo) Once(ctx context.Context, key domain.EventKey, fn func(ctx context.Context) error) (bool, error) {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return false, fmt.Errorf("begin tx: %w", err)
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			if rbErr := tx.Rollback(ctx); rbErr != nil {
+				r.log.Error("tx rollback failed after panic", logx.Any("err", rbErr))
+			}
+			panic(p)
+		}
+	}()
+
+	ct, err := tx.Exec(ctx, `
+        INSERT INTO processed_events (topic, partition, "offset")
+        VALUES ($1, $2, $3)
+        ON CONFLICT DO NOTHING
+    `, key.Topic, key.Partition, key.Offset)
+	if err != nil {
+		_ = tx.Rollback(ctx)
+		return false, fmt.Errorf("record processed event %s: %w", key, err)
+	}
+	if ct.RowsAffected() == 0 {
+		return false, tx.Rollback(ctx)
+	}
+
+	if err := fn(withAmbientTx(ctx, tx)); err != nil {
+		if rbErr := tx.Rollback(ctx); rbErr != nil {
+			return false, fmt.Errorf("rollback tx: %w (original error: %s)", rbErr, err.Error())
+		}
+		return false, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("commit tx: %w", err)
+	}
+	return true, nil
+}
@@ -0,0 +1,123 @@
+//go:build integration
+
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/suite"
+
+	"course-go-avito-Orurh/internal/domain"
+	"course-go-avito-Orurh/internal/logx"
+	"course-go-avito-Orurh/internal/ports/deliverytx"
+	"course-go-avito-Orurh/internal/repository"
+)
+
+type ProcessedEventRepositorySuite struct {
+	suite.Suite
+	pool         *pgxpool.Pool
+	ledger       *repository.ProcessedEventRepo
+	deliveryRepo *repository.DeliveryRepo
+}
+
+func (s *ProcessedEventRepositorySuite) SetupSuite() {
+	s.Require().NotNil(tcPool, "tcPool must be initialized in TestMain")
+
+	s.pool = tcPool
+	s.ledger = repository.NewProcessedEventRepo(tcPool, logx.Nop())
+	s.deliveryRepo = repository.NewDeliveryRepo(tcPool, logx.Nop())
+}
+
+func (s *ProcessedEventRepositorySuite) SetupTest() {
+	_, err := s.pool.Exec(context.Background(), `TRUNCATE processed_events, pending_orders`)
+	s.Require().NoError(err)
+}
+
+var eventKey = domain.EventKey{Topic: "orders", Partition: 0, Offset: 7}
+
+func (s *ProcessedEventRepositorySuite) enqueue(ctx context.Context, orderID string) error {
+	return s.deliveryRepo.EnqueuePendingOrder(ctx, domain.PendingOrder{OrderID: orderID, EnqueuedAt: time.Now()})
+}
+
+func (s *ProcessedEventRepositorySuite) countPending() int {
+	var n int
+	s.Require().NoError(s.pool.QueryRow(context.Background(), `SELECT count(*) FROM pending_orders`).Scan(&n))
+	return n
+}
+
+func (s *ProcessedEventRepositorySuite) TestOnce_SecondDeliveryIsSkipped() {
+	ctx := context.Background()
+
+	calls := 0
+	fn := func(ctx context.Context) error {
+		calls++
+		return s.enqueue(ctx, "order-1")
+	}
+
+	applied, err := s.ledger.Once(ctx, eventKey, fn)
+	s.Require().NoError(err)
+	s.True(applied)
+
+	applied, err = s.ledger.Once(ctx, eventKey, fn)
+	s.Require().NoError(err)
+	s.False(applied)
+	s.Equal(1, calls)
+	s.Equal(1, s.countPending())
+
+	applied, err = s.ledger.Once(ctx, domain.EventKey{Topic: "orders", Partition: 1, Offset: 7}, func(context.Context) error {
+		return nil
+	})
+	s.Require().NoError(err)
+	s.True(applied, "the same offset in another partition is another event")
+}
+
+func (s *ProcessedEventRepositorySuite) TestOnce_FailureRollsBackChangesAndLedger() {
+	ctx := context.Background()
+	boom := errors.New("boom")
+
+	_, err := s.ledger.Once(ctx, eventKey, func(ctx context.Context) error {
+		if err := s.enqueue(ctx, "order-1"); err != nil {
+			return err
+		}
+		return boom
+	})
+	s.ErrorIs(err, boom)
+	s.Equal(0, s.countPending(), "the business change is rolled back with the ledger entry")
+
+	applied, err := s.ledger.Once(ctx, eventKey, func(ctx context.Context) error {
+		return s.enqueue(ctx, "order-1")
+	})
+	s.Require().NoError(err)
+	s.True(applied, "a failed event is processed again on redelivery")
+	s.Equal(1, s.countPending())
+}
+
+func (s *ProcessedEventRepositorySuite) TestOnce_NestedWithTxUsesSavepoint() {
+	ctx := context.Background()
+	boom := errors.New("no courier")
+
+	applied, err := s.ledger.Once(ctx, eventKey, func(ctx context.Context) error {
+		err := s.deliveryRepo.WithTx(ctx, func(tx deliverytx.Repository) error {
+			if err := tx.EnqueuePendingOrder(ctx, domain.PendingOrder{OrderID: "inner", EnqueuedAt: time.Now()}); err != nil {
+				return err
+			}
+			return boom
+		})
+		s.Require().ErrorIs(err, boom)
+		return s.enqueue(ctx, "outer")
+	})
+	s.Require().NoError(err)
+	s.True(applied)
+
+	var orderID string
+	s.Require().NoError(s.pool.QueryRow(ctx, `SELECT order_id FROM pending_orders`).Scan(&orderID))
+	s.Equal("outer", orderID, "only the failed savepoint is rolled back")
+}
+
+func TestProcessedEventRepositorySuite(t *testing.T) {
+	suite.Run(t, new(ProcessedEventRepositorySuite))
+}
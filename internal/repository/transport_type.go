@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"course-go-avito-Orurh/internal/apperr"
+	"course-go-avito-Orurh/internal/domain"
+)
+
+const transportTypeColumns = `code, speed_kmh, max_capacity, base_deadline_seconds`
+
+// TransportTypeRepo represents transport type repository.
+type TransportTypeRepo struct{ db *pgxpool.Pool }
+
+// NewTransportTypeRepo creates a new TransportTypeRepo.
+func NewTransportTypeRepo(db *pgxpool.Pool) *TransportTypeRepo { return &TransportTypeRepo{db: db} }
+
+func scanTransportType(row pgx.Row) (domain.TransportType, error) {
+	var (
+		t               domain.TransportType
+		deadlineSeconds int
+	)
+	if err := row.Scan(&t.Code, &t.SpeedKmh, &t.MaxCapacity, &deadlineSeconds); err != nil {
+		return domain.TransportType{}, err
+	}
+	t.BaseDeadline = time.Duration(deadlineSeconds) * time.Second
+	return t, nil
+}
+
+// List returns all transport types ordered by code.
+func (r *TransportTypeRepo) List(ctx context.Context) ([]domain.TransportType, error) {
+	rows, err := r.db.Query(ctx, `SELECT `+transportTypeColumns+` FROM transport_types ORDER BY code`)
+	if err != nil {
+		return nil, fmt.Errorf("list transport types: %w", err)
+	}
+	defer rows.Close()
+
+	var out []domain.TransportType
+	for rows.Next() {
+		t, err := scanTransportType(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan transport type: %w", err)
+		}
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
+
+// Get returns the transport type with the given code, or nil if there is none.
+func (r *TransportTypeRepo) Get(ctx context.Context, code domain.CourierTransportType) (*domain.TransportType, error) {
+	t, err := scanTransportType(r.db.QueryRow(ctx,
+		`SELECT `+transportTypeColumns+` FROM transport_types WHERE code = $1`, code))
+	if err != nil {
+		if IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get transport type %s: %w", code, err)
+	}
+	return &t, nil
+}
+
+// Create inserts a new transport type, returns apperr.ErrConflict if the code is taken.
+func (r *TransportTypeRepo) Create(ctx context.Context, t domain.TransportType) error {
+	_, err := r.db.Exec(ctx, `
+        INSERT INTO transport_types (code, speed_kmh, max_capacity, base_deadline_seconds)
+        VALUES ($1, $2, $3, $4)
+    `, t.Code, t.SpeedKmh, t.MaxCapacity, int(t.BaseDeadline/time.Second))
+	if err != nil {
+		if IsDuplicate(err) {
+			return apperr.ErrConflict
+		}
+		return fmt.Errorf("create transport type %s: %w", t.Code, err)
+	}
+	return nil
+}
+
+// Update replaces the rules of a transport type and returns false if it does not exist.
+func (r *TransportTypeRepo) Update(ctx context.Context, t domain.TransportType) (bool, error) {
+	ct, err := r.db.Exec(ctx, `
+        UPDATE transport_types
+        SET speed_kmh             = $2,
+            max_capacity          = $3,
+            base_deadline_seconds = $4,
+            updated_at            = now()
+        WHERE code = $1
+    `, t.Code, t.SpeedKmh, t.MaxCapacity, int(t.BaseDeadline/time.Second))
+	if err != nil {
+		return false, fmt.Errorf("update transport type %s: %w", t.Code, err)
+	}
+	return ct.RowsAffected() > 0, nil
+}
+
+// Delete removes a transport type and returns false if it does not exist.
+// A transport type used by couriers is not deleted, apperr.ErrConflict is returned instead.
+func (r *TransportTypeRepo) Delete(ctx context.Context, code domain.CourierTransportType) (bool, error) {
+	ct, err := r.db.Exec(ctx, `DELETE FROM transport_types WHERE code = $1`, code)
+	if err != nil {
+		if IsForeignKeyViolation(err) {
+			return false, apperr.ErrConflict
+		}
+		return false, fmt.Errorf("delete transport type %s: %w", code, err)
+	}
+	return ct.RowsAffected() > 0, nil
+}
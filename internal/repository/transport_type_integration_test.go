@@ -0,0 +1,122 @@
+//go:build integration
+
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/suite"
+
+	"course-go-avito-Orurh/internal/apperr"
+	"course-go-avito-Orurh/internal/domain"
+	"course-go-avito-Orurh/internal/repository"
+)
+
+type TransportTypeRepositorySuite struct {
+	suite.Suite
+	pool        *pgxpool.Pool
+	repo        *repository.TransportTypeRepo
+	courierRepo *repository.CourierRepo
+}
+
+func (s *TransportTypeRepositorySuite) SetupSuite() {
+	s.Require().NotNil(tcPool, "tcPool must be initialized in TestMain")
+
+	s.pool = tcPool
+	s.repo = repository.NewTransportTypeRepo(tcPool)
+	s.courierRepo = repository.NewCourierRepo(tcPool)
+}
+
+func (s *TransportTypeRepositorySuite) SetupTest() {
+	ctx := context.Background()
+	_, err := s.pool.Exec(ctx, `TRUNCATE couriers RESTART IDENTITY CASCADE`)
+	s.Require().NoError(err)
+	_, err = s.pool.Exec(ctx, `DELETE FROM transport_types WHERE code NOT IN ('on_foot', 'scooter', 'car')`)
+	s.Require().NoError(err)
+}
+
+var bike = domain.TransportType{
+	Code:         "bike",
+	SpeedKmh:     15,
+	MaxCapacity:  2,
+	BaseDeadline: 20 * time.Minute,
+}
+
+func (s *TransportTypeRepositorySuite) TestCreateGetList() {
+	ctx := context.Background()
+
+	s.Require().NoError(s.repo.Create(ctx, bike))
+	s.ErrorIs(s.repo.Create(ctx, bike), apperr.ErrConflict)
+
+	got, err := s.repo.Get(ctx, "bike")
+	s.Require().NoError(err)
+	s.Require().NotNil(got)
+	s.Equal(bike, *got)
+
+	list, err := s.repo.List(ctx)
+	s.Require().NoError(err)
+	codes := make([]domain.CourierTransportType, 0, len(list))
+	for _, t := range list {
+		codes = append(codes, t.Code)
+	}
+	s.Equal([]domain.CourierTransportType{"bike", "car", "on_foot", "scooter"}, codes)
+
+	missing, err := s.repo.Get(ctx, "boat")
+	s.Require().NoError(err)
+	s.Nil(missing)
+}
+
+func (s *TransportTypeRepositorySuite) TestUpdate() {
+	ctx := context.Background()
+
+	s.Require().NoError(s.repo.Create(ctx, bike))
+
+	faster := bike
+	faster.SpeedKmh = 18
+	faster.BaseDeadline = 15 * time.Minute
+	ok, err := s.repo.Update(ctx, faster)
+	s.Require().NoError(err)
+	s.True(ok)
+
+	got, err := s.repo.Get(ctx, "bike")
+	s.Require().NoError(err)
+	s.Equal(faster, *got)
+
+	ok, err = s.repo.Update(ctx, domain.TransportType{Code: "boat", SpeedKmh: 1, MaxCapacity: 1, BaseDeadline: time.Hour})
+	s.Require().NoError(err)
+	s.False(ok)
+}
+
+func (s *TransportTypeRepositorySuite) TestDelete_InUse() {
+	ctx := context.Background()
+
+	s.Require().NoError(s.repo.Create(ctx, bike))
+	_, err := s.courierRepo.Create(ctx, &domain.Courier{
+		Name:          "Artem",
+		Phone:         "+70000000000",
+		Status:        domain.StatusAvailable,
+		TransportType: "bike",
+	})
+	s.Require().NoError(err)
+
+	_, err = s.repo.Delete(ctx, "bike")
+	s.ErrorIs(err, apperr.ErrConflict, "bike is used by a courier")
+
+	_, err = s.pool.Exec(ctx, `TRUNCATE couriers RESTART IDENTITY CASCADE`)
+	s.Require().NoError(err)
+
+	ok, err := s.repo.Delete(ctx, "bike")
+	s.Require().NoError(err)
+	s.True(ok)
+
+	ok, err = s.repo.Delete(ctx, "bike")
+	s.Require().NoError(err)
+	s.False(ok)
+}
+
+func TestTransportTypeRepositorySuite(t *testing.T) {
+	suite.Run(t, new(TransportTypeRepositorySuite))
+}
@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// conn is implemented by both the pool and a transaction.
+type conn interface {
+	execer
+	querier
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+type ambientTxKey struct{}
+
+// withAmbientTx returns a context carrying tx. Repositories called with it
+// run their statements inside tx instead of taking a pool connection.
+func withAmbientTx(ctx context.Context, tx pgx.Tx) context.Context {
+	return context.WithValue(ctx, ambientTxKey{}, tx)
+}
+
+func ambientTx(ctx context.Context) pgx.Tx {
+	tx, _ := ctx.Value(ambientTxKey{}).(pgx.Tx)
+	return tx
+}
+
+// connFrom returns the ambient transaction of ctx, or the pool if there is none.
+func connFrom(ctx context.Context, pool *pgxpool.Pool) conn {
+	if tx := ambientTx(ctx); tx != nil {
+		return tx
+	}
+	return pool
+}
+
+// beginTx opens a transaction, or a savepoint inside the ambient transaction of ctx.
+func beginTx(ctx context.Context, pool *pgxpool.Pool) (pgx.Tx, error) {
+	if tx := ambientTx(ctx); tx != nil {
+		return tx.Begin(ctx)
+	}
+	return pool.BeginTx(ctx, pgx.TxOptions{})
+}
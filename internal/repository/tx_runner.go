@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"course-go-avito-Orurh/internal/logx"
+)
+
+// TxRunner runs a unit of work in one transaction: repositories called with the context
+// passed to it join the transaction.
+type TxRunner struct {
+	db  *pgxpool.Pool
+	log logx.Logger
+}
+
+// NewTxRunner creates a new TxRunner.
+func NewTxRunner(db *pgxpool.Pool, log logx.Logger) *TxRunner {
+	return &TxRunner{db: db, log: log}
+}
+
+// InTx runs fn in a transaction and commits it unless fn fails.
+func (r *TxRunner) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return r.run(ctx, true, fn)
+}
+
+// DryRun runs fn in a transaction and always rolls it back.
+func (r *TxRunner) DryRun(ctx context.Context, fn func(ctx context.Context) error) error {
+	return r.run(ctx, false, fn)
+}
+
+func (r *TxRunner) run(ctx context.Context, commit bool, fn func(ctx context.Context) error) error {
+	tx, err := beginTx(ctx, r.db)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			if rbErr := tx.Rollback(ctx); rbErr != nil {
+				r.log.Error("tx rollback failed after panic", logx.Any("err", rbErr))
+			}
+			panic(p)
+		}
+	}()
+
+	if err := fn(withAmbientTx(ctx, tx)); err != nil || !commit {
+		if rbErr := tx.Rollback(ctx); rbErr != nil {
+			if err == nil {
+				return fmt.Errorf("rollback tx: %w", rbErr)
+			}
+			return fmt.Errorf("rollback tx: %w (original error: %s)", rbErr, err.Error())
+		}
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// WatermarkRepo stores how far background jobs have synchronized, one watermark per job name.
+type WatermarkRepo struct{ db *pgxpool.Pool }
+
+// NewWatermarkRepo creates a new WatermarkRepo.
+func NewWatermarkRepo(db *pgxpool.Pool) *WatermarkRepo { return &WatermarkRepo{db: db} }
+
+// Load returns the watermark of the job, or zero time if none was saved yet.
+func (r *WatermarkRepo) Load(ctx context.Context, name string) (time.Time, error) {
+	var at time.Time
+	err := connFrom(ctx, r.db).QueryRow(ctx, `
+        SELECT watermark
+        FROM sync_watermarks
+        WHERE name = $1
+    `, name).Scan(&at)
+	if err != nil {
+		if IsNotFound(err) {
+			return time.Time{}, nil
+		}
+		return time.Time{}, fmt.Errorf("load watermark %q: %w", name, err)
+	}
+	return at.UTC(), nil
+}
+
+// Save records at as the watermark of the job.
+func (r *WatermarkRepo) Save(ctx context.Context, name string, at time.Time) error {
+	_, err := connFrom(ctx, r.db).Exec(ctx, `
+        INSERT INTO sync_watermarks (name, watermark)
+        VALUES ($1, $2)
+        ON CONFLICT (name) DO UPDATE
+        SET watermark  = EXCLUDED.watermark,
+            updated_at = now()
+    `, name, at.UTC())
+	if err != nil {
+		return fmt.Errorf("save watermark %q: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+//go:build integration
+
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/suite"
+
+	"course-go-avito-Orurh/internal/repository"
+)
+
+type WatermarkRepositorySuite struct {
+	suite.Suite
+	pool       *pgxpool.Pool
+	watermarks *repository.WatermarkRepo
+}
+
+func (s *WatermarkRepositorySuite) SetupSuite() {
+	s.Require().NotNil(tcPool, "tcPool must be initialized in TestMain")
+
+	s.pool = tcPool
+	s.watermarks = repository.NewWatermarkRepo(tcPool)
+}
+
+func (s *WatermarkRepositorySuite) SetupTest() {
+	_, err := s.pool.Exec(context.Background(), `TRUNCATE sync_watermarks`)
+	s.Require().NoError(err)
+}
+
+func (s *WatermarkRepositorySuite) TestLoad_NoneSaved() {
+	at, err := s.watermarks.Load(context.Background(), "orders")
+	s.Require().NoError(err)
+	s.True(at.IsZero())
+}
+
+func (s *WatermarkRepositorySuite) TestSave_OverwritesPerName() {
+	ctx := context.Background()
+	at := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	s.Require().NoError(s.watermarks.Save(ctx, "orders", at))
+	s.Require().NoError(s.watermarks.Save(ctx, "orders", at.Add(time.Hour)))
+	s.Require().NoError(s.watermarks.Save(ctx, "other", at))
+
+	got, err := s.watermarks.Load(ctx, "orders")
+	s.Require().NoError(err)
+	s.Equal(at.Add(time.Hour), got)
+}
+
+func TestWatermarkRepositorySuite(t *testing.T) {
+	suite.Run(t, new(WatermarkRepositorySuite))
+}
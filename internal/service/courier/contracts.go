@@ -1,4 +1,5 @@
 //go:generate mockgen -source=contracts.go -destination=courier_mocks_test.go -package=courier_test
+//go:generate mockgen -source=../../ports/deliverytx/contracts.go -destination=deliverytx_mocks_test.go -package=courier_test
 package courier
 
 import (
@@ -13,4 +14,5 @@ type courierRepository interface {
 	List(ctx context.Context, limit, offset *int) ([]domain.Courier, error)
 	Create(ctx context.Context, c *domain.Courier) (int64, error)
 	UpdatePartial(ctx context.Context, u domain.PartialCourierUpdate) (bool, error)
+	UpdateLocation(ctx context.Context, id int64, loc domain.Location) (bool, error)
 }
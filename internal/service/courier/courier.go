@@ -7,27 +7,54 @@ import (
 
 	"course-go-avito-Orurh/internal/apperr"
 	"course-go-avito-Orurh/internal/domain"
+	"course-go-avito-Orurh/internal/ports/deliverytx"
 )
 
 // Service coordinates courier business logic and orchestrates repository calls.
 type Service struct {
-	repo             courierRepository
+	repo courierRepository
+	// tx runs updates that must be recorded in the outbox
+	tx deliverytx.Runner
+	// types are the transport types that are valid and give the default capacity
+	types            domain.TransportTypes
 	operationTimeout time.Duration
+	// courierAvailable is called after a courier was created or updated as available
+	courierAvailable func()
+	now              func() time.Time
 }
 
 // NewService creates and configures a courier Service.
-func NewService(r courierRepository, timeout time.Duration) *Service {
+// If types is nil, the built-in transport types are used.
+func NewService(r courierRepository, tx deliverytx.Runner, types domain.TransportTypes, timeout time.Duration) *Service {
 	if timeout <= 0 {
 		timeout = 3 * time.Second
 	}
-	return &Service{repo: r, operationTimeout: timeout}
+	if types == nil {
+		types = domain.NewTransportTypeSet(domain.DefaultTransportTypes())
+	}
+	return &Service{
+		repo:             r,
+		tx:               tx,
+		types:            types,
+		operationTimeout: timeout,
+		courierAvailable: func() {},
+		now:              func() time.Time { return time.Now().UTC() },
+	}
+}
+
+// WithCourierAvailableHook sets fn to be called whenever a courier becomes available.
+func (s *Service) WithCourierAvailableHook(fn func()) *Service {
+	if fn != nil {
+		s.courierAvailable = fn
+	}
+	return s
 }
 
 func (s *Service) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
 	return context.WithTimeout(ctx, s.operationTimeout)
 }
 
-func validateCreate(c *domain.Courier) error {
+func validateCreate(c *domain.Courier, types domain.TransportTypes) error {
 	if c == nil {
 		return apperr.ErrInvalid
 	}
@@ -43,7 +70,10 @@ func validateCreate(c *domain.Courier) error {
 	if c.TransportType == "" {
 		c.TransportType = domain.TransportTypeFoot
 	}
-	if !domain.CourierTransportType(c.TransportType).Valid() {
+	if !domain.CourierTransportType(c.TransportType).Valid(types) {
+		return apperr.ErrInvalid
+	}
+	if c.Capacity != nil && *c.Capacity <= 0 {
 		return apperr.ErrInvalid
 	}
 	return nil
@@ -57,13 +87,16 @@ var courierUpdateRules = []updateRule{
 	ruleBadName,
 	ruleBadPhone,
 	ruleBadStatus,
-	ruleBadTransportType,
+	ruleBadCapacity,
 }
 
-func validateUpdate(u *domain.PartialCourierUpdate) error {
+func validateUpdate(u *domain.PartialCourierUpdate, types domain.TransportTypes) error {
 	if u == nil || u.ID <= 0 {
 		return apperr.ErrInvalid
 	}
+	if u.TransportType != nil && !domain.CourierTransportType(*u.TransportType).Valid(types) {
+		return apperr.ErrInvalid
+	}
 
 	for _, bad := range courierUpdateRules {
 		if bad(u) {
@@ -75,7 +108,7 @@ func validateUpdate(u *domain.PartialCourierUpdate) error {
 }
 
 func ruleNoUpdateFields(u *domain.PartialCourierUpdate) bool {
-	return u.Name == nil && u.Phone == nil && u.Status == nil && u.TransportType == nil
+	return u.Name == nil && u.Phone == nil && u.Status == nil && u.TransportType == nil && u.Capacity == nil
 }
 
 func ruleBadName(u *domain.PartialCourierUpdate) bool {
@@ -90,11 +123,11 @@ func ruleBadStatus(u *domain.PartialCourierUpdate) bool {
 	return u.Status != nil && !domain.CourierStatus(*u.Status).Valid()
 }
 
-func ruleBadTransportType(u *domain.PartialCourierUpdate) bool {
-	return u.TransportType != nil && !domain.CourierTransportType(*u.TransportType).Valid()
+func ruleBadCapacity(u *domain.PartialCourierUpdate) bool {
+	return u.Capacity != nil && *u.Capacity <= 0
 }
 
-// Get retrieves a courier by its ID.
+// Get retrieves a courier by its ID, with the capacity in effect.
 func (s *Service) Get(ctx context.Context, id int64) (*domain.Courier, error) {
 	ctx, cancel := s.withTimeout(ctx)
 	defer cancel()
@@ -105,39 +138,157 @@ func (s *Service) Get(ctx context.Context, id int64) (*domain.Courier, error) {
 	if c == nil {
 		return nil, apperr.ErrNotFound
 	}
+	s.resolveCapacity(c)
 	return c, nil
 }
 
-// List returns couriers with optional pagination
+// List returns couriers with optional pagination, with the capacity in effect
 func (s *Service) List(ctx context.Context, limit, offset *int) ([]domain.Courier, error) {
 	ctx, cancel := s.withTimeout(ctx)
 	defer cancel()
-	return s.repo.List(ctx, limit, offset)
+	list, err := s.repo.List(ctx, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	for i := range list {
+		s.resolveCapacity(&list[i])
+	}
+	return list, nil
+}
+
+// resolveCapacity fills in the transport default for a courier without its own capacity
+func (s *Service) resolveCapacity(c *domain.Courier) {
+	n := c.MaxActiveDeliveries(s.types)
+	c.Capacity = &n
 }
 
 // Create persists a new courier and returns its generated ID.
 func (s *Service) Create(ctx context.Context, c *domain.Courier) (int64, error) {
-	if err := validateCreate(c); err != nil {
+	if err := validateCreate(c, s.types); err != nil {
 		return 0, err
 	}
 	ctx, cancel := s.withTimeout(ctx)
 	defer cancel()
-	return s.repo.Create(ctx, c)
+	id, err := s.repo.Create(ctx, c)
+	if err != nil {
+		return 0, err
+	}
+	if c.Status == domain.StatusAvailable {
+		s.courierAvailable()
+	}
+	return id, nil
 }
 
 // UpdatePartial applies a partial update to a courier. It returns true if a row was updated.
+// A status change is recorded in the outbox in the same transaction as the update. A change of the
+// capacity or the transport re-checks whether the courier is busy with its active deliveries.
 func (s *Service) UpdatePartial(ctx context.Context, u domain.PartialCourierUpdate) (bool, error) {
-	if err := validateUpdate(&u); err != nil {
+	if err := validateUpdate(&u, s.types); err != nil {
 		return false, err
 	}
 	ctx, cancel := s.withTimeout(ctx)
 	defer cancel()
-	ok, err := s.repo.UpdatePartial(ctx, u)
+
+	if u.Status == nil && !changesCapacity(u) {
+		ok, err := s.repo.UpdatePartial(ctx, u)
+		if err != nil {
+			return false, err
+		}
+		if !ok {
+			return false, apperr.ErrNotFound
+		}
+		return true, nil
+	}
+
+	var available bool
+	err := s.tx.WithTx(ctx, func(tx deliverytx.Repository) error {
+		var err error
+		available, err = s.updateInTx(ctx, tx, u)
+		return err
+	})
+	if err != nil {
+		return false, err
+	}
+	if available {
+		s.courierAvailable()
+	}
+	return true, nil
+}
+
+// changesCapacity reports whether u may change how many deliveries the courier carries at once
+func changesCapacity(u domain.PartialCourierUpdate) bool {
+	return u.Capacity != nil || u.TransportType != nil
+}
+
+// updateInTx locks the courier, applies u, makes the courier busy or available by its new capacity
+// and records the status change in the outbox. It reports whether the courier may take new orders
+// it could not take before.
+func (s *Service) updateInTx(ctx context.Context, tx deliverytx.Repository, u domain.PartialCourierUpdate) (bool, error) {
+	before, err := tx.LockCourier(ctx, u.ID)
+	if err != nil {
+		return false, err
+	}
+	if before == nil {
+		return false, apperr.ErrNotFound
+	}
+	ok, err := tx.UpdateCourier(ctx, u)
 	if err != nil {
 		return false, err
 	}
 	if !ok {
 		return false, apperr.ErrNotFound
 	}
-	return true, nil
+
+	after := u.Apply(*before)
+	if changesCapacity(u) {
+		active, err := tx.CountActiveDeliveries(ctx, u.ID)
+		if err != nil {
+			return false, err
+		}
+		if want := after.LoadStatus(active, s.types); want != after.Status {
+			if err := tx.UpdateCourierStatus(ctx, u.ID, want); err != nil {
+				return false, err
+			}
+			after.Status = want
+		}
+	}
+
+	if err := s.announceStatus(ctx, tx, *before, after); err != nil {
+		return false, err
+	}
+	available := after.Status == domain.StatusAvailable && (u.Status != nil ||
+		before.Status != domain.StatusAvailable || after.MaxActiveDeliveries(s.types) > before.MaxActiveDeliveries(s.types))
+	return available, nil
+}
+
+// announceStatus records in the outbox that the courier status changed, if it did.
+func (s *Service) announceStatus(ctx context.Context, tx deliverytx.Repository, before, after domain.Courier) error {
+	if after.Status == before.Status {
+		return nil
+	}
+	return tx.AppendOutbox(ctx, domain.OutboxEvent{
+		Type:          domain.OutboxCourierStatusChanged,
+		CourierID:     after.ID,
+		CourierStatus: after.Status,
+		OccurredAt:    s.now(),
+	})
+}
+
+// UpdateLocation stores the last reported location of a courier.
+func (s *Service) UpdateLocation(ctx context.Context, id int64, loc domain.Location) error {
+	if id <= 0 || !loc.Valid() {
+		return apperr.ErrInvalid
+	}
+
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	ok, err := s.repo.UpdateLocation(ctx, id, loc)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return apperr.ErrNotFound
+	}
+	return nil
 }
@@ -80,6 +80,21 @@ func (mr *MockcourierRepositoryMockRecorder) List(ctx, limit, offset interface{}
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockcourierRepository)(nil).List), ctx, limit, offset)
 }
 
+// UpdateLocation mocks base method.
+func (m *MockcourierRepository) UpdateLocation(ctx context.Context, id int64, loc domain.Location) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateLocation", ctx, id, loc)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateLocation indicates an expected call of UpdateLocation.
+func (mr *MockcourierRepositoryMockRecorder) UpdateLocation(ctx, id, loc interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateLocation", reflect.TypeOf((*MockcourierRepository)(nil).UpdateLocation), ctx, id, loc)
+}
+
 // UpdatePartial mocks base method.
 func (m *MockcourierRepository) UpdatePartial(ctx context.Context, u domain.PartialCourierUpdate) (bool, error) {
 	m.ctrl.T.Helper()
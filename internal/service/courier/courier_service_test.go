@@ -12,6 +12,7 @@ import (
 
 	"course-go-avito-Orurh/internal/apperr"
 	"course-go-avito-Orurh/internal/domain"
+	"course-go-avito-Orurh/internal/ports/deliverytx"
 	"course-go-avito-Orurh/internal/service/courier"
 )
 
@@ -32,11 +33,38 @@ func TestService_Get_Success(t *testing.T) {
 	repo.EXPECT().
 		Get(gomock.Any(), expected.ID).
 		Return(expected, nil)
-	service := courier.NewService(repo, time.Second)
+	service := courier.NewService(repo, nil, nil, time.Second)
 
 	got, err := service.Get(context.Background(), expected.ID)
 	require.NoError(t, err)
 	require.Equal(t, expected, got)
+	require.Equal(t, 1, *got.Capacity, "transport default capacity")
+}
+
+func TestService_UsesGivenTransportTypes(t *testing.T) {
+	t.Parallel()
+
+	bike := domain.TransportType{Code: "bike", SpeedKmh: 15, MaxCapacity: 2, BaseDeadline: 20 * time.Minute}
+	types := domain.NewTransportTypeSet([]domain.TransportType{bike})
+
+	ctrl := gomock.NewController(t)
+	repo := NewMockcourierRepository(ctrl)
+	repo.EXPECT().
+		List(gomock.Any(), nil, nil).
+		Return([]domain.Courier{{ID: 1, TransportType: "bike"}}, nil)
+	service := courier.NewService(repo, nil, types, time.Second)
+
+	list, err := service.List(context.Background(), nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, 2, *list[0].Capacity)
+
+	_, err = service.Create(context.Background(), &domain.Courier{
+		Name:          "Artem",
+		Phone:         "+71111111111",
+		Status:        domain.StatusAvailable,
+		TransportType: domain.TransportTypeCar,
+	})
+	require.ErrorIs(t, err, apperr.ErrInvalid, "car is not among the given types")
 }
 
 func TestService_Get_NotFound(t *testing.T) {
@@ -49,7 +77,7 @@ func TestService_Get_NotFound(t *testing.T) {
 		Get(gomock.Any(), int64(1)).
 		Return(nil, nil)
 
-	service := courier.NewService(repo, time.Second)
+	service := courier.NewService(repo, nil, nil, time.Second)
 
 	got, err := service.Get(context.Background(), 1)
 	require.Error(t, err)
@@ -68,7 +96,7 @@ func TestService_Get_RepoError(t *testing.T) {
 		Get(gomock.Any(), int64(1)).
 		Return(nil, wantErr)
 
-	service := courier.NewService(repo, time.Second)
+	service := courier.NewService(repo, nil, nil, time.Second)
 
 	_, err := service.Get(context.Background(), 1)
 	require.ErrorIs(t, err, wantErr)
@@ -91,7 +119,7 @@ func TestService_List_Success(t *testing.T) {
 		List(gomock.Any(), &limit, &offset).
 		Return(expected, nil)
 
-	service := courier.NewService(repo, time.Second)
+	service := courier.NewService(repo, nil, nil, time.Second)
 
 	res, err := service.List(context.Background(), &limit, &offset)
 	require.NoError(t, err)
@@ -115,7 +143,7 @@ func TestService_List_RepoError(t *testing.T) {
 		List(gomock.Any(), gomock.Nil(), gomock.Nil()).
 		Return(nil, wantErr)
 
-	service := courier.NewService(repo, time.Second)
+	service := courier.NewService(repo, nil, nil, time.Second)
 
 	_, err := service.List(context.Background(), nil, nil)
 	require.ErrorIs(t, err, wantErr)
@@ -128,7 +156,7 @@ func TestService_Create_InvalidInput(t *testing.T) {
 
 	repo := NewMockcourierRepository(ctrl)
 
-	service := courier.NewService(repo, time.Second)
+	service := courier.NewService(repo, nil, nil, time.Second)
 
 	c := &domain.Courier{
 		Name:          " ",
@@ -156,7 +184,7 @@ func TestService_Create_SetsDefaultTransportAndCallsRepo(t *testing.T) {
 			return 123, nil
 		})
 
-	service := courier.NewService(repo, time.Second)
+	service := courier.NewService(repo, nil, nil, time.Second)
 
 	c := &domain.Courier{
 		Name:   "Artem",
@@ -177,7 +205,7 @@ func TestService_UpdatePartial_Invalid(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	repo := NewMockcourierRepository(ctrl)
 
-	service := courier.NewService(repo, time.Second)
+	service := courier.NewService(repo, nil, nil, time.Second)
 	u := domain.PartialCourierUpdate{}
 
 	_, err := service.UpdatePartial(context.Background(), u)
@@ -205,7 +233,7 @@ func TestService_UpdatePartial_Success(t *testing.T) {
 			return true, nil
 		})
 
-	service := courier.NewService(repo, time.Second)
+	service := courier.NewService(repo, nil, nil, time.Second)
 
 	ok, err := service.UpdatePartial(context.Background(), u)
 	require.NoError(t, err)
@@ -231,7 +259,7 @@ func TestService_UpdatePartial_NotFound(t *testing.T) {
 		UpdatePartial(gomock.Any(), u).
 		Return(false, nil)
 
-	service := courier.NewService(repo, time.Second)
+	service := courier.NewService(repo, nil, nil, time.Second)
 
 	ok, err := service.UpdatePartial(context.Background(), u)
 	require.False(t, ok)
@@ -255,12 +283,66 @@ func TestService_UpdatePartial_RepoError(t *testing.T) {
 		UpdatePartial(gomock.Any(), u).
 		Return(false, wantErr)
 
-	service := courier.NewService(repo, time.Second)
+	service := courier.NewService(repo, nil, nil, time.Second)
 
 	_, err := service.UpdatePartial(context.Background(), u)
 	require.ErrorIs(t, err, wantErr)
 }
 
+func TestService_UpdateLocation_Success(t *testing.T) {
+	t.Parallel()
+
+	loc := domain.Location{Lat: 55.75, Lon: 37.61}
+
+	ctrl := gomock.NewController(t)
+
+	repo := NewMockcourierRepository(ctrl)
+	repo.EXPECT().
+		UpdateLocation(gomock.Any(), int64(5), loc).
+		Return(true, nil)
+
+	service := courier.NewService(repo, nil, nil, time.Second)
+
+	require.NoError(t, service.UpdateLocation(context.Background(), 5, loc))
+}
+
+func TestService_UpdateLocation_Invalid(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	service := courier.NewService(NewMockcourierRepository(ctrl), nil, nil, time.Second)
+
+	cases := []struct {
+		name string
+		id   int64
+		loc  domain.Location
+	}{
+		{name: "zero id", id: 0, loc: domain.Location{Lat: 1, Lon: 1}},
+		{name: "lat out of range", id: 1, loc: domain.Location{Lat: 90.1, Lon: 1}},
+		{name: "lon out of range", id: 1, loc: domain.Location{Lat: 1, Lon: -180.1}},
+	}
+	for _, tc := range cases {
+		err := service.UpdateLocation(context.Background(), tc.id, tc.loc)
+		require.ErrorIs(t, err, apperr.ErrInvalid, tc.name)
+	}
+}
+
+func TestService_UpdateLocation_NotFound(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+
+	repo := NewMockcourierRepository(ctrl)
+	repo.EXPECT().
+		UpdateLocation(gomock.Any(), int64(404), gomock.Any()).
+		Return(false, nil)
+
+	service := courier.NewService(repo, nil, nil, time.Second)
+
+	err := service.UpdateLocation(context.Background(), 404, domain.Location{Lat: 1, Lon: 1})
+	require.ErrorIs(t, err, apperr.ErrNotFound)
+}
+
 func TestService_TimeoutConfiguration_Behavior(t *testing.T) {
 	t.Parallel()
 
@@ -304,7 +386,7 @@ func assertServiceUsesTimeoutRange(t *testing.T, timeout, min, max time.Duration
 
 	ctrl := gomock.NewController(t)
 	repo := NewMockcourierRepository(ctrl)
-	svc := courier.NewService(repo, timeout)
+	svc := courier.NewService(repo, nil, nil, timeout)
 
 	ctx := context.Background()
 	const id int64 = 1
@@ -331,3 +413,252 @@ func assertServiceUsesTimeoutRange(t *testing.T, timeout, min, max time.Duration
 	require.Greater(t, remaining, min)
 	require.Less(t, remaining, max)
 }
+
+// inTx makes runner run the given function with tx as the transaction
+func inTx(runner *MockRunner, tx *MockRepository) {
+	runner.EXPECT().WithTx(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, fn func(deliverytx.Repository) error) error { return fn(tx) }).
+		AnyTimes()
+}
+
+func TestService_UpdatePartial_AvailableNotifies(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+
+	runner, tx := NewMockRunner(ctrl), NewMockRepository(ctrl)
+	inTx(runner, tx)
+	tx.EXPECT().LockCourier(gomock.Any(), int64(1)).Return(&domain.Courier{ID: 1, Status: domain.StatusPaused}, nil).Times(2)
+	tx.EXPECT().UpdateCourier(gomock.Any(), gomock.Any()).Return(true, nil).Times(2)
+	tx.EXPECT().AppendOutbox(gomock.Any(), gomock.Any()).Return(nil).Times(2)
+
+	var notified int
+	service := courier.NewService(NewMockcourierRepository(ctrl), runner, nil, time.Second).
+		WithCourierAvailableHook(func() { notified++ })
+
+	busy, available := domain.StatusBusy, domain.StatusAvailable
+
+	_, err := service.UpdatePartial(context.Background(), domain.PartialCourierUpdate{ID: 1, Status: &busy})
+	require.NoError(t, err)
+	require.Equal(t, 0, notified)
+
+	_, err = service.UpdatePartial(context.Background(), domain.PartialCourierUpdate{ID: 1, Status: &available})
+	require.NoError(t, err)
+	require.Equal(t, 1, notified)
+}
+
+func TestService_UpdatePartial_StatusChangeWritesOutboxInTx(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+
+	runner, tx := NewMockRunner(ctrl), NewMockRepository(ctrl)
+	inTx(runner, tx)
+	paused, available := domain.StatusPaused, domain.StatusAvailable
+	name := "Artem"
+	u := domain.PartialCourierUpdate{ID: 7, Name: &name, Status: &paused}
+
+	var events []domain.OutboxEvent
+	gomock.InOrder(
+		tx.EXPECT().LockCourier(gomock.Any(), int64(7)).Return(&domain.Courier{ID: 7, Status: domain.StatusAvailable}, nil),
+		tx.EXPECT().UpdateCourier(gomock.Any(), u).Return(true, nil),
+		tx.EXPECT().AppendOutbox(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, ev domain.OutboxEvent) error {
+			events = append(events, ev)
+			return nil
+		}),
+	)
+	service := courier.NewService(NewMockcourierRepository(ctrl), runner, nil, time.Second)
+
+	ok, err := service.UpdatePartial(context.Background(), u)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Len(t, events, 1)
+	require.Equal(t, domain.OutboxCourierStatusChanged, events[0].Type)
+	require.Equal(t, int64(7), events[0].CourierID)
+	require.Equal(t, domain.StatusPaused, events[0].CourierStatus)
+	require.WithinDuration(t, time.Now(), events[0].OccurredAt, time.Minute)
+
+	// the same status is no change: nothing is written
+	tx.EXPECT().LockCourier(gomock.Any(), int64(7)).Return(&domain.Courier{ID: 7, Status: domain.StatusAvailable}, nil)
+	tx.EXPECT().UpdateCourier(gomock.Any(), gomock.Any()).Return(true, nil)
+	_, err = service.UpdatePartial(context.Background(), domain.PartialCourierUpdate{ID: 7, Status: &available})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+}
+
+func TestService_UpdatePartial_StatusOfMissingCourier(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+
+	runner, tx := NewMockRunner(ctrl), NewMockRepository(ctrl)
+	inTx(runner, tx)
+	tx.EXPECT().LockCourier(gomock.Any(), int64(404)).Return(nil, nil)
+	service := courier.NewService(NewMockcourierRepository(ctrl), runner, nil, time.Second)
+
+	busy := domain.StatusBusy
+	ok, err := service.UpdatePartial(context.Background(), domain.PartialCourierUpdate{ID: 404, Status: &busy})
+	require.False(t, ok)
+	require.ErrorIs(t, err, apperr.ErrNotFound)
+}
+
+func TestService_UpdatePartial_OutboxErrorFailsUpdate(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+
+	runner, tx := NewMockRunner(ctrl), NewMockRepository(ctrl)
+	inTx(runner, tx)
+	tx.EXPECT().LockCourier(gomock.Any(), int64(1)).Return(&domain.Courier{ID: 1, Status: domain.StatusBusy}, nil)
+	tx.EXPECT().UpdateCourier(gomock.Any(), gomock.Any()).Return(true, nil)
+	tx.EXPECT().AppendOutbox(gomock.Any(), gomock.Any()).Return(assert.AnError)
+
+	var notified int
+	service := courier.NewService(NewMockcourierRepository(ctrl), runner, nil, time.Second).
+		WithCourierAvailableHook(func() { notified++ })
+
+	available := domain.StatusAvailable
+	_, err := service.UpdatePartial(context.Background(), domain.PartialCourierUpdate{ID: 1, Status: &available})
+	require.ErrorIs(t, err, assert.AnError)
+	require.Zero(t, notified, "the update was rolled back")
+}
+
+func TestService_Capacity_Validation(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	runner, tx := NewMockRunner(ctrl), NewMockRepository(ctrl)
+	inTx(runner, tx)
+	service := courier.NewService(NewMockcourierRepository(ctrl), runner, nil, time.Second)
+
+	zero, two := 0, 2
+
+	_, err := service.Create(context.Background(), &domain.Courier{
+		Name:     "Artem",
+		Phone:    "+79990000000",
+		Status:   domain.StatusAvailable,
+		Capacity: &zero,
+	})
+	require.ErrorIs(t, err, apperr.ErrInvalid)
+
+	_, err = service.UpdatePartial(context.Background(), domain.PartialCourierUpdate{ID: 1, Capacity: &zero})
+	require.ErrorIs(t, err, apperr.ErrInvalid)
+
+	tx.EXPECT().LockCourier(gomock.Any(), int64(1)).Return(&domain.Courier{ID: 1, Status: domain.StatusAvailable}, nil)
+	tx.EXPECT().UpdateCourier(gomock.Any(), domain.PartialCourierUpdate{ID: 1, Capacity: &two}).Return(true, nil)
+	tx.EXPECT().CountActiveDeliveries(gomock.Any(), int64(1)).Return(0, nil)
+	ok, err := service.UpdatePartial(context.Background(), domain.PartialCourierUpdate{ID: 1, Capacity: &two})
+	require.NoError(t, err)
+	require.True(t, ok)
+}
+
+func TestService_UpdatePartial_CapacitySyncsStatus(t *testing.T) {
+	t.Parallel()
+
+	one, two, three := 1, 2, 3
+	cases := []struct {
+		name     string
+		courier  domain.Courier
+		update   domain.PartialCourierUpdate
+		active   int
+		want     domain.CourierStatus
+		notified int
+	}{
+		{
+			name:    "lowered to the load",
+			courier: domain.Courier{ID: 1, Status: domain.StatusAvailable, TransportType: domain.TransportTypeCar},
+			update:  domain.PartialCourierUpdate{ID: 1, Capacity: &two},
+			active:  2,
+			want:    domain.StatusBusy,
+		},
+		{
+			name:     "raised above the load",
+			courier:  domain.Courier{ID: 1, Status: domain.StatusBusy, TransportType: domain.TransportTypeCar, Capacity: &two},
+			update:   domain.PartialCourierUpdate{ID: 1, Capacity: &three},
+			active:   2,
+			want:     domain.StatusAvailable,
+			notified: 1,
+		},
+		{
+			name:     "raised for an available courier frees a slot",
+			courier:  domain.Courier{ID: 1, Status: domain.StatusAvailable, Capacity: &one},
+			update:   domain.PartialCourierUpdate{ID: 1, Capacity: &three},
+			active:   0,
+			want:     domain.StatusAvailable,
+			notified: 1,
+		},
+		{
+			name:    "transport with a smaller default",
+			courier: domain.Courier{ID: 1, Status: domain.StatusAvailable, TransportType: domain.TransportTypeCar},
+			update:  domain.PartialCourierUpdate{ID: 1, TransportType: ptr(domain.TransportTypeFoot)},
+			active:  1,
+			want:    domain.StatusBusy,
+		},
+		{
+			name:    "paused stays paused",
+			courier: domain.Courier{ID: 1, Status: domain.StatusPaused, Capacity: &three},
+			update:  domain.PartialCourierUpdate{ID: 1, Capacity: &one},
+			active:  1,
+			want:    domain.StatusPaused,
+		},
+		{
+			name:    "explicit available over the load",
+			courier: domain.Courier{ID: 1, Status: domain.StatusPaused, Capacity: &three},
+			update:  domain.PartialCourierUpdate{ID: 1, Status: ptr(domain.StatusAvailable), Capacity: &one},
+			active:  1,
+			want:    domain.StatusBusy,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			runner, tx := NewMockRunner(ctrl), NewMockRepository(ctrl)
+			inTx(runner, tx)
+
+			var (
+				status *domain.CourierStatus
+				events []domain.OutboxEvent
+			)
+			tx.EXPECT().LockCourier(gomock.Any(), int64(1)).Return(&tc.courier, nil)
+			tx.EXPECT().UpdateCourier(gomock.Any(), tc.update).Return(true, nil)
+			tx.EXPECT().CountActiveDeliveries(gomock.Any(), int64(1)).Return(tc.active, nil)
+			tx.EXPECT().UpdateCourierStatus(gomock.Any(), int64(1), gomock.Any()).
+				DoAndReturn(func(_ context.Context, _ int64, st domain.CourierStatus) error {
+					status = &st
+					return nil
+				}).AnyTimes()
+			tx.EXPECT().AppendOutbox(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ context.Context, ev domain.OutboxEvent) error {
+					events = append(events, ev)
+					return nil
+				}).AnyTimes()
+
+			var notified int
+			service := courier.NewService(NewMockcourierRepository(ctrl), runner, nil, time.Second).
+				WithCourierAvailableHook(func() { notified++ })
+
+			_, err := service.UpdatePartial(context.Background(), tc.update)
+			require.NoError(t, err)
+			require.Equal(t, tc.notified, notified)
+
+			final := tc.courier.Status
+			if tc.update.Status != nil {
+				final = *tc.update.Status
+			}
+			if tc.want != final {
+				require.NotNil(t, status, "the status is synced with the load")
+				require.Equal(t, tc.want, *status)
+			} else {
+				require.Nil(t, status)
+			}
+			if tc.want == tc.courier.Status {
+				require.Empty(t, events)
+			} else {
+				require.Len(t, events, 1)
+				require.Equal(t, tc.want, events[0].CourierStatus)
+			}
+		})
+	}
+}
@@ -99,7 +99,7 @@ func assertCreateValidationCase(t *testing.T, c *domain.Courier, wantErr bool) {
 			Return(int64(123), nil)
 	}
 
-	svc := courier.NewService(repo, time.Second)
+	svc := courier.NewService(repo, nil, nil, time.Second)
 	id, err := svc.Create(context.Background(), c)
 
 	if wantErr {
@@ -225,14 +225,25 @@ func assertUpdateValidationCase(t *testing.T, upd *domain.PartialCourierUpdate,
 
 	ctrl := gomock.NewController(t)
 	repo := NewMockcourierRepository(ctrl)
+	runner, tx := NewMockRunner(ctrl), NewMockRepository(ctrl)
 
-	if !wantErr {
+	switch {
+	case wantErr:
+	case upd.Status != nil || upd.Capacity != nil || upd.TransportType != nil:
+		// status and capacity changes go through a transaction with the outbox
+		inTx(runner, tx)
+		tx.EXPECT().LockCourier(gomock.Any(), upd.ID).Return(&domain.Courier{ID: upd.ID, Status: domain.StatusPaused}, nil)
+		tx.EXPECT().UpdateCourier(gomock.Any(), gomock.Any()).Return(true, nil)
+		tx.EXPECT().CountActiveDeliveries(gomock.Any(), upd.ID).Return(0, nil).AnyTimes()
+		tx.EXPECT().UpdateCourierStatus(gomock.Any(), upd.ID, gomock.Any()).Return(nil).AnyTimes()
+		tx.EXPECT().AppendOutbox(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	default:
 		repo.EXPECT().
 			UpdatePartial(gomock.Any(), gomock.Any()).
 			Return(true, nil)
 	}
 
-	svc := courier.NewService(repo, time.Second)
+	svc := courier.NewService(repo, runner, nil, time.Second)
 
 	update := domain.PartialCourierUpdate{}
 	if upd != nil {
@@ -0,0 +1,322 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ../../ports/deliverytx/contracts.go
+
+// Package courier_test is a generated GoMock package.
+package courier_test
+
+import (
+	context "context"
+	domain "course-go-avito-Orurh/internal/domain"
+	deliverytx "course-go-avito-Orurh/internal/ports/deliverytx"
+	reflect "reflect"
+	time "time"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockRepository is a mock of Repository interface.
+type MockRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockRepositoryMockRecorder
+}
+
+// MockRepositoryMockRecorder is the mock recorder for MockRepository.
+type MockRepositoryMockRecorder struct {
+	mock *MockRepository
+}
+
+// NewMockRepository creates a new mock instance.
+func NewMockRepository(ctrl *gomock.Controller) *MockRepository {
+	mock := &MockRepository{ctrl: ctrl}
+	mock.recorder = &MockRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRepository) EXPECT() *MockRepositoryMockRecorder {
+	return m.recorder
+}
+
+// AppendOutbox mocks base method.
+func (m *MockRepository) AppendOutbox(ctx context.Context, ev domain.OutboxEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AppendOutbox", ctx, ev)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AppendOutbox indicates an expected call of AppendOutbox.
+func (mr *MockRepositoryMockRecorder) AppendOutbox(ctx, ev interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AppendOutbox", reflect.TypeOf((*MockRepository)(nil).AppendOutbox), ctx, ev)
+}
+
+// CountActiveDeliveries mocks base method.
+func (m *MockRepository) CountActiveDeliveries(ctx context.Context, courierID int64) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountActiveDeliveries", ctx, courierID)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountActiveDeliveries indicates an expected call of CountActiveDeliveries.
+func (mr *MockRepositoryMockRecorder) CountActiveDeliveries(ctx, courierID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountActiveDeliveries", reflect.TypeOf((*MockRepository)(nil).CountActiveDeliveries), ctx, courierID)
+}
+
+// DeletePendingOrder mocks base method.
+func (m *MockRepository) DeletePendingOrder(ctx context.Context, orderID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeletePendingOrder", ctx, orderID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeletePendingOrder indicates an expected call of DeletePendingOrder.
+func (mr *MockRepositoryMockRecorder) DeletePendingOrder(ctx, orderID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePendingOrder", reflect.TypeOf((*MockRepository)(nil).DeletePendingOrder), ctx, orderID)
+}
+
+// EnqueuePendingOrder mocks base method.
+func (m *MockRepository) EnqueuePendingOrder(ctx context.Context, p domain.PendingOrder) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnqueuePendingOrder", ctx, p)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// EnqueuePendingOrder indicates an expected call of EnqueuePendingOrder.
+func (mr *MockRepositoryMockRecorder) EnqueuePendingOrder(ctx, p interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnqueuePendingOrder", reflect.TypeOf((*MockRepository)(nil).EnqueuePendingOrder), ctx, p)
+}
+
+// ExpireOverdueDeliveries mocks base method.
+func (m *MockRepository) ExpireOverdueDeliveries(ctx context.Context, now time.Time, limit int) ([]domain.Delivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExpireOverdueDeliveries", ctx, now, limit)
+	ret0, _ := ret[0].([]domain.Delivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExpireOverdueDeliveries indicates an expected call of ExpireOverdueDeliveries.
+func (mr *MockRepositoryMockRecorder) ExpireOverdueDeliveries(ctx, now, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExpireOverdueDeliveries", reflect.TypeOf((*MockRepository)(nil).ExpireOverdueDeliveries), ctx, now, limit)
+}
+
+// GetByOrderID mocks base method.
+func (m *MockRepository) GetByOrderID(ctx context.Context, orderID string) (*domain.Delivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByOrderID", ctx, orderID)
+	ret0, _ := ret[0].(*domain.Delivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByOrderID indicates an expected call of GetByOrderID.
+func (mr *MockRepositoryMockRecorder) GetByOrderID(ctx, orderID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByOrderID", reflect.TypeOf((*MockRepository)(nil).GetByOrderID), ctx, orderID)
+}
+
+// InsertDelivery mocks base method.
+func (m *MockRepository) InsertDelivery(ctx context.Context, d *domain.Delivery) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertDelivery", ctx, d)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InsertDelivery indicates an expected call of InsertDelivery.
+func (mr *MockRepositoryMockRecorder) InsertDelivery(ctx, d interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertDelivery", reflect.TypeOf((*MockRepository)(nil).InsertDelivery), ctx, d)
+}
+
+// InsertReassignment mocks base method.
+func (m *MockRepository) InsertReassignment(ctx context.Context, r domain.Reassignment) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertReassignment", ctx, r)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InsertReassignment indicates an expected call of InsertReassignment.
+func (mr *MockRepositoryMockRecorder) InsertReassignment(ctx, r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertReassignment", reflect.TypeOf((*MockRepository)(nil).InsertReassignment), ctx, r)
+}
+
+// ListAvailableCouriers mocks base method.
+func (m *MockRepository) ListAvailableCouriers(ctx context.Context) ([]domain.CourierCandidate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAvailableCouriers", ctx)
+	ret0, _ := ret[0].([]domain.CourierCandidate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAvailableCouriers indicates an expected call of ListAvailableCouriers.
+func (mr *MockRepositoryMockRecorder) ListAvailableCouriers(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAvailableCouriers", reflect.TypeOf((*MockRepository)(nil).ListAvailableCouriers), ctx)
+}
+
+// ListOverduePickedUp mocks base method.
+func (m *MockRepository) ListOverduePickedUp(ctx context.Context, now time.Time, limit int) ([]domain.Delivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListOverduePickedUp", ctx, now, limit)
+	ret0, _ := ret[0].([]domain.Delivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListOverduePickedUp indicates an expected call of ListOverduePickedUp.
+func (mr *MockRepositoryMockRecorder) ListOverduePickedUp(ctx, now, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListOverduePickedUp", reflect.TypeOf((*MockRepository)(nil).ListOverduePickedUp), ctx, now, limit)
+}
+
+// ListPendingOrdersForUpdate mocks base method.
+func (m *MockRepository) ListPendingOrdersForUpdate(ctx context.Context, limit int) ([]domain.PendingOrder, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPendingOrdersForUpdate", ctx, limit)
+	ret0, _ := ret[0].([]domain.PendingOrder)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPendingOrdersForUpdate indicates an expected call of ListPendingOrdersForUpdate.
+func (mr *MockRepositoryMockRecorder) ListPendingOrdersForUpdate(ctx, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPendingOrdersForUpdate", reflect.TypeOf((*MockRepository)(nil).ListPendingOrdersForUpdate), ctx, limit)
+}
+
+// LockAvailableCourier mocks base method.
+func (m *MockRepository) LockAvailableCourier(ctx context.Context, id int64) (*domain.Courier, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LockAvailableCourier", ctx, id)
+	ret0, _ := ret[0].(*domain.Courier)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LockAvailableCourier indicates an expected call of LockAvailableCourier.
+func (mr *MockRepositoryMockRecorder) LockAvailableCourier(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LockAvailableCourier", reflect.TypeOf((*MockRepository)(nil).LockAvailableCourier), ctx, id)
+}
+
+// LockCourier mocks base method.
+func (m *MockRepository) LockCourier(ctx context.Context, id int64) (*domain.Courier, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LockCourier", ctx, id)
+	ret0, _ := ret[0].(*domain.Courier)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LockCourier indicates an expected call of LockCourier.
+func (mr *MockRepositoryMockRecorder) LockCourier(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LockCourier", reflect.TypeOf((*MockRepository)(nil).LockCourier), ctx, id)
+}
+
+// RecordAssignment mocks base method.
+func (m *MockRepository) RecordAssignment(ctx context.Context, courierID int64, at time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordAssignment", ctx, courierID, at)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordAssignment indicates an expected call of RecordAssignment.
+func (mr *MockRepositoryMockRecorder) RecordAssignment(ctx, courierID, at interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordAssignment", reflect.TypeOf((*MockRepository)(nil).RecordAssignment), ctx, courierID, at)
+}
+
+// UpdateCourier mocks base method.
+func (m *MockRepository) UpdateCourier(ctx context.Context, u domain.PartialCourierUpdate) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateCourier", ctx, u)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateCourier indicates an expected call of UpdateCourier.
+func (mr *MockRepositoryMockRecorder) UpdateCourier(ctx, u interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateCourier", reflect.TypeOf((*MockRepository)(nil).UpdateCourier), ctx, u)
+}
+
+// UpdateCourierStatus mocks base method.
+func (m *MockRepository) UpdateCourierStatus(ctx context.Context, id int64, status domain.CourierStatus) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateCourierStatus", ctx, id, status)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateCourierStatus indicates an expected call of UpdateCourierStatus.
+func (mr *MockRepositoryMockRecorder) UpdateCourierStatus(ctx, id, status interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateCourierStatus", reflect.TypeOf((*MockRepository)(nil).UpdateCourierStatus), ctx, id, status)
+}
+
+// UpdateDeliveryStatus mocks base method.
+func (m *MockRepository) UpdateDeliveryStatus(ctx context.Context, id int64, from, to domain.DeliveryStatus, at time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateDeliveryStatus", ctx, id, from, to, at)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateDeliveryStatus indicates an expected call of UpdateDeliveryStatus.
+func (mr *MockRepositoryMockRecorder) UpdateDeliveryStatus(ctx, id, from, to, at interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateDeliveryStatus", reflect.TypeOf((*MockRepository)(nil).UpdateDeliveryStatus), ctx, id, from, to, at)
+}
+
+// MockRunner is a mock of Runner interface.
+type MockRunner struct {
+	ctrl     *gomock.Controller
+	recorder *MockRunnerMockRecorder
+}
+
+// MockRunnerMockRecorder is the mock recorder for MockRunner.
+type MockRunnerMockRecorder struct {
+	mock *MockRunner
+}
+
+// NewMockRunner creates a new mock instance.
+func NewMockRunner(ctrl *gomock.Controller) *MockRunner {
+	mock := &MockRunner{ctrl: ctrl}
+	mock.recorder = &MockRunnerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRunner) EXPECT() *MockRunnerMockRecorder {
+	return m.recorder
+}
+
+// WithTx mocks base method.
+func (m *MockRunner) WithTx(ctx context.Context, fn func(deliverytx.Repository) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WithTx", ctx, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WithTx indicates an expected call of WithTx.
+func (mr *MockRunnerMockRecorder) WithTx(ctx, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithTx", reflect.TypeOf((*MockRunner)(nil).WithTx), ctx, fn)
+}
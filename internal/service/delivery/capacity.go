@@ -0,0 +1,84 @@
+package delivery
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"time"
+
+	"course-go-avito-Orurh/internal/domain"
+	"course-go-avito-Orurh/internal/ports/deliverytx"
+)
+
+// syncCourierStatus marks a locked courier busy when all of its slots are taken and available otherwise.
+// Paused couriers keep their status.
+func (s *Service) syncCourierStatus(ctx context.Context, tx deliverytx.Repository, c *domain.Courier) error {
+	if c.Status == domain.StatusPaused {
+		return nil
+	}
+	active, err := tx.CountActiveDeliveries(ctx, c.ID)
+	if err != nil {
+		return err
+	}
+
+	want := c.LoadStatus(active, s.types)
+	if want == c.Status {
+		return nil
+	}
+	if err := tx.UpdateCourierStatus(ctx, c.ID, want); err != nil {
+		return err
+	}
+	return announceCourierStatus(ctx, tx, c.ID, want, s.now())
+}
+
+// releaseSlot updates the status of a courier whose delivery has just finished.
+func (s *Service) releaseSlot(ctx context.Context, tx deliverytx.Repository, courierID int64) error {
+	c, err := tx.LockCourier(ctx, courierID)
+	if err != nil || c == nil {
+		return err
+	}
+	return s.syncCourierStatus(ctx, tx, c)
+}
+
+// withFreeSlots drops candidates that already carry as many deliveries as they can.
+func (s *Service) withFreeSlots(candidates []domain.CourierCandidate) []domain.CourierCandidate {
+	return slices.DeleteFunc(candidates, func(c domain.CourierCandidate) bool {
+		return c.ActiveDeliveries >= c.Courier.MaxActiveDeliveries(s.types)
+	})
+}
+
+// splitCourier separates the candidate of the given courier from the others, leaving candidates intact.
+func splitCourier(candidates []domain.CourierCandidate, courierID int64) (others, courier []domain.CourierCandidate) {
+	for _, c := range candidates {
+		if c.Courier.ID == courierID {
+			courier = append(courier, c)
+		} else {
+			others = append(others, c)
+		}
+	}
+	return others, courier
+}
+
+// mergeByID puts split off candidates back, in the courier ID order the candidates are listed in.
+func mergeByID(candidates, back []domain.CourierCandidate) []domain.CourierCandidate {
+	if len(back) == 0 {
+		return candidates
+	}
+	candidates = append(candidates, back...)
+	slices.SortFunc(candidates, func(a, b domain.CourierCandidate) int {
+		return cmp.Compare(a.Courier.ID, b.Courier.ID)
+	})
+	return candidates
+}
+
+// takeSlot accounts a delivery just assigned to the courier and drops the courier once it is full.
+func (s *Service) takeSlot(candidates []domain.CourierCandidate, courierID int64, at time.Time) []domain.CourierCandidate {
+	for i := range candidates {
+		if candidates[i].Courier.ID == courierID {
+			candidates[i].ActiveDeliveries++
+			candidates[i].TotalDeliveries++
+			candidates[i].LastAssignedAt = &at
+		}
+	}
+	return s.withFreeSlots(candidates)
+}
@@ -0,0 +1,95 @@
+package delivery_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"course-go-avito-Orurh/internal/domain"
+	"course-go-avito-Orurh/internal/service/delivery"
+)
+
+func completeWith(t *testing.T, courier domain.Courier, stillActive int) []domain.CourierStatus {
+	t.Helper()
+
+	repo := NewMockdeliveryRepository(newCtrl(t))
+
+	var statuses []domain.CourierStatus
+	repo.EXPECT().WithTx(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, fn func(delivery.TxRepository) error) error {
+			return fn(&stubTx{
+				getFn: func(context.Context, string) (*domain.Delivery, error) {
+					return &domain.Delivery{ID: 7, CourierID: courier.ID, OrderID: "order_1", Status: domain.DeliveryStatusAssigned}, nil
+				},
+				courFn: func(_ context.Context, id int64) (*domain.Courier, error) {
+					require.Equal(t, courier.ID, id)
+					return &courier, nil
+				},
+				countFn: func(context.Context, int64) (int, error) {
+					return stillActive, nil
+				},
+				updFn: func(_ context.Context, _ int64, st domain.CourierStatus) error {
+					statuses = append(statuses, st)
+					return nil
+				},
+			})
+		})
+
+	svc := newTestDeliveryService(repo, stubTimeFactory{})
+	_, err := svc.Complete(context.Background(), "order_1")
+	require.NoError(t, err)
+	return statuses
+}
+
+func TestService_Complete_FreesOneSlot(t *testing.T) {
+	t.Parallel()
+
+	car := domain.Courier{ID: 10, Status: domain.StatusBusy, TransportType: domain.TransportTypeCar}
+	require.Equal(t, []domain.CourierStatus{domain.StatusAvailable}, completeWith(t, car, 3),
+		"a full car with one delivery done has a free slot")
+
+	one := 1
+	overridden := domain.Courier{ID: 11, Status: domain.StatusBusy, TransportType: domain.TransportTypeCar, Capacity: &one}
+	require.Empty(t, completeWith(t, overridden, 1),
+		"a courier still at capacity stays busy")
+
+	paused := domain.Courier{ID: 12, Status: domain.StatusPaused, TransportType: domain.TransportTypeFoot}
+	require.Empty(t, completeWith(t, paused, 0), "a paused courier stays paused")
+}
+
+func TestService_Assign_KeepsCourierAvailableWhileSlotsLeft(t *testing.T) {
+	t.Parallel()
+
+	repo := NewMockdeliveryRepository(newCtrl(t))
+
+	var statuses []domain.CourierStatus
+	repo.EXPECT().WithTx(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, fn func(delivery.TxRepository) error) error {
+			return fn(&stubTx{
+				listFn: func(context.Context) ([]domain.CourierCandidate, error) {
+					full := candidate(1, domain.TransportTypeScooter)
+					full.ActiveDeliveries = 2
+					car := candidate(2, domain.TransportTypeCar)
+					car.ActiveDeliveries = 1
+					return []domain.CourierCandidate{full, car}, nil
+				},
+				countFn: func(context.Context, int64) (int, error) { return 2, nil },
+				updFn: func(_ context.Context, _ int64, st domain.CourierStatus) error {
+					statuses = append(statuses, st)
+					return nil
+				},
+			})
+		})
+
+	svc := newTestDeliveryService(repo, stubTimeFactory{fn: func(domain.CourierTransportType, time.Time) (time.Time, error) {
+		return time.Now().Add(time.Hour), nil
+	}})
+
+	res, err := svc.Assign(context.Background(), "order_1", nil)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), res.CourierID, "a courier without free slots is not chosen")
+	require.Empty(t, statuses, "the car still has free slots")
+}
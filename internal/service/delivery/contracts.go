@@ -16,10 +16,58 @@ type TxRepository = deliverytx.Repository
 // deliveryRepository is an interface for the service layer.
 type deliveryRepository interface {
 	WithTx(ctx context.Context, fn func(tx TxRepository) error) error
-	ReleaseCouriers(ctx context.Context, now time.Time) (int64, error)
+	EnqueuePendingOrder(ctx context.Context, p domain.PendingOrder) error
+	DeletePendingOrder(ctx context.Context, orderID string) (bool, error)
+	GetByOrderID(ctx context.Context, orderID string) (*domain.Delivery, error)
+	ListByCourier(ctx context.Context, courierID int64, activeOnly bool, limit, offset int) ([]domain.Delivery, error)
+	ListOverdue(ctx context.Context, now time.Time, limit, offset int) ([]domain.Delivery, error)
+}
+
+type counter interface {
+	Inc()
+}
+
+type gauge interface {
+	Set(v float64)
 }
 
 // TimeFactory is a factory for calculating delivery deadline.
 type TimeFactory interface {
 	Deadline(transport domain.CourierTransportType, now time.Time) (time.Time, error)
 }
+
+// DeadlineInput is what a deadline may depend on besides the transport and the assignment time.
+type DeadlineInput struct {
+	Transport domain.CourierTransportType
+	Now       time.Time
+	// Pickup is the pickup point, nil if the order has no coordinates
+	Pickup *domain.Location
+	// CourierLocation is the last reported courier location, nil if unknown
+	CourierLocation *domain.Location
+}
+
+// DeadlineQuote is a computed deadline together with a human-readable explanation of the applied rules.
+type DeadlineQuote struct {
+	At          time.Time
+	Explanation string
+}
+
+// DeadlineQuoter is implemented by a TimeFactory that takes the whole assignment context into account
+// and explains its deadlines. The service prefers it over TimeFactory.Deadline when available.
+type DeadlineQuoter interface {
+	Quote(in DeadlineInput) (DeadlineQuote, error)
+}
+
+// TransportTypes resolves transport types by code, usually from the cached transport catalog.
+type TransportTypes interface {
+	Get(code domain.CourierTransportType) (domain.TransportType, bool)
+}
+
+// AssignmentStrategy chooses which available courier gets an order.
+type AssignmentStrategy interface {
+	// Name identifies the strategy in config and logs.
+	Name() string
+	// Choose returns the index of the preferred candidate, or false if none fits.
+	// pickup is nil if the order has no pickup coordinates.
+	Choose(pickup *domain.Location, candidates []domain.CourierCandidate) (int, bool)
+}
@@ -0,0 +1,180 @@
+package delivery
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"course-go-avito-Orurh/internal/domain"
+)
+
+// DeadlinePolicy holds the rules the rule-based TimeFactory applies on top of the transport base deadline.
+// The zero value applies no rules, so the deadline equals the transport base.
+type DeadlinePolicy struct {
+	// Timezone is the IANA zone peak hours and weekends are evaluated in, UTC if empty
+	Timezone string `json:"timezone"`
+	// PeakHours multiply the deadline when the assignment time falls into them; the first match wins
+	PeakHours []PeakHours `json:"peak_hours"`
+	// WeekendMultiplier multiplies the deadline on Saturdays and Sundays, 0 means no change
+	WeekendMultiplier float64 `json:"weekend_multiplier"`
+	// Zones add a fixed offset when the pickup point lies inside them; the first match wins
+	Zones []DeadlineZone `json:"zones"`
+	// DistanceFactor scales the travel time from the courier to the pickup point
+	// (distance divided by the transport speed), 0 disables the distance rule
+	DistanceFactor float64 `json:"distance_factor"`
+
+	loc *time.Location
+}
+
+// PeakHours is a daily time window, "From" inclusive and "To" exclusive, in "HH:MM".
+// A window with From after To spans midnight.
+type PeakHours struct {
+	From       string  `json:"from"`
+	To         string  `json:"to"`
+	Multiplier float64 `json:"multiplier"`
+
+	from, to time.Duration
+}
+
+// DeadlineZone is a rectangular area with a deadline offset.
+type DeadlineZone struct {
+	Name   string   `json:"name"`
+	MinLat float64  `json:"min_lat"`
+	MaxLat float64  `json:"max_lat"`
+	MinLon float64  `json:"min_lon"`
+	MaxLon float64  `json:"max_lon"`
+	Offset Duration `json:"offset"`
+}
+
+// Duration is a time.Duration decoded from a Go duration string such as "5m".
+type Duration time.Duration
+
+// UnmarshalJSON decodes a Go duration string.
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("duration must be a string like \"5m\": %w", err)
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// LoadDeadlinePolicy reads a JSON deadline policy from path. An empty path returns the zero policy.
+func LoadDeadlinePolicy(path string) (DeadlinePolicy, error) {
+	if path == "" {
+		return DeadlinePolicy{}, nil
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return DeadlinePolicy{}, fmt.Errorf("read deadline policy: %w", err)
+	}
+	var p DeadlinePolicy
+	if err := json.Unmarshal(b, &p); err != nil {
+		return DeadlinePolicy{}, fmt.Errorf("parse deadline policy %s: %w", path, err)
+	}
+	if err := p.compile(); err != nil {
+		return DeadlinePolicy{}, fmt.Errorf("deadline policy %s: %w", path, err)
+	}
+	return p, nil
+}
+
+// compile validates the policy and prepares it for evaluation.
+func (p *DeadlinePolicy) compile() error {
+	p.loc = time.UTC
+	if p.Timezone != "" {
+		loc, err := time.LoadLocation(p.Timezone)
+		if err != nil {
+			return fmt.Errorf("timezone: %w", err)
+		}
+		p.loc = loc
+	}
+	if p.WeekendMultiplier < 0 || p.DistanceFactor < 0 {
+		return errors.New("weekend_multiplier and distance_factor must not be negative")
+	}
+	for i := range p.PeakHours {
+		if err := p.PeakHours[i].compile(); err != nil {
+			return fmt.Errorf("peak_hours[%d]: %w", i, err)
+		}
+	}
+	for i, z := range p.Zones {
+		if z.Name == "" || z.MinLat > z.MaxLat || z.MinLon > z.MaxLon {
+			return fmt.Errorf("zones[%d]: a name and min <= max bounds are required", i)
+		}
+	}
+	return nil
+}
+
+func (h *PeakHours) compile() error {
+	var err error
+	if h.from, err = parseClock(h.From); err != nil {
+		return err
+	}
+	if h.to, err = parseClock(h.To); err != nil {
+		return err
+	}
+	if h.from == h.to {
+		return errors.New("from and to must differ")
+	}
+	if h.Multiplier <= 0 {
+		return errors.New("multiplier must be positive")
+	}
+	return nil
+}
+
+func parseClock(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day %q, want HH:MM", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+func (h PeakHours) contains(t time.Time) bool {
+	clock := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+	if h.from < h.to {
+		return clock >= h.from && clock < h.to
+	}
+	return clock >= h.from || clock < h.to
+}
+
+func (z DeadlineZone) contains(l domain.Location) bool {
+	return l.Lat >= z.MinLat && l.Lat <= z.MaxLat && l.Lon >= z.MinLon && l.Lon <= z.MaxLon
+}
+
+func (p DeadlinePolicy) location() *time.Location {
+	if p.loc == nil {
+		return time.UTC
+	}
+	return p.loc
+}
+
+func (p DeadlinePolicy) peak(t time.Time) (PeakHours, bool) {
+	for _, h := range p.PeakHours {
+		if h.contains(t) {
+			return h, true
+		}
+	}
+	return PeakHours{}, false
+}
+
+func (p DeadlinePolicy) zone(pickup *domain.Location) (DeadlineZone, bool) {
+	if pickup == nil {
+		return DeadlineZone{}, false
+	}
+	for _, z := range p.Zones {
+		if z.contains(*pickup) {
+			return z, true
+		}
+	}
+	return DeadlineZone{}, false
+}
+
+func isWeekend(t time.Time) bool {
+	return t.Weekday() == time.Saturday || t.Weekday() == time.Sunday
+}
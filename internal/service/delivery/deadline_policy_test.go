@@ -0,0 +1,194 @@
+package delivery_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"course-go-avito-Orurh/internal/domain"
+	"course-go-avito-Orurh/internal/service/delivery"
+)
+
+func quoter(t *testing.T, p delivery.DeadlinePolicy) delivery.DeadlineQuoter {
+	t.Helper()
+	f, err := delivery.NewRuleTimeFactory(p, nil)
+	require.NoError(t, err)
+	q, ok := f.(delivery.DeadlineQuoter)
+	require.True(t, ok, "the rule-based factory explains its deadlines")
+	return q
+}
+
+// Thursday, 2025-01-02
+var weekday = time.Date(2025, 1, 2, 12, 0, 0, 0, time.UTC)
+
+func TestRuleTimeFactory_NoRules_UsesTransportBase(t *testing.T) {
+	t.Parallel()
+
+	q, err := quoter(t, delivery.DeadlinePolicy{}).Quote(delivery.DeadlineInput{
+		Transport: domain.TransportTypeScooter,
+		Now:       weekday,
+		Pickup:    pickup,
+	})
+	require.NoError(t, err)
+	require.Equal(t, weekday.Add(15*time.Minute), q.At)
+	require.Equal(t, "base scooter 15m0s = 15m0s", q.Explanation)
+}
+
+func TestRuleTimeFactory_PeakAndWeekend(t *testing.T) {
+	t.Parallel()
+
+	p := delivery.DeadlinePolicy{
+		Timezone: "Europe/Moscow",
+		PeakHours: []delivery.PeakHours{
+			{From: "08:00", To: "10:00", Multiplier: 1.5},
+			{From: "22:00", To: "02:00", Multiplier: 2},
+		},
+		WeekendMultiplier: 1.2,
+	}
+	q := quoter(t, p)
+
+	// 09:00 in Moscow on a Thursday
+	morning := time.Date(2025, 1, 2, 6, 0, 0, 0, time.UTC)
+	got, err := q.Quote(delivery.DeadlineInput{Transport: domain.TransportTypeFoot, Now: morning})
+	require.NoError(t, err)
+	require.Equal(t, morning.Add(45*time.Minute), got.At)
+	require.Contains(t, got.Explanation, "peak 08:00-10:00 x1.50")
+
+	// 01:00 in Moscow on a Saturday: the window spans midnight and the weekend applies too
+	night := time.Date(2025, 1, 3, 22, 0, 0, 0, time.UTC)
+	got, err = q.Quote(delivery.DeadlineInput{Transport: domain.TransportTypeCar, Now: night})
+	require.NoError(t, err)
+	require.Equal(t, night.Add(12*time.Minute), got.At)
+	require.Equal(t, "base car 5m0s; peak 22:00-02:00 x2.00; weekend x1.20 = 12m0s", got.Explanation)
+
+	// 12:00 in UTC is 15:00 in Moscow, off peak
+	got, err = q.Quote(delivery.DeadlineInput{Transport: domain.TransportTypeCar, Now: weekday})
+	require.NoError(t, err)
+	require.Equal(t, weekday.Add(5*time.Minute), got.At)
+}
+
+func TestRuleTimeFactory_ZoneAndDistance(t *testing.T) {
+	t.Parallel()
+
+	p := delivery.DeadlinePolicy{
+		Zones: []delivery.DeadlineZone{
+			{Name: "center", MinLat: 55.70, MaxLat: 55.80, MinLon: 37.55, MaxLon: 37.70, Offset: delivery.Duration(5 * time.Minute)},
+		},
+		DistanceFactor: 1,
+	}
+	q := quoter(t, p)
+
+	// ~5.6 km north of the pickup point, a car at 40 km/h needs ~8.4 minutes
+	courier := &domain.Location{Lat: 55.8058, Lon: 37.6173}
+	got, err := q.Quote(delivery.DeadlineInput{
+		Transport:       domain.TransportTypeCar,
+		Now:             weekday,
+		Pickup:          pickup,
+		CourierLocation: courier,
+	})
+	require.NoError(t, err)
+	d := got.At.Sub(weekday)
+	require.InDelta(t, (5*time.Minute + 5*time.Minute + 8*time.Minute + 20*time.Second).Seconds(), d.Seconds(), 10)
+	require.Contains(t, got.Explanation, "zone center +5m0s")
+	require.Contains(t, got.Explanation, "distance 5.56km at 40km/h x1.00")
+
+	// without coordinates neither the zone nor the distance applies
+	got, err = q.Quote(delivery.DeadlineInput{Transport: domain.TransportTypeCar, Now: weekday})
+	require.NoError(t, err)
+	require.Equal(t, weekday.Add(5*time.Minute), got.At)
+}
+
+func TestRuleTimeFactory_UnknownTransport(t *testing.T) {
+	t.Parallel()
+
+	_, err := quoter(t, delivery.DeadlinePolicy{}).Quote(delivery.DeadlineInput{Transport: "horse", Now: weekday})
+	require.Error(t, err)
+}
+
+func TestNewRuleTimeFactory_InvalidPolicy(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]delivery.DeadlinePolicy{
+		"bad timezone":    {Timezone: "Mars/Olympus"},
+		"bad clock":       {PeakHours: []delivery.PeakHours{{From: "8am", To: "10:00", Multiplier: 1.5}}},
+		"empty window":    {PeakHours: []delivery.PeakHours{{From: "08:00", To: "08:00", Multiplier: 1.5}}},
+		"zero multiplier": {PeakHours: []delivery.PeakHours{{From: "08:00", To: "10:00"}}},
+		"negative factor": {DistanceFactor: -1},
+		"inverted zone":   {Zones: []delivery.DeadlineZone{{Name: "z", MinLat: 56, MaxLat: 55}}},
+	}
+	for name, p := range cases {
+		_, err := delivery.NewRuleTimeFactory(p, nil)
+		require.Error(t, err, name)
+	}
+}
+
+func TestLoadDeadlinePolicy(t *testing.T) {
+	t.Parallel()
+
+	p, err := delivery.LoadDeadlinePolicy("")
+	require.NoError(t, err)
+	require.Equal(t, delivery.DeadlinePolicy{}, p)
+
+	path := filepath.Join(t.TempDir(), "policy.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{
+		"peak_hours": [{"from": "08:00", "to": "10:00", "multiplier": 1.5}],
+		"weekend_multiplier": 1.2,
+		"zones": [{"name": "center", "min_lat": 55.7, "max_lat": 55.8, "min_lon": 37.5, "max_lon": 37.7, "offset": "-2m"}],
+		"distance_factor": 0.5
+	}`), 0o600))
+
+	p, err = delivery.LoadDeadlinePolicy(path)
+	require.NoError(t, err)
+	require.Len(t, p.PeakHours, 1)
+	require.Equal(t, delivery.Duration(-2*time.Minute), p.Zones[0].Offset)
+	require.InDelta(t, 0.5, p.DistanceFactor, 1e-9)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"zones": [{"name": "z", "offset": 5}]}`), 0o600))
+	_, err = delivery.LoadDeadlinePolicy(path)
+	require.Error(t, err, "offsets are duration strings")
+
+	_, err = delivery.LoadDeadlinePolicy(filepath.Join(t.TempDir(), "missing.json"))
+	require.Error(t, err)
+}
+
+type stubQuoter struct {
+	stubTimeFactory
+	fn func(in delivery.DeadlineInput) (delivery.DeadlineQuote, error)
+}
+
+func (s stubQuoter) Quote(in delivery.DeadlineInput) (delivery.DeadlineQuote, error) { return s.fn(in) }
+
+func TestService_Assign_UsesDeadlineQuote(t *testing.T) {
+	t.Parallel()
+
+	repo := NewMockdeliveryRepository(newCtrl(t))
+	courierAt := &domain.Location{Lat: 55.76, Lon: 37.62}
+	repo.EXPECT().WithTx(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, fn func(delivery.TxRepository) error) error {
+			return fn(&stubTx{
+				listFn: func(context.Context) ([]domain.CourierCandidate, error) {
+					c := candidate(10, domain.TransportTypeFoot)
+					c.Location = courierAt
+					return []domain.CourierCandidate{c}, nil
+				},
+			})
+		})
+
+	deadline := weekday.Add(40 * time.Minute)
+	factory := stubQuoter{fn: func(in delivery.DeadlineInput) (delivery.DeadlineQuote, error) {
+		require.Equal(t, domain.TransportTypeFoot, in.Transport)
+		require.Equal(t, pickup, in.Pickup)
+		require.Equal(t, courierAt, in.CourierLocation)
+		return delivery.DeadlineQuote{At: deadline, Explanation: "base on_foot 30m0s; zone center +10m0s = 40m0s"}, nil
+	}}
+
+	res, err := newTestDeliveryService(repo, factory).Assign(context.Background(), "order_1", pickup)
+	require.NoError(t, err)
+	require.True(t, res.Deadline.Equal(deadline))
+	require.Equal(t, "base on_foot 30m0s; zone center +10m0s = 40m0s", res.DeadlineExplanation)
+}
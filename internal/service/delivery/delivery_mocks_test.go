@@ -37,19 +37,78 @@ func (m *MockdeliveryRepository) EXPECT() *MockdeliveryRepositoryMockRecorder {
 	return m.recorder
 }
 
-// ReleaseCouriers mocks base method.
-func (m *MockdeliveryRepository) ReleaseCouriers(ctx context.Context, now time.Time) (int64, error) {
+// DeletePendingOrder mocks base method.
+func (m *MockdeliveryRepository) DeletePendingOrder(ctx context.Context, orderID string) (bool, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ReleaseCouriers", ctx, now)
-	ret0, _ := ret[0].(int64)
+	ret := m.ctrl.Call(m, "DeletePendingOrder", ctx, orderID)
+	ret0, _ := ret[0].(bool)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// ReleaseCouriers indicates an expected call of ReleaseCouriers.
-func (mr *MockdeliveryRepositoryMockRecorder) ReleaseCouriers(ctx, now interface{}) *gomock.Call {
+// DeletePendingOrder indicates an expected call of DeletePendingOrder.
+func (mr *MockdeliveryRepositoryMockRecorder) DeletePendingOrder(ctx, orderID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseCouriers", reflect.TypeOf((*MockdeliveryRepository)(nil).ReleaseCouriers), ctx, now)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePendingOrder", reflect.TypeOf((*MockdeliveryRepository)(nil).DeletePendingOrder), ctx, orderID)
+}
+
+// EnqueuePendingOrder mocks base method.
+func (m *MockdeliveryRepository) EnqueuePendingOrder(ctx context.Context, p domain.PendingOrder) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnqueuePendingOrder", ctx, p)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// EnqueuePendingOrder indicates an expected call of EnqueuePendingOrder.
+func (mr *MockdeliveryRepositoryMockRecorder) EnqueuePendingOrder(ctx, p interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnqueuePendingOrder", reflect.TypeOf((*MockdeliveryRepository)(nil).EnqueuePendingOrder), ctx, p)
+}
+
+// GetByOrderID mocks base method.
+func (m *MockdeliveryRepository) GetByOrderID(ctx context.Context, orderID string) (*domain.Delivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByOrderID", ctx, orderID)
+	ret0, _ := ret[0].(*domain.Delivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByOrderID indicates an expected call of GetByOrderID.
+func (mr *MockdeliveryRepositoryMockRecorder) GetByOrderID(ctx, orderID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByOrderID", reflect.TypeOf((*MockdeliveryRepository)(nil).GetByOrderID), ctx, orderID)
+}
+
+// ListByCourier mocks base method.
+func (m *MockdeliveryRepository) ListByCourier(ctx context.Context, courierID int64, activeOnly bool, limit, offset int) ([]domain.Delivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListByCourier", ctx, courierID, activeOnly, limit, offset)
+	ret0, _ := ret[0].([]domain.Delivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListByCourier indicates an expected call of ListByCourier.
+func (mr *MockdeliveryRepositoryMockRecorder) ListByCourier(ctx, courierID, activeOnly, limit, offset interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByCourier", reflect.TypeOf((*MockdeliveryRepository)(nil).ListByCourier), ctx, courierID, activeOnly, limit, offset)
+}
+
+// ListOverdue mocks base method.
+func (m *MockdeliveryRepository) ListOverdue(ctx context.Context, now time.Time, limit, offset int) ([]domain.Delivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListOverdue", ctx, now, limit, offset)
+	ret0, _ := ret[0].([]domain.Delivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListOverdue indicates an expected call of ListOverdue.
+func (mr *MockdeliveryRepositoryMockRecorder) ListOverdue(ctx, now, limit, offset interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListOverdue", reflect.TypeOf((*MockdeliveryRepository)(nil).ListOverdue), ctx, now, limit, offset)
 }
 
 // WithTx mocks base method.
@@ -66,6 +125,76 @@ func (mr *MockdeliveryRepositoryMockRecorder) WithTx(ctx, fn interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithTx", reflect.TypeOf((*MockdeliveryRepository)(nil).WithTx), ctx, fn)
 }
 
+// Mockcounter is a mock of counter interface.
+type Mockcounter struct {
+	ctrl     *gomock.Controller
+	recorder *MockcounterMockRecorder
+}
+
+// MockcounterMockRecorder is the mock recorder for Mockcounter.
+type MockcounterMockRecorder struct {
+	mock *Mockcounter
+}
+
+// NewMockcounter creates a new mock instance.
+func NewMockcounter(ctrl *gomock.Controller) *Mockcounter {
+	mock := &Mockcounter{ctrl: ctrl}
+	mock.recorder = &MockcounterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *Mockcounter) EXPECT() *MockcounterMockRecorder {
+	return m.recorder
+}
+
+// Inc mocks base method.
+func (m *Mockcounter) Inc() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Inc")
+}
+
+// Inc indicates an expected call of Inc.
+func (mr *MockcounterMockRecorder) Inc() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Inc", reflect.TypeOf((*Mockcounter)(nil).Inc))
+}
+
+// Mockgauge is a mock of gauge interface.
+type Mockgauge struct {
+	ctrl     *gomock.Controller
+	recorder *MockgaugeMockRecorder
+}
+
+// MockgaugeMockRecorder is the mock recorder for Mockgauge.
+type MockgaugeMockRecorder struct {
+	mock *Mockgauge
+}
+
+// NewMockgauge creates a new mock instance.
+func NewMockgauge(ctrl *gomock.Controller) *Mockgauge {
+	mock := &Mockgauge{ctrl: ctrl}
+	mock.recorder = &MockgaugeMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *Mockgauge) EXPECT() *MockgaugeMockRecorder {
+	return m.recorder
+}
+
+// Set mocks base method.
+func (m *Mockgauge) Set(v float64) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Set", v)
+}
+
+// Set indicates an expected call of Set.
+func (mr *MockgaugeMockRecorder) Set(v interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Set", reflect.TypeOf((*Mockgauge)(nil).Set), v)
+}
+
 // MockTimeFactory is a mock of TimeFactory interface.
 type MockTimeFactory struct {
 	ctrl     *gomock.Controller
@@ -103,3 +232,131 @@ func (mr *MockTimeFactoryMockRecorder) Deadline(transport, now interface{}) *gom
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Deadline", reflect.TypeOf((*MockTimeFactory)(nil).Deadline), transport, now)
 }
+
+// MockDeadlineQuoter is a mock of DeadlineQuoter interface.
+type MockDeadlineQuoter struct {
+	ctrl     *gomock.Controller
+	recorder *MockDeadlineQuoterMockRecorder
+}
+
+// MockDeadlineQuoterMockRecorder is the mock recorder for MockDeadlineQuoter.
+type MockDeadlineQuoterMockRecorder struct {
+	mock *MockDeadlineQuoter
+}
+
+// NewMockDeadlineQuoter creates a new mock instance.
+func NewMockDeadlineQuoter(ctrl *gomock.Controller) *MockDeadlineQuoter {
+	mock := &MockDeadlineQuoter{ctrl: ctrl}
+	mock.recorder = &MockDeadlineQuoterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDeadlineQuoter) EXPECT() *MockDeadlineQuoterMockRecorder {
+	return m.recorder
+}
+
+// Quote mocks base method.
+func (m *MockDeadlineQuoter) Quote(in delivery.DeadlineInput) (delivery.DeadlineQuote, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Quote", in)
+	ret0, _ := ret[0].(delivery.DeadlineQuote)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Quote indicates an expected call of Quote.
+func (mr *MockDeadlineQuoterMockRecorder) Quote(in interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Quote", reflect.TypeOf((*MockDeadlineQuoter)(nil).Quote), in)
+}
+
+// MockTransportTypes is a mock of TransportTypes interface.
+type MockTransportTypes struct {
+	ctrl     *gomock.Controller
+	recorder *MockTransportTypesMockRecorder
+}
+
+// MockTransportTypesMockRecorder is the mock recorder for MockTransportTypes.
+type MockTransportTypesMockRecorder struct {
+	mock *MockTransportTypes
+}
+
+// NewMockTransportTypes creates a new mock instance.
+func NewMockTransportTypes(ctrl *gomock.Controller) *MockTransportTypes {
+	mock := &MockTransportTypes{ctrl: ctrl}
+	mock.recorder = &MockTransportTypesMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTransportTypes) EXPECT() *MockTransportTypesMockRecorder {
+	return m.recorder
+}
+
+// Get mocks base method.
+func (m *MockTransportTypes) Get(code domain.CourierTransportType) (domain.TransportType, bool) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", code)
+	ret0, _ := ret[0].(domain.TransportType)
+	ret1, _ := ret[1].(bool)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockTransportTypesMockRecorder) Get(code interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockTransportTypes)(nil).Get), code)
+}
+
+// MockAssignmentStrategy is a mock of AssignmentStrategy interface.
+type MockAssignmentStrategy struct {
+	ctrl     *gomock.Controller
+	recorder *MockAssignmentStrategyMockRecorder
+}
+
+// MockAssignmentStrategyMockRecorder is the mock recorder for MockAssignmentStrategy.
+type MockAssignmentStrategyMockRecorder struct {
+	mock *MockAssignmentStrategy
+}
+
+// NewMockAssignmentStrategy creates a new mock instance.
+func NewMockAssignmentStrategy(ctrl *gomock.Controller) *MockAssignmentStrategy {
+	mock := &MockAssignmentStrategy{ctrl: ctrl}
+	mock.recorder = &MockAssignmentStrategyMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAssignmentStrategy) EXPECT() *MockAssignmentStrategyMockRecorder {
+	return m.recorder
+}
+
+// Choose mocks base method.
+func (m *MockAssignmentStrategy) Choose(pickup *domain.Location, candidates []domain.CourierCandidate) (int, bool) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Choose", pickup, candidates)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(bool)
+	return ret0, ret1
+}
+
+// Choose indicates an expected call of Choose.
+func (mr *MockAssignmentStrategyMockRecorder) Choose(pickup, candidates interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Choose", reflect.TypeOf((*MockAssignmentStrategy)(nil).Choose), pickup, candidates)
+}
+
+// Name mocks base method.
+func (m *MockAssignmentStrategy) Name() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Name")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// Name indicates an expected call of Name.
+func (mr *MockAssignmentStrategyMockRecorder) Name() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Name", reflect.TypeOf((*MockAssignmentStrategy)(nil).Name))
+}
@@ -0,0 +1,181 @@
+package delivery
+
+import (
+	"context"
+	"time"
+
+	"course-go-avito-Orurh/internal/domain"
+	"course-go-avito-Orurh/internal/logx"
+	"course-go-avito-Orurh/internal/ports/deliverytx"
+)
+
+// expireBatchSize limits how many overdue deliveries one ReleaseExpired run handles.
+const expireBatchSize = 100
+
+// ReleaseExpired expires overdue assigned deliveries, frees a slot of each of their couriers,
+// and reassigns the orders to other couriers.
+// Orders no other courier is available for are put into the pending queue.
+// Overdue picked up deliveries stay with their courier, who has the goods, and are only reported.
+func (s *Service) ReleaseExpired(ctx context.Context) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	now := s.now()
+	var (
+		records []domain.Reassignment
+		results []domain.AssignResult
+		overdue []domain.Delivery
+	)
+	err := s.repo.WithTx(ctx, func(tx deliverytx.Repository) error {
+		records, results = nil, nil
+
+		var err error
+		overdue, err = tx.ListOverduePickedUp(ctx, now, expireBatchSize)
+		if err != nil {
+			return err
+		}
+
+		expired, err := tx.ExpireOverdueDeliveries(ctx, now, expireBatchSize)
+		if err != nil || len(expired) == 0 {
+			return err
+		}
+		for _, d := range expired {
+			if err := announceDeliveryStatus(ctx, tx, d, domain.DeliveryStatusExpired, now); err != nil {
+				return err
+			}
+		}
+
+		if err := s.releaseSlots(ctx, tx, expired); err != nil {
+			return err
+		}
+
+		candidates, err := tx.ListAvailableCouriers(ctx)
+		if err != nil {
+			return err
+		}
+		candidates = s.withFreeSlots(candidates)
+		for _, d := range expired {
+			ra, r, err := s.reassign(ctx, tx, d, candidates, now)
+			if err != nil {
+				return err
+			}
+			if ra.ToCourierID != nil {
+				candidates = s.takeSlot(candidates, *ra.ToCourierID, now)
+				results = append(results, r)
+			}
+			records = append(records, ra)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s.reportOverduePickedUp(overdue, now)
+	for _, ra := range records {
+		s.logReassigned(ra)
+		if ra.ToCourierID != nil && s.reassigned != nil {
+			s.reassigned.Inc()
+		}
+	}
+	for _, r := range results {
+		s.logAssigned(r)
+	}
+	if len(records) > 0 {
+		// every expired delivery freed a courier slot
+		s.courierAvailable()
+	}
+	return nil
+}
+
+// releaseSlots updates the status of every courier of the expired deliveries.
+func (s *Service) releaseSlots(ctx context.Context, tx deliverytx.Repository, expired []domain.Delivery) error {
+	seen := make(map[int64]struct{}, len(expired))
+	for _, d := range expired {
+		if _, ok := seen[d.CourierID]; ok {
+			continue
+		}
+		seen[d.CourierID] = struct{}{}
+
+		if err := s.releaseSlot(ctx, tx, d.CourierID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reassign hands the order of an expired delivery over to another courier,
+// or queues it if none is available, and records the outcome.
+func (s *Service) reassign(
+	ctx context.Context,
+	tx deliverytx.Repository,
+	expired domain.Delivery,
+	candidates []domain.CourierCandidate,
+	now time.Time,
+) (domain.Reassignment, domain.AssignResult, error) {
+	ra := domain.Reassignment{
+		OrderID:           expired.OrderID,
+		ExpiredDeliveryID: expired.ID,
+		FromCourierID:     expired.CourierID,
+		At:                now,
+	}
+
+	others, _ := splitCourier(candidates, expired.CourierID)
+	c, _, err := s.findCourier(ctx, tx, expired.Pickup, others)
+	if err != nil {
+		return domain.Reassignment{}, domain.AssignResult{}, err
+	}
+
+	var r domain.AssignResult
+	if c == nil {
+		err = tx.EnqueuePendingOrder(ctx, domain.PendingOrder{
+			OrderID:           expired.OrderID,
+			Pickup:            expired.Pickup,
+			EnqueuedAt:        now,
+			ExcludedCourierID: &expired.CourierID,
+		})
+	} else {
+		r, err = s.assignTo(ctx, tx, expired.OrderID, expired.Pickup, c)
+		ra.ToCourierID = &c.Courier.ID
+	}
+	if err != nil {
+		return domain.Reassignment{}, domain.AssignResult{}, err
+	}
+
+	if err := tx.InsertReassignment(ctx, ra); err != nil {
+		return domain.Reassignment{}, domain.AssignResult{}, err
+	}
+	return ra, r, nil
+}
+
+// reportOverduePickedUp logs every picked up delivery past its deadline and sets the gauge to their number.
+func (s *Service) reportOverduePickedUp(overdue []domain.Delivery, now time.Time) {
+	if s.overduePickedUp != nil {
+		s.overduePickedUp.Set(float64(len(overdue)))
+	}
+	for _, d := range overdue {
+		s.logger.Warn("picked up delivery is overdue",
+			logx.String("event", "delivery_overdue_picked_up"),
+			logx.String("order_id", d.OrderID),
+			logx.Int64("courier_id", d.CourierID),
+			logx.Duration("overdue", now.Sub(d.Deadline)),
+		)
+	}
+}
+
+func (s *Service) logReassigned(ra domain.Reassignment) {
+	if ra.ToCourierID == nil {
+		s.logger.Warn("expired delivery queued, no other courier available",
+			logx.String("event", "delivery_reassign_queued"),
+			logx.String("order_id", ra.OrderID),
+			logx.Int64("from_courier_id", ra.FromCourierID),
+		)
+		return
+	}
+	s.logger.Info("expired delivery reassigned",
+		logx.String("event", "delivery_reassigned"),
+		logx.String("order_id", ra.OrderID),
+		logx.Int64("from_courier_id", ra.FromCourierID),
+		logx.Int64("to_courier_id", *ra.ToCourierID),
+	)
+}
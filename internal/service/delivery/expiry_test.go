@@ -0,0 +1,258 @@
+package delivery_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"course-go-avito-Orurh/internal/domain"
+	"course-go-avito-Orurh/internal/service/delivery"
+)
+
+func TestService_ReleaseExpired_ReassignsToAnotherCourier(t *testing.T) {
+	t.Parallel()
+
+	ctrl := newCtrl(t)
+	repo := NewMockdeliveryRepository(ctrl)
+
+	var (
+		freed    []int64
+		inserted []*domain.Delivery
+		records  []domain.Reassignment
+	)
+	repo.EXPECT().WithTx(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, fn func(delivery.TxRepository) error) error {
+			return fn(&stubTx{
+				expireFn: func(_ context.Context, _ time.Time, limit int) ([]domain.Delivery, error) {
+					require.Positive(t, limit)
+					return []domain.Delivery{{ID: 7, CourierID: 1, OrderID: "order_1"}}, nil
+				},
+				updFn: func(_ context.Context, id int64, st domain.CourierStatus) error {
+					if st == domain.StatusAvailable {
+						freed = append(freed, id)
+					}
+					return nil
+				},
+				// the released original courier is the least loaded one but must not get the order back
+				listFn: func(context.Context) ([]domain.CourierCandidate, error) {
+					original := candidate(1, domain.TransportTypeFoot)
+					other := candidate(2, domain.TransportTypeCar)
+					other.TotalDeliveries = 10
+					return []domain.CourierCandidate{original, other}, nil
+				},
+				insertFn: func(_ context.Context, d *domain.Delivery) error {
+					inserted = append(inserted, d)
+					return nil
+				},
+				recordFn: func(_ context.Context, r domain.Reassignment) error {
+					records = append(records, r)
+					return nil
+				},
+				queueFn: func(context.Context, domain.PendingOrder) error {
+					t.Fatal("order must be reassigned, not queued")
+					return nil
+				},
+			})
+		})
+
+	reassigned := NewMockcounter(ctrl)
+	reassigned.EXPECT().Inc()
+
+	notified := 0
+	svc := newTestDeliveryService(repo, stubTimeFactory{fn: fixedDeadline}).
+		WithCourierAvailableHook(func() { notified++ }).
+		WithReassignCounter(reassigned)
+
+	require.NoError(t, svc.ReleaseExpired(context.Background()))
+
+	require.Equal(t, []int64{1}, freed)
+	require.Len(t, inserted, 1)
+	require.Equal(t, int64(2), inserted[0].CourierID)
+	require.Equal(t, "order_1", inserted[0].OrderID)
+
+	require.Len(t, records, 1)
+	require.Equal(t, "order_1", records[0].OrderID)
+	require.Equal(t, int64(7), records[0].ExpiredDeliveryID)
+	require.Equal(t, int64(1), records[0].FromCourierID)
+	require.NotNil(t, records[0].ToCourierID)
+	require.Equal(t, int64(2), *records[0].ToCourierID)
+
+	require.Equal(t, 1, notified)
+}
+
+func TestService_ReleaseExpired_NoOtherCourier_QueuesOrder(t *testing.T) {
+	t.Parallel()
+
+	ctrl := newCtrl(t)
+	repo := NewMockdeliveryRepository(ctrl)
+
+	pickup := domain.Location{Lat: 1, Lon: 1}
+	var (
+		queued  []domain.PendingOrder
+		records []domain.Reassignment
+	)
+	repo.EXPECT().WithTx(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, fn func(delivery.TxRepository) error) error {
+			return fn(&stubTx{
+				expireFn: func(context.Context, time.Time, int) ([]domain.Delivery, error) {
+					return []domain.Delivery{{ID: 7, CourierID: 1, OrderID: "order_1", Pickup: &pickup}}, nil
+				},
+				listFn: available(&domain.Courier{ID: 1}),
+				insertFn: func(context.Context, *domain.Delivery) error {
+					t.Fatal("the original courier must not get the order back")
+					return nil
+				},
+				queueFn: func(_ context.Context, p domain.PendingOrder) error {
+					queued = append(queued, p)
+					return nil
+				},
+				recordFn: func(_ context.Context, r domain.Reassignment) error {
+					records = append(records, r)
+					return nil
+				},
+			})
+		})
+
+	notified := 0
+	svc := newTestDeliveryService(repo, stubTimeFactory{fn: fixedDeadline}).
+		WithCourierAvailableHook(func() { notified++ }).
+		WithReassignCounter(NewMockcounter(ctrl))
+
+	require.NoError(t, svc.ReleaseExpired(context.Background()))
+
+	require.Len(t, queued, 1)
+	require.Equal(t, "order_1", queued[0].OrderID)
+	require.Equal(t, int64(1), *queued[0].ExcludedCourierID, "the dispatcher must not give the order back either")
+	require.Equal(t, &pickup, queued[0].Pickup, "the dispatcher looks for a courier near the same pickup point")
+	require.Len(t, records, 1)
+	require.Nil(t, records[0].ToCourierID)
+	require.Equal(t, 1, notified)
+}
+
+func TestService_ReleaseExpired_ReassignsNearStoredPickup(t *testing.T) {
+	t.Parallel()
+
+	ctrl := newCtrl(t)
+	repo := NewMockdeliveryRepository(ctrl)
+
+	pickup := domain.Location{Lat: 1, Lon: 1}
+	var inserted []*domain.Delivery
+	repo.EXPECT().WithTx(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, fn func(delivery.TxRepository) error) error {
+			return fn(&stubTx{
+				expireFn: func(context.Context, time.Time, int) ([]domain.Delivery, error) {
+					return []domain.Delivery{{ID: 7, CourierID: 1, OrderID: "order_1", Pickup: &pickup}}, nil
+				},
+				// the idle courier is across the city, the busier one is next to the pickup point
+				listFn: func(context.Context) ([]domain.CourierCandidate, error) {
+					near := at(candidate(3, domain.TransportTypeFoot), 1.001, 1)
+					near.TotalDeliveries = 10
+					return []domain.CourierCandidate{
+						at(candidate(1, domain.TransportTypeFoot), 1, 1),
+						at(candidate(2, domain.TransportTypeCar), 2, 2),
+						near,
+					}, nil
+				},
+				insertFn: func(_ context.Context, d *domain.Delivery) error {
+					inserted = append(inserted, d)
+					return nil
+				},
+			})
+		})
+
+	reassigned := NewMockcounter(ctrl)
+	reassigned.EXPECT().Inc()
+	svc := newTestDeliveryService(repo, stubTimeFactory{fn: fixedDeadline}).
+		WithReassignCounter(reassigned)
+
+	require.NoError(t, svc.ReleaseExpired(context.Background()))
+
+	require.Len(t, inserted, 1)
+	require.Equal(t, int64(3), inserted[0].CourierID)
+	require.Equal(t, &pickup, inserted[0].Pickup, "the new delivery keeps the pickup point for the next reassignment")
+}
+
+func TestService_ReleaseExpired_NothingExpired(t *testing.T) {
+	t.Parallel()
+
+	repo := NewMockdeliveryRepository(newCtrl(t))
+	repo.EXPECT().WithTx(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, fn func(delivery.TxRepository) error) error {
+			return fn(&stubTx{
+				courFn: func(context.Context, int64) (*domain.Courier, error) {
+					t.Fatal("no couriers to release")
+					return nil, nil
+				},
+			})
+		})
+
+	notified := 0
+	svc := newTestDeliveryService(repo, stubTimeFactory{}).
+		WithCourierAvailableHook(func() { notified++ })
+
+	require.NoError(t, svc.ReleaseExpired(context.Background()))
+	require.Equal(t, 0, notified)
+}
+
+func TestService_ReleaseExpired_RepoError(t *testing.T) {
+	t.Parallel()
+
+	repo := NewMockdeliveryRepository(newCtrl(t))
+
+	wantErr := errors.New("boom")
+	repo.EXPECT().WithTx(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, fn func(delivery.TxRepository) error) error {
+			return fn(&stubTx{
+				expireFn: func(context.Context, time.Time, int) ([]domain.Delivery, error) {
+					return []domain.Delivery{{ID: 7, CourierID: 1, OrderID: "order_1"}}, nil
+				},
+				courFn: func(context.Context, int64) (*domain.Courier, error) {
+					return nil, wantErr
+				},
+			})
+		})
+
+	svc := newTestDeliveryService(repo, stubTimeFactory{})
+
+	require.ErrorIs(t, svc.ReleaseExpired(context.Background()), wantErr)
+}
+
+func TestService_ReleaseExpired_OverduePickedUpIsOnlyReported(t *testing.T) {
+	t.Parallel()
+
+	ctrl := newCtrl(t)
+	repo := NewMockdeliveryRepository(ctrl)
+	repo.EXPECT().WithTx(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, fn func(delivery.TxRepository) error) error {
+			return fn(&stubTx{
+				lateFn: func(context.Context, time.Time, int) ([]domain.Delivery, error) {
+					return []domain.Delivery{{ID: 7, CourierID: 1, OrderID: "order_1", Status: domain.DeliveryStatusPickedUp}}, nil
+				},
+				listFn: available(&domain.Courier{ID: 2}),
+				insertFn: func(context.Context, *domain.Delivery) error {
+					t.Fatal("the courier with the goods keeps the order")
+					return nil
+				},
+				queueFn: func(context.Context, domain.PendingOrder) error {
+					t.Fatal("the courier with the goods keeps the order")
+					return nil
+				},
+			})
+		})
+
+	overdue := NewMockgauge(ctrl)
+	overdue.EXPECT().Set(float64(1))
+
+	notified := 0
+	svc := newTestDeliveryService(repo, stubTimeFactory{fn: fixedDeadline}).
+		WithCourierAvailableHook(func() { notified++ }).
+		WithReassignCounter(NewMockcounter(ctrl)).
+		WithOverduePickedUpGauge(overdue)
+
+	require.NoError(t, svc.ReleaseExpired(context.Background()))
+	require.Equal(t, 0, notified)
+}
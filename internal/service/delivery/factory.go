@@ -2,28 +2,99 @@ package delivery
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"course-go-avito-Orurh/internal/domain"
 )
 
-type defaultTimeFactory struct{}
+// builtinTransportTypes resolves the built-in transport types, for callers that are given no catalog.
+func builtinTransportTypes() TransportTypes {
+	return domain.NewTransportTypeSet(domain.DefaultTransportTypes())
+}
+
+// ruleTimeFactory computes deadlines from the transport base deadline and a DeadlinePolicy.
+type ruleTimeFactory struct {
+	types  TransportTypes
+	policy DeadlinePolicy
+}
 
-// NewTimeFactory - creates a new TimeFactory.
-func NewTimeFactory() TimeFactory {
-	return defaultTimeFactory{}
+// NewTimeFactory - creates a new TimeFactory that takes base deadlines from types and applies no other rules.
+// If types is nil, the built-in transport types are used.
+func NewTimeFactory(types TransportTypes) TimeFactory {
+	f, _ := NewRuleTimeFactory(DeadlinePolicy{}, types)
+	return f
+}
+
+// NewRuleTimeFactory creates a TimeFactory that applies policy on top of the transport base deadline.
+// If types is nil, the built-in transport types are used.
+func NewRuleTimeFactory(policy DeadlinePolicy, types TransportTypes) (TimeFactory, error) {
+	if err := policy.compile(); err != nil {
+		return nil, fmt.Errorf("deadline policy: %w", err)
+	}
+	if types == nil {
+		types = builtinTransportTypes()
+	}
+	return ruleTimeFactory{types: types, policy: policy}, nil
 }
 
 // Deadline returns the delivery deadline based on the transport type and the current time.
-func (defaultTimeFactory) Deadline(transport domain.CourierTransportType, now time.Time) (time.Time, error) {
-	switch transport {
-	case domain.TransportTypeFoot:
-		return now.Add(30 * time.Minute), nil
-	case domain.TransportTypeScooter:
-		return now.Add(15 * time.Minute), nil
-	case domain.TransportTypeCar:
-		return now.Add(5 * time.Minute), nil
-	default:
-		return time.Time{}, fmt.Errorf("unknown transport type: %s", transport)
+func (f ruleTimeFactory) Deadline(transport domain.CourierTransportType, now time.Time) (time.Time, error) {
+	q, err := f.Quote(DeadlineInput{Transport: transport, Now: now})
+	if err != nil {
+		return time.Time{}, err
+	}
+	return q.At, nil
+}
+
+// Quote returns the delivery deadline together with the rules that produced it.
+func (f ruleTimeFactory) Quote(in DeadlineInput) (DeadlineQuote, error) {
+	t, ok := f.types.Get(in.Transport)
+	if !ok {
+		return DeadlineQuote{}, fmt.Errorf("unknown transport type: %s", in.Transport)
+	}
+
+	p := f.policy
+	local := in.Now.In(p.location())
+	d := t.BaseDeadline
+	steps := []string{fmt.Sprintf("base %s %s", t.Code, t.BaseDeadline)}
+
+	if h, ok := p.peak(local); ok {
+		d = scale(d, h.Multiplier)
+		steps = append(steps, fmt.Sprintf("peak %s-%s x%.2f", h.From, h.To, h.Multiplier))
+	}
+	if p.WeekendMultiplier > 0 && isWeekend(local) {
+		d = scale(d, p.WeekendMultiplier)
+		steps = append(steps, fmt.Sprintf("weekend x%.2f", p.WeekendMultiplier))
+	}
+	if z, ok := p.zone(in.Pickup); ok {
+		d += time.Duration(z.Offset)
+		steps = append(steps, fmt.Sprintf("zone %s %s", z.Name, signed(time.Duration(z.Offset))))
+	}
+	if p.DistanceFactor > 0 && in.Pickup != nil && in.CourierLocation != nil && t.SpeedKmh > 0 {
+		km := in.CourierLocation.DistanceKm(*in.Pickup)
+		travel := scale(time.Hour, km/t.SpeedKmh*p.DistanceFactor).Round(time.Second)
+		d += travel
+		steps = append(steps, fmt.Sprintf("distance %.2fkm at %gkm/h x%.2f +%s", km, t.SpeedKmh, p.DistanceFactor, travel))
+	}
+	if d <= 0 {
+		return DeadlineQuote{}, fmt.Errorf("deadline policy produced a non-positive deadline %s", d)
+	}
+
+	d = d.Round(time.Second)
+	return DeadlineQuote{
+		At:          in.Now.Add(d),
+		Explanation: strings.Join(steps, "; ") + " = " + d.String(),
+	}, nil
+}
+
+func scale(d time.Duration, k float64) time.Duration {
+	return time.Duration(float64(d) * k)
+}
+
+func signed(d time.Duration) string {
+	if d < 0 {
+		return d.String()
 	}
+	return "+" + d.String()
 }
@@ -0,0 +1,55 @@
+package delivery
+
+import (
+	"context"
+	"time"
+
+	"course-go-avito-Orurh/internal/domain"
+	"course-go-avito-Orurh/internal/ports/deliverytx"
+)
+
+// announceAssigned records in the outbox that the delivery was assigned.
+func announceAssigned(ctx context.Context, tx deliverytx.Repository, d *domain.Delivery) error {
+	deadline := d.Deadline
+	return tx.AppendOutbox(ctx, domain.OutboxEvent{
+		Type:           domain.OutboxDeliveryAssigned,
+		OrderID:        d.OrderID,
+		CourierID:      d.CourierID,
+		DeliveryStatus: domain.DeliveryStatusAssigned,
+		Deadline:       &deadline,
+		OccurredAt:     d.AssignedAt,
+	})
+}
+
+// announceDeliveryStatus records in the outbox that the delivery moved to status.
+func announceDeliveryStatus(
+	ctx context.Context,
+	tx deliverytx.Repository,
+	d domain.Delivery,
+	status domain.DeliveryStatus,
+	at time.Time,
+) error {
+	return tx.AppendOutbox(ctx, domain.OutboxEvent{
+		Type:           domain.OutboxDeliveryStatusChanged,
+		OrderID:        d.OrderID,
+		CourierID:      d.CourierID,
+		DeliveryStatus: status,
+		OccurredAt:     at,
+	})
+}
+
+// announceCourierStatus records in the outbox that the courier status changed.
+func announceCourierStatus(
+	ctx context.Context,
+	tx deliverytx.Repository,
+	courierID int64,
+	status domain.CourierStatus,
+	at time.Time,
+) error {
+	return tx.AppendOutbox(ctx, domain.OutboxEvent{
+		Type:          domain.OutboxCourierStatusChanged,
+		CourierID:     courierID,
+		CourierStatus: status,
+		OccurredAt:    at,
+	})
+}
@@ -0,0 +1,106 @@
+package delivery_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"course-go-avito-Orurh/internal/domain"
+	"course-go-avito-Orurh/internal/service/delivery"
+)
+
+func eventTypes(events []domain.OutboxEvent) []domain.OutboxEventType {
+	out := make([]domain.OutboxEventType, 0, len(events))
+	for _, ev := range events {
+		out = append(out, ev.Type)
+	}
+	return out
+}
+
+func TestService_Assign_RecordsOutboxEvents(t *testing.T) {
+	t.Parallel()
+
+	repo := NewMockdeliveryRepository(newCtrl(t))
+	tx := &stubTx{listFn: available(&domain.Courier{ID: 10, Status: domain.StatusAvailable, Capacity: ptr(1)})}
+	repo.EXPECT().WithTx(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, fn func(delivery.TxRepository) error) error { return fn(tx) })
+
+	_, err := newTestDeliveryService(repo, stubTimeFactory{fn: fixedDeadline}).Assign(context.Background(), "order_1", nil)
+	require.NoError(t, err)
+
+	require.Equal(t, []domain.OutboxEventType{
+		domain.OutboxDeliveryAssigned,
+		domain.OutboxCourierStatusChanged,
+	}, eventTypes(tx.outbox))
+
+	assigned := tx.outbox[0]
+	require.Equal(t, "order_1", assigned.OrderID)
+	require.Equal(t, int64(10), assigned.CourierID)
+	require.NotNil(t, assigned.Deadline)
+	require.True(t, assigned.Deadline.Equal(time.Date(2025, 1, 2, 15, 0, 0, 0, time.UTC)))
+	require.Equal(t, domain.StatusBusy, tx.outbox[1].CourierStatus)
+}
+
+func TestService_Complete_RecordsOutboxEvents(t *testing.T) {
+	t.Parallel()
+
+	repo := NewMockdeliveryRepository(newCtrl(t))
+	tx := &stubTx{
+		getFn: func(context.Context, string) (*domain.Delivery, error) {
+			return &domain.Delivery{ID: 1, CourierID: 10, OrderID: "order_1", Status: domain.DeliveryStatusPickedUp}, nil
+		},
+	}
+	repo.EXPECT().WithTx(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, fn func(delivery.TxRepository) error) error { return fn(tx) })
+
+	res, err := newTestDeliveryService(repo, stubTimeFactory{}).Complete(context.Background(), "order_1")
+	require.NoError(t, err)
+
+	require.Equal(t, []domain.OutboxEventType{
+		domain.OutboxDeliveryStatusChanged,
+		domain.OutboxCourierStatusChanged,
+	}, eventTypes(tx.outbox))
+	require.Equal(t, domain.DeliveryStatusDelivered, tx.outbox[0].DeliveryStatus)
+	require.True(t, res.At.Equal(tx.outbox[0].OccurredAt))
+	require.Equal(t, domain.StatusAvailable, tx.outbox[1].CourierStatus)
+}
+
+func TestService_ReleaseExpired_RecordsExpiredEvents(t *testing.T) {
+	t.Parallel()
+
+	repo := NewMockdeliveryRepository(newCtrl(t))
+	tx := &stubTx{
+		expireFn: func(context.Context, time.Time, int) ([]domain.Delivery, error) {
+			return []domain.Delivery{{ID: 7, CourierID: 1, OrderID: "order_1"}}, nil
+		},
+	}
+	repo.EXPECT().WithTx(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, fn func(delivery.TxRepository) error) error { return fn(tx) })
+
+	require.NoError(t, newTestDeliveryService(repo, stubTimeFactory{fn: fixedDeadline}).ReleaseExpired(context.Background()))
+
+	require.NotEmpty(t, tx.outbox)
+	require.Equal(t, domain.OutboxDeliveryStatusChanged, tx.outbox[0].Type)
+	require.Equal(t, domain.DeliveryStatusExpired, tx.outbox[0].DeliveryStatus)
+	require.Equal(t, "order_1", tx.outbox[0].OrderID)
+}
+
+func TestService_Assign_OutboxErrorAbortsTransaction(t *testing.T) {
+	t.Parallel()
+
+	repo := NewMockdeliveryRepository(newCtrl(t))
+	sentinel := errors.New("outbox down")
+	tx := &stubTx{
+		listFn:   available(&domain.Courier{ID: 10, Status: domain.StatusAvailable}),
+		outboxFn: func(context.Context, domain.OutboxEvent) error { return sentinel },
+	}
+	repo.EXPECT().WithTx(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, fn func(delivery.TxRepository) error) error { return fn(tx) })
+
+	_, err := newTestDeliveryService(repo, stubTimeFactory{fn: fixedDeadline}).Assign(context.Background(), "order_1", nil)
+	require.ErrorIs(t, err, sentinel)
+}
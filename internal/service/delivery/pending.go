@@ -0,0 +1,145 @@
+package delivery
+
+import (
+	"context"
+
+	"course-go-avito-Orurh/internal/apperr"
+	"course-go-avito-Orurh/internal/domain"
+	"course-go-avito-Orurh/internal/logx"
+	"course-go-avito-Orurh/internal/ports/deliverytx"
+)
+
+// Enqueue puts an order that could not be assigned into the pending queue.
+// Enqueueing an already queued order is a no-op.
+func (s *Service) Enqueue(ctx context.Context, orderID string, pickup *domain.Location) error {
+	orderID, err := validateOrderID(orderID)
+	if err != nil {
+		return err
+	}
+	if pickup != nil && !pickup.Valid() {
+		return apperr.ErrInvalid
+	}
+
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	p := domain.PendingOrder{OrderID: orderID, Pickup: pickup, EnqueuedAt: s.now()}
+	if err := s.repo.EnqueuePendingOrder(ctx, p); err != nil {
+		return err
+	}
+	s.logger.Info("order queued until a courier is available",
+		logx.String("event", "order_pending"),
+		logx.String("order_id", orderID),
+	)
+	return nil
+}
+
+// Dequeue removes an order from the pending queue, e.g. when the order is canceled.
+// It returns apperr.ErrNotFound if the order is not queued.
+func (s *Service) Dequeue(ctx context.Context, orderID string) error {
+	orderID, err := validateOrderID(orderID)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	ok, err := s.repo.DeletePendingOrder(ctx, orderID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return apperr.ErrNotFound
+	}
+	return nil
+}
+
+// DispatchPending assigns up to limit queued orders to available couriers,
+// highest priority and oldest first. Orders no available courier fits stay in the queue
+// and do not block the orders behind them. It returns the assigned orders.
+func (s *Service) DispatchPending(ctx context.Context, limit int) ([]domain.AssignResult, error) {
+	if limit <= 0 {
+		return nil, apperr.ErrInvalid
+	}
+
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	var results []domain.AssignResult
+	err := s.repo.WithTx(ctx, func(tx deliverytx.Repository) error {
+		results = nil
+
+		pending, err := tx.ListPendingOrdersForUpdate(ctx, limit)
+		if err != nil || len(pending) == 0 {
+			return err
+		}
+		candidates, err := tx.ListAvailableCouriers(ctx)
+		if err != nil {
+			return err
+		}
+		candidates = s.withFreeSlots(candidates)
+
+		for _, p := range pending {
+			if len(candidates) == 0 {
+				break
+			}
+			r, rest, ok, err := s.dispatchOne(ctx, tx, p, candidates)
+			if err != nil {
+				return err
+			}
+			candidates = rest
+			if ok {
+				results = append(results, r)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, r := range results {
+		s.logAssigned(r)
+	}
+	return results, nil
+}
+
+// dispatchOne assigns a single pending order and drops it from the queue.
+// Orders that already got an active delivery in the meantime are dropped without assigning.
+// The excluded courier of the order is not offered to the strategy but stays a candidate for other orders.
+func (s *Service) dispatchOne(
+	ctx context.Context,
+	tx deliverytx.Repository,
+	p domain.PendingOrder,
+	candidates []domain.CourierCandidate,
+) (domain.AssignResult, []domain.CourierCandidate, bool, error) {
+	d, err := tx.GetByOrderID(ctx, p.OrderID)
+	if err != nil {
+		return domain.AssignResult{}, nil, false, err
+	}
+	if d != nil && d.Status.Active() {
+		return domain.AssignResult{}, candidates, false, tx.DeletePendingOrder(ctx, p.OrderID)
+	}
+
+	eligible, excluded := candidates, []domain.CourierCandidate(nil)
+	if p.ExcludedCourierID != nil {
+		eligible, excluded = splitCourier(candidates, *p.ExcludedCourierID)
+	}
+	c, rest, err := s.findCourier(ctx, tx, p.Pickup, eligible)
+	if err != nil {
+		return domain.AssignResult{}, nil, false, err
+	}
+	rest = mergeByID(rest, excluded)
+	if c == nil {
+		return domain.AssignResult{}, rest, false, nil
+	}
+	r, err := s.assignTo(ctx, tx, p.OrderID, p.Pickup, c)
+	if err != nil {
+		return domain.AssignResult{}, nil, false, err
+	}
+	if err := tx.DeletePendingOrder(ctx, p.OrderID); err != nil {
+		return domain.AssignResult{}, nil, false, err
+	}
+	return r, s.takeSlot(rest, c.Courier.ID, s.now()), true, nil
+}
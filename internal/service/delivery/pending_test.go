@@ -0,0 +1,259 @@
+package delivery_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"course-go-avito-Orurh/internal/apperr"
+	"course-go-avito-Orurh/internal/domain"
+	"course-go-avito-Orurh/internal/service/delivery"
+)
+
+func fixedDeadline(domain.CourierTransportType, time.Time) (time.Time, error) {
+	return time.Date(2025, 1, 2, 15, 0, 0, 0, time.UTC), nil
+}
+
+func TestService_Enqueue(t *testing.T) {
+	t.Parallel()
+
+	repo := NewMockdeliveryRepository(newCtrl(t))
+	pickup := &domain.Location{Lat: 55.75, Lon: 37.61}
+
+	repo.EXPECT().EnqueuePendingOrder(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, p domain.PendingOrder) error {
+			require.Equal(t, "order_1", p.OrderID)
+			require.Equal(t, pickup, p.Pickup)
+			require.False(t, p.EnqueuedAt.IsZero())
+			return nil
+		})
+
+	svc := newTestDeliveryService(repo, stubTimeFactory{})
+
+	require.NoError(t, svc.Enqueue(context.Background(), " order_1 ", pickup))
+	require.ErrorIs(t, svc.Enqueue(context.Background(), " ", nil), apperr.ErrInvalid)
+	require.ErrorIs(t, svc.Enqueue(context.Background(), "order_2", &domain.Location{Lat: 91}), apperr.ErrInvalid)
+}
+
+func TestService_Dequeue(t *testing.T) {
+	t.Parallel()
+
+	repo := NewMockdeliveryRepository(newCtrl(t))
+	repo.EXPECT().DeletePendingOrder(gomock.Any(), "order_1").Return(true, nil)
+	repo.EXPECT().DeletePendingOrder(gomock.Any(), "order_2").Return(false, nil)
+
+	svc := newTestDeliveryService(repo, stubTimeFactory{})
+
+	require.NoError(t, svc.Dequeue(context.Background(), "order_1"))
+	require.ErrorIs(t, svc.Dequeue(context.Background(), "order_2"), apperr.ErrNotFound)
+}
+
+func TestService_DispatchPending_AssignsInQueueOrder(t *testing.T) {
+	t.Parallel()
+
+	repo := NewMockdeliveryRepository(newCtrl(t))
+
+	var inserted, dropped []string
+	repo.EXPECT().WithTx(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, fn func(delivery.TxRepository) error) error {
+			return fn(&stubTx{
+				pendFn: func(_ context.Context, limit int) ([]domain.PendingOrder, error) {
+					require.Equal(t, 10, limit)
+					return []domain.PendingOrder{
+						{OrderID: "first"},
+						{OrderID: "second"},
+						{OrderID: "third"},
+					}, nil
+				},
+				listFn: available(
+					&domain.Courier{ID: 1, TransportType: domain.TransportTypeFoot},
+					&domain.Courier{ID: 2, TransportType: domain.TransportTypeFoot},
+				),
+				insertFn: func(_ context.Context, d *domain.Delivery) error {
+					inserted = append(inserted, d.OrderID)
+					return nil
+				},
+				dropFn: func(_ context.Context, orderID string) error {
+					dropped = append(dropped, orderID)
+					return nil
+				},
+			})
+		})
+
+	svc := newTestDeliveryService(repo, stubTimeFactory{fn: fixedDeadline})
+
+	res, err := svc.DispatchPending(context.Background(), 10)
+	require.NoError(t, err)
+	require.Len(t, res, 2)
+	require.Equal(t, []string{"first", "second"}, inserted)
+	require.Equal(t, []string{"first", "second"}, dropped, "orders without a courier stay queued")
+	require.NotEqual(t, res[0].CourierID, res[1].CourierID)
+}
+
+func TestService_DispatchPending_FillsCourierCapacity(t *testing.T) {
+	t.Parallel()
+
+	repo := NewMockdeliveryRepository(newCtrl(t))
+
+	capacity := 2
+	var (
+		inserted []int64
+		statuses []domain.CourierStatus
+	)
+	repo.EXPECT().WithTx(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, fn func(delivery.TxRepository) error) error {
+			return fn(&stubTx{
+				pendFn: func(context.Context, int) ([]domain.PendingOrder, error) {
+					return []domain.PendingOrder{{OrderID: "a"}, {OrderID: "b"}, {OrderID: "c"}}, nil
+				},
+				listFn: available(&domain.Courier{
+					ID: 1, Status: domain.StatusAvailable, TransportType: domain.TransportTypeCar, Capacity: &capacity,
+				}),
+				insertFn: func(_ context.Context, d *domain.Delivery) error {
+					inserted = append(inserted, d.CourierID)
+					return nil
+				},
+				updFn: func(_ context.Context, _ int64, st domain.CourierStatus) error {
+					statuses = append(statuses, st)
+					return nil
+				},
+			})
+		})
+
+	svc := newTestDeliveryService(repo, stubTimeFactory{fn: fixedDeadline})
+
+	res, err := svc.DispatchPending(context.Background(), 10)
+	require.NoError(t, err)
+	require.Len(t, res, 2, "the courier override caps the car at two orders")
+	require.Equal(t, []int64{1, 1}, inserted)
+	require.Equal(t, []domain.CourierStatus{domain.StatusBusy}, statuses, "busy only once the last slot is taken")
+}
+
+func TestService_DispatchPending_SkipsOrdersNoCourierFits(t *testing.T) {
+	t.Parallel()
+
+	repo := NewMockdeliveryRepository(newCtrl(t))
+
+	far := &domain.Location{Lat: 10, Lon: 10}
+	var inserted []string
+	repo.EXPECT().WithTx(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, fn func(delivery.TxRepository) error) error {
+			return fn(&stubTx{
+				pendFn: func(context.Context, int) ([]domain.PendingOrder, error) {
+					return []domain.PendingOrder{
+						{OrderID: "far", Pickup: far},
+						{OrderID: "anywhere"},
+					}, nil
+				},
+				listFn: func(context.Context) ([]domain.CourierCandidate, error) {
+					return []domain.CourierCandidate{at(candidate(1, domain.TransportTypeFoot), 55.75, 37.61)}, nil
+				},
+				insertFn: func(_ context.Context, d *domain.Delivery) error {
+					inserted = append(inserted, d.OrderID)
+					return nil
+				},
+			})
+		})
+
+	svc := newTestDeliveryService(repo, stubTimeFactory{fn: fixedDeadline})
+
+	res, err := svc.DispatchPending(context.Background(), 10)
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	require.Equal(t, []string{"anywhere"}, inserted)
+}
+
+func TestService_DispatchPending_SkipsExcludedCourier(t *testing.T) {
+	t.Parallel()
+
+	repo := NewMockdeliveryRepository(newCtrl(t))
+
+	excluded := int64(1)
+	assigned := make(map[string]int64)
+	repo.EXPECT().WithTx(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, fn func(delivery.TxRepository) error) error {
+			return fn(&stubTx{
+				pendFn: func(context.Context, int) ([]domain.PendingOrder, error) {
+					return []domain.PendingOrder{
+						{OrderID: "expired", ExcludedCourierID: &excluded},
+						{OrderID: "new"},
+					}, nil
+				},
+				listFn: available(&domain.Courier{ID: 1, TransportType: domain.TransportTypeFoot}),
+				insertFn: func(_ context.Context, d *domain.Delivery) error {
+					assigned[d.OrderID] = d.CourierID
+					return nil
+				},
+			})
+		})
+
+	svc := newTestDeliveryService(repo, stubTimeFactory{fn: fixedDeadline})
+
+	res, err := svc.DispatchPending(context.Background(), 10)
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	require.Equal(t, map[string]int64{"new": 1}, assigned,
+		"the order is not given back to the courier it expired with, who still takes other orders")
+}
+
+func TestService_DispatchPending_DropsAlreadyAssignedOrder(t *testing.T) {
+	t.Parallel()
+
+	repo := NewMockdeliveryRepository(newCtrl(t))
+
+	var dropped []string
+	repo.EXPECT().WithTx(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, fn func(delivery.TxRepository) error) error {
+			return fn(&stubTx{
+				pendFn: func(context.Context, int) ([]domain.PendingOrder, error) {
+					return []domain.PendingOrder{{OrderID: "order_1"}}, nil
+				},
+				listFn: available(&domain.Courier{ID: 1}),
+				getFn: func(context.Context, string) (*domain.Delivery, error) {
+					return &domain.Delivery{OrderID: "order_1", Status: domain.DeliveryStatusAssigned}, nil
+				},
+				insertFn: func(context.Context, *domain.Delivery) error {
+					t.Fatal("already assigned order must not be assigned again")
+					return nil
+				},
+				dropFn: func(_ context.Context, orderID string) error {
+					dropped = append(dropped, orderID)
+					return nil
+				},
+			})
+		})
+
+	svc := newTestDeliveryService(repo, stubTimeFactory{fn: fixedDeadline})
+
+	res, err := svc.DispatchPending(context.Background(), 10)
+	require.NoError(t, err)
+	require.Empty(t, res)
+	require.Equal(t, []string{"order_1"}, dropped)
+}
+
+func TestService_DispatchPending_Errors(t *testing.T) {
+	t.Parallel()
+
+	repo := NewMockdeliveryRepository(newCtrl(t))
+	svc := newTestDeliveryService(repo, stubTimeFactory{fn: fixedDeadline})
+
+	_, err := svc.DispatchPending(context.Background(), 0)
+	require.ErrorIs(t, err, apperr.ErrInvalid)
+
+	wantErr := errors.New("boom")
+	repo.EXPECT().WithTx(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, fn func(delivery.TxRepository) error) error {
+			return fn(&stubTx{
+				pendFn: func(context.Context, int) ([]domain.PendingOrder, error) {
+					return nil, wantErr
+				},
+			})
+		})
+
+	_, err = svc.DispatchPending(context.Background(), 10)
+	require.ErrorIs(t, err, wantErr)
+}
@@ -0,0 +1,81 @@
+package delivery
+
+import (
+	"context"
+
+	"course-go-avito-Orurh/internal/apperr"
+	"course-go-avito-Orurh/internal/domain"
+)
+
+const (
+	defaultPageLimit = 50
+	maxPageLimit     = 500
+)
+
+// page applies the default limit to an unset one and rejects negative or too large values.
+func page(limit, offset *int) (int, int, error) {
+	l, o := defaultPageLimit, 0
+	if limit != nil {
+		l = *limit
+	}
+	if offset != nil {
+		o = *offset
+	}
+	if l <= 0 || l > maxPageLimit || o < 0 {
+		return 0, 0, apperr.ErrInvalid
+	}
+	return l, o, nil
+}
+
+// Get returns the latest delivery of the order.
+func (s *Service) Get(ctx context.Context, orderID string) (*domain.Delivery, error) {
+	orderID, err := validateOrderID(orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	d, err := s.repo.GetByOrderID(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if d == nil {
+		return nil, apperr.ErrNotFound
+	}
+	return d, nil
+}
+
+// ListByCourier returns a page of the courier's deliveries, newest first.
+// activeOnly limits the page to deliveries still in progress.
+func (s *Service) ListByCourier(
+	ctx context.Context,
+	courierID int64,
+	activeOnly bool,
+	limit, offset *int,
+) ([]domain.Delivery, error) {
+	if courierID <= 0 {
+		return nil, apperr.ErrInvalid
+	}
+	l, o, err := page(limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	return s.repo.ListByCourier(ctx, courierID, activeOnly, l, o)
+}
+
+// ListOverdue returns a page of active deliveries past their deadline, the most overdue first.
+func (s *Service) ListOverdue(ctx context.Context, limit, offset *int) ([]domain.Delivery, error) {
+	l, o, err := page(limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	return s.repo.ListOverdue(ctx, s.now(), l, o)
+}
@@ -0,0 +1,77 @@
+package delivery_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"course-go-avito-Orurh/internal/apperr"
+	"course-go-avito-Orurh/internal/domain"
+)
+
+func TestService_Get(t *testing.T) {
+	t.Parallel()
+
+	repo := NewMockdeliveryRepository(newCtrl(t))
+	want := &domain.Delivery{ID: 1, OrderID: "order_1", CourierID: 10, Status: domain.DeliveryStatusAssigned}
+	repo.EXPECT().GetByOrderID(gomock.Any(), "order_1").Return(want, nil)
+	repo.EXPECT().GetByOrderID(gomock.Any(), "missing").Return(nil, nil)
+
+	svc := newTestDeliveryService(repo, stubTimeFactory{})
+
+	got, err := svc.Get(context.Background(), " order_1 ")
+	require.NoError(t, err)
+	require.Equal(t, want, got)
+
+	_, err = svc.Get(context.Background(), "missing")
+	require.ErrorIs(t, err, apperr.ErrNotFound)
+
+	_, err = svc.Get(context.Background(), "  ")
+	require.ErrorIs(t, err, apperr.ErrInvalid)
+}
+
+func TestService_ListByCourier_Pagination(t *testing.T) {
+	t.Parallel()
+
+	repo := NewMockdeliveryRepository(newCtrl(t))
+	repo.EXPECT().ListByCourier(gomock.Any(), int64(10), false, 50, 0).Return(nil, nil)
+	repo.EXPECT().ListByCourier(gomock.Any(), int64(10), true, 5, 20).
+		Return([]domain.Delivery{{ID: 3, CourierID: 10}}, nil)
+
+	svc := newTestDeliveryService(repo, stubTimeFactory{})
+
+	_, err := svc.ListByCourier(context.Background(), 10, false, nil, nil)
+	require.NoError(t, err, "the default page is used without limit/offset")
+
+	got, err := svc.ListByCourier(context.Background(), 10, true, ptr(5), ptr(20))
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+
+	for _, bad := range []struct{ limit, offset *int }{
+		{ptr(0), nil}, {ptr(501), nil}, {nil, ptr(-1)},
+	} {
+		_, err = svc.ListByCourier(context.Background(), 10, false, bad.limit, bad.offset)
+		require.ErrorIs(t, err, apperr.ErrInvalid)
+	}
+	_, err = svc.ListByCourier(context.Background(), 0, false, nil, nil)
+	require.ErrorIs(t, err, apperr.ErrInvalid)
+}
+
+func TestService_ListOverdue_UsesCurrentTime(t *testing.T) {
+	t.Parallel()
+
+	repo := NewMockdeliveryRepository(newCtrl(t))
+	before := time.Now().UTC()
+	repo.EXPECT().ListOverdue(gomock.Any(), gomock.Any(), 50, 0).
+		DoAndReturn(func(_ context.Context, now time.Time, _, _ int) ([]domain.Delivery, error) {
+			require.False(t, now.Before(before))
+			return []domain.Delivery{{ID: 1}}, nil
+		})
+
+	got, err := newTestDeliveryService(repo, stubTimeFactory{}).ListOverdue(context.Background(), nil, nil)
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+}
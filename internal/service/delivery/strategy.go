@@ -0,0 +1,196 @@
+package delivery
+
+import (
+	"fmt"
+	"strings"
+
+	"course-go-avito-Orurh/internal/domain"
+)
+
+// Supported assignment strategy names.
+const (
+	StrategyLeastLoaded = "least_loaded"
+	StrategyRoundRobin  = "round_robin"
+	StrategyNearest     = "nearest"
+	StrategyWeighted    = "weighted"
+)
+
+// NewAssignmentStrategy builds the strategy registered under name, an empty name means nearest.
+// radiusKm limits the distance to the pickup point for location-aware strategies.
+func NewAssignmentStrategy(name string, radiusKm float64) (AssignmentStrategy, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case StrategyLeastLoaded:
+		return LeastLoadedStrategy{}, nil
+	case StrategyRoundRobin:
+		return RoundRobinStrategy{}, nil
+	case "", StrategyNearest:
+		return NewNearestStrategy(radiusKm), nil
+	case StrategyWeighted:
+		return NewWeightedStrategy(radiusKm), nil
+	default:
+		return nil, fmt.Errorf("unknown assignment strategy %q", name)
+	}
+}
+
+// pickBest returns the index of the candidate for which better reports true against every other one.
+// Candidates rejected by accept are skipped.
+func pickBest(
+	candidates []domain.CourierCandidate,
+	accept func(domain.CourierCandidate) bool,
+	better func(a, b domain.CourierCandidate) bool,
+) (int, bool) {
+	best := -1
+	for i, c := range candidates {
+		if accept != nil && !accept(c) {
+			continue
+		}
+		if best < 0 || better(c, candidates[best]) {
+			best = i
+		}
+	}
+	return best, best >= 0
+}
+
+// LeastLoadedStrategy prefers the courier with the fewest deliveries overall.
+type LeastLoadedStrategy struct{}
+
+// Name returns the strategy name.
+func (LeastLoadedStrategy) Name() string { return StrategyLeastLoaded }
+
+// Choose picks the candidate with the fewest deliveries, ties are broken by courier ID.
+func (LeastLoadedStrategy) Choose(_ *domain.Location, candidates []domain.CourierCandidate) (int, bool) {
+	return pickBest(candidates, nil, lessLoaded)
+}
+
+func lessLoaded(a, b domain.CourierCandidate) bool {
+	if a.TotalDeliveries != b.TotalDeliveries {
+		return a.TotalDeliveries < b.TotalDeliveries
+	}
+	return a.Courier.ID < b.Courier.ID
+}
+
+// RoundRobinStrategy rotates orders between couriers: the one who waited the longest since
+// the last assignment goes first. The rotation is derived from stored deliveries,
+// so it stays consistent across service instances.
+type RoundRobinStrategy struct{}
+
+// Name returns the strategy name.
+func (RoundRobinStrategy) Name() string { return StrategyRoundRobin }
+
+// Choose picks the least recently assigned candidate; never assigned couriers go first.
+func (RoundRobinStrategy) Choose(_ *domain.Location, candidates []domain.CourierCandidate) (int, bool) {
+	return pickBest(candidates, nil, func(a, b domain.CourierCandidate) bool {
+		switch {
+		case a.LastAssignedAt == nil && b.LastAssignedAt != nil:
+			return true
+		case a.LastAssignedAt != nil && b.LastAssignedAt == nil:
+			return false
+		case a.LastAssignedAt != nil && !a.LastAssignedAt.Equal(*b.LastAssignedAt):
+			return a.LastAssignedAt.Before(*b.LastAssignedAt)
+		}
+		return a.Courier.ID < b.Courier.ID
+	})
+}
+
+// NearestStrategy prefers the courier closest to the pickup point within a radius.
+// Couriers that never reported a location rank after every courier within the radius,
+// the least loaded of them first. Orders without pickup coordinates fall back to LeastLoadedStrategy.
+type NearestStrategy struct {
+	radiusKm float64
+	fallback AssignmentStrategy
+}
+
+// NewNearestStrategy creates a NearestStrategy limited by radiusKm (the default radius if not positive).
+func NewNearestStrategy(radiusKm float64) NearestStrategy {
+	if radiusKm <= 0 {
+		radiusKm = defaultAssignRadiusKm
+	}
+	return NearestStrategy{radiusKm: radiusKm, fallback: LeastLoadedStrategy{}}
+}
+
+// Name returns the strategy name.
+func (NearestStrategy) Name() string { return StrategyNearest }
+
+// Choose picks the closest candidate with a known location within the radius, or else the least
+// loaded candidate without a location. Couriers known to be outside the radius are never chosen.
+func (s NearestStrategy) Choose(pickup *domain.Location, candidates []domain.CourierCandidate) (int, bool) {
+	if pickup == nil {
+		return s.fallback.Choose(nil, candidates)
+	}
+	dist := func(c domain.CourierCandidate) float64 { return c.Location.DistanceKm(*pickup) }
+	if i, ok := pickBest(candidates,
+		func(c domain.CourierCandidate) bool { return c.Location != nil && dist(c) <= s.radiusKm },
+		func(a, b domain.CourierCandidate) bool {
+			da, db := dist(a), dist(b)
+			if da != db {
+				return da < db
+			}
+			return a.Courier.ID < b.Courier.ID
+		},
+	); ok {
+		return i, true
+	}
+	return pickBest(candidates, func(c domain.CourierCandidate) bool { return c.Location == nil }, lessLoaded)
+}
+
+// WeightedStrategy scores candidates by transport, current workload and distance
+// to the pickup point; the lowest score wins.
+type WeightedStrategy struct {
+	radiusKm float64
+	// transport is the penalty per transport type, faster transport is cheaper
+	transport map[domain.CourierTransportType]float64
+	// perActive is the penalty per active delivery
+	perActive float64
+	// perKm is the penalty per kilometer to the pickup point
+	perKm float64
+}
+
+// NewWeightedStrategy creates a WeightedStrategy with default weights.
+// Couriers further than radiusKm (the default radius if not positive) from the pickup point are not considered.
+func NewWeightedStrategy(radiusKm float64) WeightedStrategy {
+	if radiusKm <= 0 {
+		radiusKm = defaultAssignRadiusKm
+	}
+	return WeightedStrategy{
+		radiusKm: radiusKm,
+		transport: map[domain.CourierTransportType]float64{
+			domain.TransportTypeCar:     0,
+			domain.TransportTypeScooter: 1,
+			domain.TransportTypeFoot:    2,
+		},
+		perActive: 5,
+		perKm:     1,
+	}
+}
+
+// Name returns the strategy name.
+func (WeightedStrategy) Name() string { return StrategyWeighted }
+
+// Choose picks the candidate with the lowest score, ties are broken by courier ID.
+func (s WeightedStrategy) Choose(pickup *domain.Location, candidates []domain.CourierCandidate) (int, bool) {
+	return pickBest(candidates,
+		func(c domain.CourierCandidate) bool {
+			return pickup == nil || c.Location == nil || c.Location.DistanceKm(*pickup) <= s.radiusKm
+		},
+		func(a, b domain.CourierCandidate) bool {
+			sa, sb := s.score(pickup, a), s.score(pickup, b)
+			if sa != sb {
+				return sa < sb
+			}
+			return a.Courier.ID < b.Courier.ID
+		},
+	)
+}
+
+func (s WeightedStrategy) score(pickup *domain.Location, c domain.CourierCandidate) float64 {
+	score := s.transport[c.Courier.TransportType] + s.perActive*float64(c.ActiveDeliveries)
+	if pickup == nil {
+		return score
+	}
+	// an unknown location counts as the worst distance still within the radius
+	km := s.radiusKm
+	if c.Location != nil {
+		km = c.Location.DistanceKm(*pickup)
+	}
+	return score + s.perKm*km
+}
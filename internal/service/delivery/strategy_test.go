@@ -0,0 +1,156 @@
+package delivery_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"course-go-avito-Orurh/internal/domain"
+	"course-go-avito-Orurh/internal/service/delivery"
+)
+
+func candidate(id int64, transport domain.CourierTransportType) domain.CourierCandidate {
+	return domain.CourierCandidate{
+		Courier: domain.Courier{ID: id, Status: domain.StatusAvailable, TransportType: transport},
+	}
+}
+
+func at(c domain.CourierCandidate, lat, lon float64) domain.CourierCandidate {
+	c.Location = &domain.Location{Lat: lat, Lon: lon}
+	return c
+}
+
+func chosenID(t *testing.T, st delivery.AssignmentStrategy, pickup *domain.Location, cs []domain.CourierCandidate) int64 {
+	t.Helper()
+	i, ok := st.Choose(pickup, cs)
+	require.True(t, ok, "expected a candidate to be chosen")
+	return cs[i].Courier.ID
+}
+
+// Moscow center; 0.01 degree of latitude is ~1.1 km.
+var pickup = &domain.Location{Lat: 55.7558, Lon: 37.6173}
+
+func TestNewAssignmentStrategy(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]string{
+		"least_loaded":  delivery.StrategyLeastLoaded,
+		" Round_Robin ": delivery.StrategyRoundRobin,
+		"nearest":       delivery.StrategyNearest,
+		"":              delivery.StrategyNearest,
+		"WEIGHTED":      delivery.StrategyWeighted,
+	}
+	for in, want := range cases {
+		st, err := delivery.NewAssignmentStrategy(in, 3)
+		require.NoError(t, err, in)
+		require.Equal(t, want, st.Name(), in)
+	}
+
+	_, err := delivery.NewAssignmentStrategy("random", 3)
+	require.Error(t, err)
+}
+
+func TestLeastLoadedStrategy_Choose(t *testing.T) {
+	t.Parallel()
+
+	st := delivery.LeastLoadedStrategy{}
+
+	_, ok := st.Choose(pickup, nil)
+	require.False(t, ok)
+
+	busy := candidate(1, domain.TransportTypeCar)
+	busy.TotalDeliveries = 5
+	idle := candidate(2, domain.TransportTypeFoot)
+	idle.TotalDeliveries = 1
+	idleToo := candidate(3, domain.TransportTypeFoot)
+	idleToo.TotalDeliveries = 1
+
+	require.Equal(t, int64(2), chosenID(t, st, nil, []domain.CourierCandidate{busy, idleToo, idle}))
+}
+
+func TestRoundRobinStrategy_Choose(t *testing.T) {
+	t.Parallel()
+
+	st := delivery.RoundRobinStrategy{}
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	recent := candidate(1, domain.TransportTypeFoot)
+	recent.LastAssignedAt = ptr(now)
+	older := candidate(2, domain.TransportTypeFoot)
+	older.LastAssignedAt = ptr(now.Add(-time.Hour))
+
+	require.Equal(t, int64(2), chosenID(t, st, nil, []domain.CourierCandidate{recent, older}))
+
+	never := candidate(3, domain.TransportTypeFoot)
+	require.Equal(t, int64(3), chosenID(t, st, nil, []domain.CourierCandidate{recent, older, never}))
+
+	sameTime := candidate(0, domain.TransportTypeFoot)
+	sameTime.LastAssignedAt = ptr(now.Add(-time.Hour))
+	require.Equal(t, int64(0), chosenID(t, st, nil, []domain.CourierCandidate{older, sameTime}))
+}
+
+func TestNearestStrategy_Choose(t *testing.T) {
+	t.Parallel()
+
+	st := delivery.NewNearestStrategy(5)
+
+	far := at(candidate(1, domain.TransportTypeCar), 55.7800, 37.6173)     // ~2.7 km
+	near := at(candidate(2, domain.TransportTypeFoot), 55.7560, 37.6180)   // ~50 m
+	outside := at(candidate(3, domain.TransportTypeCar), 55.9000, 37.6173) // ~16 km
+	unknown := candidate(4, domain.TransportTypeCar)
+
+	require.Equal(t, int64(2), chosenID(t, st, pickup, []domain.CourierCandidate{far, outside, unknown, near}))
+
+	_, ok := st.Choose(pickup, []domain.CourierCandidate{outside})
+	require.False(t, ok, "couriers outside the radius must not be chosen")
+	require.Equal(t, int64(4), chosenID(t, st, pickup, []domain.CourierCandidate{outside, unknown}),
+		"a courier without location ranks after those within the radius, not out of the choice")
+
+	// without pickup coordinates the least loaded courier is chosen
+	far.TotalDeliveries = 3
+	require.Equal(t, int64(2), chosenID(t, st, nil, []domain.CourierCandidate{far, near}))
+}
+
+func TestNearestStrategy_NoCandidateHasLocation(t *testing.T) {
+	t.Parallel()
+
+	st, err := delivery.NewAssignmentStrategy("", 5)
+	require.NoError(t, err)
+
+	loaded := candidate(1, domain.TransportTypeCar)
+	loaded.TotalDeliveries = 4
+	fresh := candidate(2, domain.TransportTypeFoot)
+	freshToo := candidate(3, domain.TransportTypeFoot)
+
+	require.Equal(t, int64(2), chosenID(t, st, pickup, []domain.CourierCandidate{loaded, freshToo, fresh}),
+		"the least loaded courier is chosen when nobody reported a location")
+}
+
+func TestWeightedStrategy_Choose(t *testing.T) {
+	t.Parallel()
+
+	st := delivery.NewWeightedStrategy(5)
+
+	foot := at(candidate(1, domain.TransportTypeFoot), 55.7558, 37.6173)
+	car := at(candidate(2, domain.TransportTypeCar), 55.7558, 37.6173)
+	require.Equal(t, int64(2), chosenID(t, st, pickup, []domain.CourierCandidate{foot, car}),
+		"faster transport wins at the same distance and load")
+
+	car.ActiveDeliveries = 1
+	require.Equal(t, int64(1), chosenID(t, st, pickup, []domain.CourierCandidate{foot, car}),
+		"an active delivery outweighs the transport bonus")
+
+	farCar := at(candidate(3, domain.TransportTypeCar), 55.7900, 37.6173) // ~3.8 km
+	require.Equal(t, int64(1), chosenID(t, st, pickup, []domain.CourierCandidate{foot, farCar}),
+		"distance outweighs the transport bonus")
+
+	outside := at(candidate(4, domain.TransportTypeCar), 55.9000, 37.6173)
+	_, ok := st.Choose(pickup, []domain.CourierCandidate{outside})
+	require.False(t, ok)
+
+	require.Equal(t, int64(4), chosenID(t, st, nil, []domain.CourierCandidate{foot, outside}),
+		"distance is ignored without pickup coordinates")
+}
+
+func ptr[T any](v T) *T { return &v }
@@ -2,6 +2,7 @@ package delivery
 
 import (
 	"context"
+	"slices"
 	"strings"
 	"time"
 
@@ -15,72 +16,195 @@ import (
 type Service struct {
 	repo             deliveryRepository
 	factory          TimeFactory
+	strategy         AssignmentStrategy
+	types            TransportTypes
 	operationTimeout time.Duration
 	logger           logx.Logger
 	now              func() time.Time
+	// courierAvailable is called after a courier may have become available for new orders
+	courierAvailable func()
+	reassigned       counter
+	overduePickedUp  gauge
 }
 
+const defaultAssignRadiusKm = 5.0
+
 func (s *Service) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
 	return context.WithTimeout(ctx, s.operationTimeout)
 }
 
 // NewDeliveryService - creates a new DeliveryService.
-func NewDeliveryService(r deliveryRepository, f TimeFactory, timeout time.Duration, logger logx.Logger) *Service {
+// A nil strategy means NearestStrategy with the default radius.
+func NewDeliveryService(
+	r deliveryRepository,
+	f TimeFactory,
+	st AssignmentStrategy,
+	timeout time.Duration,
+	logger logx.Logger,
+) *Service {
 	if timeout <= 0 {
 		timeout = 3 * time.Second
 	}
+	if st == nil {
+		st = NewNearestStrategy(defaultAssignRadiusKm)
+	}
 	return &Service{
 		repo:             r,
 		factory:          f,
+		strategy:         st,
+		types:            builtinTransportTypes(),
 		operationTimeout: timeout,
 		logger:           logger,
 		now:              func() time.Time { return time.Now().UTC() },
+		courierAvailable: func() {},
+	}
+}
+
+// WithCourierAvailableHook sets fn to be called whenever the service frees a courier.
+func (s *Service) WithCourierAvailableHook(fn func()) *Service {
+	if fn != nil {
+		s.courierAvailable = fn
 	}
+	return s
+}
+
+// WithTransportTypes sets the transport types courier capacity is taken from, the built-in ones by default.
+func (s *Service) WithTransportTypes(types TransportTypes) *Service {
+	if types != nil {
+		s.types = types
+	}
+	return s
+}
+
+// WithReassignCounter sets the counter of orders reassigned after their delivery expired.
+func (s *Service) WithReassignCounter(c counter) *Service {
+	s.reassigned = c
+	return s
+}
+
+// WithOverduePickedUpGauge sets the gauge of picked up deliveries past their deadline.
+func (s *Service) WithOverduePickedUpGauge(g gauge) *Service {
+	s.overduePickedUp = g
+	return s
 }
 
-// Assign assigns a delivery to a courier.
-func (s *Service) Assign(ctx context.Context, orderID string) (domain.AssignResult, error) {
+// Assign assigns a delivery to a courier chosen by the assignment strategy.
+// pickup is optional and is used by location-aware strategies.
+func (s *Service) Assign(ctx context.Context, orderID string, pickup *domain.Location) (domain.AssignResult, error) {
 	orderID, err := validateOrderID(orderID)
 	if err != nil {
 		return domain.AssignResult{}, err
 	}
+	if pickup != nil && !pickup.Valid() {
+		return domain.AssignResult{}, apperr.ErrInvalid
+	}
 
 	ctx, cancel := s.withTimeout(ctx)
 	defer cancel()
 	var result domain.AssignResult
 	err = s.repo.WithTx(ctx, func(tx deliverytx.Repository) error {
-		c, err := tx.FindAvailableCourierForUpdate(ctx)
+		candidates, err := tx.ListAvailableCouriers(ctx)
 		if err != nil {
 			return err
 		}
-		if c == nil {
-			return apperr.ErrConflict
-		}
-
-		now := s.now()
-		deadline, err := s.factory.Deadline(domain.CourierTransportType(c.TransportType), now)
+		c, _, err := s.findCourier(ctx, tx, pickup, s.withFreeSlots(candidates))
 		if err != nil {
 			return err
 		}
+		if c == nil {
+			return apperr.ErrNoCourierAvailable
+		}
 
-		d, r := buildAssign(now, deadline, orderID, c)
+		result, err = s.assignTo(ctx, tx, orderID, pickup, c)
+		return err
+	})
+	if err != nil {
+		return domain.AssignResult{}, err
+	}
 
-		if err := tx.InsertDelivery(ctx, d); err != nil {
-			return err
+	s.logAssigned(result)
+	return result, nil
+}
+
+// findCourier lets the strategy choose among candidates and locks the chosen courier.
+// If the chosen courier was taken by a concurrent assignment, the strategy chooses again without it.
+// The chosen candidate carries the locked courier row; the candidates left after the taken ones
+// are removed are returned as well.
+func (s *Service) findCourier(
+	ctx context.Context,
+	tx deliverytx.Repository,
+	pickup *domain.Location,
+	candidates []domain.CourierCandidate,
+) (*domain.CourierCandidate, []domain.CourierCandidate, error) {
+	for len(candidates) > 0 {
+		i, ok := s.strategy.Choose(pickup, candidates)
+		if !ok {
+			return nil, candidates, nil
 		}
-		if err := tx.UpdateCourierStatus(ctx, c.ID, domain.StatusBusy); err != nil {
-			return err
+		c, err := tx.LockAvailableCourier(ctx, candidates[i].Courier.ID)
+		if err != nil {
+			return nil, nil, err
 		}
+		if c != nil {
+			chosen := candidates[i]
+			chosen.Courier = *c
+			return &chosen, candidates, nil
+		}
+		candidates = slices.Delete(candidates, i, i+1)
+	}
+	return nil, candidates, nil
+}
 
-		result = r
-		return nil
+// assignTo creates the delivery of the order for the locked courier and marks the courier busy
+// once it has no free slots left.
+func (s *Service) assignTo(
+	ctx context.Context,
+	tx deliverytx.Repository,
+	orderID string,
+	pickup *domain.Location,
+	cand *domain.CourierCandidate,
+) (domain.AssignResult, error) {
+	c := &cand.Courier
+	now := s.now()
+	deadline, err := s.deadline(DeadlineInput{
+		Transport:       c.TransportType,
+		Now:             now,
+		Pickup:          pickup,
+		CourierLocation: cand.Location,
 	})
 	if err != nil {
 		return domain.AssignResult{}, err
 	}
 
-	s.logAssigned(result)
-	return result, nil
+	d, r := buildAssign(now, deadline.At, orderID, c)
+	d.Pickup = pickup
+	r.DeadlineExplanation = deadline.Explanation
+
+	if err := tx.InsertDelivery(ctx, d); err != nil {
+		return domain.AssignResult{}, err
+	}
+	if err := tx.RecordAssignment(ctx, c.ID, now); err != nil {
+		return domain.AssignResult{}, err
+	}
+	if err := announceAssigned(ctx, tx, d); err != nil {
+		return domain.AssignResult{}, err
+	}
+	if err := s.syncCourierStatus(ctx, tx, c); err != nil {
+		return domain.AssignResult{}, err
+	}
+	return r, nil
+}
+
+// deadline asks the factory for a deadline, with an explanation if the factory can give one.
+func (s *Service) deadline(in DeadlineInput) (DeadlineQuote, error) {
+	if q, ok := s.factory.(DeadlineQuoter); ok {
+		return q.Quote(in)
+	}
+	at, err := s.factory.Deadline(in.Transport, in.Now)
+	if err != nil {
+		return DeadlineQuote{}, err
+	}
+	return DeadlineQuote{At: at}, nil
 }
 
 func buildAssign(
@@ -92,6 +216,7 @@ func buildAssign(
 	d := &domain.Delivery{
 		CourierID:  c.ID,
 		OrderID:    orderID,
+		Status:     domain.DeliveryStatusAssigned,
 		AssignedAt: now,
 		Deadline:   deadline,
 	}
@@ -111,21 +236,56 @@ func (s *Service) logAssigned(r domain.AssignResult) {
 		logx.Int64("courier_id", r.CourierID),
 		logx.String("transport", string(r.TransportType)),
 		logx.Time("deadline", r.Deadline),
+		logx.String("deadline_explanation", r.DeadlineExplanation),
+		logx.String("strategy", s.strategy.Name()),
 	)
 }
 
 // Unassign unassigns a delivery from a courier.
 func (s *Service) Unassign(ctx context.Context, orderID string) (domain.UnassignResult, error) {
-	orderID, err := validateOrderID(orderID)
+	r, err := s.transition(ctx, orderID, domain.DeliveryStatusCanceled)
 	if err != nil {
 		return domain.UnassignResult{}, err
 	}
+	return domain.UnassignResult{
+		CourierID: r.CourierID,
+		OrderID:   r.OrderID,
+		Status:    "unassigned",
+	}, nil
+}
+
+// PickUp marks a delivery as picked up by its courier.
+func (s *Service) PickUp(ctx context.Context, orderID string) (domain.TransitionResult, error) {
+	return s.transition(ctx, orderID, domain.DeliveryStatusPickedUp)
+}
+
+// Complete marks a delivery as delivered and frees its courier.
+func (s *Service) Complete(ctx context.Context, orderID string) (domain.TransitionResult, error) {
+	return s.transition(ctx, orderID, domain.DeliveryStatusDelivered)
+}
+
+// Fail marks a delivery as failed and frees its courier.
+func (s *Service) Fail(ctx context.Context, orderID string) (domain.TransitionResult, error) {
+	return s.transition(ctx, orderID, domain.DeliveryStatusFailed)
+}
+
+// transition moves the latest delivery of the order to the given status.
+// It returns apperr.ErrNotFound if the order has no delivery and apperr.ErrConflict
+// if the current status does not allow the transition.
+func (s *Service) transition(
+	ctx context.Context,
+	orderID string,
+	to domain.DeliveryStatus,
+) (domain.TransitionResult, error) {
+	orderID, err := validateOrderID(orderID)
+	if err != nil {
+		return domain.TransitionResult{}, err
+	}
 
 	ctx, cancel := s.withTimeout(ctx)
 	defer cancel()
 
-	var result domain.UnassignResult
-
+	var result domain.TransitionResult
 	err = s.repo.WithTx(ctx, func(tx deliverytx.Repository) error {
 		d, err := tx.GetByOrderID(ctx, orderID)
 		if err != nil {
@@ -134,30 +294,52 @@ func (s *Service) Unassign(ctx context.Context, orderID string) (domain.Unassign
 		if d == nil {
 			return apperr.ErrNotFound
 		}
+		if !d.Status.CanTransitionTo(to) {
+			return apperr.ErrConflict
+		}
 
-		if err := tx.DeleteByOrderID(ctx, orderID); err != nil {
+		now := s.now()
+		if err := tx.UpdateDeliveryStatus(ctx, d.ID, d.Status, to, now); err != nil {
 			return err
 		}
-
-		if err := tx.UpdateCourierStatus(ctx, d.CourierID, domain.StatusAvailable); err != nil {
+		if err := announceDeliveryStatus(ctx, tx, *d, to, now); err != nil {
 			return err
 		}
+		if !to.Active() {
+			if err := s.releaseSlot(ctx, tx, d.CourierID); err != nil {
+				return err
+			}
+		}
 
-		result = domain.UnassignResult{
+		result = domain.TransitionResult{
 			CourierID: d.CourierID,
 			OrderID:   orderID,
-			Status:    "unassigned",
+			Status:    to,
+			At:        now,
 		}
-
 		return nil
 	})
 	if err != nil {
-		return domain.UnassignResult{}, err
+		return domain.TransitionResult{}, err
 	}
 
+	s.logTransition(result)
+	if !to.Active() {
+		s.courierAvailable()
+	}
 	return result, nil
 }
 
+func (s *Service) logTransition(r domain.TransitionResult) {
+	s.logger.Info("delivery status changed",
+		logx.String("event", "delivery_"+string(r.Status)),
+		logx.String("order_id", r.OrderID),
+		logx.Int64("courier_id", r.CourierID),
+		logx.String("status", string(r.Status)),
+		logx.Time("at", r.At),
+	)
+}
+
 func validateOrderID(raw string) (string, error) {
 	orderID := strings.TrimSpace(raw)
 	if orderID == "" {
@@ -165,13 +347,3 @@ func validateOrderID(raw string) (string, error) {
 	}
 	return orderID, nil
 }
-
-// ReleaseExpired releases expired couriers.
-func (s *Service) ReleaseExpired(ctx context.Context) error {
-	ctx, cancel := s.withTimeout(ctx)
-	defer cancel()
-
-	now := s.now()
-	_, err := s.repo.ReleaseCouriers(ctx, now)
-	return err
-}
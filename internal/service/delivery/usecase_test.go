@@ -35,24 +35,97 @@ func (s stubTimeFactory) Deadline(transport domain.CourierTransportType, now tim
 }
 
 type stubTx struct {
-	findFn   func(context.Context) (*domain.Courier, error)
+	listFn   func(context.Context) ([]domain.CourierCandidate, error)
+	lockFn   func(context.Context, int64) (*domain.Courier, error)
 	insertFn func(context.Context, *domain.Delivery) error
 	getFn    func(context.Context, string) (*domain.Delivery, error)
-	delFn    func(context.Context, string) error
+	statusFn func(context.Context, int64, domain.DeliveryStatus, domain.DeliveryStatus, time.Time) error
 	updFn    func(context.Context, int64, domain.CourierStatus) error
+	pendFn   func(context.Context, int) ([]domain.PendingOrder, error)
+	dropFn   func(context.Context, string) error
+	queueFn  func(context.Context, domain.PendingOrder) error
+	expireFn func(context.Context, time.Time, int) ([]domain.Delivery, error)
+	lateFn   func(context.Context, time.Time, int) ([]domain.Delivery, error)
+	courFn   func(context.Context, int64) (*domain.Courier, error)
+	countFn  func(context.Context, int64) (int, error)
+	recordFn func(context.Context, domain.Reassignment) error
+	outboxFn func(context.Context, domain.OutboxEvent) error
+
+	listed   []domain.CourierCandidate
+	inserted map[int64]int
+	assigned map[int64]time.Time
+	outbox   []domain.OutboxEvent
 }
 
-func (s *stubTx) FindAvailableCourierForUpdate(ctx context.Context) (*domain.Courier, error) {
-	if s.findFn == nil {
+// available returns a listFn offering the given couriers as candidates.
+func available(couriers ...*domain.Courier) func(context.Context) ([]domain.CourierCandidate, error) {
+	return func(context.Context) ([]domain.CourierCandidate, error) {
+		out := make([]domain.CourierCandidate, 0, len(couriers))
+		for _, c := range couriers {
+			out = append(out, domain.CourierCandidate{Courier: *c})
+		}
+		return out, nil
+	}
+}
+
+func (s *stubTx) ListAvailableCouriers(ctx context.Context) ([]domain.CourierCandidate, error) {
+	if s.listFn == nil {
 		return nil, nil
 	}
-	return s.findFn(ctx)
+	var err error
+	s.listed, err = s.listFn(ctx)
+	return s.listed, err
+}
+
+// LockAvailableCourier locks any listed candidate unless lockFn overrides it.
+func (s *stubTx) LockAvailableCourier(ctx context.Context, id int64) (*domain.Courier, error) {
+	if s.lockFn != nil {
+		return s.lockFn(ctx, id)
+	}
+	for _, c := range s.listed {
+		if c.Courier.ID == id {
+			courier := c.Courier
+			return &courier, nil
+		}
+	}
+	return nil, nil
 }
 func (s *stubTx) InsertDelivery(ctx context.Context, d *domain.Delivery) error {
-	if s.insertFn == nil {
-		return nil
+	if s.insertFn != nil {
+		if err := s.insertFn(ctx, d); err != nil {
+			return err
+		}
+	}
+	if s.inserted == nil {
+		s.inserted = make(map[int64]int)
 	}
-	return s.insertFn(ctx, d)
+	s.inserted[d.CourierID]++
+	return nil
+}
+
+// RecordAssignment remembers the latest assignment time of every courier.
+func (s *stubTx) RecordAssignment(_ context.Context, courierID int64, at time.Time) error {
+	if s.assigned == nil {
+		s.assigned = make(map[int64]time.Time)
+	}
+	s.assigned[courierID] = at
+	return nil
+}
+
+// LockCourier returns a busy courier unless courFn overrides it.
+func (s *stubTx) LockCourier(ctx context.Context, id int64) (*domain.Courier, error) {
+	if s.courFn != nil {
+		return s.courFn(ctx, id)
+	}
+	return &domain.Courier{ID: id, Status: domain.StatusBusy}, nil
+}
+
+// CountActiveDeliveries counts the deliveries inserted through the stub unless countFn overrides it.
+func (s *stubTx) CountActiveDeliveries(ctx context.Context, courierID int64) (int, error) {
+	if s.countFn != nil {
+		return s.countFn(ctx, courierID)
+	}
+	return s.inserted[courierID], nil
 }
 func (s *stubTx) GetByOrderID(ctx context.Context, orderID string) (*domain.Delivery, error) {
 	if s.getFn == nil {
@@ -60,11 +133,16 @@ func (s *stubTx) GetByOrderID(ctx context.Context, orderID string) (*domain.Deli
 	}
 	return s.getFn(ctx, orderID)
 }
-func (s *stubTx) DeleteByOrderID(ctx context.Context, orderID string) error {
-	if s.delFn == nil {
+func (s *stubTx) UpdateDeliveryStatus(
+	ctx context.Context,
+	id int64,
+	from, to domain.DeliveryStatus,
+	at time.Time,
+) error {
+	if s.statusFn == nil {
 		return nil
 	}
-	return s.delFn(ctx, orderID)
+	return s.statusFn(ctx, id, from, to, at)
 }
 func (s *stubTx) UpdateCourierStatus(ctx context.Context, id int64, status domain.CourierStatus) error {
 	if s.updFn == nil {
@@ -72,13 +150,64 @@ func (s *stubTx) UpdateCourierStatus(ctx context.Context, id int64, status domai
 	}
 	return s.updFn(ctx, id, status)
 }
+func (s *stubTx) UpdateCourier(context.Context, domain.PartialCourierUpdate) (bool, error) {
+	return true, nil
+}
+
+func (s *stubTx) ListPendingOrdersForUpdate(ctx context.Context, limit int) ([]domain.PendingOrder, error) {
+	if s.pendFn == nil {
+		return nil, nil
+	}
+	return s.pendFn(ctx, limit)
+}
+func (s *stubTx) DeletePendingOrder(ctx context.Context, orderID string) error {
+	if s.dropFn == nil {
+		return nil
+	}
+	return s.dropFn(ctx, orderID)
+}
+
+func (s *stubTx) EnqueuePendingOrder(ctx context.Context, p domain.PendingOrder) error {
+	if s.queueFn == nil {
+		return nil
+	}
+	return s.queueFn(ctx, p)
+}
+func (s *stubTx) ExpireOverdueDeliveries(ctx context.Context, now time.Time, limit int) ([]domain.Delivery, error) {
+	if s.expireFn == nil {
+		return nil, nil
+	}
+	return s.expireFn(ctx, now, limit)
+}
+func (s *stubTx) ListOverduePickedUp(ctx context.Context, now time.Time, limit int) ([]domain.Delivery, error) {
+	if s.lateFn == nil {
+		return nil, nil
+	}
+	return s.lateFn(ctx, now, limit)
+}
+func (s *stubTx) InsertReassignment(ctx context.Context, r domain.Reassignment) error {
+	if s.recordFn == nil {
+		return nil
+	}
+	return s.recordFn(ctx, r)
+}
+
+func (s *stubTx) AppendOutbox(ctx context.Context, ev domain.OutboxEvent) error {
+	if s.outboxFn != nil {
+		if err := s.outboxFn(ctx, ev); err != nil {
+			return err
+		}
+	}
+	s.outbox = append(s.outbox, ev)
+	return nil
+}
 
 func testLogger(_ io.Writer) logx.Logger {
 	return logx.Nop()
 }
 
 func newTestDeliveryService(repo *MockdeliveryRepository, f delivery.TimeFactory) *delivery.Service {
-	return delivery.NewDeliveryService(repo, f, 3*time.Second, testLogger(io.Discard))
+	return delivery.NewDeliveryService(repo, f, nil, 3*time.Second, testLogger(io.Discard))
 }
 
 func TestService_Assign_Success(t *testing.T) {
@@ -103,35 +232,36 @@ func TestService_Assign_Success(t *testing.T) {
 		TransportType: domain.TransportTypeFoot,
 	}
 
+	tx := &stubTx{
+		listFn: available(courier),
+		insertFn: func(_ context.Context, d *domain.Delivery) error {
+			require.Equal(t, courier.ID, d.CourierID)
+			require.Equal(t, orderID, d.OrderID)
+			require.True(t, d.Deadline.Equal(expectedDeadline))
+			return nil
+		},
+		updFn: func(_ context.Context, id int64, st domain.CourierStatus) error {
+			require.Equal(t, courier.ID, id)
+			require.Equal(t, domain.StatusBusy, st)
+			return nil
+		},
+	}
 	repo.EXPECT().
 		WithTx(gomock.Any(), gomock.Any()).
 		DoAndReturn(func(ctx context.Context, fn func(delivery.TxRepository) error) error {
-			tx := &stubTx{
-				findFn: func(context.Context) (*domain.Courier, error) { return courier, nil },
-				insertFn: func(_ context.Context, d *domain.Delivery) error {
-					require.Equal(t, courier.ID, d.CourierID)
-					require.Equal(t, orderID, d.OrderID)
-					require.True(t, d.Deadline.Equal(expectedDeadline))
-					return nil
-				},
-				updFn: func(_ context.Context, id int64, st domain.CourierStatus) error {
-					require.Equal(t, courier.ID, id)
-					require.Equal(t, domain.StatusBusy, st)
-					return nil
-				},
-			}
 			return fn(tx)
 		})
 
 	service := newTestDeliveryService(repo, factory)
 
-	res, err := service.Assign(ctx, orderID)
+	res, err := service.Assign(ctx, orderID, nil)
 
 	require.NoError(t, err)
 	require.Equal(t, courier.ID, res.CourierID)
 	require.Equal(t, orderID, res.OrderID)
 	require.Equal(t, courier.TransportType, res.TransportType)
 	require.True(t, res.Deadline.Equal(expectedDeadline))
+	require.Contains(t, tx.assigned, courier.ID, "the assignment is added to the courier workload")
 }
 
 func TestService_Assign_InvalidOrderID(t *testing.T) {
@@ -147,7 +277,7 @@ func TestService_Assign_InvalidOrderID(t *testing.T) {
 
 	service := newTestDeliveryService(repo, factory)
 
-	res, err := service.Assign(ctx, badOrderID)
+	res, err := service.Assign(ctx, badOrderID, nil)
 	require.ErrorIs(t, err, apperr.ErrInvalid)
 	require.Equal(t, domain.AssignResult{}, res)
 }
@@ -168,7 +298,7 @@ func TestService_Assign_BeginTxError(t *testing.T) {
 
 	repo.EXPECT().WithTx(gomock.Any(), gomock.Any()).Return(txErr)
 
-	res, err := service.Assign(ctx, orderID)
+	res, err := service.Assign(ctx, orderID, nil)
 
 	require.ErrorIs(t, err, txErr)
 	require.Equal(t, domain.AssignResult{}, res)
@@ -188,19 +318,124 @@ func TestService_Assign_NoCourierAvailable(t *testing.T) {
 	repo.EXPECT().WithTx(gomock.Any(), gomock.Any()).
 		DoAndReturn(func(ctx context.Context, fn func(delivery.TxRepository) error) error {
 			tx := &stubTx{
-				findFn: func(context.Context) (*domain.Courier, error) { return nil, nil },
+				listFn: available(),
 			}
 			return fn(tx)
 		})
 
 	service := newTestDeliveryService(repo, factory)
 
-	res, err := service.Assign(ctx, orderID)
+	res, err := service.Assign(ctx, orderID, nil)
+
+	require.ErrorIs(t, err, apperr.ErrNoCourierAvailable)
+	require.ErrorIs(t, err, apperr.ErrConflict)
+	require.Equal(t, domain.AssignResult{}, res)
+}
+
+func TestService_Assign_UsesStrategyChoice(t *testing.T) {
+	t.Parallel()
+
+	ctrl := newCtrl(t)
+
+	ctx := context.Background()
+	pickup := domain.Location{Lat: 55.7558, Lon: 37.6173}
+	first := &domain.Courier{ID: 1, TransportType: domain.TransportTypeFoot}
+	second := &domain.Courier{ID: 7, TransportType: domain.TransportTypeScooter}
+
+	repo := NewMockdeliveryRepository(ctrl)
+	repo.EXPECT().WithTx(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, fn func(delivery.TxRepository) error) error {
+			return fn(&stubTx{listFn: available(first, second)})
+		})
 
+	strategy := NewMockAssignmentStrategy(ctrl)
+	strategy.EXPECT().Name().Return("stub").AnyTimes()
+	strategy.EXPECT().
+		Choose(&pickup, gomock.Len(2)).
+		Return(1, true)
+
+	factory := stubTimeFactory{
+		fn: func(_ domain.CourierTransportType, now time.Time) (time.Time, error) { return now, nil },
+	}
+	service := delivery.NewDeliveryService(repo, factory, strategy, time.Second, testLogger(io.Discard))
+
+	res, err := service.Assign(ctx, "order_1", &pickup)
+	require.NoError(t, err)
+	require.Equal(t, second.ID, res.CourierID)
+}
+
+func TestService_Assign_ChosenCourierTaken_ChoosesAgain(t *testing.T) {
+	t.Parallel()
+
+	ctrl := newCtrl(t)
+
+	taken := &domain.Courier{ID: 1, TransportType: domain.TransportTypeFoot}
+	free := &domain.Courier{ID: 2, TransportType: domain.TransportTypeFoot}
+
+	var locked []int64
+	repo := NewMockdeliveryRepository(ctrl)
+	repo.EXPECT().WithTx(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, fn func(delivery.TxRepository) error) error {
+			return fn(&stubTx{
+				listFn: available(taken, free),
+				lockFn: func(_ context.Context, id int64) (*domain.Courier, error) {
+					locked = append(locked, id)
+					if id == taken.ID {
+						return nil, nil
+					}
+					return free, nil
+				},
+			})
+		})
+
+	factory := stubTimeFactory{
+		fn: func(_ domain.CourierTransportType, now time.Time) (time.Time, error) { return now, nil },
+	}
+	service := delivery.NewDeliveryService(repo, factory, delivery.LeastLoadedStrategy{}, time.Second, testLogger(io.Discard))
+
+	res, err := service.Assign(context.Background(), "order_1", nil)
+	require.NoError(t, err)
+	require.Equal(t, free.ID, res.CourierID)
+	require.Equal(t, []int64{taken.ID, free.ID}, locked)
+}
+
+func TestService_Assign_WithPickup_NoCandidateInRadius(t *testing.T) {
+	t.Parallel()
+
+	ctrl := newCtrl(t)
+
+	repo := NewMockdeliveryRepository(ctrl)
+	repo.EXPECT().WithTx(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, fn func(delivery.TxRepository) error) error {
+			return fn(&stubTx{
+				listFn: func(context.Context) ([]domain.CourierCandidate, error) {
+					return []domain.CourierCandidate{{
+						Courier:  domain.Courier{ID: 1, TransportType: domain.TransportTypeFoot},
+						Location: &domain.Location{Lat: 2, Lon: 2}, // ~157 km away
+					}}, nil
+				},
+			})
+		})
+
+	service := newTestDeliveryService(repo, NewMockTimeFactory(ctrl))
+
+	res, err := service.Assign(context.Background(), "order_1", &domain.Location{Lat: 1, Lon: 1})
 	require.ErrorIs(t, err, apperr.ErrConflict)
 	require.Equal(t, domain.AssignResult{}, res)
 }
 
+func TestService_Assign_InvalidPickup(t *testing.T) {
+	t.Parallel()
+
+	ctrl := newCtrl(t)
+
+	service := newTestDeliveryService(NewMockdeliveryRepository(ctrl), NewMockTimeFactory(ctrl))
+
+	res, err := service.Assign(context.Background(), "order_1", &domain.Location{Lat: 91, Lon: 0})
+	require.ErrorIs(t, err, apperr.ErrInvalid)
+	require.Equal(t, domain.AssignResult{}, res)
+}
+
 func TestService_Assign_FindAvailableCourierError(t *testing.T) {
 	t.Parallel()
 
@@ -217,14 +452,14 @@ func TestService_Assign_FindAvailableCourierError(t *testing.T) {
 	repo.EXPECT().WithTx(gomock.Any(), gomock.Any()).
 		DoAndReturn(func(ctx context.Context, fn func(delivery.TxRepository) error) error {
 			tx := &stubTx{
-				findFn: func(context.Context) (*domain.Courier, error) { return nil, wantErr },
+				listFn: func(context.Context) ([]domain.CourierCandidate, error) { return nil, wantErr },
 			}
 			return fn(tx)
 		})
 
 	service := newTestDeliveryService(repo, factory)
 
-	res, err := service.Assign(ctx, orderID)
+	res, err := service.Assign(ctx, orderID, nil)
 
 	require.ErrorIs(t, err, wantErr)
 	require.Equal(t, domain.AssignResult{}, res)
@@ -251,7 +486,7 @@ func TestService_Assign_DeadlineError(t *testing.T) {
 	repo.EXPECT().WithTx(gomock.Any(), gomock.Any()).
 		DoAndReturn(func(ctx context.Context, fn func(delivery.TxRepository) error) error {
 			tx := &stubTx{
-				findFn: func(context.Context) (*domain.Courier, error) { return courier, nil },
+				listFn: available(courier),
 			}
 			factory.EXPECT().Deadline(domain.TransportTypeFoot, gomock.Any()).Return(time.Time{}, wantErr)
 			return fn(tx)
@@ -259,7 +494,7 @@ func TestService_Assign_DeadlineError(t *testing.T) {
 
 	service := newTestDeliveryService(repo, factory)
 
-	res, err := service.Assign(ctx, orderID)
+	res, err := service.Assign(ctx, orderID, nil)
 
 	require.ErrorIs(t, err, wantErr)
 	require.Equal(t, domain.AssignResult{}, res)
@@ -287,7 +522,7 @@ func TestService_Assign_InsertDeliveryError(t *testing.T) {
 	repo.EXPECT().WithTx(gomock.Any(), gomock.Any()).
 		DoAndReturn(func(ctx context.Context, fn func(delivery.TxRepository) error) error {
 			tx := &stubTx{
-				findFn: func(context.Context) (*domain.Courier, error) { return courier, nil },
+				listFn: available(courier),
 				insertFn: func(context.Context, *domain.Delivery) error {
 					return wantErr
 				},
@@ -298,7 +533,7 @@ func TestService_Assign_InsertDeliveryError(t *testing.T) {
 
 	service := newTestDeliveryService(repo, factory)
 
-	res, err := service.Assign(ctx, orderID)
+	res, err := service.Assign(ctx, orderID, nil)
 
 	require.ErrorIs(t, err, wantErr)
 	require.Equal(t, domain.AssignResult{}, res)
@@ -326,7 +561,7 @@ func TestService_Assign_UpdateCourierStatusError(t *testing.T) {
 	repo.EXPECT().WithTx(gomock.Any(), gomock.Any()).
 		DoAndReturn(func(ctx context.Context, fn func(delivery.TxRepository) error) error {
 			tx := &stubTx{
-				findFn:   func(context.Context) (*domain.Courier, error) { return courier, nil },
+				listFn:   available(courier),
 				insertFn: func(context.Context, *domain.Delivery) error { return nil },
 				updFn:    func(context.Context, int64, domain.CourierStatus) error { return wantErr },
 			}
@@ -336,7 +571,7 @@ func TestService_Assign_UpdateCourierStatusError(t *testing.T) {
 
 	service := newTestDeliveryService(repo, factory)
 
-	res, err := service.Assign(ctx, orderID)
+	res, err := service.Assign(ctx, orderID, nil)
 
 	require.ErrorIs(t, err, wantErr)
 	require.Equal(t, domain.AssignResult{}, res)
@@ -350,7 +585,7 @@ func TestNewDeliveryService_ZeroTimeoutUsesDefault_Behavior(t *testing.T) {
 	repo := NewMockdeliveryRepository(ctrl)
 	factory := NewMockTimeFactory(ctrl)
 
-	svc := delivery.NewDeliveryService(repo, factory, 0, testLogger(io.Discard))
+	svc := delivery.NewDeliveryService(repo, factory, nil, 0, testLogger(io.Discard))
 
 	ctx := context.Background()
 	orderID := "order_1"
@@ -365,7 +600,7 @@ func TestNewDeliveryService_ZeroTimeoutUsesDefault_Behavior(t *testing.T) {
 			return wantErr
 		})
 
-	res, err := svc.Assign(ctx, orderID)
+	res, err := svc.Assign(ctx, orderID, nil)
 	require.Equal(t, domain.AssignResult{}, res)
 
 	require.ErrorIs(t, err, wantErr)
@@ -399,6 +634,7 @@ func TestService_Unassign_Success(t *testing.T) {
 		ID:        100,
 		CourierID: 10,
 		OrderID:   orderID,
+		Status:    domain.DeliveryStatusAssigned,
 	}
 
 	repo.EXPECT().
@@ -409,8 +645,10 @@ func TestService_Unassign_Success(t *testing.T) {
 					require.Equal(t, orderID, gotOrderID)
 					return existing, nil
 				},
-				delFn: func(_ context.Context, gotOrderID string) error {
-					require.Equal(t, orderID, gotOrderID)
+				statusFn: func(_ context.Context, id int64, from, to domain.DeliveryStatus, _ time.Time) error {
+					require.Equal(t, existing.ID, id)
+					require.Equal(t, domain.DeliveryStatusAssigned, from)
+					require.Equal(t, domain.DeliveryStatusCanceled, to)
 					return nil
 				},
 				updFn: func(_ context.Context, id int64, st domain.CourierStatus) error {
@@ -499,7 +737,7 @@ func TestService_Unassign_GetByOrderIDError(t *testing.T) {
 	require.Equal(t, domain.UnassignResult{}, res)
 }
 
-func TestService_Unassign_DeleteByOrderIDError(t *testing.T) {
+func TestService_Unassign_UpdateDeliveryStatusError(t *testing.T) {
 	t.Parallel()
 
 	ctrl := newCtrl(t)
@@ -514,9 +752,10 @@ func TestService_Unassign_DeleteByOrderIDError(t *testing.T) {
 		ID:        100,
 		CourierID: 10,
 		OrderID:   orderID,
+		Status:    domain.DeliveryStatusAssigned,
 	}
 
-	wantErr := errors.New("delete error")
+	wantErr := errors.New("update delivery status error")
 
 	repo.EXPECT().WithTx(gomock.Any(), gomock.Any()).
 		DoAndReturn(func(ctx context.Context, fn func(delivery.TxRepository) error) error {
@@ -525,8 +764,7 @@ func TestService_Unassign_DeleteByOrderIDError(t *testing.T) {
 					require.Equal(t, orderID, gotOrderID)
 					return existing, nil
 				},
-				delFn: func(_ context.Context, gotOrderID string) error {
-					require.Equal(t, orderID, gotOrderID)
+				statusFn: func(context.Context, int64, domain.DeliveryStatus, domain.DeliveryStatus, time.Time) error {
 					return wantErr
 				},
 			}
@@ -556,6 +794,7 @@ func TestService_Unassign_UpdateCourierStatusError(t *testing.T) {
 		ID:        100,
 		CourierID: 10,
 		OrderID:   orderID,
+		Status:    domain.DeliveryStatusPickedUp,
 	}
 
 	wantErr := errors.New("update status error")
@@ -567,10 +806,6 @@ func TestService_Unassign_UpdateCourierStatusError(t *testing.T) {
 					require.Equal(t, orderID, gotOrderID)
 					return existing, nil
 				},
-				delFn: func(_ context.Context, gotOrderID string) error {
-					require.Equal(t, orderID, gotOrderID)
-					return nil
-				},
 				updFn: func(_ context.Context, id int64, st domain.CourierStatus) error {
 					require.Equal(t, existing.CourierID, id)
 					require.Equal(t, domain.StatusAvailable, st)
@@ -615,29 +850,146 @@ func TestService_Unassign_DeliveryNotFound(t *testing.T) {
 	require.Equal(t, domain.UnassignResult{}, res)
 }
 
-func TestService_ReleaseExpired_RepoError(t *testing.T) {
+func TestService_Unassign_AlreadyFinished_Conflict(t *testing.T) {
 	t.Parallel()
 
 	ctrl := newCtrl(t)
 
 	ctx := context.Background()
+	orderID := "order_1"
 
 	repo := NewMockdeliveryRepository(ctrl)
 	factory := NewMockTimeFactory(ctrl)
 
-	wantErr := errors.New("boom")
-	repo.EXPECT().ReleaseCouriers(gomock.Any(), gomock.Any()).Return(int64(0), wantErr)
+	repo.EXPECT().WithTx(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, fn func(delivery.TxRepository) error) error {
+			tx := &stubTx{
+				getFn: func(context.Context, string) (*domain.Delivery, error) {
+					return &domain.Delivery{ID: 1, CourierID: 10, OrderID: orderID, Status: domain.DeliveryStatusDelivered}, nil
+				},
+				statusFn: func(context.Context, int64, domain.DeliveryStatus, domain.DeliveryStatus, time.Time) error {
+					t.Fatalf("UpdateDeliveryStatus must not be called for a finished delivery")
+					return nil
+				},
+			}
+			return fn(tx)
+		})
 
-	svc := newTestDeliveryService(repo, factory)
+	service := newTestDeliveryService(repo, factory)
 
-	err := svc.ReleaseExpired(ctx)
-	require.ErrorIs(t, err, wantErr)
+	res, err := service.Unassign(ctx, orderID)
+
+	require.ErrorIs(t, err, apperr.ErrConflict)
+	require.Equal(t, domain.UnassignResult{}, res)
+}
+
+func TestService_PickUp_KeepsCourierBusy(t *testing.T) {
+	t.Parallel()
+
+	ctrl := newCtrl(t)
+
+	ctx := context.Background()
+	orderID := "order_1"
+
+	repo := NewMockdeliveryRepository(ctrl)
+	factory := NewMockTimeFactory(ctrl)
+
+	repo.EXPECT().WithTx(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, fn func(delivery.TxRepository) error) error {
+			tx := &stubTx{
+				getFn: func(context.Context, string) (*domain.Delivery, error) {
+					return &domain.Delivery{ID: 7, CourierID: 10, OrderID: orderID, Status: domain.DeliveryStatusAssigned}, nil
+				},
+				statusFn: func(_ context.Context, id int64, from, to domain.DeliveryStatus, _ time.Time) error {
+					require.Equal(t, int64(7), id)
+					require.Equal(t, domain.DeliveryStatusAssigned, from)
+					require.Equal(t, domain.DeliveryStatusPickedUp, to)
+					return nil
+				},
+				updFn: func(context.Context, int64, domain.CourierStatus) error {
+					t.Fatalf("courier must stay busy after pick up")
+					return nil
+				},
+			}
+			return fn(tx)
+		})
+
+	service := newTestDeliveryService(repo, factory)
+
+	res, err := service.PickUp(ctx, orderID)
+	require.NoError(t, err)
+	require.Equal(t, domain.DeliveryStatusPickedUp, res.Status)
+	require.Equal(t, int64(10), res.CourierID)
+}
+
+func TestService_Complete_FreesCourier(t *testing.T) {
+	t.Parallel()
+
+	ctrl := newCtrl(t)
+
+	ctx := context.Background()
+	orderID := "order_1"
+
+	repo := NewMockdeliveryRepository(ctrl)
+	factory := NewMockTimeFactory(ctrl)
+
+	var freed bool
+	repo.EXPECT().WithTx(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, fn func(delivery.TxRepository) error) error {
+			tx := &stubTx{
+				getFn: func(context.Context, string) (*domain.Delivery, error) {
+					return &domain.Delivery{ID: 7, CourierID: 10, OrderID: orderID, Status: domain.DeliveryStatusPickedUp}, nil
+				},
+				statusFn: func(_ context.Context, _ int64, from, to domain.DeliveryStatus, _ time.Time) error {
+					require.Equal(t, domain.DeliveryStatusPickedUp, from)
+					require.Equal(t, domain.DeliveryStatusDelivered, to)
+					return nil
+				},
+				updFn: func(_ context.Context, id int64, st domain.CourierStatus) error {
+					require.Equal(t, int64(10), id)
+					require.Equal(t, domain.StatusAvailable, st)
+					freed = true
+					return nil
+				},
+			}
+			return fn(tx)
+		})
+
+	var notified bool
+	service := newTestDeliveryService(repo, factory).
+		WithCourierAvailableHook(func() { notified = true })
+
+	res, err := service.Complete(ctx, orderID)
+	require.NoError(t, err)
+	require.True(t, freed)
+	require.True(t, notified)
+	require.Equal(t, domain.DeliveryStatusDelivered, res.Status)
+}
+
+func TestService_Fail_NotFound(t *testing.T) {
+	t.Parallel()
+
+	ctrl := newCtrl(t)
+
+	repo := NewMockdeliveryRepository(ctrl)
+	factory := NewMockTimeFactory(ctrl)
+
+	repo.EXPECT().WithTx(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, fn func(delivery.TxRepository) error) error {
+			return fn(&stubTx{})
+		})
+
+	service := newTestDeliveryService(repo, factory)
+
+	res, err := service.Fail(context.Background(), "order_1")
+	require.ErrorIs(t, err, apperr.ErrNotFound)
+	require.Equal(t, domain.TransitionResult{}, res)
 }
 
 func TestDefaultTimeFactory_Deadline(t *testing.T) {
 	t.Parallel()
 
-	f := delivery.NewTimeFactory()
+	f := delivery.NewTimeFactory(nil)
 	now := time.Date(2025, 1, 2, 10, 0, 0, 0, time.UTC)
 
 	tests := []struct {
@@ -685,3 +1037,22 @@ func TestDefaultTimeFactory_Deadline(t *testing.T) {
 		})
 	}
 }
+
+func TestDefaultTimeFactory_Deadline_FromTransportTypes(t *testing.T) {
+	t.Parallel()
+
+	types := NewMockTransportTypes(newCtrl(t))
+	types.EXPECT().Get(domain.CourierTransportType("bike")).
+		Return(domain.TransportType{Code: "bike", SpeedKmh: 15, MaxCapacity: 2, BaseDeadline: 20 * time.Minute}, true)
+	types.EXPECT().Get(domain.TransportTypeCar).Return(domain.TransportType{}, false)
+
+	f := delivery.NewTimeFactory(types)
+	now := time.Date(2025, 1, 2, 10, 0, 0, 0, time.UTC)
+
+	got, err := f.Deadline("bike", now)
+	require.NoError(t, err)
+	require.Equal(t, now.Add(20*time.Minute), got)
+
+	_, err = f.Deadline(domain.TransportTypeCar, now)
+	require.Error(t, err, "a transport type removed from the catalog is unknown")
+}
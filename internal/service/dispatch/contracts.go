@@ -0,0 +1,13 @@
+//go:generate mockgen -source=contracts.go -destination=dispatch_mocks_test.go -package=dispatch_test
+package dispatch
+
+import (
+	"context"
+
+	"course-go-avito-Orurh/internal/domain"
+)
+
+// PendingAssigner assigns orders waiting in the pending queue
+type PendingAssigner interface {
+	DispatchPending(ctx context.Context, limit int) ([]domain.AssignResult, error)
+}
@@ -0,0 +1,51 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: contracts.go
+
+// Package dispatch_test is a generated GoMock package.
+package dispatch_test
+
+import (
+	context "context"
+	domain "course-go-avito-Orurh/internal/domain"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockPendingAssigner is a mock of PendingAssigner interface.
+type MockPendingAssigner struct {
+	ctrl     *gomock.Controller
+	recorder *MockPendingAssignerMockRecorder
+}
+
+// MockPendingAssignerMockRecorder is the mock recorder for MockPendingAssigner.
+type MockPendingAssignerMockRecorder struct {
+	mock *MockPendingAssigner
+}
+
+// NewMockPendingAssigner creates a new mock instance.
+func NewMockPendingAssigner(ctrl *gomock.Controller) *MockPendingAssigner {
+	mock := &MockPendingAssigner{ctrl: ctrl}
+	mock.recorder = &MockPendingAssignerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPendingAssigner) EXPECT() *MockPendingAssignerMockRecorder {
+	return m.recorder
+}
+
+// DispatchPending mocks base method.
+func (m *MockPendingAssigner) DispatchPending(ctx context.Context, limit int) ([]domain.AssignResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DispatchPending", ctx, limit)
+	ret0, _ := ret[0].([]domain.AssignResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DispatchPending indicates an expected call of DispatchPending.
+func (mr *MockPendingAssignerMockRecorder) DispatchPending(ctx, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DispatchPending", reflect.TypeOf((*MockPendingAssigner)(nil).DispatchPending), ctx, limit)
+}
@@ -0,0 +1,93 @@
+package dispatch
+
+import (
+	"context"
+	"time"
+
+	"course-go-avito-Orurh/internal/logx"
+)
+
+const (
+	defaultInterval  = 5 * time.Second
+	defaultBatchSize = 50
+)
+
+// Signal wakes the Dispatcher up when a courier may have become available.
+// Notifications are coalesced: many calls between two dispatch runs trigger a single run.
+type Signal struct {
+	ch chan struct{}
+}
+
+// NewSignal creates a Signal.
+func NewSignal() *Signal {
+	return &Signal{ch: make(chan struct{}, 1)}
+}
+
+// Notify requests a dispatch run without blocking the caller.
+func (s *Signal) Notify() {
+	select {
+	case s.ch <- struct{}{}:
+	default:
+	}
+}
+
+// Dispatcher assigns pending orders in the background.
+// It runs on every Signal notification and periodically, so that couriers
+// freed by another process are picked up as well.
+type Dispatcher struct {
+	svc       PendingAssigner
+	signal    *Signal
+	interval  time.Duration
+	batchSize int
+	logger    logx.Logger
+}
+
+// NewDispatcher creates a Dispatcher. Non-positive interval and batch size fall back to defaults.
+func NewDispatcher(svc PendingAssigner, signal *Signal, interval time.Duration, batchSize int, logger logx.Logger) *Dispatcher {
+	if signal == nil {
+		signal = NewSignal()
+	}
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
+	if logger == nil {
+		logger = logx.Nop()
+	}
+	return &Dispatcher{svc: svc, signal: signal, interval: interval, batchSize: batchSize, logger: logger}
+}
+
+// Run dispatches pending orders until ctx is canceled.
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-d.signal.ch:
+		}
+		d.drain(ctx)
+	}
+}
+
+// drain dispatches batches while every order of the previous batch got a courier.
+func (d *Dispatcher) drain(ctx context.Context) {
+	for ctx.Err() == nil {
+		assigned, err := d.svc.DispatchPending(ctx, d.batchSize)
+		if err != nil {
+			d.logger.Error("dispatch pending orders failed", logx.Any("err", err))
+			return
+		}
+		if len(assigned) > 0 {
+			d.logger.Info("pending orders assigned", logx.Int("count", len(assigned)))
+		}
+		if len(assigned) < d.batchSize {
+			return
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package dispatch_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"course-go-avito-Orurh/internal/domain"
+	"course-go-avito-Orurh/internal/logx"
+	"course-go-avito-Orurh/internal/service/dispatch"
+)
+
+func TestSignal_NotifyDoesNotBlock(t *testing.T) {
+	t.Parallel()
+
+	s := dispatch.NewSignal()
+	for range 3 {
+		s.Notify()
+	}
+}
+
+func TestDispatcher_RunsOnSignal(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	svc := NewMockPendingAssigner(ctrl)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	called := make(chan int, 1)
+	svc.EXPECT().DispatchPending(gomock.Any(), 10).
+		DoAndReturn(func(_ context.Context, limit int) ([]domain.AssignResult, error) {
+			called <- limit
+			return nil, nil
+		})
+
+	sig := dispatch.NewSignal()
+	d := dispatch.NewDispatcher(svc, sig, time.Hour, 10, logx.Nop())
+
+	done := make(chan struct{})
+	go func() {
+		d.Run(ctx)
+		close(done)
+	}()
+
+	sig.Notify()
+	select {
+	case got := <-called:
+		require.Equal(t, 10, got)
+	case <-time.After(time.Second):
+		t.Fatal("dispatcher did not run on signal")
+	}
+
+	cancel()
+	<-done
+}
+
+func TestDispatcher_DrainsFullBatches(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	svc := NewMockPendingAssigner(ctrl)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan struct{})
+	gomock.InOrder(
+		svc.EXPECT().DispatchPending(gomock.Any(), 2).
+			Return(make([]domain.AssignResult, 2), nil),
+		svc.EXPECT().DispatchPending(gomock.Any(), 2).
+			DoAndReturn(func(context.Context, int) ([]domain.AssignResult, error) {
+				close(done)
+				return make([]domain.AssignResult, 1), nil
+			}),
+	)
+
+	sig := dispatch.NewSignal()
+	d := dispatch.NewDispatcher(svc, sig, time.Hour, 2, logx.Nop())
+	go d.Run(ctx)
+
+	sig.Notify()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("dispatcher did not drain the queue")
+	}
+}
+
+func TestDispatcher_RunsPeriodicallyAndSurvivesErrors(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	svc := NewMockPendingAssigner(ctrl)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	calls := make(chan struct{}, 2)
+	svc.EXPECT().DispatchPending(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(context.Context, int) ([]domain.AssignResult, error) {
+			select {
+			case calls <- struct{}{}:
+			default:
+			}
+			return nil, errors.New("db down")
+		}).MinTimes(2)
+
+	d := dispatch.NewDispatcher(svc, nil, 10*time.Millisecond, 0, nil)
+	go d.Run(ctx)
+
+	for range 2 {
+		select {
+		case <-calls:
+		case <-time.After(time.Second):
+			t.Fatal("dispatcher did not run on tick")
+		}
+	}
+	cancel()
+}
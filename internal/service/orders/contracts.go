@@ -4,6 +4,9 @@ package orders
 
 import (
 	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
 
 	"course-go-avito-Orurh/internal/domain"
 )
@@ -11,6 +14,48 @@ import (
 // DeliveryPort abstracts the subset of delivery service operations
 // needed by orders Processor when handling order events
 type DeliveryPort interface {
-	Assign(ctx context.Context, orderID string) (domain.AssignResult, error)
+	Assign(ctx context.Context, orderID string, pickup *domain.Location) (domain.AssignResult, error)
 	Unassign(ctx context.Context, orderID string) (domain.UnassignResult, error)
+	Complete(ctx context.Context, orderID string) (domain.TransitionResult, error)
+	Enqueue(ctx context.Context, orderID string, pickup *domain.Location) error
+	Dequeue(ctx context.Context, orderID string) error
+}
+
+// EventLedger runs fn at most once per event key. The ledger entry and the changes fn
+// makes through the delivery repositories are committed in one transaction.
+type EventLedger interface {
+	Once(ctx context.Context, key domain.EventKey, fn func(ctx context.Context) error) (bool, error)
+}
+
+// OrderVersions stores the last applied status of every order.
+type OrderVersions interface {
+	// Last returns the last applied version of the order, or nil if none was applied yet.
+	// Inside the ledger transaction the version stays locked until the transaction ends.
+	Last(ctx context.Context, orderID string) (*domain.OrderVersion, error)
+	Save(ctx context.Context, v domain.OrderVersion) error
+}
+
+// Verifications is the queue of orders applied from event payloads while the orders service
+// was unavailable.
+type Verifications interface {
+	// Claim returns up to limit verifications due at now and postpones them by lease,
+	// so a verification that is not resolved is claimed again once the lease is over.
+	Claim(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]domain.OrderVerification, error)
+	// Resolve removes v unless a later event of the order has rescheduled it.
+	Resolve(ctx context.Context, v domain.OrderVerification) error
+}
+
+// OrderStatuses looks up orders in the orders service.
+type OrderStatuses interface {
+	// Status returns the current status of the order, or "" if the orders service does not know it.
+	Status(ctx context.Context, orderID string) (string, error)
+}
+
+// EventHandler applies a single order event.
+type EventHandler interface {
+	Handle(ctx context.Context, e Event) error
+}
+
+type labeledCounter interface {
+	WithLabelValues(lvs ...string) prometheus.Counter
 }
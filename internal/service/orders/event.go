@@ -2,6 +2,8 @@ package orders
 
 import (
 	"time"
+
+	"course-go-avito-Orurh/internal/domain"
 )
 
 // Event is a single order event
@@ -9,4 +11,10 @@ type Event struct {
 	OrderID   string
 	Status    string
 	CreatedAt time.Time
+	// Pickup is where the courier collects the order; nil if the producer did not send it
+	Pickup *domain.Location
+	// Key is the position of the event in Kafka; zero for events that did not come from there
+	Key domain.EventKey
+	// At is when the event was produced; zero if unknown
+	At time.Time
 }
@@ -7,6 +7,15 @@ import (
 
 type actionFunc func(context.Context, Event) error
 
+// knownStatuses has the statuses of the factory without the actions
+var knownStatuses = newActionFactory(nil, nil, nil)
+
+// KnownStatus reports whether Handle acts on events with the status, events with other statuses are ignored
+func KnownStatus(status string) bool {
+	_, ok := knownStatuses.get(status)
+	return ok
+}
+
 type actionFactory struct {
 	byStatus map[string]actionFunc
 }
@@ -7,9 +7,12 @@ package orders_test
 import (
 	context "context"
 	domain "course-go-avito-Orurh/internal/domain"
+	orders "course-go-avito-Orurh/internal/service/orders"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
+	prometheus "github.com/prometheus/client_golang/prometheus"
 )
 
 // MockDeliveryPort is a mock of DeliveryPort interface.
@@ -36,18 +39,61 @@ func (m *MockDeliveryPort) EXPECT() *MockDeliveryPortMockRecorder {
 }
 
 // Assign mocks base method.
-func (m *MockDeliveryPort) Assign(ctx context.Context, orderID string) (domain.AssignResult, error) {
+func (m *MockDeliveryPort) Assign(ctx context.Context, orderID string, pickup *domain.Location) (domain.AssignResult, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Assign", ctx, orderID)
+	ret := m.ctrl.Call(m, "Assign", ctx, orderID, pickup)
 	ret0, _ := ret[0].(domain.AssignResult)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Assign indicates an expected call of Assign.
-func (mr *MockDeliveryPortMockRecorder) Assign(ctx, orderID interface{}) *gomock.Call {
+func (mr *MockDeliveryPortMockRecorder) Assign(ctx, orderID, pickup interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Assign", reflect.TypeOf((*MockDeliveryPort)(nil).Assign), ctx, orderID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Assign", reflect.TypeOf((*MockDeliveryPort)(nil).Assign), ctx, orderID, pickup)
+}
+
+// Complete mocks base method.
+func (m *MockDeliveryPort) Complete(ctx context.Context, orderID string) (domain.TransitionResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Complete", ctx, orderID)
+	ret0, _ := ret[0].(domain.TransitionResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Complete indicates an expected call of Complete.
+func (mr *MockDeliveryPortMockRecorder) Complete(ctx, orderID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Complete", reflect.TypeOf((*MockDeliveryPort)(nil).Complete), ctx, orderID)
+}
+
+// Dequeue mocks base method.
+func (m *MockDeliveryPort) Dequeue(ctx context.Context, orderID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Dequeue", ctx, orderID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Dequeue indicates an expected call of Dequeue.
+func (mr *MockDeliveryPortMockRecorder) Dequeue(ctx, orderID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Dequeue", reflect.TypeOf((*MockDeliveryPort)(nil).Dequeue), ctx, orderID)
+}
+
+// Enqueue mocks base method.
+func (m *MockDeliveryPort) Enqueue(ctx context.Context, orderID string, pickup *domain.Location) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Enqueue", ctx, orderID, pickup)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Enqueue indicates an expected call of Enqueue.
+func (mr *MockDeliveryPortMockRecorder) Enqueue(ctx, orderID, pickup interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Enqueue", reflect.TypeOf((*MockDeliveryPort)(nil).Enqueue), ctx, orderID, pickup)
 }
 
 // Unassign mocks base method.
@@ -64,3 +110,261 @@ func (mr *MockDeliveryPortMockRecorder) Unassign(ctx, orderID interface{}) *gomo
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unassign", reflect.TypeOf((*MockDeliveryPort)(nil).Unassign), ctx, orderID)
 }
+
+// MockEventLedger is a mock of EventLedger interface.
+type MockEventLedger struct {
+	ctrl     *gomock.Controller
+	recorder *MockEventLedgerMockRecorder
+}
+
+// MockEventLedgerMockRecorder is the mock recorder for MockEventLedger.
+type MockEventLedgerMockRecorder struct {
+	mock *MockEventLedger
+}
+
+// NewMockEventLedger creates a new mock instance.
+func NewMockEventLedger(ctrl *gomock.Controller) *MockEventLedger {
+	mock := &MockEventLedger{ctrl: ctrl}
+	mock.recorder = &MockEventLedgerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockEventLedger) EXPECT() *MockEventLedgerMockRecorder {
+	return m.recorder
+}
+
+// Once mocks base method.
+func (m *MockEventLedger) Once(ctx context.Context, key domain.EventKey, fn func(context.Context) error) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Once", ctx, key, fn)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Once indicates an expected call of Once.
+func (mr *MockEventLedgerMockRecorder) Once(ctx, key, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Once", reflect.TypeOf((*MockEventLedger)(nil).Once), ctx, key, fn)
+}
+
+// MockOrderVersions is a mock of OrderVersions interface.
+type MockOrderVersions struct {
+	ctrl     *gomock.Controller
+	recorder *MockOrderVersionsMockRecorder
+}
+
+// MockOrderVersionsMockRecorder is the mock recorder for MockOrderVersions.
+type MockOrderVersionsMockRecorder struct {
+	mock *MockOrderVersions
+}
+
+// NewMockOrderVersions creates a new mock instance.
+func NewMockOrderVersions(ctrl *gomock.Controller) *MockOrderVersions {
+	mock := &MockOrderVersions{ctrl: ctrl}
+	mock.recorder = &MockOrderVersionsMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOrderVersions) EXPECT() *MockOrderVersionsMockRecorder {
+	return m.recorder
+}
+
+// Last mocks base method.
+func (m *MockOrderVersions) Last(ctx context.Context, orderID string) (*domain.OrderVersion, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Last", ctx, orderID)
+	ret0, _ := ret[0].(*domain.OrderVersion)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Last indicates an expected call of Last.
+func (mr *MockOrderVersionsMockRecorder) Last(ctx, orderID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Last", reflect.TypeOf((*MockOrderVersions)(nil).Last), ctx, orderID)
+}
+
+// Save mocks base method.
+func (m *MockOrderVersions) Save(ctx context.Context, v domain.OrderVersion) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Save", ctx, v)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Save indicates an expected call of Save.
+func (mr *MockOrderVersionsMockRecorder) Save(ctx, v interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockOrderVersions)(nil).Save), ctx, v)
+}
+
+// MockVerifications is a mock of Verifications interface.
+type MockVerifications struct {
+	ctrl     *gomock.Controller
+	recorder *MockVerificationsMockRecorder
+}
+
+// MockVerificationsMockRecorder is the mock recorder for MockVerifications.
+type MockVerificationsMockRecorder struct {
+	mock *MockVerifications
+}
+
+// NewMockVerifications creates a new mock instance.
+func NewMockVerifications(ctrl *gomock.Controller) *MockVerifications {
+	mock := &MockVerifications{ctrl: ctrl}
+	mock.recorder = &MockVerificationsMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockVerifications) EXPECT() *MockVerificationsMockRecorder {
+	return m.recorder
+}
+
+// Claim mocks base method.
+func (m *MockVerifications) Claim(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]domain.OrderVerification, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Claim", ctx, now, limit, lease)
+	ret0, _ := ret[0].([]domain.OrderVerification)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Claim indicates an expected call of Claim.
+func (mr *MockVerificationsMockRecorder) Claim(ctx, now, limit, lease interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Claim", reflect.TypeOf((*MockVerifications)(nil).Claim), ctx, now, limit, lease)
+}
+
+// Resolve mocks base method.
+func (m *MockVerifications) Resolve(ctx context.Context, v domain.OrderVerification) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Resolve", ctx, v)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Resolve indicates an expected call of Resolve.
+func (mr *MockVerificationsMockRecorder) Resolve(ctx, v interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Resolve", reflect.TypeOf((*MockVerifications)(nil).Resolve), ctx, v)
+}
+
+// MockOrderStatuses is a mock of OrderStatuses interface.
+type MockOrderStatuses struct {
+	ctrl     *gomock.Controller
+	recorder *MockOrderStatusesMockRecorder
+}
+
+// MockOrderStatusesMockRecorder is the mock recorder for MockOrderStatuses.
+type MockOrderStatusesMockRecorder struct {
+	mock *MockOrderStatuses
+}
+
+// NewMockOrderStatuses creates a new mock instance.
+func NewMockOrderStatuses(ctrl *gomock.Controller) *MockOrderStatuses {
+	mock := &MockOrderStatuses{ctrl: ctrl}
+	mock.recorder = &MockOrderStatusesMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOrderStatuses) EXPECT() *MockOrderStatusesMockRecorder {
+	return m.recorder
+}
+
+// Status mocks base method.
+func (m *MockOrderStatuses) Status(ctx context.Context, orderID string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Status", ctx, orderID)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Status indicates an expected call of Status.
+func (mr *MockOrderStatusesMockRecorder) Status(ctx, orderID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Status", reflect.TypeOf((*MockOrderStatuses)(nil).Status), ctx, orderID)
+}
+
+// MockEventHandler is a mock of EventHandler interface.
+type MockEventHandler struct {
+	ctrl     *gomock.Controller
+	recorder *MockEventHandlerMockRecorder
+}
+
+// MockEventHandlerMockRecorder is the mock recorder for MockEventHandler.
+type MockEventHandlerMockRecorder struct {
+	mock *MockEventHandler
+}
+
+// NewMockEventHandler creates a new mock instance.
+func NewMockEventHandler(ctrl *gomock.Controller) *MockEventHandler {
+	mock := &MockEventHandler{ctrl: ctrl}
+	mock.recorder = &MockEventHandlerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockEventHandler) EXPECT() *MockEventHandlerMockRecorder {
+	return m.recorder
+}
+
+// Handle mocks base method.
+func (m *MockEventHandler) Handle(ctx context.Context, e orders.Event) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Handle", ctx, e)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Handle indicates an expected call of Handle.
+func (mr *MockEventHandlerMockRecorder) Handle(ctx, e interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Handle", reflect.TypeOf((*MockEventHandler)(nil).Handle), ctx, e)
+}
+
+// MocklabeledCounter is a mock of labeledCounter interface.
+type MocklabeledCounter struct {
+	ctrl     *gomock.Controller
+	recorder *MocklabeledCounterMockRecorder
+}
+
+// MocklabeledCounterMockRecorder is the mock recorder for MocklabeledCounter.
+type MocklabeledCounterMockRecorder struct {
+	mock *MocklabeledCounter
+}
+
+// NewMocklabeledCounter creates a new mock instance.
+func NewMocklabeledCounter(ctrl *gomock.Controller) *MocklabeledCounter {
+	mock := &MocklabeledCounter{ctrl: ctrl}
+	mock.recorder = &MocklabeledCounterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MocklabeledCounter) EXPECT() *MocklabeledCounterMockRecorder {
+	return m.recorder
+}
+
+// WithLabelValues mocks base method.
+func (m *MocklabeledCounter) WithLabelValues(lvs ...string) prometheus.Counter {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{}
+	for _, a := range lvs {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "WithLabelValues", varargs...)
+	ret0, _ := ret[0].(prometheus.Counter)
+	return ret0
+}
+
+// WithLabelValues indicates an expected call of WithLabelValues.
+func (mr *MocklabeledCounterMockRecorder) WithLabelValues(lvs ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithLabelValues", reflect.TypeOf((*MocklabeledCounter)(nil).WithLabelValues), lvs...)
+}
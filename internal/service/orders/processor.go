@@ -5,31 +5,57 @@ import (
 	"errors"
 
 	"course-go-avito-Orurh/internal/apperr"
-	"course-go-avito-Orurh/internal/domain"
-	"course-go-avito-Orurh/internal/ports/deliverytx"
+	"course-go-avito-Orurh/internal/logx"
 )
 
 // Processor processes orders events
 type Processor struct {
 	delivery DeliveryPort
-	repo     deliverytx.Runner
 	factory  *actionFactory
+	ledger   EventLedger
+	versions OrderVersions
+	rejected labeledCounter
+	logger   logx.Logger
 }
 
 // NewProcessorWithDeps creates a Processor from interfaces (handy for tests).
-func NewProcessorWithDeps(deliverySvc DeliveryPort, repo deliverytx.Runner) *Processor {
-	return newProcessor(deliverySvc, repo)
+func NewProcessorWithDeps(deliverySvc DeliveryPort) *Processor {
+	return newProcessor(deliverySvc)
 }
 
-func newProcessor(deliverySvc DeliveryPort, repo deliverytx.Runner) *Processor {
+func newProcessor(deliverySvc DeliveryPort) *Processor {
 	p := &Processor{
 		delivery: deliverySvc,
-		repo:     repo,
+		logger:   logx.Nop(),
 	}
 	p.factory = newActionFactory(p.onCreated, p.onCanceled, p.onCompleted)
 	return p
 }
 
+// WithLedger makes Handle skip events whose key the ledger has already recorded,
+// so events redelivered after a rebalance or a restarted session become no-ops.
+func (p *Processor) WithLedger(l EventLedger) *Processor {
+	p.ledger = l
+	return p
+}
+
+// WithVersions makes Handle skip events produced before the last applied event of the order
+// or moving the order back in its lifecycle. Skipped events are counted in rejected by reason;
+// rejected may be nil. The check and the change are atomic only inside the ledger transaction.
+func (p *Processor) WithVersions(v OrderVersions, rejected labeledCounter) *Processor {
+	p.versions = v
+	p.rejected = rejected
+	return p
+}
+
+// WithLogger sets the logger of the processor
+func (p *Processor) WithLogger(l logx.Logger) *Processor {
+	if l != nil {
+		p.logger = l
+	}
+	return p
+}
+
 // Handle processes a single orders.Event
 func (p *Processor) Handle(ctx context.Context, e Event) error {
 	if p.factory == nil {
@@ -39,34 +65,54 @@ func (p *Processor) Handle(ctx context.Context, e Event) error {
 	if !ok {
 		return nil
 	}
-	return fn(ctx, e)
+	if p.versions != nil {
+		fn = p.versioned(fn)
+	}
+	if p.ledger == nil || e.Key.IsZero() {
+		return fn(ctx, e)
+	}
+	// a duplicate is not an error: the first delivery of the event has already been applied
+	_, err := p.ledger.Once(ctx, e.Key, func(ctx context.Context) error {
+		return fn(ctx, e)
+	})
+	return err
+}
+
+func (p *Processor) versioned(fn actionFunc) actionFunc {
+	return func(ctx context.Context, e Event) error {
+		return p.applyVersioned(ctx, fn, e)
+	}
 }
 
 func (p *Processor) onCreated(ctx context.Context, e Event) error {
-	_, err := p.delivery.Assign(ctx, e.OrderID)
-	if errors.Is(err, apperr.ErrConflict) {
+	_, err := p.delivery.Assign(ctx, e.OrderID, e.Pickup)
+	switch {
+	case errors.Is(err, apperr.ErrNoCourierAvailable):
+		// the dispatcher assigns the order once a courier frees up
+		return p.delivery.Enqueue(ctx, e.OrderID, e.Pickup)
+	case errors.Is(err, apperr.ErrConflict):
 		return nil
 	}
 	return err
 }
 
 func (p *Processor) onCanceled(ctx context.Context, e Event) error {
-	_, err := p.delivery.Unassign(ctx, e.OrderID)
-	if errors.Is(err, apperr.ErrNotFound) {
-		return nil
+	if err := p.delivery.Dequeue(ctx, e.OrderID); err != nil && !errors.Is(err, apperr.ErrNotFound) {
+		return err
 	}
-	return err
+	_, err := p.delivery.Unassign(ctx, e.OrderID)
+	return ignoreFinished(err)
 }
 
 func (p *Processor) onCompleted(ctx context.Context, e Event) error {
-	return p.repo.WithTx(ctx, func(tx deliverytx.Repository) error {
-		d, err := tx.GetByOrderID(ctx, e.OrderID)
-		if err != nil {
-			return err
-		}
-		if d == nil {
-			return nil
-		}
-		return tx.UpdateCourierStatus(ctx, d.CourierID, domain.StatusAvailable)
-	})
+	_, err := p.delivery.Complete(ctx, e.OrderID)
+	return ignoreFinished(err)
+}
+
+// ignoreFinished drops errors meaning the order has no delivery or it has already left the active state
+func ignoreFinished(err error) error {
+	if errors.Is(err, apperr.ErrNotFound) || errors.Is(err, apperr.ErrConflict) {
+		return nil
+	}
+	return err
 }
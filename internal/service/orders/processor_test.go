@@ -11,98 +11,105 @@ import (
 
 	"course-go-avito-Orurh/internal/apperr"
 	"course-go-avito-Orurh/internal/domain"
-	"course-go-avito-Orurh/internal/ports/deliverytx"
 	"course-go-avito-Orurh/internal/service/orders"
 )
 
-type stubTx struct {
-	getFn    func(ctx context.Context, orderID string) (*domain.Delivery, error)
-	updateFn func(ctx context.Context, id int64, status domain.CourierStatus) error
-}
+func TestProcessor_Handle_Created_AssignOK(t *testing.T) {
+	t.Parallel()
 
-func (s *stubTx) FindAvailableCourierForUpdate(ctx context.Context) (*domain.Courier, error) {
-	panic("not used in orders processor tests")
-}
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
 
-func (s *stubTx) InsertDelivery(ctx context.Context, d *domain.Delivery) error {
-	panic("not used in orders processor tests")
-}
+	d := NewMockDeliveryPort(ctrl)
+	p := orders.NewProcessorWithDeps(d)
 
-func (s *stubTx) DeleteByOrderID(ctx context.Context, orderID string) error {
-	panic("not used in orders processor tests")
-}
+	d.EXPECT().
+		Assign(gomock.Any(), "order-1", nil).
+		Return(domain.AssignResult{}, nil)
 
-func (s *stubTx) GetByOrderID(ctx context.Context, orderID string) (*domain.Delivery, error) {
-	if s.getFn == nil {
-		return nil, nil
-	}
-	return s.getFn(ctx, orderID)
+	err := p.Handle(context.Background(), orders.Event{
+		OrderID:   "order-1",
+		Status:    "  CREATED  ",
+		CreatedAt: time.Now(),
+	})
+	require.NoError(t, err)
 }
 
-func (s *stubTx) UpdateCourierStatus(ctx context.Context, id int64, status domain.CourierStatus) error {
-	if s.updateFn == nil {
-		return nil
-	}
-	return s.updateFn(ctx, id, status)
-}
+func TestProcessor_Handle_Created_PassesPickup(t *testing.T) {
+	t.Parallel()
 
-type noopRunner struct{}
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
 
-func (noopRunner) WithTx(ctx context.Context, fn func(tx deliverytx.Repository) error) error {
-	panic("WithTx must not be called in this test")
-}
+	d := NewMockDeliveryPort(ctrl)
+	p := orders.NewProcessorWithDeps(d)
 
-type stubRunner struct {
-	withTx func(ctx context.Context, fn func(tx deliverytx.Repository) error) error
-}
+	pickup := &domain.Location{Lat: 55.75, Lon: 37.61}
+	d.EXPECT().
+		Assign(gomock.Any(), "order-1", pickup).
+		Return(domain.AssignResult{}, nil)
 
-func (s stubRunner) WithTx(ctx context.Context, fn func(tx deliverytx.Repository) error) error {
-	if s.withTx == nil {
-		return fn(nil)
-	}
-	return s.withTx(ctx, fn)
+	err := p.Handle(context.Background(), orders.Event{OrderID: "order-1", Status: "created", Pickup: pickup})
+	require.NoError(t, err)
 }
 
-func TestProcessor_Handle_Created_AssignOK(t *testing.T) {
+func TestProcessor_Handle_Created_ConflictIsIgnored(t *testing.T) {
 	t.Parallel()
 
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	d := NewMockDeliveryPort(ctrl)
-	r := noopRunner{}
-
-	p := orders.NewProcessorWithDeps(d, r)
+	p := orders.NewProcessorWithDeps(d)
 
 	d.EXPECT().
-		Assign(gomock.Any(), "order-1").
-		Return(domain.AssignResult{}, nil)
+		Assign(gomock.Any(), "order-1", nil).
+		Return(domain.AssignResult{}, apperr.ErrConflict)
 
-	err := p.Handle(context.Background(), orders.Event{
-		OrderID:   "order-1",
-		Status:    "  CREATED  ",
-		CreatedAt: time.Now(),
-	})
+	err := p.Handle(context.Background(), orders.Event{OrderID: "order-1", Status: "created"})
 	require.NoError(t, err)
 }
 
-func TestProcessor_Handle_Created_ConflictIsIgnored(t *testing.T) {
+func TestProcessor_Handle_Created_NoCourierEnqueues(t *testing.T) {
 	t.Parallel()
 
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	d := NewMockDeliveryPort(ctrl)
-	r := noopRunner{}
+	p := orders.NewProcessorWithDeps(d)
 
-	p := orders.NewProcessorWithDeps(d, r)
+	pickup := &domain.Location{Lat: 55.75, Lon: 37.61}
+	d.EXPECT().
+		Assign(gomock.Any(), "order-1", pickup).
+		Return(domain.AssignResult{}, apperr.ErrNoCourierAvailable)
+	d.EXPECT().
+		Enqueue(gomock.Any(), "order-1", pickup).
+		Return(nil)
+
+	err := p.Handle(context.Background(), orders.Event{OrderID: "order-1", Status: "created", Pickup: pickup})
+	require.NoError(t, err)
+}
 
+func TestProcessor_Handle_Created_EnqueueErrorReturned(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	d := NewMockDeliveryPort(ctrl)
+	p := orders.NewProcessorWithDeps(d)
+
+	wantErr := errors.New("db down")
 	d.EXPECT().
-		Assign(gomock.Any(), "order-1").
-		Return(domain.AssignResult{}, apperr.ErrConflict)
+		Assign(gomock.Any(), "order-1", nil).
+		Return(domain.AssignResult{}, apperr.ErrNoCourierAvailable)
+	d.EXPECT().
+		Enqueue(gomock.Any(), "order-1", nil).
+		Return(wantErr)
 
 	err := p.Handle(context.Background(), orders.Event{OrderID: "order-1", Status: "created"})
-	require.NoError(t, err)
+	require.ErrorIs(t, err, wantErr)
 }
 
 func TestProcessor_Handle_Created_OtherErrorReturned(t *testing.T) {
@@ -112,13 +119,11 @@ func TestProcessor_Handle_Created_OtherErrorReturned(t *testing.T) {
 	defer ctrl.Finish()
 
 	d := NewMockDeliveryPort(ctrl)
-	r := noopRunner{}
-
-	p := orders.NewProcessorWithDeps(d, r)
+	p := orders.NewProcessorWithDeps(d)
 
 	wantErr := errors.New("boom")
 	d.EXPECT().
-		Assign(gomock.Any(), "order-1").
+		Assign(gomock.Any(), "order-1", nil).
 		Return(domain.AssignResult{}, wantErr)
 
 	err := p.Handle(context.Background(), orders.Event{OrderID: "order-1", Status: "created"})
@@ -132,10 +137,11 @@ func TestProcessor_Handle_Canceled_UnassignOK(t *testing.T) {
 	defer ctrl.Finish()
 
 	d := NewMockDeliveryPort(ctrl)
-	r := noopRunner{}
-
-	p := orders.NewProcessorWithDeps(d, r)
+	p := orders.NewProcessorWithDeps(d)
 
+	d.EXPECT().
+		Dequeue(gomock.Any(), "order-2").
+		Return(apperr.ErrNotFound)
 	d.EXPECT().
 		Unassign(gomock.Any(), "order-2").
 		Return(domain.UnassignResult{}, nil)
@@ -151,10 +157,11 @@ func TestProcessor_Handle_Canceled_NotFoundIsIgnored(t *testing.T) {
 	defer ctrl.Finish()
 
 	d := NewMockDeliveryPort(ctrl)
-	r := noopRunner{}
-
-	p := orders.NewProcessorWithDeps(d, r)
+	p := orders.NewProcessorWithDeps(d)
 
+	d.EXPECT().
+		Dequeue(gomock.Any(), "order-2").
+		Return(nil)
 	d.EXPECT().
 		Unassign(gomock.Any(), "order-2").
 		Return(domain.UnassignResult{}, apperr.ErrNotFound)
@@ -163,75 +170,176 @@ func TestProcessor_Handle_Canceled_NotFoundIsIgnored(t *testing.T) {
 	require.NoError(t, err)
 }
 
-func TestProcessor_Handle_Completed_NoDelivery_NoUpdate(t *testing.T) {
+func TestProcessor_Handle_Canceled_DequeueErrorReturned(t *testing.T) {
 	t.Parallel()
 
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	d := NewMockDeliveryPort(ctrl)
-	r := stubRunner{
-		withTx: func(ctx context.Context, fn func(tx deliverytx.Repository) error) error {
-			tx := &stubTx{
-				getFn: func(ctx context.Context, orderID string) (*domain.Delivery, error) {
-					require.Equal(t, "order-3", orderID)
-					return nil, nil
-				},
-				updateFn: func(ctx context.Context, id int64, status domain.CourierStatus) error {
-					t.Fatalf("UpdateCourierStatus must not be called when delivery is nil")
-					return nil
-				},
-			}
-			return fn(tx)
-		},
-	}
+	p := orders.NewProcessorWithDeps(d)
+
+	wantErr := errors.New("db down")
+	d.EXPECT().
+		Dequeue(gomock.Any(), "order-2").
+		Return(wantErr)
+
+	err := p.Handle(context.Background(), orders.Event{OrderID: "order-2", Status: "canceled"})
+	require.ErrorIs(t, err, wantErr)
+}
+
+func TestProcessor_Handle_Completed_CompletesDelivery(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	d := NewMockDeliveryPort(ctrl)
+	p := orders.NewProcessorWithDeps(d)
+
+	d.EXPECT().
+		Complete(gomock.Any(), "order-4").
+		Return(domain.TransitionResult{OrderID: "order-4", Status: domain.DeliveryStatusDelivered}, nil)
 
-	p := orders.NewProcessorWithDeps(d, r)
+	err := p.Handle(context.Background(), orders.Event{OrderID: "order-4", Status: "completed"})
+	require.NoError(t, err)
+}
+
+func TestProcessor_Handle_Completed_NoDeliveryIsIgnored(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	d := NewMockDeliveryPort(ctrl)
+	p := orders.NewProcessorWithDeps(d)
+
+	d.EXPECT().
+		Complete(gomock.Any(), "order-3").
+		Return(domain.TransitionResult{}, apperr.ErrNotFound)
 
 	err := p.Handle(context.Background(), orders.Event{OrderID: "order-3", Status: "completed"})
 	require.NoError(t, err)
 }
 
-func TestProcessor_Handle_Completed_UpdateCourierStatus(t *testing.T) {
+func TestProcessor_Handle_Completed_AlreadyFinishedIsIgnored(t *testing.T) {
 	t.Parallel()
 
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	d := NewMockDeliveryPort(ctrl)
-	r := stubRunner{
-		withTx: func(ctx context.Context, fn func(tx deliverytx.Repository) error) error {
-			tx := &stubTx{
-				getFn: func(ctx context.Context, orderID string) (*domain.Delivery, error) {
-					return &domain.Delivery{OrderID: orderID, CourierID: 42}, nil
-				},
-				updateFn: func(ctx context.Context, id int64, status domain.CourierStatus) error {
-					require.Equal(t, int64(42), id)
-					require.Equal(t, domain.StatusAvailable, status)
-					return nil
-				},
-			}
-			return fn(tx)
-		},
-	}
+	p := orders.NewProcessorWithDeps(d)
 
-	p := orders.NewProcessorWithDeps(d, r)
+	d.EXPECT().
+		Complete(gomock.Any(), "order-5").
+		Return(domain.TransitionResult{}, apperr.ErrConflict)
 
-	err := p.Handle(context.Background(), orders.Event{OrderID: "order-4", Status: "completed"})
+	err := p.Handle(context.Background(), orders.Event{OrderID: "order-5", Status: "completed"})
 	require.NoError(t, err)
 }
 
-func TestProcessor_Handle_UnknownStatus_NoOps(t *testing.T) {
+func TestProcessor_Handle_Completed_OtherErrorReturned(t *testing.T) {
 	t.Parallel()
 
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	d := NewMockDeliveryPort(ctrl)
-	r := noopRunner{}
+	p := orders.NewProcessorWithDeps(d)
+
+	wantErr := errors.New("boom")
+	d.EXPECT().
+		Complete(gomock.Any(), "order-6").
+		Return(domain.TransitionResult{}, wantErr)
+
+	err := p.Handle(context.Background(), orders.Event{OrderID: "order-6", Status: "completed"})
+	require.ErrorIs(t, err, wantErr)
+}
+
+func TestProcessor_Handle_UnknownStatus_NoOps(t *testing.T) {
+	t.Parallel()
 
-	p := orders.NewProcessorWithDeps(d, r)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	d := NewMockDeliveryPort(ctrl)
+	p := orders.NewProcessorWithDeps(d)
 
 	err := p.Handle(context.Background(), orders.Event{OrderID: "order-x", Status: "some-new-status"})
 	require.NoError(t, err)
 }
+
+func TestProcessor_Handle_WithLedger_RunsInsideLedger(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	d := NewMockDeliveryPort(ctrl)
+	l := NewMockEventLedger(ctrl)
+	p := orders.NewProcessorWithDeps(d).WithLedger(l)
+
+	key := domain.EventKey{Topic: "orders", Partition: 1, Offset: 42}
+	type ctxKey struct{}
+	l.EXPECT().
+		Once(gomock.Any(), key, gomock.Any()).
+		DoAndReturn(func(ctx context.Context, _ domain.EventKey, fn func(context.Context) error) (bool, error) {
+			return true, fn(context.WithValue(ctx, ctxKey{}, "tx"))
+		})
+	d.EXPECT().
+		Assign(gomock.Any(), "order-1", nil).
+		DoAndReturn(func(ctx context.Context, _ string, _ *domain.Location) (domain.AssignResult, error) {
+			require.Equal(t, "tx", ctx.Value(ctxKey{}), "the business change runs in the ledger's context")
+			return domain.AssignResult{}, nil
+		})
+
+	err := p.Handle(context.Background(), orders.Event{OrderID: "order-1", Status: "created", Key: key})
+	require.NoError(t, err)
+}
+
+func TestProcessor_Handle_WithLedger_DuplicateIsNoOp(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	d := NewMockDeliveryPort(ctrl)
+	l := NewMockEventLedger(ctrl)
+	p := orders.NewProcessorWithDeps(d).WithLedger(l)
+
+	key := domain.EventKey{Topic: "orders", Partition: 1, Offset: 42}
+	l.EXPECT().Once(gomock.Any(), key, gomock.Any()).Return(false, nil)
+
+	err := p.Handle(context.Background(), orders.Event{OrderID: "order-1", Status: "created", Key: key})
+	require.NoError(t, err)
+}
+
+func TestProcessor_Handle_WithLedger_SkipsEventsWithoutKey(t *testing.T) {
+	t.Parallel()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	d := NewMockDeliveryPort(ctrl)
+	l := NewMockEventLedger(ctrl)
+	p := orders.NewProcessorWithDeps(d).WithLedger(l)
+
+	d.EXPECT().
+		Complete(gomock.Any(), "order-1").
+		Return(domain.TransitionResult{}, nil)
+
+	err := p.Handle(context.Background(), orders.Event{OrderID: "order-1", Status: "completed"})
+	require.NoError(t, err)
+}
+
+func TestKnownStatus(t *testing.T) {
+	t.Parallel()
+
+	for _, st := range []string{"created", " Canceled ", "deleted", "COMPLETED"} {
+		require.True(t, orders.KnownStatus(st), st)
+	}
+	for _, st := range []string{"", "paid", "shipped"} {
+		require.False(t, orders.KnownStatus(st), st)
+	}
+}
@@ -0,0 +1,176 @@
+package orders
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"course-go-avito-Orurh/internal/domain"
+	"course-go-avito-Orurh/internal/logx"
+)
+
+const (
+	defaultVerifyInterval = 30 * time.Second
+	defaultVerifyLease    = time.Minute
+	defaultVerifyBatch    = 50
+)
+
+// Outcomes of a verification.
+const (
+	// VerifyConfirmed - the orders service reports the status the event was applied with
+	VerifyConfirmed = "confirmed"
+	// VerifyCorrected - the orders service reports another status and it was applied
+	VerifyCorrected = "corrected"
+	// VerifyFailed - the order could not be checked, it is checked again after the lease
+	VerifyFailed = "failed"
+)
+
+// unknownOrderStatus is the status an order unknown to the orders service is corrected with:
+// the worker ignores events of such orders, so the delivery is released.
+const unknownOrderStatus = "deleted"
+
+// Verifier checks orders whose events were applied from their payload against the orders
+// service. If the orders service reports another status, the Verifier applies it through the
+// handler with the time of the checked event, so later events of the order still win.
+type Verifier struct {
+	queue     Verifications
+	statuses  OrderStatuses
+	handler   EventHandler
+	interval  time.Duration
+	lease     time.Duration
+	batchSize int
+	outcomes  labeledCounter
+	logger    logx.Logger
+	now       func() time.Time
+}
+
+// NewVerifier creates a Verifier. A claimed verification that fails is claimed again after lease.
+// Non-positive interval, lease and batch size fall back to defaults.
+func NewVerifier(
+	queue Verifications,
+	statuses OrderStatuses,
+	handler EventHandler,
+	interval, lease time.Duration,
+	batchSize int,
+	logger logx.Logger,
+) *Verifier {
+	if interval <= 0 {
+		interval = defaultVerifyInterval
+	}
+	if lease <= 0 {
+		lease = defaultVerifyLease
+	}
+	if batchSize <= 0 {
+		batchSize = defaultVerifyBatch
+	}
+	if logger == nil {
+		logger = logx.Nop()
+	}
+	return &Verifier{
+		queue:     queue,
+		statuses:  statuses,
+		handler:   handler,
+		interval:  interval,
+		lease:     lease,
+		batchSize: batchSize,
+		logger:    logger,
+		now:       time.Now,
+	}
+}
+
+// WithMetrics counts verifications by outcome; outcomes may be nil.
+func (v *Verifier) WithMetrics(outcomes labeledCounter) *Verifier {
+	v.outcomes = outcomes
+	return v
+}
+
+// Run verifies due orders every interval until ctx is canceled.
+func (v *Verifier) Run(ctx context.Context) {
+	ticker := time.NewTicker(v.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		for ctx.Err() == nil {
+			n, err := v.VerifyDue(ctx)
+			if err != nil {
+				v.logger.Error("order verification failed", logx.Any("err", err))
+				break
+			}
+			if n < v.batchSize {
+				break
+			}
+		}
+	}
+}
+
+// VerifyDue claims one batch of due verifications and checks them in order. It stops at the
+// first failure: the orders service is most likely still unavailable and the rest of the batch
+// is claimed again after the lease. It returns how many verifications were claimed.
+func (v *Verifier) VerifyDue(ctx context.Context) (int, error) {
+	due, err := v.queue.Claim(ctx, v.now(), v.batchSize, v.lease)
+	if err != nil {
+		return 0, err
+	}
+	for _, ver := range due {
+		if err := v.verify(ctx, ver); err != nil {
+			v.count(VerifyFailed)
+			return len(due), fmt.Errorf("verify order %q: %w", ver.OrderID, err)
+		}
+	}
+	return len(due), nil
+}
+
+func (v *Verifier) verify(ctx context.Context, ver domain.OrderVerification) error {
+	status, err := v.statuses.Status(ctx, ver.OrderID)
+	if err != nil {
+		return err
+	}
+	if status == "" {
+		status = unknownOrderStatus
+	}
+
+	outcome := VerifyConfirmed
+	if !sameAction(status, ver.Status) {
+		outcome = VerifyCorrected
+		if err := v.handler.Handle(ctx, Event{OrderID: ver.OrderID, Status: status, At: ver.At}); err != nil {
+			return err
+		}
+		v.logger.Warn("order applied from event payload corrected",
+			logx.String("order_id", ver.OrderID),
+			logx.String("payload_status", ver.Status),
+			logx.String("status", status),
+		)
+	}
+	if err := v.queue.Resolve(ctx, ver); err != nil {
+		return err
+	}
+	v.count(outcome)
+	return nil
+}
+
+func (v *Verifier) count(outcome string) {
+	if v.outcomes != nil {
+		v.outcomes.WithLabelValues(outcome).Inc()
+	}
+}
+
+// statusAliases maps statuses applied the same way to one of them
+var statusAliases = map[string]string{"deleted": "canceled"}
+
+// sameAction reports whether events with statuses a and b are applied the same way
+func sameAction(a, b string) bool {
+	return canonicalStatus(a) == canonicalStatus(b)
+}
+
+func canonicalStatus(status string) string {
+	status = normalizeStatus(status)
+	if alias, ok := statusAliases[status]; ok {
+		return alias
+	}
+	return status
+}
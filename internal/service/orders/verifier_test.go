@@ -0,0 +1,125 @@
+package orders_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	"course-go-avito-Orurh/internal/domain"
+	"course-go-avito-Orurh/internal/service/orders"
+	testlog "course-go-avito-Orurh/internal/testutil"
+)
+
+type verifierFixture struct {
+	queue    *MockVerifications
+	statuses *MockOrderStatuses
+	handler  *MockEventHandler
+	outcomes *prometheus.CounterVec
+	rec      *testlog.Recorder
+	v        *orders.Verifier
+}
+
+func newVerifierFixture(t *testing.T) *verifierFixture {
+	ctrl := gomock.NewController(t)
+	f := &verifierFixture{
+		queue:    NewMockVerifications(ctrl),
+		statuses: NewMockOrderStatuses(ctrl),
+		handler:  NewMockEventHandler(ctrl),
+		outcomes: prometheus.NewCounterVec(prometheus.CounterOpts{Name: "test_verifications_total"}, []string{"result"}),
+		rec:      testlog.New(),
+	}
+	f.v = orders.NewVerifier(f.queue, f.statuses, f.handler, time.Hour, time.Minute, 3, f.rec.Logger()).
+		WithMetrics(f.outcomes)
+	return f
+}
+
+func TestVerifier_ConfirmsAndCorrects(t *testing.T) {
+	t.Parallel()
+
+	f := newVerifierFixture(t)
+	at := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	due := []domain.OrderVerification{
+		{OrderID: "o1", Status: "created", At: at},
+		{OrderID: "o2", Status: "created", At: at},
+		{OrderID: "o3", Status: "canceled"},
+	}
+	f.queue.EXPECT().Claim(gomock.Any(), gomock.Any(), 3, time.Minute).Return(due, nil)
+
+	gomock.InOrder(
+		f.statuses.EXPECT().Status(gomock.Any(), "o1").Return("Created", nil),
+		f.queue.EXPECT().Resolve(gomock.Any(), due[0]).Return(nil),
+
+		f.statuses.EXPECT().Status(gomock.Any(), "o2").Return("canceled", nil),
+		f.handler.EXPECT().Handle(gomock.Any(), orders.Event{OrderID: "o2", Status: "canceled", At: at}).Return(nil),
+		f.queue.EXPECT().Resolve(gomock.Any(), due[1]).Return(nil),
+
+		// an order unknown to the orders service is released, which a canceled event has done already
+		f.statuses.EXPECT().Status(gomock.Any(), "o3").Return("", nil),
+		f.queue.EXPECT().Resolve(gomock.Any(), due[2]).Return(nil),
+	)
+
+	n, err := f.v.VerifyDue(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 3, n)
+	require.InDelta(t, 2, testutil.ToFloat64(f.outcomes.WithLabelValues(orders.VerifyConfirmed)), 0)
+	require.InDelta(t, 1, testutil.ToFloat64(f.outcomes.WithLabelValues(orders.VerifyCorrected)), 0)
+
+	var corrected []string
+	for _, e := range f.rec.Entries() {
+		if e.Msg == "order applied from event payload corrected" {
+			corrected = append(corrected, e.Fields[0].Value.(string))
+		}
+	}
+	require.Equal(t, []string{"o2"}, corrected)
+}
+
+func TestVerifier_ReleasesUnknownOrders(t *testing.T) {
+	t.Parallel()
+
+	f := newVerifierFixture(t)
+	v := domain.OrderVerification{OrderID: "o1", Status: "created"}
+	f.queue.EXPECT().Claim(gomock.Any(), gomock.Any(), 3, time.Minute).Return([]domain.OrderVerification{v}, nil)
+	f.statuses.EXPECT().Status(gomock.Any(), "o1").Return("", nil)
+	f.handler.EXPECT().Handle(gomock.Any(), orders.Event{OrderID: "o1", Status: "deleted"}).Return(nil)
+	f.queue.EXPECT().Resolve(gomock.Any(), v).Return(nil)
+
+	_, err := f.v.VerifyDue(context.Background())
+	require.NoError(t, err)
+}
+
+func TestVerifier_StopsAtFirstFailure(t *testing.T) {
+	t.Parallel()
+
+	f := newVerifierFixture(t)
+	sentinel := errors.New("orders service down")
+	f.queue.EXPECT().Claim(gomock.Any(), gomock.Any(), 3, time.Minute).Return([]domain.OrderVerification{
+		{OrderID: "o1", Status: "created"},
+		{OrderID: "o2", Status: "created"},
+	}, nil)
+	f.statuses.EXPECT().Status(gomock.Any(), "o1").Return("", sentinel)
+
+	n, err := f.v.VerifyDue(context.Background())
+	require.ErrorIs(t, err, sentinel)
+	require.Equal(t, 2, n)
+	require.InDelta(t, 1, testutil.ToFloat64(f.outcomes.WithLabelValues(orders.VerifyFailed)), 0)
+}
+
+func TestVerifier_KeepsVerificationWhenCorrectionFails(t *testing.T) {
+	t.Parallel()
+
+	f := newVerifierFixture(t)
+	sentinel := errors.New("db down")
+	f.queue.EXPECT().Claim(gomock.Any(), gomock.Any(), 3, time.Minute).
+		Return([]domain.OrderVerification{{OrderID: "o1", Status: "created"}}, nil)
+	f.statuses.EXPECT().Status(gomock.Any(), "o1").Return("completed", nil)
+	f.handler.EXPECT().Handle(gomock.Any(), gomock.Any()).Return(sentinel)
+
+	_, err := f.v.VerifyDue(context.Background())
+	require.ErrorIs(t, err, sentinel, "the verification is not resolved and is claimed again after the lease")
+}
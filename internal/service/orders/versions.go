@@ -0,0 +1,74 @@
+package orders
+
+import (
+	"context"
+	"strings"
+
+	"course-go-avito-Orurh/internal/domain"
+	"course-go-avito-Orurh/internal/logx"
+)
+
+// Reasons an event is rejected as out of order.
+const (
+	// RejectStale - the event was produced before the last applied one
+	RejectStale = "stale"
+	// RejectRegressive - the event moves the order back in its lifecycle, e.g. created after canceled
+	RejectRegressive = "regressive"
+)
+
+// statusRank orders the order lifecycle: an order never returns to a lower rank.
+var statusRank = map[string]int{
+	"created":   1,
+	"canceled":  2,
+	"deleted":   2,
+	"completed": 2,
+}
+
+func normalizeStatus(status string) string {
+	return strings.ToLower(strings.TrimSpace(status))
+}
+
+// outOfOrder returns why e must not be applied after last, or "" if it may be.
+// Times are compared only if both events carry one.
+func outOfOrder(last *domain.OrderVersion, e Event) string {
+	if last == nil {
+		return ""
+	}
+	if statusRank[normalizeStatus(e.Status)] < statusRank[last.Status] {
+		return RejectRegressive
+	}
+	if !e.At.IsZero() && !last.At.IsZero() && e.At.Before(last.At) {
+		return RejectStale
+	}
+	return ""
+}
+
+// applyVersioned applies e with fn unless it is out of order and records it as the last applied version.
+func (p *Processor) applyVersioned(ctx context.Context, fn actionFunc, e Event) error {
+	last, err := p.versions.Last(ctx, e.OrderID)
+	if err != nil {
+		return err
+	}
+	if reason := outOfOrder(last, e); reason != "" {
+		p.logger.Warn("out-of-order order event skipped",
+			logx.String("order_id", e.OrderID),
+			logx.String("status", e.Status),
+			logx.String("last_status", last.Status),
+			logx.String("reason", reason),
+			logx.String("event", e.Key.String()),
+		)
+		if p.rejected != nil {
+			p.rejected.WithLabelValues(reason).Inc()
+		}
+		return nil
+	}
+
+	if err := fn(ctx, e); err != nil {
+		return err
+	}
+	return p.versions.Save(ctx, domain.OrderVersion{
+		OrderID: e.OrderID,
+		Status:  normalizeStatus(e.Status),
+		At:      e.At,
+	})
+}
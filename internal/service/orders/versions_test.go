@@ -0,0 +1,114 @@
+package orders_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	"course-go-avito-Orurh/internal/domain"
+	"course-go-avito-Orurh/internal/service/orders"
+	testlog "course-go-avito-Orurh/internal/testutil"
+)
+
+// memVersions is an in-memory orders.OrderVersions
+type memVersions map[string]domain.OrderVersion
+
+func (m memVersions) Last(_ context.Context, orderID string) (*domain.OrderVersion, error) {
+	v, ok := m[orderID]
+	if !ok {
+		return nil, nil
+	}
+	return &v, nil
+}
+
+func (m memVersions) Save(_ context.Context, v domain.OrderVersion) error {
+	m[v.OrderID] = v
+	return nil
+}
+
+func newRejected() *prometheus.CounterVec {
+	return prometheus.NewCounterVec(prometheus.CounterOpts{Name: "orders_out_of_order_events_total"}, []string{"reason"})
+}
+
+var t0 = time.Date(2025, 1, 2, 3, 0, 0, 0, time.UTC)
+
+func TestProcessor_WithVersions_RegressiveEventIsSkipped(t *testing.T) {
+	t.Parallel()
+
+	d := NewMockDeliveryPort(gomock.NewController(t))
+	versions := memVersions{}
+	rejected := newRejected()
+	rec := testlog.New()
+	p := orders.NewProcessorWithDeps(d).WithVersions(versions, rejected).WithLogger(rec.Logger())
+
+	d.EXPECT().Dequeue(gomock.Any(), "order-1").Return(nil)
+	d.EXPECT().Unassign(gomock.Any(), "order-1").Return(domain.UnassignResult{}, nil)
+	require.NoError(t, p.Handle(context.Background(), orders.Event{OrderID: "order-1", Status: "canceled", At: t0.Add(time.Minute)}))
+	require.Equal(t, domain.OrderVersion{OrderID: "order-1", Status: "canceled", At: t0.Add(time.Minute)}, versions["order-1"])
+
+	// a delayed created arriving after canceled must not assign the order again, even if it looks newer
+	require.NoError(t, p.Handle(context.Background(), orders.Event{OrderID: "order-1", Status: "created", At: t0.Add(time.Hour)}))
+
+	require.Equal(t, "canceled", versions["order-1"].Status)
+	require.InDelta(t, 1, testutil.ToFloat64(rejected.WithLabelValues(orders.RejectRegressive)), 0)
+	require.True(t, hasMsg(rec.Entries(), "out-of-order order event skipped"))
+}
+
+func TestProcessor_WithVersions_StaleEventIsSkipped(t *testing.T) {
+	t.Parallel()
+
+	d := NewMockDeliveryPort(gomock.NewController(t))
+	versions := memVersions{"order-1": {OrderID: "order-1", Status: "completed", At: t0.Add(time.Minute)}}
+	rejected := newRejected()
+	p := orders.NewProcessorWithDeps(d).WithVersions(versions, rejected)
+
+	require.NoError(t, p.Handle(context.Background(), orders.Event{OrderID: "order-1", Status: "canceled", At: t0}))
+
+	require.Equal(t, "completed", versions["order-1"].Status)
+	require.InDelta(t, 1, testutil.ToFloat64(rejected.WithLabelValues(orders.RejectStale)), 0)
+}
+
+func TestProcessor_WithVersions_ForwardEventsAreApplied(t *testing.T) {
+	t.Parallel()
+
+	d := NewMockDeliveryPort(gomock.NewController(t))
+	versions := memVersions{}
+	p := orders.NewProcessorWithDeps(d).WithVersions(versions, nil)
+
+	d.EXPECT().Assign(gomock.Any(), "order-1", nil).Return(domain.AssignResult{}, nil)
+	d.EXPECT().Complete(gomock.Any(), "order-1").Return(domain.TransitionResult{}, nil)
+
+	require.NoError(t, p.Handle(context.Background(), orders.Event{OrderID: "order-1", Status: "Created", At: t0}))
+	// events without a time are checked by the lifecycle only
+	require.NoError(t, p.Handle(context.Background(), orders.Event{OrderID: "order-1", Status: "completed"}))
+
+	require.Equal(t, domain.OrderVersion{OrderID: "order-1", Status: "completed"}, versions["order-1"])
+}
+
+func TestProcessor_WithVersions_FailedEventIsNotRecorded(t *testing.T) {
+	t.Parallel()
+
+	d := NewMockDeliveryPort(gomock.NewController(t))
+	versions := memVersions{}
+	p := orders.NewProcessorWithDeps(d).WithVersions(versions, nil)
+
+	d.EXPECT().Complete(gomock.Any(), "order-1").Return(domain.TransitionResult{}, context.DeadlineExceeded)
+
+	err := p.Handle(context.Background(), orders.Event{OrderID: "order-1", Status: "completed", At: t0})
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Empty(t, versions)
+}
+
+func hasMsg(entries []testlog.Entry, msg string) bool {
+	for _, e := range entries {
+		if e.Msg == msg {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,49 @@
+//go:generate mockgen -source=contracts.go -destination=reconcile_mocks_test.go -package=reconcile_test
+package reconcile
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"course-go-avito-Orurh/internal/domain"
+	"course-go-avito-Orurh/internal/service/orders"
+)
+
+// Order is an order as the orders service reports it
+type Order struct {
+	ID        string
+	Status    string
+	CreatedAt time.Time
+}
+
+// OrderSource lists orders of the orders service
+type OrderSource interface {
+	// ListFrom returns the orders created at or after from
+	ListFrom(ctx context.Context, from time.Time) ([]Order, error)
+}
+
+// Deliveries tells what the courier service knows about an order
+type Deliveries interface {
+	// GetByOrderID returns the latest delivery of the order, or nil if it has none
+	GetByOrderID(ctx context.Context, orderID string) (*domain.Delivery, error)
+	// IsPendingOrder reports whether the order waits in the pending queue
+	IsPendingOrder(ctx context.Context, orderID string) (bool, error)
+}
+
+// Watermarks stores how far the reconciliation has got
+type Watermarks interface {
+	// Load returns the saved watermark, or zero time if none was saved yet
+	Load(ctx context.Context, name string) (time.Time, error)
+	Save(ctx context.Context, name string, at time.Time) error
+}
+
+// Fixer applies the status of an order the way an order event would
+type Fixer interface {
+	Handle(ctx context.Context, e orders.Event) error
+}
+
+type labeledCounter interface {
+	WithLabelValues(lvs ...string) prometheus.Counter
+}
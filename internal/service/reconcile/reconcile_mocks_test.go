@@ -0,0 +1,238 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: contracts.go
+
+// Package reconcile_test is a generated GoMock package.
+package reconcile_test
+
+import (
+	context "context"
+	domain "course-go-avito-Orurh/internal/domain"
+	orders "course-go-avito-Orurh/internal/service/orders"
+	reconcile "course-go-avito-Orurh/internal/service/reconcile"
+	reflect "reflect"
+	time "time"
+
+	gomock "github.com/golang/mock/gomock"
+	prometheus "github.com/prometheus/client_golang/prometheus"
+)
+
+// MockOrderSource is a mock of OrderSource interface.
+type MockOrderSource struct {
+	ctrl     *gomock.Controller
+	recorder *MockOrderSourceMockRecorder
+}
+
+// MockOrderSourceMockRecorder is the mock recorder for MockOrderSource.
+type MockOrderSourceMockRecorder struct {
+	mock *MockOrderSource
+}
+
+// NewMockOrderSource creates a new mock instance.
+func NewMockOrderSource(ctrl *gomock.Controller) *MockOrderSource {
+	mock := &MockOrderSource{ctrl: ctrl}
+	mock.recorder = &MockOrderSourceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOrderSource) EXPECT() *MockOrderSourceMockRecorder {
+	return m.recorder
+}
+
+// ListFrom mocks base method.
+func (m *MockOrderSource) ListFrom(ctx context.Context, from time.Time) ([]reconcile.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListFrom", ctx, from)
+	ret0, _ := ret[0].([]reconcile.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListFrom indicates an expected call of ListFrom.
+func (mr *MockOrderSourceMockRecorder) ListFrom(ctx, from interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListFrom", reflect.TypeOf((*MockOrderSource)(nil).ListFrom), ctx, from)
+}
+
+// MockDeliveries is a mock of Deliveries interface.
+type MockDeliveries struct {
+	ctrl     *gomock.Controller
+	recorder *MockDeliveriesMockRecorder
+}
+
+// MockDeliveriesMockRecorder is the mock recorder for MockDeliveries.
+type MockDeliveriesMockRecorder struct {
+	mock *MockDeliveries
+}
+
+// NewMockDeliveries creates a new mock instance.
+func NewMockDeliveries(ctrl *gomock.Controller) *MockDeliveries {
+	mock := &MockDeliveries{ctrl: ctrl}
+	mock.recorder = &MockDeliveriesMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDeliveries) EXPECT() *MockDeliveriesMockRecorder {
+	return m.recorder
+}
+
+// GetByOrderID mocks base method.
+func (m *MockDeliveries) GetByOrderID(ctx context.Context, orderID string) (*domain.Delivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByOrderID", ctx, orderID)
+	ret0, _ := ret[0].(*domain.Delivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByOrderID indicates an expected call of GetByOrderID.
+func (mr *MockDeliveriesMockRecorder) GetByOrderID(ctx, orderID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByOrderID", reflect.TypeOf((*MockDeliveries)(nil).GetByOrderID), ctx, orderID)
+}
+
+// IsPendingOrder mocks base method.
+func (m *MockDeliveries) IsPendingOrder(ctx context.Context, orderID string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsPendingOrder", ctx, orderID)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsPendingOrder indicates an expected call of IsPendingOrder.
+func (mr *MockDeliveriesMockRecorder) IsPendingOrder(ctx, orderID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsPendingOrder", reflect.TypeOf((*MockDeliveries)(nil).IsPendingOrder), ctx, orderID)
+}
+
+// MockWatermarks is a mock of Watermarks interface.
+type MockWatermarks struct {
+	ctrl     *gomock.Controller
+	recorder *MockWatermarksMockRecorder
+}
+
+// MockWatermarksMockRecorder is the mock recorder for MockWatermarks.
+type MockWatermarksMockRecorder struct {
+	mock *MockWatermarks
+}
+
+// NewMockWatermarks creates a new mock instance.
+func NewMockWatermarks(ctrl *gomock.Controller) *MockWatermarks {
+	mock := &MockWatermarks{ctrl: ctrl}
+	mock.recorder = &MockWatermarksMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockWatermarks) EXPECT() *MockWatermarksMockRecorder {
+	return m.recorder
+}
+
+// Load mocks base method.
+func (m *MockWatermarks) Load(ctx context.Context, name string) (time.Time, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Load", ctx, name)
+	ret0, _ := ret[0].(time.Time)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Load indicates an expected call of Load.
+func (mr *MockWatermarksMockRecorder) Load(ctx, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Load", reflect.TypeOf((*MockWatermarks)(nil).Load), ctx, name)
+}
+
+// Save mocks base method.
+func (m *MockWatermarks) Save(ctx context.Context, name string, at time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Save", ctx, name, at)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Save indicates an expected call of Save.
+func (mr *MockWatermarksMockRecorder) Save(ctx, name, at interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockWatermarks)(nil).Save), ctx, name, at)
+}
+
+// MockFixer is a mock of Fixer interface.
+type MockFixer struct {
+	ctrl     *gomock.Controller
+	recorder *MockFixerMockRecorder
+}
+
+// MockFixerMockRecorder is the mock recorder for MockFixer.
+type MockFixerMockRecorder struct {
+	mock *MockFixer
+}
+
+// NewMockFixer creates a new mock instance.
+func NewMockFixer(ctrl *gomock.Controller) *MockFixer {
+	mock := &MockFixer{ctrl: ctrl}
+	mock.recorder = &MockFixerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockFixer) EXPECT() *MockFixerMockRecorder {
+	return m.recorder
+}
+
+// Handle mocks base method.
+func (m *MockFixer) Handle(ctx context.Context, e orders.Event) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Handle", ctx, e)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Handle indicates an expected call of Handle.
+func (mr *MockFixerMockRecorder) Handle(ctx, e interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Handle", reflect.TypeOf((*MockFixer)(nil).Handle), ctx, e)
+}
+
+// MocklabeledCounter is a mock of labeledCounter interface.
+type MocklabeledCounter struct {
+	ctrl     *gomock.Controller
+	recorder *MocklabeledCounterMockRecorder
+}
+
+// MocklabeledCounterMockRecorder is the mock recorder for MocklabeledCounter.
+type MocklabeledCounterMockRecorder struct {
+	mock *MocklabeledCounter
+}
+
+// NewMocklabeledCounter creates a new mock instance.
+func NewMocklabeledCounter(ctrl *gomock.Controller) *MocklabeledCounter {
+	mock := &MocklabeledCounter{ctrl: ctrl}
+	mock.recorder = &MocklabeledCounterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MocklabeledCounter) EXPECT() *MocklabeledCounterMockRecorder {
+	return m.recorder
+}
+
+// WithLabelValues mocks base method.
+func (m *MocklabeledCounter) WithLabelValues(lvs ...string) prometheus.Counter {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{}
+	for _, a := range lvs {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "WithLabelValues", varargs...)
+	ret0, _ := ret[0].(prometheus.Counter)
+	return ret0
+}
+
+// WithLabelValues indicates an expected call of WithLabelValues.
+func (mr *MocklabeledCounterMockRecorder) WithLabelValues(lvs ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithLabelValues", reflect.TypeOf((*MocklabeledCounter)(nil).WithLabelValues), lvs...)
+}
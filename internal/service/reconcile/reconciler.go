@@ -0,0 +1,290 @@
+package reconcile
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"course-go-avito-Orurh/internal/logx"
+	"course-go-avito-Orurh/internal/service/orders"
+)
+
+// WatermarkName is the name the reconciliation watermark is saved under
+const WatermarkName = "orders_reconcile"
+
+const (
+	defaultInterval = 5 * time.Minute
+	defaultLookback = time.Hour
+)
+
+// Kinds of drift between the orders service and the delivery table.
+const (
+	// DriftMissing - a created order has neither a delivery nor a place in the pending queue
+	DriftMissing = "missing"
+	// DriftNotReleased - a canceled order still holds a courier or waits in the pending queue
+	DriftNotReleased = "not_released"
+	// DriftNotCompleted - a completed order still holds a courier
+	DriftNotCompleted = "not_completed"
+)
+
+// fixStatus is the order status a drift is fixed with
+var fixStatus = map[string]string{
+	DriftMissing:      "created",
+	DriftNotReleased:  "canceled",
+	DriftNotCompleted: "completed",
+}
+
+// Report describes what a reconciliation run did
+type Report struct {
+	DryRun bool
+	// From is the time the orders were listed from
+	From time.Time
+	// Watermark is the watermark after the run
+	Watermark time.Time
+	Checked   int
+	// Found and Fixed count orders by drift kind
+	Found map[string]int
+	Fixed map[string]int
+	// Failed counts orders that could not be checked or fixed
+	Failed int
+}
+
+// Drift returns how many orders drifted
+func (r Report) Drift() int {
+	n := 0
+	for _, c := range r.Found {
+		n += c
+	}
+	return n
+}
+
+// Write prints the report as a table, drift kinds in alphabetical order
+func (r Report) Write(w io.Writer) error {
+	mode := "fixed"
+	if r.DryRun {
+		mode = "dry run, nothing fixed"
+	}
+	if _, err := fmt.Fprintf(w, "checked %d orders from %s, %d drifted (%s)\n",
+		r.Checked, r.From.Format(time.RFC3339), r.Drift(), mode); err != nil {
+		return err
+	}
+	kinds := make([]string, 0, len(r.Found))
+	for k := range r.Found {
+		kinds = append(kinds, k)
+	}
+	slices.Sort(kinds)
+	for _, k := range kinds {
+		if _, err := fmt.Fprintf(w, "  %-14s found %d, fixed %d\n", k, r.Found[k], r.Fixed[k]); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "  %-14s %d\n  %-14s %s\n", "failed", r.Failed, "watermark", r.Watermark.Format(time.RFC3339))
+	return err
+}
+
+// Reconciler is the safety net for order events lost or skipped on the way from Kafka.
+// It lists the orders of the orders service and fixes the ones the delivery table disagrees with:
+// it assigns created orders nobody handled and releases canceled and completed ones.
+//
+// The orders service lists orders by creation time, so every run lists from the watermark,
+// the latest creation time seen, minus lookback: an order whose status changes later than
+// lookback after its creation is not reconciled.
+type Reconciler struct {
+	orders     OrderSource
+	deliveries Deliveries
+	watermarks Watermarks
+	fixer      Fixer
+	interval   time.Duration
+	lookback   time.Duration
+	dryRun     bool
+	found      labeledCounter
+	fixed      labeledCounter
+	logger     logx.Logger
+	now        func() time.Time
+}
+
+// NewReconciler creates a Reconciler. Non-positive interval and lookback fall back to defaults.
+func NewReconciler(
+	src OrderSource,
+	deliveries Deliveries,
+	watermarks Watermarks,
+	fixer Fixer,
+	interval, lookback time.Duration,
+	logger logx.Logger,
+) *Reconciler {
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+	if lookback <= 0 {
+		lookback = defaultLookback
+	}
+	if logger == nil {
+		logger = logx.Nop()
+	}
+	return &Reconciler{
+		orders:     src,
+		deliveries: deliveries,
+		watermarks: watermarks,
+		fixer:      fixer,
+		interval:   interval,
+		lookback:   lookback,
+		logger:     logger,
+		now:        time.Now,
+	}
+}
+
+// WithDryRun makes the Reconciler only report drift: nothing is fixed and the watermark stays.
+func (r *Reconciler) WithDryRun(dryRun bool) *Reconciler {
+	r.dryRun = dryRun
+	return r
+}
+
+// WithMetrics counts drifted and fixed orders by drift kind; both may be nil.
+func (r *Reconciler) WithMetrics(found, fixed labeledCounter) *Reconciler {
+	r.found = found
+	r.fixed = fixed
+	return r
+}
+
+// Run reconciles orders every interval until ctx is canceled.
+func (r *Reconciler) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		report, err := r.Reconcile(ctx)
+		if err != nil {
+			r.logger.Error("order reconciliation failed", logx.Any("err", err))
+			continue
+		}
+		r.logger.Info("orders reconciled",
+			logx.Int("checked", report.Checked),
+			logx.Int("drift", report.Drift()),
+			logx.Int("failed", report.Failed),
+			logx.Any("dry_run", report.DryRun),
+			logx.Time("watermark", report.Watermark),
+		)
+	}
+}
+
+// Reconcile runs one reconciliation. An order that fails is counted in the report and holds the
+// watermark back, so that the next run checks it again.
+func (r *Reconciler) Reconcile(ctx context.Context) (Report, error) {
+	watermark, err := r.watermarks.Load(ctx, WatermarkName)
+	if err != nil {
+		return Report{}, err
+	}
+	from := r.now().Add(-r.lookback)
+	if !watermark.IsZero() {
+		from = watermark.Add(-r.lookback)
+	}
+	list, err := r.orders.ListFrom(ctx, from)
+	if err != nil {
+		return Report{}, fmt.Errorf("list orders from %s: %w", from.Format(time.RFC3339), err)
+	}
+
+	report := Report{DryRun: r.dryRun, From: from, Found: map[string]int{}, Fixed: map[string]int{}}
+	next := watermark
+	var firstFailed time.Time
+	for _, o := range list {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		report.Checked++
+		if err := r.reconcile(ctx, o, &report); err != nil {
+			report.Failed++
+			r.logger.Error("order reconciliation failed",
+				logx.String("order_id", o.ID),
+				logx.String("status", o.Status),
+				logx.Any("err", err),
+			)
+			if firstFailed.IsZero() || o.CreatedAt.Before(firstFailed) {
+				firstFailed = o.CreatedAt
+			}
+		}
+		if o.CreatedAt.After(next) {
+			next = o.CreatedAt
+		}
+	}
+	if !firstFailed.IsZero() && firstFailed.Before(next) {
+		next = firstFailed
+		if next.Before(watermark) {
+			next = watermark
+		}
+	}
+	report.Watermark = next
+
+	if r.dryRun || !next.After(watermark) {
+		return report, nil
+	}
+	return report, r.watermarks.Save(ctx, WatermarkName, next)
+}
+
+func (r *Reconciler) reconcile(ctx context.Context, o Order, report *Report) error {
+	kind, err := r.drift(ctx, o)
+	if err != nil || kind == "" {
+		return err
+	}
+	report.Found[kind]++
+	count(r.found, kind)
+	r.logger.Warn("order drift found",
+		logx.String("order_id", o.ID),
+		logx.String("status", o.Status),
+		logx.String("drift", kind),
+		logx.Any("dry_run", r.dryRun),
+	)
+	if r.dryRun {
+		return nil
+	}
+
+	if err := r.fixer.Handle(ctx, orders.Event{OrderID: o.ID, Status: fixStatus[kind], CreatedAt: o.CreatedAt}); err != nil {
+		return fmt.Errorf("fix %s: %w", kind, err)
+	}
+	report.Fixed[kind]++
+	count(r.fixed, kind)
+	return nil
+}
+
+// drift returns how the delivery table disagrees with the order, or "" if it does not
+func (r *Reconciler) drift(ctx context.Context, o Order) (string, error) {
+	status := strings.ToLower(strings.TrimSpace(o.Status))
+	switch status {
+	case "created", "canceled", "deleted", "completed":
+	default:
+		return "", nil
+	}
+
+	d, err := r.deliveries.GetByOrderID(ctx, o.ID)
+	if err != nil {
+		return "", err
+	}
+	pending, err := r.deliveries.IsPendingOrder(ctx, o.ID)
+	if err != nil {
+		return "", err
+	}
+	active := d != nil && d.Status.Active()
+
+	switch {
+	case status == "created" && d == nil && !pending:
+		return DriftMissing, nil
+	case status == "completed" && active:
+		return DriftNotCompleted, nil
+	case status != "created" && (active || pending):
+		return DriftNotReleased, nil
+	}
+	return "", nil
+}
+
+func count(c labeledCounter, kind string) {
+	if c != nil {
+		c.WithLabelValues(kind).Inc()
+	}
+}
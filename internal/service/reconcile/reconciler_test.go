@@ -0,0 +1,183 @@
+package reconcile_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	"course-go-avito-Orurh/internal/domain"
+	"course-go-avito-Orurh/internal/logx"
+	"course-go-avito-Orurh/internal/service/orders"
+	"course-go-avito-Orurh/internal/service/reconcile"
+)
+
+var base = time.Date(2025, 1, 2, 3, 0, 0, 0, time.UTC)
+
+type reconcilerFixture struct {
+	src        *MockOrderSource
+	deliveries *MockDeliveries
+	watermarks *MockWatermarks
+	fixer      *MockFixer
+	found      *prometheus.CounterVec
+	fixed      *prometheus.CounterVec
+	r          *reconcile.Reconciler
+}
+
+func newReconcilerFixture(t *testing.T) *reconcilerFixture {
+	ctrl := gomock.NewController(t)
+	f := &reconcilerFixture{
+		src:        NewMockOrderSource(ctrl),
+		deliveries: NewMockDeliveries(ctrl),
+		watermarks: NewMockWatermarks(ctrl),
+		fixer:      NewMockFixer(ctrl),
+		found:      prometheus.NewCounterVec(prometheus.CounterOpts{Name: "test_found_total"}, []string{"kind"}),
+		fixed:      prometheus.NewCounterVec(prometheus.CounterOpts{Name: "test_fixed_total"}, []string{"kind"}),
+	}
+	f.r = reconcile.NewReconciler(f.src, f.deliveries, f.watermarks, f.fixer, time.Hour, time.Hour, logx.Nop()).
+		WithMetrics(f.found, f.fixed)
+	return f
+}
+
+// delivery sets what the delivery table knows about the order
+func (f *reconcilerFixture) delivery(orderID string, status domain.DeliveryStatus, pending bool) {
+	var d *domain.Delivery
+	if status != "" {
+		d = &domain.Delivery{OrderID: orderID, Status: status}
+	}
+	f.deliveries.EXPECT().GetByOrderID(gomock.Any(), orderID).Return(d, nil)
+	f.deliveries.EXPECT().IsPendingOrder(gomock.Any(), orderID).Return(pending, nil)
+}
+
+func (f *reconcilerFixture) expectFix(orderID, status string, createdAt time.Time, err error) {
+	f.fixer.EXPECT().Handle(gomock.Any(), orders.Event{OrderID: orderID, Status: status, CreatedAt: createdAt}).Return(err)
+}
+
+func TestReconciler_FixesDrift(t *testing.T) {
+	t.Parallel()
+
+	f := newReconcilerFixture(t)
+	f.watermarks.EXPECT().Load(gomock.Any(), reconcile.WatermarkName).Return(base, nil)
+	f.src.EXPECT().ListFrom(gomock.Any(), base.Add(-time.Hour)).Return([]reconcile.Order{
+		{ID: "missing", Status: "created", CreatedAt: base.Add(1 * time.Minute)},
+		{ID: "assigned", Status: "created", CreatedAt: base.Add(2 * time.Minute)},
+		{ID: "queued", Status: "created", CreatedAt: base.Add(3 * time.Minute)},
+		{ID: "canceled", Status: "Canceled", CreatedAt: base.Add(4 * time.Minute)},
+		{ID: "completed", Status: "completed", CreatedAt: base.Add(5 * time.Minute)},
+		{ID: "completed-queued", Status: "completed", CreatedAt: base.Add(6 * time.Minute)},
+		{ID: "deleted", Status: "deleted", CreatedAt: base.Add(7 * time.Minute)},
+		{ID: "paid", Status: "paid", CreatedAt: base.Add(8 * time.Minute)},
+	}, nil)
+
+	f.delivery("missing", "", false)
+	f.expectFix("missing", "created", base.Add(1*time.Minute), nil)
+	f.delivery("assigned", domain.DeliveryStatusAssigned, false)
+	f.delivery("queued", "", true)
+	f.delivery("canceled", domain.DeliveryStatusPickedUp, false)
+	f.expectFix("canceled", "canceled", base.Add(4*time.Minute), nil)
+	f.delivery("completed", domain.DeliveryStatusAssigned, false)
+	f.expectFix("completed", "completed", base.Add(5*time.Minute), nil)
+	f.delivery("completed-queued", "", true)
+	f.expectFix("completed-queued", "canceled", base.Add(6*time.Minute), nil)
+	f.delivery("deleted", domain.DeliveryStatusDelivered, false)
+
+	f.watermarks.EXPECT().Save(gomock.Any(), reconcile.WatermarkName, base.Add(8*time.Minute)).Return(nil)
+
+	report, err := f.r.Reconcile(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 8, report.Checked)
+	require.Equal(t, 4, report.Drift())
+	want := map[string]int{reconcile.DriftMissing: 1, reconcile.DriftNotReleased: 2, reconcile.DriftNotCompleted: 1}
+	require.Equal(t, want, report.Found)
+	require.Equal(t, want, report.Fixed)
+	require.Zero(t, report.Failed)
+	require.InDelta(t, 2, testutil.ToFloat64(f.found.WithLabelValues(reconcile.DriftNotReleased)), 0)
+	require.InDelta(t, 2, testutil.ToFloat64(f.fixed.WithLabelValues(reconcile.DriftNotReleased)), 0)
+}
+
+func TestReconciler_DryRunFixesNothing(t *testing.T) {
+	t.Parallel()
+
+	f := newReconcilerFixture(t)
+	f.r.WithDryRun(true)
+	f.watermarks.EXPECT().Load(gomock.Any(), reconcile.WatermarkName).Return(base, nil)
+	f.src.EXPECT().ListFrom(gomock.Any(), gomock.Any()).
+		Return([]reconcile.Order{{ID: "missing", Status: "created", CreatedAt: base.Add(time.Minute)}}, nil)
+	f.delivery("missing", "", false)
+
+	report, err := f.r.Reconcile(context.Background())
+	require.NoError(t, err)
+	require.True(t, report.DryRun)
+	require.Equal(t, map[string]int{reconcile.DriftMissing: 1}, report.Found)
+	require.Empty(t, report.Fixed)
+	require.Equal(t, base.Add(time.Minute), report.Watermark, "the report shows the watermark, it is not saved")
+	require.InDelta(t, 1, testutil.ToFloat64(f.found.WithLabelValues(reconcile.DriftMissing)), 0)
+	require.Zero(t, testutil.CollectAndCount(f.fixed))
+}
+
+func TestReconciler_FailureHoldsWatermarkBack(t *testing.T) {
+	t.Parallel()
+
+	f := newReconcilerFixture(t)
+	sentinel := errors.New("db down")
+	f.watermarks.EXPECT().Load(gomock.Any(), reconcile.WatermarkName).Return(time.Time{}, nil)
+	f.src.EXPECT().ListFrom(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, from time.Time) ([]reconcile.Order, error) {
+			require.WithinDuration(t, time.Now().Add(-time.Hour), from, time.Minute, "the first run looks back from now")
+			return []reconcile.Order{
+				{ID: "o1", Status: "created", CreatedAt: base.Add(2 * time.Minute)},
+				{ID: "o2", Status: "created", CreatedAt: base.Add(time.Minute)},
+				{ID: "o3", Status: "created", CreatedAt: base.Add(3 * time.Minute)},
+			}, nil
+		})
+	f.delivery("o1", "", false)
+	f.expectFix("o1", "created", base.Add(2*time.Minute), sentinel)
+	f.deliveries.EXPECT().GetByOrderID(gomock.Any(), "o2").Return(nil, sentinel)
+	f.delivery("o3", domain.DeliveryStatusAssigned, false)
+	f.watermarks.EXPECT().Save(gomock.Any(), reconcile.WatermarkName, base.Add(time.Minute)).Return(nil)
+
+	report, err := f.r.Reconcile(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 2, report.Failed)
+	require.Equal(t, map[string]int{reconcile.DriftMissing: 1}, report.Found)
+	require.Empty(t, report.Fixed)
+}
+
+func TestReconciler_ListError(t *testing.T) {
+	t.Parallel()
+
+	f := newReconcilerFixture(t)
+	sentinel := errors.New("orders service down")
+	f.watermarks.EXPECT().Load(gomock.Any(), reconcile.WatermarkName).Return(base, nil)
+	f.src.EXPECT().ListFrom(gomock.Any(), gomock.Any()).Return(nil, sentinel)
+
+	_, err := f.r.Reconcile(context.Background())
+	require.ErrorIs(t, err, sentinel)
+}
+
+func TestReport_Write(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	require.NoError(t, reconcile.Report{
+		DryRun:    true,
+		From:      base,
+		Watermark: base.Add(time.Hour),
+		Checked:   5,
+		Found:     map[string]int{reconcile.DriftNotReleased: 1, reconcile.DriftMissing: 2},
+		Fixed:     map[string]int{},
+		Failed:    1,
+	}.Write(&buf))
+	require.Equal(t, `checked 5 orders from 2025-01-02T03:00:00Z, 3 drifted (dry run, nothing fixed)
+  missing        found 2, fixed 0
+  not_released   found 1, fixed 0
+  failed         1
+  watermark      2025-01-02T04:00:00Z
+`, buf.String())
+}
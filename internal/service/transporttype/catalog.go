@@ -0,0 +1,87 @@
+package transporttype
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"course-go-avito-Orurh/internal/domain"
+	"course-go-avito-Orurh/internal/logx"
+)
+
+const (
+	defaultRefreshInterval = 30 * time.Second
+	refreshTimeout         = 3 * time.Second
+)
+
+// Catalog caches transport types loaded from storage. It is shared by the services that validate
+// transports, count capacity and compute deadlines, so they follow the table without a redeploy.
+// Until the first successful load the built-in domain.DefaultTransportTypes are used.
+type Catalog struct {
+	repo     transportTypeLister
+	interval time.Duration
+	logger   logx.Logger
+	types    atomic.Pointer[domain.TransportTypeSet]
+}
+
+// NewCatalog creates a Catalog. A non-positive interval falls back to the default.
+func NewCatalog(repo transportTypeLister, interval time.Duration, logger logx.Logger) *Catalog {
+	if interval <= 0 {
+		interval = defaultRefreshInterval
+	}
+	if logger == nil {
+		logger = logx.Nop()
+	}
+	c := &Catalog{repo: repo, interval: interval, logger: logger}
+	c.set(nil)
+	return c
+}
+
+// Get returns the cached transport type with the given code.
+func (c *Catalog) Get(code domain.CourierTransportType) (domain.TransportType, bool) {
+	return c.types.Load().Get(code)
+}
+
+// set replaces the cached transport types, an empty list restores the built-in defaults
+func (c *Catalog) set(types []domain.TransportType) {
+	if len(types) == 0 {
+		types = domain.DefaultTransportTypes()
+	}
+	set := domain.NewTransportTypeSet(types)
+	c.types.Store(&set)
+}
+
+// Refresh reloads transport types from storage. On error the cached ones are kept.
+func (c *Catalog) Refresh(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, refreshTimeout)
+	defer cancel()
+
+	types, err := c.repo.List(ctx)
+	if err != nil {
+		return err
+	}
+	c.set(types)
+	return nil
+}
+
+// Run refreshes the catalog right away and then every interval until ctx is done.
+func (c *Catalog) Run(ctx context.Context) {
+	c.refreshOrLog(ctx)
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.refreshOrLog(ctx)
+		}
+	}
+}
+
+func (c *Catalog) refreshOrLog(ctx context.Context) {
+	if err := c.Refresh(ctx); err != nil && ctx.Err() == nil {
+		c.logger.Warn("transport types refresh failed, keeping cached ones", logx.Any("err", err))
+	}
+}
@@ -0,0 +1,114 @@
+package transporttype_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"course-go-avito-Orurh/internal/domain"
+	"course-go-avito-Orurh/internal/service/transporttype"
+)
+
+var cargoBike = domain.TransportType{
+	Code:         "cargo_bike",
+	SpeedKmh:     12,
+	MaxCapacity:  3,
+	BaseDeadline: 25 * time.Minute,
+}
+
+func TestCatalog_DefaultsBeforeRefresh(t *testing.T) {
+	t.Parallel()
+
+	c := transporttype.NewCatalog(NewMocktransportTypeLister(gomock.NewController(t)), time.Second, nil)
+
+	car, ok := c.Get(domain.TransportTypeCar)
+	require.True(t, ok)
+	require.Equal(t, 5*time.Minute, car.BaseDeadline)
+
+	bike, ok := c.Get(domain.TransportTypeBike)
+	require.True(t, ok, "the built-in types match the seeded table")
+	require.Equal(t, 2, bike.MaxCapacity)
+
+	_, ok = c.Get("cargo_bike")
+	require.False(t, ok)
+}
+
+func TestCatalog_Refresh_ReplacesTypes(t *testing.T) {
+	t.Parallel()
+
+	repo := NewMocktransportTypeLister(gomock.NewController(t))
+	repo.EXPECT().List(gomock.Any()).Return([]domain.TransportType{cargoBike}, nil)
+
+	c := transporttype.NewCatalog(repo, time.Second, nil)
+	require.NoError(t, c.Refresh(context.Background()))
+
+	got, ok := c.Get("cargo_bike")
+	require.True(t, ok)
+	require.Equal(t, cargoBike, got)
+
+	require.True(t, domain.CourierTransportType("cargo_bike").Valid(c))
+	require.Equal(t, 3, domain.CourierTransportType("cargo_bike").Capacity(c))
+	require.False(t, domain.TransportTypeCar.Valid(c), "types missing from the table are no longer valid")
+}
+
+func TestCatalog_Refresh_ErrorKeepsCache(t *testing.T) {
+	t.Parallel()
+
+	wantErr := errors.New("db down")
+	repo := NewMocktransportTypeLister(gomock.NewController(t))
+	gomock.InOrder(
+		repo.EXPECT().List(gomock.Any()).Return([]domain.TransportType{cargoBike}, nil),
+		repo.EXPECT().List(gomock.Any()).Return(nil, wantErr),
+	)
+
+	c := transporttype.NewCatalog(repo, time.Second, nil)
+	require.NoError(t, c.Refresh(context.Background()))
+	require.ErrorIs(t, c.Refresh(context.Background()), wantErr)
+
+	_, ok := c.Get("cargo_bike")
+	require.True(t, ok)
+}
+
+func TestCatalog_Run_RefreshesUntilCanceled(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	loaded := make(chan struct{}, 2)
+	repo := NewMocktransportTypeLister(gomock.NewController(t))
+	repo.EXPECT().List(gomock.Any()).
+		DoAndReturn(func(context.Context) ([]domain.TransportType, error) {
+			select {
+			case loaded <- struct{}{}:
+			default:
+			}
+			return []domain.TransportType{cargoBike}, nil
+		}).
+		MinTimes(2)
+
+	done := make(chan struct{})
+	go func() {
+		transporttype.NewCatalog(repo, 10*time.Millisecond, nil).Run(ctx)
+		close(done)
+	}()
+
+	for range 2 {
+		select {
+		case <-loaded:
+		case <-time.After(time.Second):
+			t.Fatal("catalog was not refreshed")
+		}
+	}
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run did not stop after cancel")
+	}
+}
@@ -0,0 +1,22 @@
+//go:generate mockgen -source=contracts.go -destination=transporttype_mocks_test.go -package=transporttype_test
+package transporttype
+
+import (
+	"context"
+
+	"course-go-avito-Orurh/internal/domain"
+)
+
+// transportTypeLister loads every transport type the Catalog caches.
+type transportTypeLister interface {
+	List(ctx context.Context) ([]domain.TransportType, error)
+}
+
+// transportTypeRepository defines storage operations required by the admin Service.
+type transportTypeRepository interface {
+	transportTypeLister
+	Get(ctx context.Context, code domain.CourierTransportType) (*domain.TransportType, error)
+	Create(ctx context.Context, t domain.TransportType) error
+	Update(ctx context.Context, t domain.TransportType) (bool, error)
+	Delete(ctx context.Context, code domain.CourierTransportType) (bool, error)
+}
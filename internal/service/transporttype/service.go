@@ -0,0 +1,103 @@
+package transporttype
+
+import (
+	"context"
+	"time"
+
+	"course-go-avito-Orurh/internal/apperr"
+	"course-go-avito-Orurh/internal/domain"
+)
+
+// Service manages transport types and keeps the Catalog in sync with every change.
+type Service struct {
+	repo             transportTypeRepository
+	catalog          *Catalog
+	operationTimeout time.Duration
+}
+
+// NewService creates a transport type Service.
+func NewService(repo transportTypeRepository, catalog *Catalog, timeout time.Duration) *Service {
+	if timeout <= 0 {
+		timeout = 3 * time.Second
+	}
+	return &Service{repo: repo, catalog: catalog, operationTimeout: timeout}
+}
+
+func (s *Service) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, s.operationTimeout)
+}
+
+// refresh reloads the catalog after a change; a failed reload is retried by Catalog.Run.
+func (s *Service) refresh(ctx context.Context) {
+	if s.catalog != nil {
+		s.catalog.refreshOrLog(ctx)
+	}
+}
+
+// List returns all transport types.
+func (s *Service) List(ctx context.Context) ([]domain.TransportType, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	return s.repo.List(ctx)
+}
+
+// Get returns the transport type with the given code.
+func (s *Service) Get(ctx context.Context, code domain.CourierTransportType) (*domain.TransportType, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	t, err := s.repo.Get(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	if t == nil {
+		return nil, apperr.ErrNotFound
+	}
+	return t, nil
+}
+
+// Create adds a new transport type.
+func (s *Service) Create(ctx context.Context, t domain.TransportType) error {
+	if !t.Valid() {
+		return apperr.ErrInvalid
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	if err := s.repo.Create(ctx, t); err != nil {
+		return err
+	}
+	s.refresh(ctx)
+	return nil
+}
+
+// Update replaces the rules of an existing transport type.
+func (s *Service) Update(ctx context.Context, t domain.TransportType) error {
+	if !t.Valid() {
+		return apperr.ErrInvalid
+	}
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	ok, err := s.repo.Update(ctx, t)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return apperr.ErrNotFound
+	}
+	s.refresh(ctx)
+	return nil
+}
+
+// Delete removes a transport type that no courier uses.
+func (s *Service) Delete(ctx context.Context, code domain.CourierTransportType) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+	ok, err := s.repo.Delete(ctx, code)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return apperr.ErrNotFound
+	}
+	s.refresh(ctx)
+	return nil
+}
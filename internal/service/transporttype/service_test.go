@@ -0,0 +1,91 @@
+package transporttype_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"course-go-avito-Orurh/internal/apperr"
+	"course-go-avito-Orurh/internal/domain"
+	"course-go-avito-Orurh/internal/service/transporttype"
+)
+
+func TestService_Create_RefreshesCatalog(t *testing.T) {
+	t.Parallel()
+
+	repo := NewMocktransportTypeRepository(gomock.NewController(t))
+	gomock.InOrder(
+		repo.EXPECT().Create(gomock.Any(), cargoBike).Return(nil),
+		repo.EXPECT().List(gomock.Any()).Return([]domain.TransportType{cargoBike}, nil),
+	)
+
+	catalog := transporttype.NewCatalog(repo, time.Second, nil)
+	svc := transporttype.NewService(repo, catalog, time.Second)
+	require.NoError(t, svc.Create(context.Background(), cargoBike))
+
+	require.True(t, domain.CourierTransportType("cargo_bike").Valid(catalog))
+}
+
+func TestService_Create_Invalid(t *testing.T) {
+	t.Parallel()
+
+	svc := transporttype.NewService(NewMocktransportTypeRepository(gomock.NewController(t)), nil, time.Second)
+
+	cases := map[string]domain.TransportType{
+		"empty code":    {SpeedKmh: 15, MaxCapacity: 1, BaseDeadline: time.Minute},
+		"bad code":      {Code: "Bike!", SpeedKmh: 15, MaxCapacity: 1, BaseDeadline: time.Minute},
+		"zero speed":    {Code: "cargo_bike", MaxCapacity: 1, BaseDeadline: time.Minute},
+		"zero capacity": {Code: "cargo_bike", SpeedKmh: 15, BaseDeadline: time.Minute},
+		"zero deadline": {Code: "cargo_bike", SpeedKmh: 15, MaxCapacity: 1},
+	}
+	for name, tt := range cases {
+		require.ErrorIs(t, svc.Create(context.Background(), tt), apperr.ErrInvalid, name)
+	}
+}
+
+func TestService_Create_Conflict(t *testing.T) {
+	t.Parallel()
+
+	repo := NewMocktransportTypeRepository(gomock.NewController(t))
+	repo.EXPECT().Create(gomock.Any(), cargoBike).Return(apperr.ErrConflict)
+
+	svc := transporttype.NewService(repo, nil, time.Second)
+	require.ErrorIs(t, svc.Create(context.Background(), cargoBike), apperr.ErrConflict)
+}
+
+func TestService_Update_NotFound(t *testing.T) {
+	t.Parallel()
+
+	repo := NewMocktransportTypeRepository(gomock.NewController(t))
+	repo.EXPECT().Update(gomock.Any(), cargoBike).Return(false, nil)
+
+	svc := transporttype.NewService(repo, nil, time.Second)
+	require.ErrorIs(t, svc.Update(context.Background(), cargoBike), apperr.ErrNotFound)
+}
+
+func TestService_Get_NotFound(t *testing.T) {
+	t.Parallel()
+
+	repo := NewMocktransportTypeRepository(gomock.NewController(t))
+	repo.EXPECT().Get(gomock.Any(), domain.CourierTransportType("cargo_bike")).Return(nil, nil)
+
+	svc := transporttype.NewService(repo, nil, time.Second)
+	got, err := svc.Get(context.Background(), "cargo_bike")
+	require.ErrorIs(t, err, apperr.ErrNotFound)
+	require.Nil(t, got)
+}
+
+func TestService_Delete(t *testing.T) {
+	t.Parallel()
+
+	repo := NewMocktransportTypeRepository(gomock.NewController(t))
+	repo.EXPECT().Delete(gomock.Any(), domain.CourierTransportType("car")).Return(false, apperr.ErrConflict)
+	repo.EXPECT().Delete(gomock.Any(), domain.CourierTransportType("boat")).Return(false, nil)
+
+	svc := transporttype.NewService(repo, nil, time.Second)
+	require.ErrorIs(t, svc.Delete(context.Background(), "car"), apperr.ErrConflict, "type used by couriers")
+	require.ErrorIs(t, svc.Delete(context.Background(), "boat"), apperr.ErrNotFound)
+}
@@ -0,0 +1,148 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: contracts.go
+
+// Package transporttype_test is a generated GoMock package.
+package transporttype_test
+
+import (
+	context "context"
+	domain "course-go-avito-Orurh/internal/domain"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MocktransportTypeLister is a mock of transportTypeLister interface.
+type MocktransportTypeLister struct {
+	ctrl     *gomock.Controller
+	recorder *MocktransportTypeListerMockRecorder
+}
+
+// MocktransportTypeListerMockRecorder is the mock recorder for MocktransportTypeLister.
+type MocktransportTypeListerMockRecorder struct {
+	mock *MocktransportTypeLister
+}
+
+// NewMocktransportTypeLister creates a new mock instance.
+func NewMocktransportTypeLister(ctrl *gomock.Controller) *MocktransportTypeLister {
+	mock := &MocktransportTypeLister{ctrl: ctrl}
+	mock.recorder = &MocktransportTypeListerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MocktransportTypeLister) EXPECT() *MocktransportTypeListerMockRecorder {
+	return m.recorder
+}
+
+// List mocks base method.
+func (m *MocktransportTypeLister) List(ctx context.Context) ([]domain.TransportType, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx)
+	ret0, _ := ret[0].([]domain.TransportType)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MocktransportTypeListerMockRecorder) List(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MocktransportTypeLister)(nil).List), ctx)
+}
+
+// MocktransportTypeRepository is a mock of transportTypeRepository interface.
+type MocktransportTypeRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MocktransportTypeRepositoryMockRecorder
+}
+
+// MocktransportTypeRepositoryMockRecorder is the mock recorder for MocktransportTypeRepository.
+type MocktransportTypeRepositoryMockRecorder struct {
+	mock *MocktransportTypeRepository
+}
+
+// NewMocktransportTypeRepository creates a new mock instance.
+func NewMocktransportTypeRepository(ctrl *gomock.Controller) *MocktransportTypeRepository {
+	mock := &MocktransportTypeRepository{ctrl: ctrl}
+	mock.recorder = &MocktransportTypeRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MocktransportTypeRepository) EXPECT() *MocktransportTypeRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MocktransportTypeRepository) Create(ctx context.Context, t domain.TransportType) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, t)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MocktransportTypeRepositoryMockRecorder) Create(ctx, t interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MocktransportTypeRepository)(nil).Create), ctx, t)
+}
+
+// Delete mocks base method.
+func (m *MocktransportTypeRepository) Delete(ctx context.Context, code domain.CourierTransportType) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, code)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MocktransportTypeRepositoryMockRecorder) Delete(ctx, code interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MocktransportTypeRepository)(nil).Delete), ctx, code)
+}
+
+// Get mocks base method.
+func (m *MocktransportTypeRepository) Get(ctx context.Context, code domain.CourierTransportType) (*domain.TransportType, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, code)
+	ret0, _ := ret[0].(*domain.TransportType)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MocktransportTypeRepositoryMockRecorder) Get(ctx, code interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MocktransportTypeRepository)(nil).Get), ctx, code)
+}
+
+// List mocks base method.
+func (m *MocktransportTypeRepository) List(ctx context.Context) ([]domain.TransportType, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx)
+	ret0, _ := ret[0].([]domain.TransportType)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MocktransportTypeRepositoryMockRecorder) List(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MocktransportTypeRepository)(nil).List), ctx)
+}
+
+// Update mocks base method.
+func (m *MocktransportTypeRepository) Update(ctx context.Context, t domain.TransportType) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", ctx, t)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Update indicates an expected call of Update.
+func (mr *MocktransportTypeRepositoryMockRecorder) Update(ctx, t interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MocktransportTypeRepository)(nil).Update), ctx, t)
+}
@@ -0,0 +1,212 @@
+package kafka
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/xdg-go/scram"
+)
+
+// Rebalance strategies of the consumer group.
+const (
+	RebalanceRange      = "range"
+	RebalanceRoundRobin = "roundrobin"
+	RebalanceSticky     = "sticky"
+)
+
+// Offsets a consumer group without committed offsets starts from.
+const (
+	InitialOffsetOldest = "oldest"
+	InitialOffsetNewest = "newest"
+)
+
+// ClientConfig is how every client of the package connects to the cluster and how consumer groups
+// fetch. Zero values keep the sarama defaults, except the initial offset which is the oldest one.
+type ClientConfig struct {
+	ClientID string
+	// Version is the Kafka version of the cluster, such as "3.6.0"
+	Version string
+	TLS     TLSConfig
+	SASL    SASLConfig
+
+	Rebalance      string
+	SessionTimeout time.Duration
+	// FetchMin, FetchDefault and FetchMax are the fetch sizes in bytes, FetchMax 0 means no limit
+	FetchMin     int32
+	FetchDefault int32
+	FetchMax     int32
+	// InitialOffset is where a group without committed offsets starts, "oldest" or "newest"
+	InitialOffset string
+}
+
+// TLSConfig enables TLS to the brokers. CAFile replaces the system roots, CertFile and KeyFile
+// are the client certificate.
+type TLSConfig struct {
+	Enabled            bool
+	CAFile             string
+	CertFile           string
+	KeyFile            string
+	InsecureSkipVerify bool
+}
+
+// SASLConfig authenticates to the brokers with PLAIN, SCRAM-SHA-256 or SCRAM-SHA-512
+type SASLConfig struct {
+	Mechanism string
+	User      string
+	Password  string
+}
+
+// saramaConfig builds a sarama config of c
+func (c ClientConfig) saramaConfig() (*sarama.Config, error) {
+	cfg := sarama.NewConfig()
+	cfg.Consumer.Offsets.Initial = sarama.OffsetOldest
+
+	if c.ClientID != "" {
+		cfg.ClientID = c.ClientID
+	}
+	if c.Version != "" {
+		v, err := sarama.ParseKafkaVersion(c.Version)
+		if err != nil {
+			return nil, fmt.Errorf("kafka version: %w", err)
+		}
+		cfg.Version = v
+	}
+
+	if c.TLS.Enabled {
+		tlsCfg, err := c.TLS.load()
+		if err != nil {
+			return nil, err
+		}
+		cfg.Net.TLS.Enable = true
+		cfg.Net.TLS.Config = tlsCfg
+	}
+	if err := c.SASL.apply(cfg); err != nil {
+		return nil, err
+	}
+	if err := c.applyConsumer(cfg); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("kafka config: %w", err)
+	}
+	return cfg, nil
+}
+
+// applyConsumer sets how consumer groups balance partitions, keep their session and fetch
+func (c ClientConfig) applyConsumer(cfg *sarama.Config) error {
+	switch c.Rebalance {
+	case "", RebalanceRange:
+	case RebalanceRoundRobin:
+		cfg.Consumer.Group.Rebalance.GroupStrategies = []sarama.BalanceStrategy{sarama.NewBalanceStrategyRoundRobin()}
+	case RebalanceSticky:
+		cfg.Consumer.Group.Rebalance.GroupStrategies = []sarama.BalanceStrategy{sarama.NewBalanceStrategySticky()}
+	default:
+		return fmt.Errorf("unknown kafka rebalance strategy %q", c.Rebalance)
+	}
+	if c.SessionTimeout > 0 {
+		cfg.Consumer.Group.Session.Timeout = c.SessionTimeout
+		// the broker expects a few heartbeats per session
+		cfg.Consumer.Group.Heartbeat.Interval = c.SessionTimeout / 3
+	}
+	if c.FetchMin > 0 {
+		cfg.Consumer.Fetch.Min = c.FetchMin
+	}
+	if c.FetchDefault > 0 {
+		cfg.Consumer.Fetch.Default = c.FetchDefault
+	}
+	if c.FetchMax > 0 {
+		cfg.Consumer.Fetch.Max = c.FetchMax
+	}
+	switch c.InitialOffset {
+	case "", InitialOffsetOldest:
+	case InitialOffsetNewest:
+		cfg.Consumer.Offsets.Initial = sarama.OffsetNewest
+	default:
+		return fmt.Errorf("unknown kafka initial offset %q", c.InitialOffset)
+	}
+	return nil
+}
+
+func (t TLSConfig) load() (*tls.Config, error) {
+	cfg := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		// opt-in for test clusters with self-signed certificates
+		InsecureSkipVerify: t.InsecureSkipVerify,
+	}
+	if t.CAFile != "" {
+		pem, err := os.ReadFile(t.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("kafka tls ca: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("kafka tls ca: no certificates in %s", t.CAFile)
+		}
+		cfg.RootCAs = pool
+	}
+	if t.CertFile != "" || t.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("kafka tls client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
+
+func (s SASLConfig) apply(cfg *sarama.Config) error {
+	if s.Mechanism == "" {
+		return nil
+	}
+	if s.User == "" || s.Password == "" {
+		return errors.New("kafka sasl requires a user and a password")
+	}
+	cfg.Net.SASL.Enable = true
+	cfg.Net.SASL.User = s.User
+	cfg.Net.SASL.Password = s.Password
+
+	switch strings.ToUpper(s.Mechanism) {
+	case sarama.SASLTypePlaintext:
+		cfg.Net.SASL.Mechanism = sarama.SASLTypePlaintext
+	case sarama.SASLTypeSCRAMSHA256:
+		cfg.Net.SASL.Mechanism = sarama.SASLTypeSCRAMSHA256
+		cfg.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient { return &scramClient{hash: scram.SHA256} }
+	case sarama.SASLTypeSCRAMSHA512:
+		cfg.Net.SASL.Mechanism = sarama.SASLTypeSCRAMSHA512
+		cfg.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient { return &scramClient{hash: scram.SHA512} }
+	default:
+		return fmt.Errorf("unknown kafka sasl mechanism %q", s.Mechanism)
+	}
+	return nil
+}
+
+// scramClient adapts a xdg-go/scram conversation to sarama.SCRAMClient
+type scramClient struct {
+	hash scram.HashGeneratorFcn
+	conv *scram.ClientConversation
+}
+
+// Begin starts a new exchange for the user
+func (c *scramClient) Begin(userName, password, authzID string) error {
+	client, err := c.hash.NewClient(userName, password, authzID)
+	if err != nil {
+		return fmt.Errorf("scram client: %w", err)
+	}
+	c.conv = client.NewConversation()
+	return nil
+}
+
+// Step returns the response to the server challenge
+func (c *scramClient) Step(challenge string) (string, error) {
+	return c.conv.Step(challenge)
+}
+
+// Done reports whether the server has been verified
+func (c *scramClient) Done() bool { return c.conv.Done() }
@@ -0,0 +1,175 @@
+package kafka
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/require"
+	"github.com/xdg-go/scram"
+)
+
+// writeCert writes a self-signed certificate and its key to dir
+func writeCert(t *testing.T, dir string) (certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "kafka"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certFile = filepath.Join(dir, "cert.pem")
+	keyFile = filepath.Join(dir, "key.pem")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	return certFile, keyFile
+}
+
+func TestClientConfig_Defaults(t *testing.T) {
+	t.Parallel()
+
+	cfg, err := ClientConfig{}.saramaConfig()
+	require.NoError(t, err)
+	def := sarama.NewConfig()
+	require.Equal(t, sarama.OffsetOldest, cfg.Consumer.Offsets.Initial)
+	require.Equal(t, def.ClientID, cfg.ClientID)
+	require.Equal(t, def.Version, cfg.Version)
+	require.False(t, cfg.Net.TLS.Enable)
+	require.False(t, cfg.Net.SASL.Enable)
+	require.Equal(t, def.Consumer.Group.Session.Timeout, cfg.Consumer.Group.Session.Timeout)
+	require.Equal(t, def.Consumer.Fetch, cfg.Consumer.Fetch)
+}
+
+func TestClientConfig_Tuning(t *testing.T) {
+	t.Parallel()
+
+	cfg, err := ClientConfig{
+		ClientID:       "courier",
+		Version:        "3.6.0",
+		Rebalance:      RebalanceSticky,
+		SessionTimeout: 30 * time.Second,
+		FetchMin:       10,
+		FetchDefault:   2 << 20,
+		FetchMax:       8 << 20,
+		InitialOffset:  InitialOffsetNewest,
+	}.saramaConfig()
+	require.NoError(t, err)
+	require.Equal(t, "courier", cfg.ClientID)
+	require.Equal(t, sarama.V3_6_0_0, cfg.Version)
+	require.Len(t, cfg.Consumer.Group.Rebalance.GroupStrategies, 1)
+	require.Equal(t, sarama.StickyBalanceStrategyName, cfg.Consumer.Group.Rebalance.GroupStrategies[0].Name())
+	require.Equal(t, 30*time.Second, cfg.Consumer.Group.Session.Timeout)
+	require.Equal(t, 10*time.Second, cfg.Consumer.Group.Heartbeat.Interval)
+	require.Equal(t, int32(10), cfg.Consumer.Fetch.Min)
+	require.Equal(t, int32(2<<20), cfg.Consumer.Fetch.Default)
+	require.Equal(t, int32(8<<20), cfg.Consumer.Fetch.Max)
+	require.Equal(t, sarama.OffsetNewest, cfg.Consumer.Offsets.Initial)
+}
+
+func TestClientConfig_SASL(t *testing.T) {
+	t.Parallel()
+
+	cfg, err := ClientConfig{SASL: SASLConfig{Mechanism: "scram-sha-512", User: "u", Password: "p"}}.saramaConfig()
+	require.NoError(t, err)
+	require.True(t, cfg.Net.SASL.Enable)
+	require.Equal(t, sarama.SASLMechanism(sarama.SASLTypeSCRAMSHA512), cfg.Net.SASL.Mechanism)
+	require.Equal(t, "u", cfg.Net.SASL.User)
+	require.NotNil(t, cfg.Net.SASL.SCRAMClientGeneratorFunc())
+
+	cfg, err = ClientConfig{SASL: SASLConfig{Mechanism: "PLAIN", User: "u", Password: "p"}}.saramaConfig()
+	require.NoError(t, err)
+	require.Equal(t, sarama.SASLMechanism(sarama.SASLTypePlaintext), cfg.Net.SASL.Mechanism)
+	require.Nil(t, cfg.Net.SASL.SCRAMClientGeneratorFunc)
+}
+
+// scramExchange runs the SASL exchange the way sarama does against a SCRAM server knowing stored
+func scramExchange(t *testing.T, client sarama.SCRAMClient, stored scram.StoredCredentials) error {
+	t.Helper()
+	srv, err := scram.SHA512.NewServer(func(string) (scram.StoredCredentials, error) { return stored, nil })
+	require.NoError(t, err)
+	conv := srv.NewConversation()
+
+	resp, err := client.Step("")
+	for err == nil && !client.Done() {
+		var challenge string
+		challenge, err = conv.Step(resp)
+		if err != nil && challenge == "" {
+			return err
+		}
+		resp, err = client.Step(challenge)
+	}
+	return err
+}
+
+func TestClientConfig_SCRAMAuthenticates(t *testing.T) {
+	t.Parallel()
+
+	cfg, err := ClientConfig{SASL: SASLConfig{Mechanism: "SCRAM-SHA-512", User: "user", Password: "pencil"}}.saramaConfig()
+	require.NoError(t, err)
+
+	admin, err := scram.SHA512.NewClient("user", "pencil", "")
+	require.NoError(t, err)
+	stored := admin.GetStoredCredentials(scram.KeyFactors{Salt: "QSXCR+Q6sek8bf92", Iters: 4096})
+
+	client := cfg.Net.SASL.SCRAMClientGeneratorFunc()
+	require.NoError(t, client.Begin("user", "pencil", ""))
+	require.NoError(t, scramExchange(t, client, stored))
+	require.True(t, client.Done())
+
+	client = cfg.Net.SASL.SCRAMClientGeneratorFunc()
+	require.NoError(t, client.Begin("user", "wrong", ""))
+	require.Error(t, scramExchange(t, client, stored))
+}
+
+func TestClientConfig_TLS(t *testing.T) {
+	t.Parallel()
+
+	certFile, keyFile := writeCert(t, t.TempDir())
+	cfg, err := ClientConfig{TLS: TLSConfig{Enabled: true, CAFile: certFile, CertFile: certFile, KeyFile: keyFile}}.saramaConfig()
+	require.NoError(t, err)
+	require.True(t, cfg.Net.TLS.Enable)
+	require.NotNil(t, cfg.Net.TLS.Config.RootCAs)
+	require.Len(t, cfg.Net.TLS.Config.Certificates, 1)
+	require.False(t, cfg.Net.TLS.Config.InsecureSkipVerify)
+}
+
+func TestClientConfig_Invalid(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	certFile, _ := writeCert(t, dir)
+	notPEM := filepath.Join(dir, "ca.txt")
+	require.NoError(t, os.WriteFile(notPEM, []byte("not a certificate"), 0o600))
+
+	for name, c := range map[string]ClientConfig{
+		"version":        {Version: "latest"},
+		"rebalance":      {Rebalance: "cooperative-sticky"},
+		"initial offset": {InitialOffset: "latest"},
+		"mechanism":      {SASL: SASLConfig{Mechanism: "GSSAPI", User: "u", Password: "p"}},
+		"no password":    {SASL: SASLConfig{Mechanism: "PLAIN", User: "u"}},
+		"missing ca":     {TLS: TLSConfig{Enabled: true, CAFile: filepath.Join(dir, "missing.pem")}},
+		"ca without pem": {TLS: TLSConfig{Enabled: true, CAFile: notPEM}},
+		"cert, no key":   {TLS: TLSConfig{Enabled: true, CertFile: certFile}},
+	} {
+		_, err := c.saramaConfig()
+		require.Error(t, err, name)
+	}
+}
@@ -0,0 +1,180 @@
+package kafka
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/IBM/sarama"
+	"google.golang.org/protobuf/proto"
+
+	orderspb "course-go-avito-Orurh/internal/proto"
+)
+
+// HeaderContentType names the encoding of an order event; without it the decoder default is used.
+// The schema version of an order event is in HeaderSchemaVersion; without it the current one is assumed.
+const HeaderContentType = "content-type"
+
+// Content types of order events.
+const (
+	ContentTypeJSON     = "application/json"
+	ContentTypeProtobuf = "application/x-protobuf"
+)
+
+// OrderEventVersion is the current schema version of order events, the version the codecs decode
+const OrderEventVersion = 1
+
+// ErrUnsupportedEvent marks an order event the decoder has no codec or upcaster for
+var ErrUnsupportedEvent = errors.New("unsupported order event")
+
+// Codec decodes order events of the current schema version in one encoding
+type Codec interface {
+	ContentType() string
+	Decode(payload []byte) (EventDTO, error)
+}
+
+// JSONCodec decodes order events encoded as JSON EventDTO
+type JSONCodec struct{}
+
+// ContentType returns ContentTypeJSON
+func (JSONCodec) ContentType() string { return ContentTypeJSON }
+
+// Decode decodes payload into EventDTO
+func (JSONCodec) Decode(payload []byte) (EventDTO, error) {
+	var dto EventDTO
+	if err := json.Unmarshal(payload, &dto); err != nil {
+		return EventDTO{}, err
+	}
+	return dto, nil
+}
+
+// ProtobufCodec decodes order events encoded as orderspb.OrderStatusChanged
+type ProtobufCodec struct{}
+
+// ContentType returns ContentTypeProtobuf
+func (ProtobufCodec) ContentType() string { return ContentTypeProtobuf }
+
+// Decode decodes payload into EventDTO
+func (ProtobufCodec) Decode(payload []byte) (EventDTO, error) {
+	var m orderspb.OrderStatusChanged
+	if err := proto.Unmarshal(payload, &m); err != nil {
+		return EventDTO{}, err
+	}
+	dto := EventDTO{OrderID: m.GetOrderId(), Status: m.GetStatus()}
+	if m.GetCreatedAt() != nil {
+		dto.CreatedAt = m.GetCreatedAt().AsTime()
+	}
+	if p := m.GetPickup(); p != nil {
+		dto.Pickup = &LocationDTO{Lat: p.GetLat(), Lon: p.GetLon()}
+	}
+	return dto, nil
+}
+
+// Upcaster rewrites a payload of schema version From into version From+1 of the same encoding
+type Upcaster struct {
+	ContentType string
+	From        int
+	Up          func(payload []byte) ([]byte, error)
+}
+
+// Decoder picks the codec of an order event by its content type header and upcasts older
+// schema versions to the current one before decoding.
+type Decoder struct {
+	codecs   map[string]Codec
+	fallback string
+	// current is the schema version the codecs decode
+	current   int
+	upcasters map[string]map[int]func([]byte) ([]byte, error)
+}
+
+// NewDecoder creates a Decoder for the JSON and protobuf codecs. fallback is the content type
+// of events without the header, empty means JSON.
+func NewDecoder(fallback string, upcasters ...Upcaster) (*Decoder, error) {
+	return newDecoder(fallback, OrderEventVersion, upcasters...)
+}
+
+func newDecoder(fallback string, current int, upcasters ...Upcaster) (*Decoder, error) {
+	d := &Decoder{
+		codecs:    make(map[string]Codec),
+		fallback:  ContentTypeJSON,
+		current:   current,
+		upcasters: make(map[string]map[int]func([]byte) ([]byte, error)),
+	}
+	for _, c := range []Codec{JSONCodec{}, ProtobufCodec{}} {
+		d.codecs[c.ContentType()] = c
+	}
+	if fallback != "" {
+		if _, ok := d.codecs[fallback]; !ok {
+			return nil, fmt.Errorf("unknown order event content type %q", fallback)
+		}
+		d.fallback = fallback
+	}
+	for _, u := range upcasters {
+		if _, ok := d.codecs[u.ContentType]; !ok || u.Up == nil || u.From < 1 || u.From >= d.current {
+			return nil, fmt.Errorf("invalid upcaster of %s from version %d", u.ContentType, u.From)
+		}
+		if d.upcasters[u.ContentType] == nil {
+			d.upcasters[u.ContentType] = make(map[int]func([]byte) ([]byte, error))
+		}
+		d.upcasters[u.ContentType][u.From] = u.Up
+	}
+	return d, nil
+}
+
+// defaultDecoder decodes JSON events of the current version, used when no Decoder is set
+var defaultDecoder = &Decoder{
+	codecs:   map[string]Codec{ContentTypeJSON: JSONCodec{}, ContentTypeProtobuf: ProtobufCodec{}},
+	fallback: ContentTypeJSON,
+	current:  OrderEventVersion,
+}
+
+// Decode decodes msg. A decoding error of the codec is returned as is; an event without a codec
+// or with a version that can not be upcast wraps ErrUnsupportedEvent.
+func (d *Decoder) Decode(msg *sarama.ConsumerMessage) (EventDTO, error) {
+	contentType, version := d.fallback, d.current
+	for _, h := range msg.Headers {
+		if h == nil {
+			continue
+		}
+		switch string(h.Key) {
+		case HeaderContentType:
+			// parameters such as charset do not change the decoding
+			contentType, _, _ = strings.Cut(strings.TrimSpace(string(h.Value)), ";")
+		case HeaderSchemaVersion:
+			v, err := strconv.Atoi(strings.TrimSpace(string(h.Value)))
+			if err != nil {
+				return EventDTO{}, fmt.Errorf("%w: schema version %q", ErrUnsupportedEvent, h.Value)
+			}
+			version = v
+		}
+	}
+
+	codec, ok := d.codecs[contentType]
+	if !ok {
+		return EventDTO{}, fmt.Errorf("%w: content type %q", ErrUnsupportedEvent, contentType)
+	}
+	payload, err := d.upcast(contentType, version, msg.Value)
+	if err != nil {
+		return EventDTO{}, err
+	}
+	return codec.Decode(payload)
+}
+
+func (d *Decoder) upcast(contentType string, version int, payload []byte) ([]byte, error) {
+	if version > d.current || version < 1 {
+		return nil, fmt.Errorf("%w: schema version %d, current is %d", ErrUnsupportedEvent, version, d.current)
+	}
+	for v := version; v < d.current; v++ {
+		up, ok := d.upcasters[contentType][v]
+		if !ok {
+			return nil, fmt.Errorf("%w: no upcaster of %s from version %d", ErrUnsupportedEvent, contentType, v)
+		}
+		var err error
+		if payload, err = up(payload); err != nil {
+			return nil, fmt.Errorf("upcast %s from version %d: %w", contentType, v, err)
+		}
+	}
+	return payload, nil
+}
@@ -0,0 +1,189 @@
+package kafka
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	orderspb "course-go-avito-Orurh/internal/proto"
+	"course-go-avito-Orurh/internal/service/orders"
+	testlog "course-go-avito-Orurh/internal/testutil"
+)
+
+func protoPayload(t *testing.T, m *orderspb.OrderStatusChanged) []byte {
+	t.Helper()
+	b, err := proto.Marshal(m)
+	require.NoError(t, err)
+	return b
+}
+
+func withHeaders(msg *sarama.ConsumerMessage, kv ...string) *sarama.ConsumerMessage {
+	for i := 0; i+1 < len(kv); i += 2 {
+		msg.Headers = append(msg.Headers, &sarama.RecordHeader{Key: []byte(kv[i]), Value: []byte(kv[i+1])})
+	}
+	return msg
+}
+
+func TestDecoder_PicksCodecByHeader(t *testing.T) {
+	t.Parallel()
+
+	created := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	pb := protoPayload(t, &orderspb.OrderStatusChanged{
+		OrderId:   "o1",
+		Status:    "created",
+		CreatedAt: timestamppb.New(created),
+		Pickup:    &orderspb.Location{Lat: 55.75, Lon: 37.61},
+	})
+	js := mustMarshal(t, EventDTO{OrderID: "o2", Status: "completed"})
+
+	dec, err := NewDecoder("")
+	require.NoError(t, err)
+
+	got, err := dec.Decode(withHeaders(&sarama.ConsumerMessage{Value: pb}, HeaderContentType, ContentTypeProtobuf))
+	require.NoError(t, err)
+	require.Equal(t, EventDTO{OrderID: "o1", Status: "created", CreatedAt: created, Pickup: &LocationDTO{Lat: 55.75, Lon: 37.61}}, got)
+
+	got, err = dec.Decode(withHeaders(&sarama.ConsumerMessage{Value: js}, HeaderContentType, "application/json; charset=utf-8"))
+	require.NoError(t, err)
+	require.Equal(t, EventDTO{OrderID: "o2", Status: "completed"}, got)
+
+	got, err = dec.Decode(&sarama.ConsumerMessage{Value: js})
+	require.NoError(t, err, "events without the header are JSON by default")
+	require.Equal(t, "o2", got.OrderID)
+}
+
+func TestDecoder_Fallback(t *testing.T) {
+	t.Parallel()
+
+	dec, err := NewDecoder(ContentTypeProtobuf)
+	require.NoError(t, err)
+	got, err := dec.Decode(&sarama.ConsumerMessage{Value: protoPayload(t, &orderspb.OrderStatusChanged{OrderId: "o1", Status: "paid"})})
+	require.NoError(t, err)
+	require.Equal(t, EventDTO{OrderID: "o1", Status: "paid"}, got)
+
+	_, err = NewDecoder("text/plain")
+	require.ErrorContains(t, err, "text/plain")
+}
+
+func TestDecoder_Unsupported(t *testing.T) {
+	t.Parallel()
+
+	dec, err := NewDecoder("")
+	require.NoError(t, err)
+	js := mustMarshal(t, EventDTO{OrderID: "o1", Status: "created"})
+
+	for name, msg := range map[string]*sarama.ConsumerMessage{
+		"content type":    withHeaders(&sarama.ConsumerMessage{Value: js}, HeaderContentType, "application/avro"),
+		"newer version":   withHeaders(&sarama.ConsumerMessage{Value: js}, HeaderSchemaVersion, "2"),
+		"zero version":    withHeaders(&sarama.ConsumerMessage{Value: js}, HeaderSchemaVersion, "0"),
+		"invalid version": withHeaders(&sarama.ConsumerMessage{Value: js}, HeaderSchemaVersion, "v1"),
+	} {
+		_, err := dec.Decode(msg)
+		require.ErrorIs(t, err, ErrUnsupportedEvent, name)
+	}
+
+	got, err := dec.Decode(withHeaders(&sarama.ConsumerMessage{Value: js}, HeaderSchemaVersion, "1"))
+	require.NoError(t, err)
+	require.Equal(t, "o1", got.OrderID)
+}
+
+func TestDecoder_UpcastsOlderVersions(t *testing.T) {
+	t.Parallel()
+
+	rename := func(from, to string) func([]byte) ([]byte, error) {
+		return func(p []byte) ([]byte, error) {
+			return bytes.ReplaceAll(p, []byte(from), []byte(to)), nil
+		}
+	}
+	dec, err := newDecoder("", 3,
+		Upcaster{ContentType: ContentTypeJSON, From: 1, Up: rename(`"id"`, `"orderId"`)},
+		Upcaster{ContentType: ContentTypeJSON, From: 2, Up: rename(`"orderId"`, `"order_id"`)},
+	)
+	require.NoError(t, err)
+
+	got, err := dec.Decode(withHeaders(&sarama.ConsumerMessage{Value: []byte(`{"id":"o1","status":"created"}`)}, HeaderSchemaVersion, "1"))
+	require.NoError(t, err)
+	require.Equal(t, EventDTO{OrderID: "o1", Status: "created"}, got)
+
+	got, err = dec.Decode(withHeaders(&sarama.ConsumerMessage{Value: []byte(`{"orderId":"o2","status":"created"}`)}, HeaderSchemaVersion, "2"))
+	require.NoError(t, err)
+	require.Equal(t, "o2", got.OrderID)
+
+	_, err = dec.Decode(withHeaders(&sarama.ConsumerMessage{Value: []byte(`{}`)},
+		HeaderSchemaVersion, "1", HeaderContentType, ContentTypeProtobuf))
+	require.ErrorIs(t, err, ErrUnsupportedEvent, "there is no protobuf upcaster")
+
+	failing, err := newDecoder("", 2, Upcaster{ContentType: ContentTypeJSON, From: 1, Up: func([]byte) ([]byte, error) {
+		return nil, errors.New("boom")
+	}})
+	require.NoError(t, err)
+	_, err = failing.Decode(withHeaders(&sarama.ConsumerMessage{Value: []byte(`{}`)}, HeaderSchemaVersion, "1"))
+	require.ErrorContains(t, err, "boom")
+}
+
+func TestNewDecoder_RejectsInvalidUpcasters(t *testing.T) {
+	t.Parallel()
+
+	up := func(p []byte) ([]byte, error) { return p, nil }
+	for name, u := range map[string]Upcaster{
+		"current version": {ContentType: ContentTypeJSON, From: 2, Up: up},
+		"zero version":    {ContentType: ContentTypeJSON, From: 0, Up: up},
+		"unknown codec":   {ContentType: "application/avro", From: 1, Up: up},
+		"no func":         {ContentType: ContentTypeJSON, From: 1},
+	} {
+		_, err := newDecoder("", 2, u)
+		require.Error(t, err, name)
+	}
+
+	_, err := NewDecoder("", Upcaster{ContentType: ContentTypeJSON, From: OrderEventVersion, Up: up})
+	require.Error(t, err, "nothing upcasts from the current version")
+}
+
+func TestConsumeClaim_DeadLettersUndecodableEvents(t *testing.T) {
+	t.Parallel()
+
+	p := &fakeProducer{}
+	var handled []string
+	dec, err := NewDecoder("")
+	require.NoError(t, err)
+	c := (&Consumer{
+		logger: testlog.New().Logger(),
+		handler: func(_ context.Context, ev orders.Event) error {
+			handled = append(handled, ev.OrderID)
+			return nil
+		},
+		dlq: newTestDLQ(p),
+	}).WithDecoder(dec)
+	h := &groupHandler{c: c}
+
+	sess := &fakeSession{ctx: context.Background()}
+	msgCh := make(chan *sarama.ConsumerMessage, 4)
+	msgCh <- withHeaders(&sarama.ConsumerMessage{Topic: "orders", Offset: 1, Value: []byte{0xff, 0xff}},
+		HeaderContentType, ContentTypeProtobuf)
+	msgCh <- withHeaders(&sarama.ConsumerMessage{Topic: "orders", Offset: 2, Value: []byte("{}")},
+		HeaderContentType, "application/avro")
+	msgCh <- withHeaders(&sarama.ConsumerMessage{Topic: "orders", Offset: 3, Value: []byte("{}")},
+		HeaderSchemaVersion, "7")
+	msgCh <- withHeaders(&sarama.ConsumerMessage{Topic: "orders", Offset: 4,
+		Value: protoPayload(t, &orderspb.OrderStatusChanged{OrderId: "o1", Status: "created"})},
+		HeaderContentType, ContentTypeProtobuf)
+	close(msgCh)
+
+	require.NoError(t, h.ConsumeClaim(sess, fakeClaim{ch: msgCh}))
+	require.Equal(t, 4, sess.MarkedCount())
+	require.Equal(t, []string{"o1"}, handled)
+
+	sent := p.Sent()
+	require.Len(t, sent, 3)
+	for _, m := range sent {
+		require.Equal(t, ReasonPermanent, headersOf(m)[HeaderDLQReason])
+	}
+	require.Contains(t, headersOf(sent[1])[HeaderDLQError], "application/avro")
+}
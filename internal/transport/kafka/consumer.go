@@ -5,10 +5,13 @@ import (
 	"encoding/json"
 	"errors"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/IBM/sarama"
 
+	"course-go-avito-Orurh/internal/domain"
 	"course-go-avito-Orurh/internal/logx"
 	"course-go-avito-Orurh/internal/service/orders"
 )
@@ -23,20 +26,32 @@ type Consumer struct {
 	handler HandleFunc
 	logger  logx.Logger
 	sleepFn func(context.Context, time.Duration) error
+
+	dlq     *DeadLetterWriter
+	retry   RetryConfig
+	retries labeledCounter
+	giveUps labeledCounter
+	// concurrency is the number of shards shared by all claims of the consumer
+	concurrency int
+	metrics     Metrics
+	decoder     *Decoder
+	// member is set while the consumer holds a consumer group session
+	member atomic.Bool
 }
 
 var newConsumerGroup = sarama.NewConsumerGroup
 
-// NewConsumer creates a new Kafka consumer
-func NewConsumer(logger logx.Logger, brokers []string, groupID, topic string, h HandleFunc) (*Consumer, error) {
+// NewConsumer creates a new Kafka consumer that connects and fetches as clientCfg says
+func NewConsumer(logger logx.Logger, brokers []string, clientCfg ClientConfig, groupID, topic string, h HandleFunc) (*Consumer, error) {
 	// не стратую если у кафки нет настроек
 	if len(brokers) == 0 || strings.TrimSpace(topic) == "" || strings.TrimSpace(groupID) == "" {
 		return nil, nil
 	}
 
-	cfg := sarama.NewConfig()
-	cfg.Consumer.Offsets.Initial = sarama.OffsetOldest
-
+	cfg, err := clientCfg.saramaConfig()
+	if err != nil {
+		return nil, err
+	}
 	group, err := newConsumerGroup(brokers, groupID, cfg)
 	if err != nil {
 		return nil, err
@@ -52,6 +67,38 @@ func NewConsumer(logger logx.Logger, brokers []string, groupID, topic string, h
 	return c, nil
 }
 
+// WithDeadLetters makes the consumer publish events it can never process to w before acking them.
+// The consumer takes ownership of w and closes it in Close.
+func (c *Consumer) WithDeadLetters(w *DeadLetterWriter) *Consumer {
+	if c != nil {
+		c.dlq = w
+	}
+	return c
+}
+
+// WithDecoder sets how order events are decoded, by default they are JSON
+func (c *Consumer) WithDecoder(d *Decoder) *Consumer {
+	if c != nil {
+		c.decoder = d
+	}
+	return c
+}
+
+// WithConcurrency sets how many events are processed in parallel across all claims of the consumer,
+// values below 1 mean one. Events are sharded by order_id, so events of the same order are still
+// processed in order.
+func (c *Consumer) WithConcurrency(n int) *Consumer {
+	if c != nil {
+		c.concurrency = n
+	}
+	return c
+}
+
+// Member reports whether the consumer has joined its consumer group and holds a session
+func (c *Consumer) Member() bool {
+	return c != nil && c.member.Load()
+}
+
 // Run starts the consumer
 func (c *Consumer) Run(ctx context.Context) error {
 	if c == nil {
@@ -125,63 +172,181 @@ func (c *Consumer) Close() error {
 	if c == nil {
 		return nil
 	}
-	return c.group.Close()
+	err := c.group.Close()
+	if dlqErr := c.dlq.Close(); dlqErr != nil && err == nil {
+		err = dlqErr
+	}
+	return err
+}
+
+// deadLetter publishes msg to the dead-letter topic, if one is configured.
+// If publishing fails the message must not be acked, so it is consumed again after the session restarts.
+func (c *Consumer) deadLetter(msg *sarama.ConsumerMessage, reason string, cause error, attempts int) error {
+	if c.dlq == nil {
+		return nil
+	}
+	if err := c.dlq.Write(msg, reason, cause, attempts); err != nil {
+		c.logger.Error("kafka dead-letter publish failed, will retry (not acked)",
+			logx.String("reason", reason),
+			logx.Int64("offset", msg.Offset),
+			logx.Any("err", err),
+		)
+		return err
+	}
+	return nil
+}
+
+// isJSONError reports whether err comes from malformed JSON
+func isJSONError(err error) bool {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	return errors.As(err, &syntaxErr) || errors.As(err, &typeErr)
+}
+
+func keyOf(msg *sarama.ConsumerMessage) domain.EventKey {
+	return domain.EventKey{Topic: msg.Topic, Partition: msg.Partition, Offset: msg.Offset}
+}
+
+// producedAt returns the time the message was produced, or zero if the message has none
+func producedAt(msg *sarama.ConsumerMessage) time.Time {
+	if msg.Timestamp.Unix() <= 0 {
+		return time.Time{}
+	}
+	return msg.Timestamp.UTC()
 }
 
-type groupHandler struct{ c *Consumer }
+type groupHandler struct {
+	c *Consumer
+
+	mu sync.Mutex
+	// workers is shared by the claims being consumed, nil while there are none
+	workers *workerPool
+	claims  int
+}
+
+// acquire returns the worker pool shared by the claims, starting it for the first claim
+func (h *groupHandler) acquire() *workerPool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.workers == nil {
+		h.workers = newWorkerPool(h.c.concurrency)
+	}
+	h.claims++
+	return h.workers
+}
+
+// release stops the worker pool once the last claim using it is done
+func (h *groupHandler) release() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.claims--
+	if h.claims == 0 {
+		h.workers.stop()
+		h.workers = nil
+	}
+}
 
 func (h *groupHandler) Setup(sarama.ConsumerGroupSession) error {
+	h.c.member.Store(true)
 	return nil
 }
 
 func (h *groupHandler) Cleanup(sarama.ConsumerGroupSession) error {
+	h.c.member.Store(false)
 	return nil
 }
 
+// ConsumeClaim fans the claim out to the sharded pool shared by all claims: events of one order
+// are handled in order, different orders in parallel. Offsets are marked only up to the lowest
+// message of the claim not yet processed.
 func (h *groupHandler) ConsumeClaim(sess sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	defer h.release()
+	p := newClaimPool(h.c, h.acquire(), sess, claim)
 	for {
 		select {
-		case <-sess.Context().Done():
-			return nil
+		case <-p.ctx.Done():
+			return p.stop()
 		case msg, ok := <-claim.Messages():
 			if !ok {
-				return nil
+				return p.stop()
 			}
+			p.submit(h.c.decode(msg))
+		}
+	}
+}
 
-			var dto EventDTO
-			if err := json.Unmarshal(msg.Value, &dto); err != nil {
-				h.c.logger.Warn("kafka bad json", logx.Any("err", err))
-				sess.MarkMessage(msg, "")
-				continue
-			}
+// job is a consumed message with its decoded event, or the reason it cannot be processed
+type job struct {
+	// claim is the claim the message came from, set when the job is submitted
+	claim  *claimPool
+	msg    *sarama.ConsumerMessage
+	ev     orders.Event
+	reason string
+	cause  error
+}
 
-			ev := ToDomain(dto)
+func (c *Consumer) decode(msg *sarama.ConsumerMessage) job {
+	return decodeMessage(c.logger, c.decoder, msg)
+}
 
-			if ev.OrderID == "" {
-				h.c.logger.Warn("kafka empty order_id")
-				sess.MarkMessage(msg, "")
-				continue
-			}
+// decodeMessage decodes msg with dec, nil means JSON only. Malformed JSON keeps its own reason,
+// every other decoding failure is permanent.
+func decodeMessage(logger logx.Logger, dec *Decoder, msg *sarama.ConsumerMessage) job {
+	if dec == nil {
+		dec = defaultDecoder
+	}
+	dto, err := dec.Decode(msg)
+	if err != nil {
+		if isJSONError(err) {
+			logger.Warn("kafka bad json", logx.Any("err", err))
+			return job{msg: msg, reason: ReasonBadJSON, cause: err}
+		}
+		logger.Warn("kafka undecodable event", logx.Any("err", err))
+		return job{msg: msg, reason: ReasonPermanent, cause: Permanent(err)}
+	}
 
-			if err := h.c.handler(sess.Context(), ev); err != nil {
-				var perr PermanentError
-				if errors.As(err, &perr) {
-					h.c.logger.Warn("kafka handle failed permanently, skipping message",
-						logx.String("order_id", ev.OrderID),
-						logx.String("status", ev.Status),
-						logx.Any("err", err),
-					)
-					sess.MarkMessage(msg, "")
-					continue
-				}
-				h.c.logger.Error("kafka handle failed, will retry (not acked)",
-					logx.String("order_id", ev.OrderID),
-					logx.String("status", ev.Status),
-					logx.Any("err", err),
-				)
-				return err
-			}
-			sess.MarkMessage(msg, "")
+	ev := ToDomain(dto)
+	ev.Key = keyOf(msg)
+	ev.At = producedAt(msg)
+	if ev.OrderID == "" {
+		logger.Warn("kafka empty order_id")
+		return job{msg: msg, reason: ReasonEmptyOrderID}
+	}
+	return job{msg: msg, ev: ev}
+}
+
+// process handles j and reports whether its offset may be committed.
+// An error means the message could not even be dead-lettered and the claim must stop.
+func (c *Consumer) process(ctx context.Context, j job) (bool, error) {
+	if j.reason != "" {
+		inc(c.metrics.Skipped, j.reason)
+		return true, c.deadLetter(j.msg, j.reason, j.cause, 1)
+	}
+
+	attempts, err := c.handle(ctx, j.ev)
+	if ctx.Err() != nil {
+		// the session is ending: leave the message unacked, it is redelivered after the rebalance
+		return false, nil
+	}
+	if err == nil {
+		if orders.KnownStatus(j.ev.Status) {
+			inc(c.metrics.Handled, statusLabel(j.ev.Status))
+		} else {
+			inc(c.metrics.Skipped, ReasonUnknownStatus)
 		}
+		return true, nil
+	}
+
+	reason := ReasonRetriesExhausted
+	var perr PermanentError
+	if errors.As(err, &perr) {
+		reason = ReasonPermanent
+		inc(c.metrics.Skipped, reason)
+		c.logger.Warn("kafka handle failed permanently, skipping message",
+			logx.String("order_id", j.ev.OrderID),
+			logx.String("status", j.ev.Status),
+			logx.Any("err", err),
+		)
 	}
+	return true, c.deadLetter(j.msg, reason, err, attempts)
 }
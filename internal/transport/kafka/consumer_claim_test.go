@@ -12,6 +12,7 @@ import (
 	"github.com/IBM/sarama"
 	"github.com/stretchr/testify/require"
 
+	"course-go-avito-Orurh/internal/domain"
 	"course-go-avito-Orurh/internal/service/orders"
 	testlog "course-go-avito-Orurh/internal/testutil"
 )
@@ -116,18 +117,22 @@ func TestConsumeClaim_EmptyOrderID_Skips(t *testing.T) {
 	require.True(t, hasMsg(rec.Entries(), "kafka empty order_id"))
 }
 
-func TestConsumeClaim_HandlerError_SkipsButMarks(t *testing.T) {
+func TestConsumeClaim_HandlerError_RetriesThenGivesUp(t *testing.T) {
 	t.Parallel()
 
 	rec := testlog.New()
 	sentinel := errors.New("boom")
+	calls := 0
 
 	c := &Consumer{
 		logger: rec.Logger(),
 		handler: func(context.Context, orders.Event) error {
+			calls++
 			return sentinel
 		},
+		sleepFn: func(context.Context, time.Duration) error { return nil },
 	}
+	c.WithRetry(RetryConfig{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}, nil, nil)
 	h := &groupHandler{c: c}
 
 	dto := EventDTO{OrderID: "o1", Status: "created", CreatedAt: time.Now().UTC()}
@@ -139,9 +144,11 @@ func TestConsumeClaim_HandlerError_SkipsButMarks(t *testing.T) {
 	close(msgCh)
 
 	err := h.ConsumeClaim(sess, fakeClaim{ch: msgCh})
-	require.ErrorIs(t, err, sentinel)
-	require.Equal(t, 0, sess.MarkedCount())
-	require.True(t, hasMsg(rec.Entries(), "kafka handle failed, will retry (not acked)"))
+	require.NoError(t, err, "a transient error no longer tears down the session")
+	require.Equal(t, 3, calls)
+	require.Equal(t, 1, sess.MarkedCount())
+	require.True(t, hasMsg(rec.Entries(), "kafka handle failed, will retry"))
+	require.True(t, hasMsg(rec.Entries(), "kafka handle failed, retries exhausted"))
 }
 
 func TestConsumeClaim_PermanentHandlerError_SkipsAndMarks(t *testing.T) {
@@ -183,6 +190,7 @@ func TestConsumeClaim_Success_Marks(t *testing.T) {
 		handler: func(_ context.Context, ev orders.Event) error {
 			calls++
 			require.Equal(t, "o1", ev.OrderID)
+			require.Equal(t, domain.EventKey{Topic: "orders", Partition: 2, Offset: 17}, ev.Key)
 			return nil
 		},
 	}
@@ -193,7 +201,7 @@ func TestConsumeClaim_Success_Marks(t *testing.T) {
 
 	sess := &fakeSession{ctx: context.Background()}
 	msgCh := make(chan *sarama.ConsumerMessage, 1)
-	msgCh <- &sarama.ConsumerMessage{Value: b}
+	msgCh <- &sarama.ConsumerMessage{Topic: "orders", Partition: 2, Offset: 17, Value: b}
 	close(msgCh)
 
 	err := h.ConsumeClaim(sess, fakeClaim{ch: msgCh})
@@ -202,6 +210,20 @@ func TestConsumeClaim_Success_Marks(t *testing.T) {
 	require.Equal(t, 1, sess.MarkedCount())
 }
 
+func TestDecode_SetsProducedAt(t *testing.T) {
+	t.Parallel()
+
+	c := &Consumer{logger: testlog.New().Logger()}
+	b := mustMarshal(t, EventDTO{OrderID: "o1", Status: "created"})
+	at := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	j := c.decode(&sarama.ConsumerMessage{Value: b, Timestamp: at})
+	require.True(t, at.Equal(j.ev.At))
+
+	j = c.decode(&sarama.ConsumerMessage{Value: b})
+	require.True(t, j.ev.At.IsZero(), "messages without a timestamp are not versioned by time")
+}
+
 func hasMsg(entries []testlog.Entry, msg string) bool {
 	for _, e := range entries {
 		if e.Msg == msg {
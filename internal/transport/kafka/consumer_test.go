@@ -19,15 +19,15 @@ func TestNewConsumer_SkipsWhenNoKafkaConfig(t *testing.T) {
 
 	rec := testlog.New()
 
-	got, err := NewConsumer(rec.Logger(), nil, "gid", "topic", func(context.Context, orders.Event) error { return nil })
+	got, err := NewConsumer(rec.Logger(), nil, ClientConfig{}, "gid", "topic", func(context.Context, orders.Event) error { return nil })
 	require.NoError(t, err)
 	require.Nil(t, got)
 
-	got, err = NewConsumer(rec.Logger(), []string{"b:9092"}, "", "topic", nil)
+	got, err = NewConsumer(rec.Logger(), []string{"b:9092"}, ClientConfig{}, "", "topic", nil)
 	require.NoError(t, err)
 	require.Nil(t, got)
 
-	got, err = NewConsumer(rec.Logger(), []string{"b:9092"}, "gid", "   ", nil)
+	got, err = NewConsumer(rec.Logger(), []string{"b:9092"}, ClientConfig{}, "gid", "   ", nil)
 	require.NoError(t, err)
 	require.Nil(t, got)
 }
@@ -42,7 +42,7 @@ func TestNewConsumer_ReturnsErrorWhenSaramaFails(t *testing.T) {
 	}
 
 	rec := testlog.New()
-	got, err := NewConsumer(rec.Logger(), []string{"b:9092"}, "gid", "topic", nil)
+	got, err := NewConsumer(rec.Logger(), []string{"b:9092"}, ClientConfig{}, "gid", "topic", nil)
 	require.ErrorIs(t, err, sentinel)
 	require.Nil(t, got)
 }
@@ -96,7 +96,7 @@ func TestNewConsumer_Success_ReturnsConsumer(t *testing.T) {
 	}
 
 	rec := testlog.New()
-	got, err := NewConsumer(rec.Logger(), []string{"b:9092"}, "gid", "topic", func(context.Context, orders.Event) error { return nil })
+	got, err := NewConsumer(rec.Logger(), []string{"b:9092"}, ClientConfig{}, "gid", "topic", func(context.Context, orders.Event) error { return nil })
 	require.NoError(t, err)
 	require.NotNil(t, got)
 	require.Same(t, fg, got.group)
@@ -190,3 +190,20 @@ func TestConsumer_Close_ClosesGroup(t *testing.T) {
 	defer fg.mu.Unlock()
 	require.True(t, fg.closed)
 }
+
+func TestConsumer_Member_FollowsGroupSession(t *testing.T) {
+	t.Parallel()
+
+	var nilConsumer *Consumer
+	require.False(t, nilConsumer.Member())
+
+	c := &Consumer{}
+	h := &groupHandler{c: c}
+	require.False(t, c.Member())
+
+	require.NoError(t, h.Setup(&fakeSession{ctx: context.Background()}))
+	require.True(t, c.Member())
+
+	require.NoError(t, h.Cleanup(&fakeSession{ctx: context.Background()}))
+	require.False(t, c.Member())
+}
@@ -0,0 +1,136 @@
+package kafka
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// Dead-letter message headers. The message value and key are the original ones.
+const (
+	HeaderDLQReason    = "dlq-reason"
+	HeaderDLQError     = "dlq-error"
+	HeaderDLQTopic     = "dlq-topic"
+	HeaderDLQPartition = "dlq-partition"
+	HeaderDLQOffset    = "dlq-offset"
+	HeaderDLQTimestamp = "dlq-timestamp"
+	HeaderDLQAttempts  = "dlq-attempts"
+	HeaderDLQFailedAt  = "dlq-failed-at"
+)
+
+// Reasons an event is dead-lettered.
+const (
+	ReasonBadJSON      = "bad_json"
+	ReasonEmptyOrderID = "empty_order_id"
+	ReasonPermanent    = "permanent_error"
+	// ReasonRetriesExhausted marks a transient error that outlived every retry
+	ReasonRetriesExhausted = "retries_exhausted"
+)
+
+// syncProducer is the subset of sarama.SyncProducer used here
+type syncProducer interface {
+	SendMessage(msg *sarama.ProducerMessage) (partition int32, offset int64, err error)
+	Close() error
+}
+
+var newSyncProducer = func(brokers []string, cfg *sarama.Config) (syncProducer, error) {
+	return sarama.NewSyncProducer(brokers, cfg)
+}
+
+func newProducerConfig(clientCfg ClientConfig) (*sarama.Config, error) {
+	cfg, err := clientCfg.saramaConfig()
+	if err != nil {
+		return nil, err
+	}
+	cfg.Producer.RequiredAcks = sarama.WaitForAll
+	cfg.Producer.Return.Successes = true
+	cfg.Producer.Retry.Max = 5
+	return cfg, nil
+}
+
+// DeadLetterWriter publishes events the worker can never process to a dead-letter topic
+type DeadLetterWriter struct {
+	producer syncProducer
+	topic    string
+	now      func() time.Time
+}
+
+// NewDeadLetterWriter creates a DeadLetterWriter. It returns nil if brokers or topic are not set.
+func NewDeadLetterWriter(brokers []string, clientCfg ClientConfig, topic string) (*DeadLetterWriter, error) {
+	if len(brokers) == 0 || strings.TrimSpace(topic) == "" {
+		return nil, nil
+	}
+	cfg, err := newProducerConfig(clientCfg)
+	if err != nil {
+		return nil, err
+	}
+	p, err := newSyncProducer(brokers, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("dlq producer: %w", err)
+	}
+	return &DeadLetterWriter{producer: p, topic: topic, now: time.Now}, nil
+}
+
+// Write publishes msg with the failure reason, the cause and where the message came from.
+// attempts is how many times the message was handled, including the last failure.
+func (w *DeadLetterWriter) Write(msg *sarama.ConsumerMessage, reason string, cause error, attempts int) error {
+	errText := ""
+	if cause != nil {
+		errText = cause.Error()
+	}
+	headers := make([]sarama.RecordHeader, 0, len(msg.Headers)+8)
+	for _, h := range msg.Headers {
+		if h != nil {
+			headers = append(headers, *h)
+		}
+	}
+	headers = append(headers,
+		header(HeaderDLQReason, reason),
+		header(HeaderDLQError, errText),
+		header(HeaderDLQTopic, msg.Topic),
+		header(HeaderDLQPartition, strconv.FormatInt(int64(msg.Partition), 10)),
+		header(HeaderDLQOffset, strconv.FormatInt(msg.Offset, 10)),
+		header(HeaderDLQTimestamp, msg.Timestamp.UTC().Format(time.RFC3339Nano)),
+		header(HeaderDLQAttempts, strconv.Itoa(attempts)),
+		header(HeaderDLQFailedAt, w.now().UTC().Format(time.RFC3339Nano)),
+	)
+
+	out := &sarama.ProducerMessage{
+		Topic:   w.topic,
+		Value:   sarama.ByteEncoder(msg.Value),
+		Headers: headers,
+	}
+	if msg.Key != nil {
+		out.Key = sarama.ByteEncoder(msg.Key)
+	}
+	if _, _, err := w.producer.SendMessage(out); err != nil {
+		return fmt.Errorf("publish to %s: %w", w.topic, err)
+	}
+	return nil
+}
+
+// Close closes the underlying producer
+func (w *DeadLetterWriter) Close() error {
+	if w == nil {
+		return nil
+	}
+	return w.producer.Close()
+}
+
+func header(key, value string) sarama.RecordHeader {
+	return sarama.RecordHeader{Key: []byte(key), Value: []byte(value)}
+}
+
+// headerValue returns the value of the last header named key
+func headerValue(headers []*sarama.RecordHeader, key string) string {
+	v := ""
+	for _, h := range headers {
+		if h != nil && string(h.Key) == key {
+			v = string(h.Value)
+		}
+	}
+	return v
+}
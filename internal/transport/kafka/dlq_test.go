@@ -0,0 +1,194 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/require"
+
+	"course-go-avito-Orurh/internal/service/orders"
+	testlog "course-go-avito-Orurh/internal/testutil"
+)
+
+type fakeProducer struct {
+	mu   sync.Mutex
+	sent []*sarama.ProducerMessage
+	err  error
+}
+
+func (p *fakeProducer) SendMessage(msg *sarama.ProducerMessage) (int32, int64, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.err != nil {
+		return 0, 0, p.err
+	}
+	p.sent = append(p.sent, msg)
+	return 0, int64(len(p.sent) - 1), nil
+}
+
+func (p *fakeProducer) Close() error { return nil }
+
+func (p *fakeProducer) Sent() []*sarama.ProducerMessage {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]*sarama.ProducerMessage(nil), p.sent...)
+}
+
+func headersOf(msg *sarama.ProducerMessage) map[string]string {
+	out := make(map[string]string, len(msg.Headers))
+	for _, h := range msg.Headers {
+		out[string(h.Key)] = string(h.Value)
+	}
+	return out
+}
+
+func encoded(t *testing.T, e sarama.Encoder) string {
+	t.Helper()
+	b, err := e.Encode()
+	require.NoError(t, err)
+	return string(b)
+}
+
+var failedAt = time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+func newTestDLQ(p *fakeProducer) *DeadLetterWriter {
+	return &DeadLetterWriter{producer: p, topic: "orders.dlq", now: func() time.Time { return failedAt }}
+}
+
+func TestNewDeadLetterWriter_SkipsWithoutConfig(t *testing.T) {
+	t.Parallel()
+
+	w, err := NewDeadLetterWriter(nil, ClientConfig{}, "orders.dlq")
+	require.NoError(t, err)
+	require.Nil(t, w)
+
+	w, err = NewDeadLetterWriter([]string{"b:9092"}, ClientConfig{}, " ")
+	require.NoError(t, err)
+	require.Nil(t, w)
+	require.NoError(t, w.Close())
+}
+
+func TestDeadLetterWriter_Write_KeepsPayloadAndAddsMetadata(t *testing.T) {
+	t.Parallel()
+
+	p := &fakeProducer{}
+	w := newTestDLQ(p)
+
+	msg := &sarama.ConsumerMessage{
+		Topic:     "orders",
+		Partition: 3,
+		Offset:    41,
+		Key:       []byte("order-1"),
+		Value:     []byte(`{"order_id":"order-1"}`),
+		Timestamp: time.Date(2025, 1, 2, 3, 0, 0, 0, time.UTC),
+		Headers:   []*sarama.RecordHeader{{Key: []byte("trace-id"), Value: []byte("abc")}},
+	}
+	require.NoError(t, w.Write(msg, ReasonPermanent, errors.New("unknown courier"), 2))
+
+	sent := p.Sent()
+	require.Len(t, sent, 1)
+	require.Equal(t, "orders.dlq", sent[0].Topic)
+	require.Equal(t, `{"order_id":"order-1"}`, encoded(t, sent[0].Value))
+	require.Equal(t, "order-1", encoded(t, sent[0].Key))
+	require.Equal(t, map[string]string{
+		"trace-id":         "abc",
+		HeaderDLQReason:    ReasonPermanent,
+		HeaderDLQError:     "unknown courier",
+		HeaderDLQTopic:     "orders",
+		HeaderDLQPartition: "3",
+		HeaderDLQOffset:    "41",
+		HeaderDLQTimestamp: "2025-01-02T03:00:00Z",
+		HeaderDLQAttempts:  "2",
+		HeaderDLQFailedAt:  "2025-01-02T03:04:05Z",
+	}, headersOf(sent[0]))
+}
+
+func TestConsumeClaim_DeadLettersUnprocessableEvents(t *testing.T) {
+	t.Parallel()
+
+	p := &fakeProducer{}
+	c := &Consumer{
+		logger: testlog.New().Logger(),
+		handler: func(_ context.Context, ev orders.Event) error {
+			return Permanent(errors.New("rejected " + ev.OrderID))
+		},
+		dlq: newTestDLQ(p),
+	}
+	h := &groupHandler{c: c}
+
+	sess := &fakeSession{ctx: context.Background()}
+	msgCh := make(chan *sarama.ConsumerMessage, 3)
+	msgCh <- &sarama.ConsumerMessage{Topic: "orders", Offset: 1, Value: []byte("not-json")}
+	msgCh <- &sarama.ConsumerMessage{Topic: "orders", Offset: 2, Value: mustMarshal(t, EventDTO{Status: "created"})}
+	msgCh <- &sarama.ConsumerMessage{Topic: "orders", Offset: 3, Value: mustMarshal(t, EventDTO{OrderID: "o1", Status: "created"})}
+	close(msgCh)
+
+	require.NoError(t, h.ConsumeClaim(sess, fakeClaim{ch: msgCh}))
+	require.Equal(t, 3, sess.MarkedCount())
+
+	sent := p.Sent()
+	require.Len(t, sent, 3)
+	require.Equal(t, ReasonBadJSON, headersOf(sent[0])[HeaderDLQReason])
+	require.Equal(t, ReasonEmptyOrderID, headersOf(sent[1])[HeaderDLQReason])
+	require.Equal(t, ReasonPermanent, headersOf(sent[2])[HeaderDLQReason])
+	require.Equal(t, "rejected o1", headersOf(sent[2])[HeaderDLQError])
+}
+
+func TestConsumeClaim_DeadLetterCarriesAttempts(t *testing.T) {
+	t.Parallel()
+
+	p := &fakeProducer{}
+	transient := errors.New("db down")
+	calls := 0
+	c := &Consumer{
+		logger: testlog.New().Logger(),
+		handler: func(context.Context, orders.Event) error {
+			calls++
+			if calls < 3 {
+				return transient
+			}
+			return Permanent(errors.New("rejected"))
+		},
+		sleepFn: func(context.Context, time.Duration) error { return nil },
+		dlq:     newTestDLQ(p),
+	}
+	c.WithRetry(RetryConfig{MaxAttempts: 5, BaseDelay: time.Millisecond}, nil, nil)
+	h := &groupHandler{c: c}
+
+	msgCh := make(chan *sarama.ConsumerMessage, 1)
+	msgCh <- &sarama.ConsumerMessage{Topic: "orders", Offset: 5, Value: mustMarshal(t, EventDTO{OrderID: "o1", Status: "created"})}
+	close(msgCh)
+	require.NoError(t, h.ConsumeClaim(&fakeSession{ctx: context.Background()}, fakeClaim{ch: msgCh}))
+
+	sent := p.Sent()
+	require.Len(t, sent, 1)
+	require.Equal(t, ReasonPermanent, headersOf(sent[0])[HeaderDLQReason])
+	require.Equal(t, "3", headersOf(sent[0])[HeaderDLQAttempts])
+}
+
+func TestConsumeClaim_DeadLetterPublishFails_NotAcked(t *testing.T) {
+	t.Parallel()
+
+	sentinel := errors.New("broker down")
+	rec := testlog.New()
+	c := &Consumer{
+		logger:  rec.Logger(),
+		handler: func(context.Context, orders.Event) error { return nil },
+		dlq:     newTestDLQ(&fakeProducer{err: sentinel}),
+	}
+	h := &groupHandler{c: c}
+
+	sess := &fakeSession{ctx: context.Background()}
+	msgCh := make(chan *sarama.ConsumerMessage, 1)
+	msgCh <- &sarama.ConsumerMessage{Value: []byte("not-json")}
+	close(msgCh)
+
+	err := h.ConsumeClaim(sess, fakeClaim{ch: msgCh})
+	require.ErrorIs(t, err, sentinel)
+	require.Equal(t, 0, sess.MarkedCount())
+	require.True(t, hasMsg(rec.Entries(), "kafka dead-letter publish failed, will retry (not acked)"))
+}
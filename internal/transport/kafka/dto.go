@@ -4,21 +4,67 @@ import (
 	"strings"
 	"time"
 
+	"course-go-avito-Orurh/internal/domain"
 	"course-go-avito-Orurh/internal/service/orders"
 )
 
 // EventDTO is a data transfer object for orders.Event
 type EventDTO struct {
-	OrderID   string    `json:"order_id"`
-	Status    string    `json:"status"`
-	CreatedAt time.Time `json:"created_at"`
+	OrderID   string       `json:"order_id"`
+	Status    string       `json:"status"`
+	CreatedAt time.Time    `json:"created_at"`
+	Pickup    *LocationDTO `json:"pickup,omitempty"`
+}
+
+// LocationDTO is a data transfer object for domain.Location
+type LocationDTO struct {
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
 }
 
 // ToDomain converts EventDTO to orders.Event
 func ToDomain(dto EventDTO) orders.Event {
-	return orders.Event{
+	e := orders.Event{
 		OrderID:   strings.TrimSpace(dto.OrderID),
 		Status:    strings.TrimSpace(dto.Status),
 		CreatedAt: dto.CreatedAt,
 	}
+	if dto.Pickup != nil {
+		e.Pickup = &domain.Location{Lat: dto.Pickup.Lat, Lon: dto.Pickup.Lon}
+	}
+	return e
+}
+
+// Schema versions of the events published to the courier/delivery events topic.
+const (
+	DeliveryEventV1 = 1
+)
+
+// DeliveryEventDTOV1 is version 1 of an event published to the courier/delivery events topic.
+// Fields may only be added to it; any other change needs a new version.
+type DeliveryEventDTOV1 struct {
+	Version        int        `json:"version"`
+	EventID        int64      `json:"event_id"`
+	Type           string     `json:"type"`
+	OrderID        string     `json:"order_id,omitempty"`
+	CourierID      int64      `json:"courier_id"`
+	DeliveryStatus string     `json:"delivery_status,omitempty"`
+	CourierStatus  string     `json:"courier_status,omitempty"`
+	Deadline       *time.Time `json:"deadline,omitempty"`
+	OccurredAt     time.Time  `json:"occurred_at"`
+}
+
+// FromOutbox converts domain.OutboxEvent to DeliveryEventDTOV1
+func FromOutbox(ev domain.OutboxEvent) DeliveryEventDTOV1 {
+	return DeliveryEventDTOV1{
+		Version:        DeliveryEventV1,
+		EventID:        ev.ID,
+		Type:           string(ev.Type),
+		OrderID:        ev.OrderID,
+		CourierID:      ev.CourierID,
+		DeliveryStatus: string(ev.DeliveryStatus),
+		CourierStatus:  string(ev.CourierStatus),
+		Deadline:       ev.Deadline,
+		OccurredAt:     ev.OccurredAt,
+	}
 }
@@ -18,3 +18,24 @@ func (e PermanentError) Unwrap() error { return e.Err }
 func Permanent(err error) error {
 	return PermanentError{Err: err}
 }
+
+// UnavailableError means a service the handler depends on is down. The handler is retried
+// with backoff for as long as the outage lasts: such calls do not use up the retry attempts
+// and the message is never dead-lettered for it.
+type UnavailableError struct {
+	Err error
+}
+
+func (e UnavailableError) Error() string {
+	if e.Err == nil {
+		return "dependency unavailable"
+	}
+	return e.Err.Error()
+}
+
+func (e UnavailableError) Unwrap() error { return e.Err }
+
+// Unavailable returns an unavailable error.
+func Unavailable(err error) error {
+	return UnavailableError{Err: err}
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/stretchr/testify/require"
 
+	"course-go-avito-Orurh/internal/domain"
 	"course-go-avito-Orurh/internal/service/orders"
 	"course-go-avito-Orurh/internal/transport/kafka"
 )
@@ -29,3 +30,15 @@ func TestToDomain_TrimsAndCopiesFields(t *testing.T) {
 		CreatedAt: ts,
 	}, got)
 }
+
+func TestToDomain_CopiesPickup(t *testing.T) {
+	t.Parallel()
+
+	got := kafka.ToDomain(kafka.EventDTO{
+		OrderID: "order-1",
+		Status:  "created",
+		Pickup:  &kafka.LocationDTO{Lat: 55.75, Lon: 37.61},
+	})
+
+	require.Equal(t, &domain.Location{Lat: 55.75, Lon: 37.61}, got.Pickup)
+}
@@ -0,0 +1,97 @@
+package kafka
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"course-go-avito-Orurh/internal/service/orders"
+)
+
+// Reason a message is acked without changing anything because its status is not handled
+const ReasonUnknownStatus = "unknown_status"
+
+type labeledObserver interface {
+	WithLabelValues(lvs ...string) prometheus.Observer
+}
+
+type labeledGauge interface {
+	WithLabelValues(lvs ...string) prometheus.Gauge
+	DeleteLabelValues(lvs ...string) bool
+}
+
+// Metrics are the collectors the consumer reports to, any of them may be nil
+type Metrics struct {
+	// Consumed counts messages read from the topic, by topic
+	Consumed labeledCounter
+	// Handled counts events handled successfully, by status
+	Handled labeledCounter
+	// Skipped counts messages acked without being applied, by reason
+	Skipped labeledCounter
+	// Failed counts handler calls failed with a transient error, by status
+	Failed labeledCounter
+	// Duration observes handler calls in seconds, by status
+	Duration labeledObserver
+	// Lag is how far the committed offset is behind the high-water mark, by topic and partition
+	Lag labeledGauge
+}
+
+// WithMetrics makes the consumer report to m
+func (c *Consumer) WithMetrics(m Metrics) *Consumer {
+	if c != nil {
+		c.metrics = m
+	}
+	return c
+}
+
+// statusLabel bounds the status label to the handled statuses
+func statusLabel(status string) string {
+	if !orders.KnownStatus(status) {
+		return ReasonUnknownStatus
+	}
+	return strings.ToLower(strings.TrimSpace(status))
+}
+
+func (m Metrics) observe(status string, d time.Duration) {
+	if m.Duration != nil {
+		m.Duration.WithLabelValues(statusLabel(status)).Observe(d.Seconds())
+	}
+}
+
+// lagTracker reports the lag of one claim
+type lagTracker struct {
+	g         labeledGauge
+	claim     lagClaim
+	partition string
+}
+
+// lagClaim is the part of sarama.ConsumerGroupClaim the lag is computed from
+type lagClaim interface {
+	Topic() string
+	HighWaterMarkOffset() int64
+}
+
+func newLagTracker(g labeledGauge, claim lagClaim, partition int32) *lagTracker {
+	if g == nil {
+		return nil
+	}
+	return &lagTracker{g: g, claim: claim, partition: strconv.Itoa(int(partition))}
+}
+
+// set reports the lag given the next offset to commit
+func (t *lagTracker) set(next int64) {
+	if t == nil {
+		return
+	}
+	t.g.WithLabelValues(t.claim.Topic(), t.partition).Set(float64(max(t.claim.HighWaterMarkOffset()-next, 0)))
+}
+
+// reset drops the gauge of a claim the consumer no longer owns
+func (t *lagTracker) reset() {
+	if t == nil {
+		return
+	}
+	t.g.DeleteLabelValues(t.claim.Topic(), t.partition)
+}
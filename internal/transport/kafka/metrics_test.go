@@ -0,0 +1,127 @@
+package kafka
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	"course-go-avito-Orurh/internal/service/orders"
+	testlog "course-go-avito-Orurh/internal/testutil"
+)
+
+type testMetrics struct {
+	consumed, handled, skipped, failed *prometheus.CounterVec
+	duration                           *prometheus.HistogramVec
+	lag                                *prometheus.GaugeVec
+}
+
+func newTestMetrics() testMetrics {
+	counter := func(name string, label string) *prometheus.CounterVec {
+		return prometheus.NewCounterVec(prometheus.CounterOpts{Name: name}, []string{label})
+	}
+	return testMetrics{
+		consumed: counter("consumed", "topic"),
+		handled:  counter("handled", "status"),
+		skipped:  counter("skipped", "reason"),
+		failed:   counter("failed", "status"),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: "duration"}, []string{"status"}),
+		lag:      prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "lag"}, []string{"topic", "partition"}),
+	}
+}
+
+func (m testMetrics) Metrics() Metrics {
+	return Metrics{
+		Consumed: m.consumed,
+		Handled:  m.handled,
+		Skipped:  m.skipped,
+		Failed:   m.failed,
+		Duration: m.duration,
+		Lag:      m.lag,
+	}
+}
+
+func TestConsumeClaim_ReportsMetrics(t *testing.T) {
+	t.Parallel()
+
+	m := newTestMetrics()
+	c := (&Consumer{
+		logger: testlog.New().Logger(),
+		handler: func(_ context.Context, ev orders.Event) error {
+			if ev.OrderID == "broken" {
+				return Permanent(context.Canceled)
+			}
+			return nil
+		},
+		sleepFn: func(context.Context, time.Duration) error { return nil },
+	}).WithRetry(RetryConfig{MaxAttempts: 1}, nil, nil).WithMetrics(m.Metrics())
+	h := &groupHandler{c: c}
+
+	msgCh := make(chan *sarama.ConsumerMessage, 5)
+	msgCh <- orderMsg(t, 0, "o1", "created")
+	msgCh <- orderMsg(t, 1, "o2", "Completed")
+	msgCh <- &sarama.ConsumerMessage{Topic: "orders", Offset: 2, Value: []byte("{bad")}
+	msgCh <- orderMsg(t, 3, "o3", "paid")
+	msgCh <- orderMsg(t, 4, "broken", "canceled")
+	close(msgCh)
+
+	sess := &fakeSession{ctx: context.Background()}
+	require.NoError(t, h.ConsumeClaim(sess, hwmClaim{fakeClaim: fakeClaim{ch: msgCh}, hwm: 9}))
+
+	require.Equal(t, 5.0, testutil.ToFloat64(m.consumed.WithLabelValues("orders")))
+	require.Equal(t, 1.0, testutil.ToFloat64(m.handled.WithLabelValues("created")))
+	require.Equal(t, 1.0, testutil.ToFloat64(m.handled.WithLabelValues("completed")))
+	require.Equal(t, 1.0, testutil.ToFloat64(m.skipped.WithLabelValues(ReasonBadJSON)))
+	require.Equal(t, 1.0, testutil.ToFloat64(m.skipped.WithLabelValues(ReasonUnknownStatus)))
+	require.Equal(t, 1.0, testutil.ToFloat64(m.skipped.WithLabelValues(ReasonPermanent)))
+	require.Zero(t, testutil.CollectAndCount(m.failed))
+	require.Equal(t, 4, testutil.CollectAndCount(m.duration), "created, completed, unknown_status and canceled")
+	require.Zero(t, testutil.CollectAndCount(m.lag), "the lag of a finished claim is dropped")
+}
+
+func TestHandle_CountsTransientFailures(t *testing.T) {
+	t.Parallel()
+
+	m := newTestMetrics()
+	calls := 0
+	c := (&Consumer{
+		logger: testlog.New().Logger(),
+		handler: func(context.Context, orders.Event) error {
+			calls++
+			if calls < 3 {
+				return context.DeadlineExceeded
+			}
+			return nil
+		},
+		sleepFn: func(context.Context, time.Duration) error { return nil },
+	}).WithRetry(RetryConfig{MaxAttempts: 3}, nil, nil).WithMetrics(m.Metrics())
+
+	attempts, err := c.handle(context.Background(), orders.Event{OrderID: "o1", Status: "created"})
+	require.NoError(t, err)
+	require.Equal(t, 3, attempts)
+	require.Equal(t, 2.0, testutil.ToFloat64(m.failed.WithLabelValues("created")))
+}
+
+func TestLagTracker_SetsAndResets(t *testing.T) {
+	t.Parallel()
+
+	g := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "lag"}, []string{"topic", "partition"})
+	claim := hwmClaim{hwm: 10}
+	lt := newLagTracker(g, claim, 3)
+
+	lt.set(4)
+	require.Equal(t, 6.0, testutil.ToFloat64(g.WithLabelValues("t", "3")))
+	lt.set(12)
+	require.Zero(t, testutil.ToFloat64(g.WithLabelValues("t", "3")), "lag is never negative")
+
+	lt.reset()
+	require.Zero(t, testutil.CollectAndCount(g))
+
+	var none *lagTracker
+	require.NotPanics(t, func() { none.set(1); none.reset() })
+	require.Nil(t, newLagTracker(nil, claim, 0))
+}
@@ -0,0 +1,141 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/IBM/sarama"
+
+	"course-go-avito-Orurh/internal/domain"
+	"course-go-avito-Orurh/internal/logx"
+)
+
+// Headers of the published courier/delivery events.
+const (
+	HeaderEventType     = "event-type"
+	HeaderSchemaVersion = "schema-version"
+)
+
+const (
+	defaultRelayInterval  = time.Second
+	defaultRelayBatchSize = 100
+)
+
+// OutboxStore hands unsent outbox events to publish in order and marks the published ones sent
+type OutboxStore interface {
+	Relay(ctx context.Context, limit int, publish func(ctx context.Context, ev domain.OutboxEvent) error) (int, error)
+}
+
+// OutboxRelay publishes the outbox to the courier/delivery events topic
+type OutboxRelay struct {
+	store     OutboxStore
+	producer  syncProducer
+	topic     string
+	interval  time.Duration
+	batchSize int
+	logger    logx.Logger
+}
+
+// NewOutboxRelay creates an OutboxRelay. Non-positive interval and batch size fall back to defaults.
+func NewOutboxRelay(
+	logger logx.Logger,
+	brokers []string,
+	clientCfg ClientConfig,
+	topic string,
+	store OutboxStore,
+	interval time.Duration,
+	batchSize int,
+) (*OutboxRelay, error) {
+	if len(brokers) == 0 || strings.TrimSpace(topic) == "" {
+		return nil, errors.New("outbox relay requires kafka brokers and an events topic")
+	}
+	if interval <= 0 {
+		interval = defaultRelayInterval
+	}
+	if batchSize <= 0 {
+		batchSize = defaultRelayBatchSize
+	}
+	cfg, err := newProducerConfig(clientCfg)
+	if err != nil {
+		return nil, err
+	}
+	p, err := newSyncProducer(brokers, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("outbox producer: %w", err)
+	}
+	return &OutboxRelay{
+		store:     store,
+		producer:  p,
+		topic:     topic,
+		interval:  interval,
+		batchSize: batchSize,
+		logger:    logger,
+	}, nil
+}
+
+// Run publishes the outbox every interval until ctx is canceled.
+func (r *OutboxRelay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		r.drain(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// drain publishes batches while the previous batch was full.
+func (r *OutboxRelay) drain(ctx context.Context) {
+	for ctx.Err() == nil {
+		n, err := r.store.Relay(ctx, r.batchSize, r.publish)
+		if n > 0 {
+			r.logger.Info("outbox events published", logx.Int("count", n))
+		}
+		if err != nil {
+			if ctx.Err() == nil {
+				r.logger.Error("outbox relay failed", logx.Any("err", err))
+			}
+			return
+		}
+		if n < r.batchSize {
+			return
+		}
+	}
+}
+
+func (r *OutboxRelay) publish(_ context.Context, ev domain.OutboxEvent) error {
+	value, err := json.Marshal(FromOutbox(ev))
+	if err != nil {
+		return fmt.Errorf("encode outbox event %d: %w", ev.ID, err)
+	}
+	msg := &sarama.ProducerMessage{
+		Topic: r.topic,
+		Key:   sarama.StringEncoder(ev.Key()),
+		Value: sarama.ByteEncoder(value),
+		Headers: []sarama.RecordHeader{
+			header(HeaderEventType, string(ev.Type)),
+			header(HeaderSchemaVersion, strconv.Itoa(DeliveryEventV1)),
+		},
+	}
+	if _, _, err := r.producer.SendMessage(msg); err != nil {
+		return fmt.Errorf("publish to %s: %w", r.topic, err)
+	}
+	return nil
+}
+
+// Close closes the underlying producer
+func (r *OutboxRelay) Close() error {
+	if r == nil {
+		return nil
+	}
+	return r.producer.Close()
+}
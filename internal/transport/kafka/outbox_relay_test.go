@@ -0,0 +1,122 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"course-go-avito-Orurh/internal/domain"
+	testlog "course-go-avito-Orurh/internal/testutil"
+)
+
+// fakeOutbox hands out its events in batches and remembers which were published
+type fakeOutbox struct {
+	events []domain.OutboxEvent
+	sent   int
+	calls  int
+}
+
+func (s *fakeOutbox) Relay(
+	ctx context.Context,
+	limit int,
+	publish func(ctx context.Context, ev domain.OutboxEvent) error,
+) (int, error) {
+	s.calls++
+	n := 0
+	for s.sent < len(s.events) && n < limit {
+		if err := publish(ctx, s.events[s.sent]); err != nil {
+			return n, err
+		}
+		s.sent++
+		n++
+	}
+	return n, nil
+}
+
+func newTestRelay(store OutboxStore, p *fakeProducer, batch int) *OutboxRelay {
+	return &OutboxRelay{
+		store:     store,
+		producer:  p,
+		topic:     "courier.delivery.events",
+		interval:  time.Hour,
+		batchSize: batch,
+		logger:    testlog.New().Logger(),
+	}
+}
+
+func TestOutboxRelay_PublishesVersionedEventsKeyedByOrder(t *testing.T) {
+	t.Parallel()
+
+	at := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	deadline := at.Add(time.Hour)
+	store := &fakeOutbox{events: []domain.OutboxEvent{
+		{ID: 1, Type: domain.OutboxDeliveryAssigned, OrderID: "order-1", CourierID: 7,
+			DeliveryStatus: domain.DeliveryStatusAssigned, Deadline: &deadline, OccurredAt: at},
+		{ID: 2, Type: domain.OutboxCourierStatusChanged, CourierID: 7, CourierStatus: domain.StatusBusy, OccurredAt: at},
+	}}
+	p := &fakeProducer{}
+	newTestRelay(store, p, 10).drain(context.Background())
+
+	sent := p.Sent()
+	require.Len(t, sent, 2)
+	require.Equal(t, "courier.delivery.events", sent[0].Topic)
+	require.Equal(t, "order-1", encoded(t, sent[0].Key))
+	require.Equal(t, "courier-7", encoded(t, sent[1].Key))
+	require.Equal(t, map[string]string{
+		HeaderEventType:     "delivery.assigned",
+		HeaderSchemaVersion: "1",
+	}, headersOf(sent[0]))
+
+	var got DeliveryEventDTOV1
+	require.NoError(t, json.Unmarshal([]byte(encoded(t, sent[0].Value)), &got))
+	require.Equal(t, DeliveryEventDTOV1{
+		Version:        1,
+		EventID:        1,
+		Type:           "delivery.assigned",
+		OrderID:        "order-1",
+		CourierID:      7,
+		DeliveryStatus: "assigned",
+		Deadline:       &deadline,
+		OccurredAt:     at,
+	}, got)
+}
+
+func TestOutboxRelay_DrainsFullBatches(t *testing.T) {
+	t.Parallel()
+
+	store := &fakeOutbox{}
+	for i := range 5 {
+		store.events = append(store.events, domain.OutboxEvent{ID: int64(i + 1), CourierID: 1})
+	}
+	p := &fakeProducer{}
+	newTestRelay(store, p, 2).drain(context.Background())
+
+	require.Len(t, p.Sent(), 5)
+	require.Equal(t, 3, store.calls)
+}
+
+func TestOutboxRelay_PublishError_StopsUntilNextRun(t *testing.T) {
+	t.Parallel()
+
+	sentinel := errors.New("broker down")
+	rec := testlog.New()
+	store := &fakeOutbox{events: []domain.OutboxEvent{{ID: 1, CourierID: 1}, {ID: 2, CourierID: 1}}}
+	r := newTestRelay(store, &fakeProducer{err: sentinel}, 1)
+	r.logger = rec.Logger()
+	r.drain(context.Background())
+
+	require.Zero(t, store.sent)
+	require.Equal(t, 1, store.calls)
+	require.True(t, hasMsg(rec.Entries(), "outbox relay failed"))
+}
+
+func TestNewOutboxRelay_RequiresConfig(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewOutboxRelay(testlog.New().Logger(), []string{"b:9092"}, ClientConfig{}, " ", &fakeOutbox{}, 0, 0)
+	require.Error(t, err)
+}
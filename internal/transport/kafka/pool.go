@@ -0,0 +1,195 @@
+package kafka
+
+import (
+	"context"
+	"hash/fnv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/IBM/sarama"
+)
+
+// shardBuffer is how many jobs may wait for each shard before the claim loop blocks
+const shardBuffer = 16
+
+// workerPool is the fixed set of shards shared by every claim the consumer holds,
+// so the consumer never runs more than its concurrency handlers at once
+type workerPool struct {
+	shards []chan job
+	wg     sync.WaitGroup
+}
+
+func newWorkerPool(n int) *workerPool {
+	w := &workerPool{shards: make([]chan job, max(n, 1))}
+	for i := range w.shards {
+		ch := make(chan job, shardBuffer)
+		w.shards[i] = ch
+		w.wg.Add(1)
+		go w.run(ch)
+	}
+	return w
+}
+
+// submit queues j on the shard of its order, blocking while the shard is full.
+// It returns false if ctx is done before the job is queued.
+func (w *workerPool) submit(ctx context.Context, j job) bool {
+	select {
+	case w.shards[w.shardOf(j)] <- j:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (w *workerPool) shardOf(j job) int {
+	if len(w.shards) == 1 {
+		return 0
+	}
+	key := j.ev.OrderID
+	if key == "" {
+		// unprocessable messages have no order, any shard will do
+		return int(j.msg.Offset % int64(len(w.shards)))
+	}
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	return int(h.Sum32() % uint32(len(w.shards)))
+}
+
+func (w *workerPool) run(ch <-chan job) {
+	defer w.wg.Done()
+	for j := range ch {
+		j.claim.handle(j)
+	}
+}
+
+// stop waits for the queued jobs and stops the workers
+func (w *workerPool) stop() {
+	for _, ch := range w.shards {
+		close(ch)
+	}
+	w.wg.Wait()
+}
+
+// claimPool tracks the jobs one claim submitted to the shared worker pool
+type claimPool struct {
+	c       *Consumer
+	sess    sarama.ConsumerGroupSession
+	ctx     context.Context
+	cancel  context.CancelFunc
+	workers *workerPool
+	// inflight counts the jobs of the claim queued or being processed
+	inflight sync.WaitGroup
+
+	offsets offsetTracker
+	lag     *lagTracker
+	// next is the offset the claim commits next, -1 until the first message
+	next atomic.Int64
+
+	mu  sync.Mutex
+	err error
+}
+
+func newClaimPool(
+	c *Consumer,
+	workers *workerPool,
+	sess sarama.ConsumerGroupSession,
+	claim sarama.ConsumerGroupClaim,
+) *claimPool {
+	ctx, cancel := context.WithCancel(sess.Context())
+	p := &claimPool{
+		c:       c,
+		sess:    sess,
+		ctx:     ctx,
+		cancel:  cancel,
+		workers: workers,
+		lag:     newLagTracker(c.metrics.Lag, claim, claim.Partition()),
+	}
+	p.next.Store(-1)
+	return p
+}
+
+// submit queues j on the shared worker pool, blocking while its shard is full
+func (p *claimPool) submit(j job) {
+	inc(p.c.metrics.Consumed, j.msg.Topic)
+	p.next.CompareAndSwap(-1, j.msg.Offset)
+	p.lag.set(p.next.Load())
+
+	p.offsets.add(j.msg)
+	j.claim = p
+	p.inflight.Add(1)
+	if !p.workers.submit(p.ctx, j) {
+		p.inflight.Done()
+	}
+}
+
+// handle processes a job of the claim on a worker of the shared pool
+func (p *claimPool) handle(j job) {
+	defer p.inflight.Done()
+	if p.ctx.Err() != nil {
+		return
+	}
+	ack, err := p.c.process(p.ctx, j)
+	if err != nil {
+		p.fail(err)
+		return
+	}
+	if ack {
+		if msg := p.offsets.complete(j.msg); msg != nil {
+			p.sess.MarkMessage(msg, "")
+			p.next.Store(msg.Offset + 1)
+			p.lag.set(msg.Offset + 1)
+		}
+	}
+}
+
+func (p *claimPool) fail(err error) {
+	p.mu.Lock()
+	if p.err == nil {
+		p.err = err
+	}
+	p.mu.Unlock()
+	p.cancel()
+}
+
+// stop waits for the queued jobs of the claim and returns the error that stopped it, if any
+func (p *claimPool) stop() error {
+	p.inflight.Wait()
+	p.cancel()
+	p.lag.reset()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.err
+}
+
+// offsetTracker finds the highest offset below which every message of the claim is processed
+type offsetTracker struct {
+	mu      sync.Mutex
+	pending []*sarama.ConsumerMessage
+	done    map[int64]bool
+}
+
+func (t *offsetTracker) add(msg *sarama.ConsumerMessage) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.pending = append(t.pending, msg)
+}
+
+// complete records msg as processed and returns the last message of the processed prefix
+// if the prefix grew, nil otherwise
+func (t *offsetTracker) complete(msg *sarama.ConsumerMessage) *sarama.ConsumerMessage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.done == nil {
+		t.done = make(map[int64]bool)
+	}
+	t.done[msg.Offset] = true
+
+	var last *sarama.ConsumerMessage
+	for len(t.pending) > 0 && t.done[t.pending[0].Offset] {
+		last = t.pending[0]
+		delete(t.done, last.Offset)
+		t.pending = t.pending[1:]
+	}
+	return last
+}
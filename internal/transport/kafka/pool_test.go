@@ -0,0 +1,196 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"course-go-avito-Orurh/internal/service/orders"
+	testlog "course-go-avito-Orurh/internal/testutil"
+)
+
+// offsetSession remembers which offsets were marked
+type offsetSession struct {
+	fakeSession
+	offsets []int64
+}
+
+func (s *offsetSession) MarkMessage(msg *sarama.ConsumerMessage, _ string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.offsets = append(s.offsets, msg.Offset)
+}
+
+func (s *offsetSession) Marked() []int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]int64(nil), s.offsets...)
+}
+
+func orderMsg(t *testing.T, offset int64, orderID, status string) *sarama.ConsumerMessage {
+	t.Helper()
+	return &sarama.ConsumerMessage{Topic: "orders", Offset: offset, Value: mustMarshal(t, EventDTO{OrderID: orderID, Status: status})}
+}
+
+func TestOffsetTracker_CompletesOnlyContiguousPrefix(t *testing.T) {
+	t.Parallel()
+
+	var tr offsetTracker
+	msgs := make([]*sarama.ConsumerMessage, 4)
+	for i := range msgs {
+		msgs[i] = &sarama.ConsumerMessage{Offset: int64(10 + i)}
+		tr.add(msgs[i])
+	}
+
+	require.Nil(t, tr.complete(msgs[1]))
+	require.Nil(t, tr.complete(msgs[3]))
+	require.Same(t, msgs[1], tr.complete(msgs[0]))
+	require.Same(t, msgs[3], tr.complete(msgs[2]))
+}
+
+func TestConsumeClaim_Concurrent_KeepsPerOrderOrder(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu   sync.Mutex
+		seen = map[string][]string{}
+	)
+	c := (&Consumer{
+		logger: testlog.New().Logger(),
+		handler: func(_ context.Context, ev orders.Event) error {
+			mu.Lock()
+			defer mu.Unlock()
+			seen[ev.OrderID] = append(seen[ev.OrderID], ev.Status)
+			return nil
+		},
+	}).WithConcurrency(4)
+	h := &groupHandler{c: c}
+
+	statuses := []string{"created", "assigned", "picked", "delivered"}
+	msgCh := make(chan *sarama.ConsumerMessage, 40)
+	offset := int64(0)
+	for _, st := range statuses {
+		for o := range 10 {
+			msgCh <- orderMsg(t, offset, fmt.Sprintf("o%d", o), st)
+			offset++
+		}
+	}
+	close(msgCh)
+
+	sess := &offsetSession{fakeSession: fakeSession{ctx: context.Background()}}
+	require.NoError(t, h.ConsumeClaim(sess, fakeClaim{ch: msgCh}))
+
+	require.Len(t, seen, 10)
+	for id, got := range seen {
+		require.Equal(t, statuses, got, id)
+	}
+	marked := sess.Marked()
+	require.NotEmpty(t, marked)
+	require.Equal(t, int64(39), marked[len(marked)-1])
+	require.IsIncreasing(t, marked)
+}
+
+func TestConsumeClaim_Concurrent_DoesNotCommitPastUnfinishedOffset(t *testing.T) {
+	t.Parallel()
+
+	release := make(chan struct{})
+	fastDone := make(chan struct{})
+	c := (&Consumer{
+		logger: testlog.New().Logger(),
+		handler: func(_ context.Context, ev orders.Event) error {
+			switch ev.OrderID {
+			case "slow":
+				<-release
+			case "fast":
+				close(fastDone)
+			}
+			return nil
+		},
+	}).WithConcurrency(2)
+	h := &groupHandler{c: c}
+
+	msgCh := make(chan *sarama.ConsumerMessage, 2)
+	slow, fast := orderMsg(t, 0, "slow", "created"), orderMsg(t, 1, "fast", "created")
+	msgCh <- slow
+	msgCh <- fast
+	close(msgCh)
+
+	w := newWorkerPool(c.concurrency)
+	require.NotEqual(t, w.shardOf(c.decode(slow)), w.shardOf(c.decode(fast)), "test orders must land on different shards")
+	w.stop()
+
+	sess := &offsetSession{fakeSession: fakeSession{ctx: context.Background()}}
+	errCh := make(chan error, 1)
+	go func() { errCh <- h.ConsumeClaim(sess, fakeClaim{ch: msgCh}) }()
+
+	select {
+	case <-fastDone:
+	case <-time.After(time.Second):
+		t.Fatal("a later order waited for a slow one")
+	}
+	require.Empty(t, sess.Marked(), "offset 1 is done but offset 0 is not")
+
+	close(release)
+	require.NoError(t, <-errCh)
+	require.Equal(t, []int64{1}, sess.Marked())
+}
+
+func TestConsumeClaim_Concurrent_ClaimsShareWorkers(t *testing.T) {
+	t.Parallel()
+
+	const concurrency = 2
+	var (
+		running, peak atomic.Int32
+		handled       atomic.Int32
+	)
+	c := (&Consumer{
+		logger: testlog.New().Logger(),
+		handler: func(context.Context, orders.Event) error {
+			n := running.Add(1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+			running.Add(-1)
+			handled.Add(1)
+			return nil
+		},
+	}).WithConcurrency(concurrency)
+	h := &groupHandler{c: c}
+
+	const claims, perClaim = 4, 10
+	var wg sync.WaitGroup
+	for cl := range claims {
+		msgCh := make(chan *sarama.ConsumerMessage, perClaim)
+		for i := range perClaim {
+			msgCh <- orderMsg(t, int64(i), fmt.Sprintf("c%d-o%d", cl, i), "created")
+		}
+		close(msgCh)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sess := &offsetSession{fakeSession: fakeSession{ctx: context.Background()}}
+			assert.NoError(t, h.ConsumeClaim(sess, fakeClaim{ch: msgCh}))
+			marked := sess.Marked()
+			if assert.NotEmpty(t, marked) {
+				assert.Equal(t, int64(perClaim-1), marked[len(marked)-1], "every claim commits its own offsets")
+			}
+		}()
+	}
+	wg.Wait()
+
+	require.Equal(t, int32(claims*perClaim), handled.Load())
+	require.LessOrEqual(t, peak.Load(), int32(concurrency), "claims must not add workers of their own")
+	require.Nil(t, h.workers, "the pool stops with the last claim")
+}
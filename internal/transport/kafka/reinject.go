@@ -0,0 +1,211 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/IBM/sarama"
+
+	"course-go-avito-Orurh/internal/logx"
+)
+
+// defaultReinjectIdle is how long a DLQ partition may stay silent before it is considered drained
+const defaultReinjectIdle = 5 * time.Second
+
+// Reinjector moves dead-lettered events back onto the main topic
+type Reinjector struct {
+	group    sarama.ConsumerGroup
+	producer syncProducer
+	dlqTopic string
+	topic    string
+	idle     time.Duration
+	logger   logx.Logger
+}
+
+// NewReinjector creates a Reinjector that reads dlqTopic in its own consumer group and
+// republishes every message to topic.
+func NewReinjector(logger logx.Logger, brokers []string, clientCfg ClientConfig, groupID, dlqTopic, topic string) (*Reinjector, error) {
+	if len(brokers) == 0 || strings.TrimSpace(groupID) == "" ||
+		strings.TrimSpace(dlqTopic) == "" || strings.TrimSpace(topic) == "" {
+		return nil, errors.New("reinject requires kafka brokers, a group id, a DLQ topic and a main topic")
+	}
+
+	cfg, err := clientCfg.saramaConfig()
+	if err != nil {
+		return nil, err
+	}
+	// a new reinject group moves every dead letter, whatever the configured initial offset
+	cfg.Consumer.Offsets.Initial = sarama.OffsetOldest
+	group, err := newConsumerGroup(brokers, groupID, cfg)
+	if err != nil {
+		return nil, err
+	}
+	pCfg, err := newProducerConfig(clientCfg)
+	if err != nil {
+		_ = group.Close()
+		return nil, err
+	}
+	producer, err := newSyncProducer(brokers, pCfg)
+	if err != nil {
+		_ = group.Close()
+		return nil, fmt.Errorf("reinject producer: %w", err)
+	}
+
+	return &Reinjector{
+		group:    group,
+		producer: producer,
+		dlqTopic: dlqTopic,
+		topic:    topic,
+		idle:     defaultReinjectIdle,
+		logger:   logger,
+	}, nil
+}
+
+// Run republishes DLQ messages until every DLQ partition is drained and returns how many were moved.
+// Offsets are committed in the reinject group, so a second run only picks up new dead letters.
+func (r *Reinjector) Run(ctx context.Context) (int, error) {
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	h := &reinjectHandler{r: r, done: cancel}
+	for runCtx.Err() == nil {
+		if err := r.group.Consume(runCtx, []string{r.dlqTopic}, h); err != nil && runCtx.Err() == nil {
+			return h.count(), err
+		}
+	}
+	if err := h.failure(); err != nil {
+		return h.count(), err
+	}
+	if ctx.Err() != nil {
+		return h.count(), ctx.Err()
+	}
+	return h.count(), nil
+}
+
+// Close stops the reinjector
+func (r *Reinjector) Close() error {
+	err := r.group.Close()
+	if pErr := r.producer.Close(); pErr != nil && err == nil {
+		err = pErr
+	}
+	return err
+}
+
+// republish sends msg to the main topic without the dead-letter headers
+func (r *Reinjector) republish(msg *sarama.ConsumerMessage) error {
+	headers := make([]sarama.RecordHeader, 0, len(msg.Headers))
+	for _, h := range msg.Headers {
+		if h != nil && !strings.HasPrefix(string(h.Key), "dlq-") {
+			headers = append(headers, *h)
+		}
+	}
+	out := &sarama.ProducerMessage{
+		Topic:   r.topic,
+		Value:   sarama.ByteEncoder(msg.Value),
+		Headers: headers,
+	}
+	if msg.Key != nil {
+		out.Key = sarama.ByteEncoder(msg.Key)
+	}
+	if _, _, err := r.producer.SendMessage(out); err != nil {
+		return fmt.Errorf("publish to %s: %w", r.topic, err)
+	}
+	return nil
+}
+
+type reinjectHandler struct {
+	r    *Reinjector
+	done context.CancelFunc
+
+	mu      sync.Mutex
+	pending int
+	moved   int
+	err     error
+}
+
+func (h *reinjectHandler) Setup(sess sarama.ConsumerGroupSession) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.pending = len(sess.Claims()[h.r.dlqTopic])
+	if h.pending == 0 {
+		h.done()
+	}
+	return nil
+}
+
+func (h *reinjectHandler) Cleanup(sess sarama.ConsumerGroupSession) error {
+	sess.Commit()
+	return nil
+}
+
+func (h *reinjectHandler) ConsumeClaim(sess sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	defer h.partitionDone()
+
+	idle := time.NewTimer(h.r.idle)
+	defer idle.Stop()
+
+	for {
+		select {
+		case <-sess.Context().Done():
+			return nil
+		case <-idle.C:
+			return nil
+		case msg, ok := <-claim.Messages():
+			if !ok {
+				return nil
+			}
+			if err := h.r.republish(msg); err != nil {
+				h.fail(err)
+				return err
+			}
+			sess.MarkMessage(msg, "")
+			h.r.logger.Info("kafka dead letter reinjected",
+				logx.String("reason", headerValue(msg.Headers, HeaderDLQReason)),
+				logx.String("source", headerValue(msg.Headers, HeaderDLQTopic)+"/"+
+					headerValue(msg.Headers, HeaderDLQPartition)+"/"+headerValue(msg.Headers, HeaderDLQOffset)),
+			)
+			h.mu.Lock()
+			h.moved++
+			h.mu.Unlock()
+
+			if msg.Offset+1 >= claim.HighWaterMarkOffset() {
+				return nil
+			}
+			idle.Reset(h.r.idle)
+		}
+	}
+}
+
+func (h *reinjectHandler) partitionDone() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.pending--
+	if h.pending <= 0 {
+		h.done()
+	}
+}
+
+func (h *reinjectHandler) fail(err error) {
+	h.mu.Lock()
+	if h.err == nil {
+		h.err = err
+	}
+	h.mu.Unlock()
+	h.done()
+}
+
+func (h *reinjectHandler) failure() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.err
+}
+
+func (h *reinjectHandler) count() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.moved
+}
@@ -0,0 +1,139 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/require"
+
+	testlog "course-go-avito-Orurh/internal/testutil"
+)
+
+type hwmClaim struct {
+	fakeClaim
+	hwm int64
+}
+
+func (c hwmClaim) HighWaterMarkOffset() int64 { return c.hwm }
+
+type claimsSession struct {
+	fakeSession
+	claims map[string][]int32
+}
+
+func (s *claimsSession) Claims() map[string][]int32 { return s.claims }
+
+func newTestReinjector(p *fakeProducer) *Reinjector {
+	return &Reinjector{
+		producer: p,
+		dlqTopic: "orders.dlq",
+		topic:    "orders",
+		idle:     50 * time.Millisecond,
+		logger:   testlog.New().Logger(),
+	}
+}
+
+func TestReinject_RepublishesWithoutDLQHeadersUntilDrained(t *testing.T) {
+	t.Parallel()
+
+	p := &fakeProducer{}
+	r := newTestReinjector(p)
+	drained := make(chan struct{})
+	h := &reinjectHandler{r: r, done: func() { close(drained) }}
+
+	sess := &claimsSession{
+		fakeSession: fakeSession{ctx: context.Background()},
+		claims:      map[string][]int32{"orders.dlq": {0}},
+	}
+	require.NoError(t, h.Setup(sess))
+
+	msgCh := make(chan *sarama.ConsumerMessage, 2)
+	for off := int64(0); off < 2; off++ {
+		msgCh <- &sarama.ConsumerMessage{
+			Topic:  "orders.dlq",
+			Offset: off,
+			Key:    []byte("order-1"),
+			Value:  []byte(`{"order_id":"order-1"}`),
+			Headers: []*sarama.RecordHeader{
+				{Key: []byte("trace-id"), Value: []byte("abc")},
+				{Key: []byte(HeaderDLQReason), Value: []byte(ReasonPermanent)},
+			},
+		}
+	}
+
+	require.NoError(t, h.ConsumeClaim(sess, hwmClaim{fakeClaim: fakeClaim{ch: msgCh}, hwm: 2}))
+	<-drained
+
+	require.Equal(t, 2, h.count())
+	require.Equal(t, 2, sess.MarkedCount())
+	sent := p.Sent()
+	require.Len(t, sent, 2)
+	require.Equal(t, "orders", sent[0].Topic)
+	require.Equal(t, `{"order_id":"order-1"}`, encoded(t, sent[0].Value))
+	require.Equal(t, map[string]string{"trace-id": "abc"}, headersOf(sent[0]))
+}
+
+func TestReinject_IdlePartitionCountsAsDrained(t *testing.T) {
+	t.Parallel()
+
+	r := newTestReinjector(&fakeProducer{})
+	drained := make(chan struct{})
+	h := &reinjectHandler{r: r, done: func() { close(drained) }}
+
+	sess := &claimsSession{
+		fakeSession: fakeSession{ctx: context.Background()},
+		claims:      map[string][]int32{"orders.dlq": {0}},
+	}
+	require.NoError(t, h.Setup(sess))
+	require.NoError(t, h.ConsumeClaim(sess, hwmClaim{fakeClaim: fakeClaim{ch: make(chan *sarama.ConsumerMessage)}}))
+	<-drained
+	require.Zero(t, h.count())
+}
+
+func TestReinject_PublishError_StopsWithoutAck(t *testing.T) {
+	t.Parallel()
+
+	sentinel := errors.New("broker down")
+	r := newTestReinjector(&fakeProducer{err: sentinel})
+	canceled := false
+	h := &reinjectHandler{r: r, done: func() { canceled = true }, pending: 1}
+
+	sess := &claimsSession{fakeSession: fakeSession{ctx: context.Background()}}
+	msgCh := make(chan *sarama.ConsumerMessage, 1)
+	msgCh <- &sarama.ConsumerMessage{Value: []byte("x")}
+
+	err := h.ConsumeClaim(sess, hwmClaim{fakeClaim: fakeClaim{ch: msgCh}, hwm: 5})
+	require.ErrorIs(t, err, sentinel)
+	require.ErrorIs(t, h.failure(), sentinel)
+	require.True(t, canceled)
+	require.Zero(t, sess.MarkedCount())
+}
+
+func TestReinjector_Run_StopsWhenNothingIsClaimed(t *testing.T) {
+	t.Parallel()
+
+	g := &fakeGroup{}
+	g.consumeFn = func(ctx context.Context, topics []string, h sarama.ConsumerGroupHandler) error {
+		require.Equal(t, []string{"orders.dlq"}, topics)
+		sess := &claimsSession{fakeSession: fakeSession{ctx: ctx}}
+		require.NoError(t, h.Setup(sess))
+		<-ctx.Done()
+		return h.Cleanup(sess)
+	}
+	r := newTestReinjector(&fakeProducer{})
+	r.group = g
+
+	n, err := r.Run(context.Background())
+	require.NoError(t, err)
+	require.Zero(t, n)
+}
+
+func TestNewReinjector_RequiresConfig(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewReinjector(testlog.New().Logger(), []string{"b:9092"}, ClientConfig{}, "g", "", "orders")
+	require.Error(t, err)
+}
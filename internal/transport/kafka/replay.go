@@ -0,0 +1,462 @@
+package kafka
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/IBM/sarama"
+
+	"course-go-avito-Orurh/internal/logx"
+)
+
+// defaultReplayIdle is how long a partition may stay silent before its replay is considered done
+const defaultReplayIdle = 5 * time.Second
+
+// ReplayOptions selects the events to replay
+type ReplayOptions struct {
+	// Group is the consumer group of the replay, it must differ from the worker group
+	Group string
+	// FromOffset is the first offset replayed in every partition, -1 to start from FromTime
+	FromOffset int64
+	// FromTime replays events produced at or after it, used when FromOffset is -1
+	FromTime time.Time
+	// ToTime stops before events produced at or after it; zero replays up to the end of each partition
+	ToTime time.Time
+	// DryRun handles events without committing their changes or the replay offsets
+	DryRun bool
+}
+
+// Validate checks that exactly one start is set and the range is not empty
+func (o ReplayOptions) Validate() error {
+	switch {
+	case strings.TrimSpace(o.Group) == "":
+		return errors.New("replay requires a consumer group")
+	case o.FromOffset < 0 && o.FromTime.IsZero():
+		return errors.New("replay requires a start offset or a start time")
+	case o.FromOffset >= 0 && !o.FromTime.IsZero():
+		return errors.New("replay accepts either a start offset or a start time, not both")
+	case !o.ToTime.IsZero() && !o.FromTime.IsZero() && !o.ToTime.After(o.FromTime):
+		return errors.New("replay end time must be after the start time")
+	}
+	return nil
+}
+
+// offsetLookup finds offsets by time, implemented by sarama.Client
+type offsetLookup interface {
+	GetOffset(topic string, partitionID int32, time int64) (int64, error)
+}
+
+// ReplayCount is how many replayed events of one status were handled and how many of them failed
+type ReplayCount struct {
+	Handled int
+	Failed  int
+}
+
+// ReplayPartition is how far the replay of a partition got: offsets before Next were replayed,
+// the range ends before End
+type ReplayPartition struct {
+	Partition int32
+	Next      int64
+	End       int64
+}
+
+// ReplaySummary describes what a replay did
+type ReplaySummary struct {
+	DryRun bool
+	// Invalid counts messages that are not valid order events
+	Invalid  int
+	ByStatus map[string]ReplayCount
+	// Stopped lists the partitions whose replay stopped before the end of their range:
+	// the run was canceled, the group rebalanced or the partition stayed idle
+	Stopped []ReplayPartition
+}
+
+// Events returns how many messages were replayed
+func (s ReplaySummary) Events() int {
+	n := s.Invalid
+	for _, c := range s.ByStatus {
+		n += c.Handled
+	}
+	return n
+}
+
+// Write prints the summary as a table, statuses in alphabetical order
+func (s ReplaySummary) Write(w io.Writer) error {
+	mode := "applied"
+	if s.DryRun {
+		mode = "dry run, nothing committed"
+	}
+	if _, err := fmt.Fprintf(w, "replayed %d events (%s)\n", s.Events(), mode); err != nil {
+		return err
+	}
+	statuses := make([]string, 0, len(s.ByStatus))
+	for st := range s.ByStatus {
+		statuses = append(statuses, st)
+	}
+	slices.Sort(statuses)
+	for _, st := range statuses {
+		c := s.ByStatus[st]
+		if _, err := fmt.Fprintf(w, "  %-12s handled %d, failed %d\n", st, c.Handled, c.Failed); err != nil {
+			return err
+		}
+	}
+	if _, err := fmt.Fprintf(w, "  %-12s %d\n", "invalid", s.Invalid); err != nil {
+		return err
+	}
+	for _, p := range s.Stopped {
+		if _, err := fmt.Fprintf(w, "stopped early: partition %d at offset %d of %d\n", p.Partition, p.Next, p.End); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Replayer runs a range of a topic through the order events pipeline again
+type Replayer struct {
+	group   sarama.ConsumerGroup
+	offsets offsetLookup
+	closer  io.Closer
+	topic   string
+	opts    ReplayOptions
+	handler HandleFunc
+	decoder *Decoder
+	idle    time.Duration
+	logger  logx.Logger
+}
+
+// NewReplayer creates a Replayer that reads topic in the opts.Group consumer group and passes
+// every event of the range to h.
+func NewReplayer(
+	logger logx.Logger,
+	brokers []string,
+	clientCfg ClientConfig,
+	topic string,
+	opts ReplayOptions,
+	h HandleFunc,
+) (*Replayer, error) {
+	if len(brokers) == 0 || strings.TrimSpace(topic) == "" {
+		return nil, errors.New("replay requires kafka brokers and a topic")
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	cfg, err := clientCfg.saramaConfig()
+	if err != nil {
+		return nil, err
+	}
+	// a new replay group reads up to the start of the range from the oldest message
+	cfg.Consumer.Offsets.Initial = sarama.OffsetOldest
+	cfg.Consumer.Offsets.AutoCommit.Enable = !opts.DryRun
+	client, err := sarama.NewClient(brokers, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("replay client: %w", err)
+	}
+	group, err := sarama.NewConsumerGroupFromClient(opts.Group, client)
+	if err != nil {
+		_ = client.Close()
+		return nil, fmt.Errorf("replay group: %w", err)
+	}
+
+	return &Replayer{
+		group:   group,
+		offsets: client,
+		closer:  client,
+		topic:   topic,
+		opts:    opts,
+		handler: h,
+		idle:    defaultReplayIdle,
+		logger:  logger,
+	}, nil
+}
+
+// WithDecoder sets how order events are decoded, by default they are JSON
+func (r *Replayer) WithDecoder(d *Decoder) *Replayer {
+	if r != nil {
+		r.decoder = d
+	}
+	return r
+}
+
+// Run replays the range in every partition of the topic and returns what was done.
+func (r *Replayer) Run(ctx context.Context) (ReplaySummary, error) {
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	h := &replayHandler{r: r, done: cancel, summary: ReplaySummary{DryRun: r.opts.DryRun}}
+	for runCtx.Err() == nil {
+		if err := r.group.Consume(runCtx, []string{r.topic}, h); err != nil && runCtx.Err() == nil {
+			return h.result(), err
+		}
+	}
+	if err := h.failure(); err != nil {
+		return h.result(), err
+	}
+	if ctx.Err() != nil {
+		return h.result(), ctx.Err()
+	}
+	return h.result(), nil
+}
+
+// Close stops the replayer
+func (r *Replayer) Close() error {
+	err := r.group.Close()
+	if r.closer != nil {
+		if cErr := r.closer.Close(); cErr != nil && err == nil {
+			err = cErr
+		}
+	}
+	return err
+}
+
+// bounds returns the offsets [start, end) of the range in the partition
+func (r *Replayer) bounds(partition int32) (int64, int64, error) {
+	end, err := r.offsets.GetOffset(r.topic, partition, sarama.OffsetNewest)
+	if err != nil {
+		return 0, 0, fmt.Errorf("newest offset of partition %d: %w", partition, err)
+	}
+	if !r.opts.ToTime.IsZero() {
+		to, err := r.offsetAt(partition, r.opts.ToTime)
+		if err != nil {
+			return 0, 0, err
+		}
+		if to >= 0 && to < end {
+			end = to
+		}
+	}
+
+	start := r.opts.FromOffset
+	if start < 0 {
+		start, err = r.offsetAt(partition, r.opts.FromTime)
+		if err != nil {
+			return 0, 0, err
+		}
+		if start < 0 {
+			// nothing was produced since FromTime
+			start = end
+		}
+	}
+	return start, end, nil
+}
+
+// offsetAt returns the first offset produced at or after t, or -1 if there is none
+func (r *Replayer) offsetAt(partition int32, t time.Time) (int64, error) {
+	off, err := r.offsets.GetOffset(r.topic, partition, t.UnixMilli())
+	if err != nil {
+		return 0, fmt.Errorf("offset of partition %d at %s: %w", partition, t.Format(time.RFC3339), err)
+	}
+	return off, nil
+}
+
+type replayRange struct{ start, end int64 }
+
+// replayHandler replays the range of every claimed partition. A claim that reaches the end of its
+// range keeps waiting for the session: sarama cancels the whole session as soon as one
+// ConsumeClaim returns. The run is stopped once every claimed partition is settled.
+type replayHandler struct {
+	r    *Replayer
+	done context.CancelFunc
+
+	mu     sync.Mutex
+	ranges map[int32]replayRange
+	// next is the first offset of the partition that is not replayed yet
+	next map[int32]int64
+	// settled partitions reached the end of their range or stayed idle
+	settled map[int32]bool
+	pending int
+	summary ReplaySummary
+	err     error
+}
+
+func (h *replayHandler) Setup(sess sarama.ConsumerGroupSession) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.ranges == nil {
+		h.ranges = make(map[int32]replayRange)
+		h.next = make(map[int32]int64)
+		h.settled = make(map[int32]bool)
+	}
+
+	h.pending = 0
+	for _, p := range sess.Claims()[h.r.topic] {
+		if _, ok := h.ranges[p]; !ok {
+			start, end, err := h.r.bounds(p)
+			if err != nil {
+				h.failLocked(err)
+				return err
+			}
+			h.ranges[p] = replayRange{start: start, end: end}
+			h.next[p] = start
+			h.settled[p] = start >= end
+		}
+		if h.settled[p] {
+			continue
+		}
+		h.pending++
+		// rewinds a group that has already committed past the start, or a new session after a rebalance
+		sess.ResetOffset(h.r.topic, p, h.next[p], "")
+	}
+	if h.pending == 0 {
+		h.done()
+	}
+	return nil
+}
+
+func (h *replayHandler) Cleanup(sess sarama.ConsumerGroupSession) error {
+	if !h.r.opts.DryRun {
+		sess.Commit()
+	}
+	return nil
+}
+
+func (h *replayHandler) ConsumeClaim(sess sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	p := claim.Partition()
+	h.mu.Lock()
+	rng, settled := h.ranges[p], h.settled[p]
+	h.mu.Unlock()
+	if !settled {
+		h.consume(sess, claim, rng)
+	}
+	<-sess.Context().Done()
+	return nil
+}
+
+// consume replays the range of the claim until its end, the partition stays idle or the session ends
+func (h *replayHandler) consume(sess sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim, rng replayRange) {
+	p := claim.Partition()
+	idle := time.NewTimer(h.r.idle)
+	defer idle.Stop()
+
+	for {
+		select {
+		case <-sess.Context().Done():
+			return
+		case <-idle.C:
+			h.settle(p, -1)
+			return
+		case msg, ok := <-claim.Messages():
+			if !ok {
+				return
+			}
+			// a new group starts from the oldest message, skip up to the start of the range
+			if msg.Offset < rng.start {
+				idle.Reset(h.r.idle)
+				continue
+			}
+			if msg.Offset >= rng.end {
+				h.settle(p, rng.end)
+				return
+			}
+
+			h.replay(sess.Context(), msg)
+			if !h.r.opts.DryRun {
+				sess.MarkMessage(msg, "")
+			}
+			if msg.Offset+1 >= rng.end {
+				h.settle(p, rng.end)
+				return
+			}
+			h.advance(p, msg.Offset+1)
+			idle.Reset(h.r.idle)
+		}
+	}
+}
+
+// replay passes msg to the handler and accounts the outcome. Failures are logged and do not stop the replay.
+func (h *replayHandler) replay(ctx context.Context, msg *sarama.ConsumerMessage) {
+	j := decodeMessage(h.r.logger, h.r.decoder, msg)
+	if j.reason != "" {
+		h.mu.Lock()
+		h.summary.Invalid++
+		h.mu.Unlock()
+		return
+	}
+
+	err := h.r.handler(ctx, j.ev)
+	if err != nil {
+		h.r.logger.Warn("kafka replay handle failed",
+			logx.String("order_id", j.ev.OrderID),
+			logx.String("status", j.ev.Status),
+			logx.String("event", j.ev.Key.String()),
+			logx.Any("err", err),
+		)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.summary.ByStatus == nil {
+		h.summary.ByStatus = make(map[string]ReplayCount)
+	}
+	status := strings.ToLower(j.ev.Status)
+	c := h.summary.ByStatus[status]
+	c.Handled++
+	if err != nil {
+		c.Failed++
+	}
+	h.summary.ByStatus[status] = c
+}
+
+func (h *replayHandler) advance(p int32, next int64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.next[p] = next
+}
+
+// settle records that the replay of p is over, next is -1 when it stopped where it got to.
+// The run is stopped once every claimed partition is settled.
+func (h *replayHandler) settle(p int32, next int64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if next >= 0 {
+		h.next[p] = next
+	}
+	h.settled[p] = true
+	h.pending--
+	if h.pending <= 0 {
+		h.done()
+	}
+}
+
+func (h *replayHandler) fail(err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.failLocked(err)
+}
+
+func (h *replayHandler) failLocked(err error) {
+	if h.err == nil {
+		h.err = err
+	}
+	h.done()
+}
+
+func (h *replayHandler) failure() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.err
+}
+
+func (h *replayHandler) result() ReplaySummary {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	out := h.summary
+	out.ByStatus = make(map[string]ReplayCount, len(h.summary.ByStatus))
+	for k, v := range h.summary.ByStatus {
+		out.ByStatus[k] = v
+	}
+	out.Stopped = nil
+	for p, rng := range h.ranges {
+		if next := h.next[p]; next < rng.end {
+			out.Stopped = append(out.Stopped, ReplayPartition{Partition: p, Next: next, End: rng.end})
+		}
+	}
+	slices.SortFunc(out.Stopped, func(a, b ReplayPartition) int { return cmp.Compare(a.Partition, b.Partition) })
+	return out
+}
@@ -0,0 +1,295 @@
+package kafka
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/require"
+
+	"course-go-avito-Orurh/internal/service/orders"
+	testlog "course-go-avito-Orurh/internal/testutil"
+)
+
+type fakeOffsets map[int64]int64
+
+func (f fakeOffsets) GetOffset(_ string, _ int32, t int64) (int64, error) {
+	off, ok := f[t]
+	if !ok {
+		return 0, errors.New("unexpected offset lookup")
+	}
+	return off, nil
+}
+
+func newTestReplayer(offsets offsetLookup, opts ReplayOptions, h HandleFunc) *Replayer {
+	return &Replayer{
+		offsets: offsets,
+		topic:   "orders",
+		opts:    opts,
+		handler: h,
+		idle:    50 * time.Millisecond,
+		logger:  testlog.New().Logger(),
+	}
+}
+
+func replayMessages(t *testing.T, statuses ...string) chan *sarama.ConsumerMessage {
+	t.Helper()
+	ch := make(chan *sarama.ConsumerMessage, len(statuses))
+	for i, st := range statuses {
+		var v []byte
+		if st == "" {
+			v = []byte("{bad")
+		} else {
+			v = mustMarshal(t, EventDTO{OrderID: "o1", Status: st})
+		}
+		ch <- &sarama.ConsumerMessage{Topic: "orders", Offset: int64(i), Value: v}
+	}
+	return ch
+}
+
+func TestReplayOptions_Validate(t *testing.T) {
+	t.Parallel()
+
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	cases := []struct {
+		name string
+		opts ReplayOptions
+		ok   bool
+	}{
+		{name: "offset", opts: ReplayOptions{Group: "g", FromOffset: 0}, ok: true},
+		{name: "time range", opts: ReplayOptions{Group: "g", FromOffset: -1, FromTime: from, ToTime: from.Add(time.Hour)}, ok: true},
+		{name: "no group", opts: ReplayOptions{FromOffset: 0}},
+		{name: "no start", opts: ReplayOptions{Group: "g", FromOffset: -1}},
+		{name: "both starts", opts: ReplayOptions{Group: "g", FromOffset: 3, FromTime: from}},
+		{name: "empty range", opts: ReplayOptions{Group: "g", FromOffset: -1, FromTime: from, ToTime: from}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			err := tc.opts.Validate()
+			if tc.ok {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestReplay_HandlesOnlyTheRangeAndCountsOutcomes(t *testing.T) {
+	t.Parallel()
+
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(time.Hour)
+	offsets := fakeOffsets{
+		sarama.OffsetNewest: 6,
+		from.UnixMilli():    1,
+		to.UnixMilli():      5,
+	}
+
+	var handled []string
+	r := newTestReplayer(offsets, ReplayOptions{Group: "g", FromOffset: -1, FromTime: from, ToTime: to},
+		func(_ context.Context, ev orders.Event) error {
+			handled = append(handled, ev.Status)
+			if ev.Status == "canceled" {
+				return errors.New("boom")
+			}
+			return nil
+		})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	h := &replayHandler{r: r, done: cancel}
+
+	sess := &claimsSession{
+		fakeSession: fakeSession{ctx: ctx},
+		claims:      map[string][]int32{"orders": {0}},
+	}
+	require.NoError(t, h.Setup(sess))
+
+	msgs := replayMessages(t, "created", "created", "", "canceled", "completed", "deleted")
+	require.NoError(t, h.ConsumeClaim(sess, fakeClaim{ch: msgs}))
+
+	require.Equal(t, []string{"created", "canceled", "completed"}, handled, "offsets 1..4 are replayed")
+	require.Equal(t, 4, sess.MarkedCount())
+
+	sum := h.result()
+	require.Equal(t, 4, sum.Events())
+	require.Equal(t, 1, sum.Invalid)
+	require.Equal(t, ReplayCount{Handled: 1, Failed: 1}, sum.ByStatus["canceled"])
+	require.Equal(t, ReplayCount{Handled: 1}, sum.ByStatus["created"])
+
+	var out bytes.Buffer
+	require.NoError(t, sum.Write(&out))
+	require.Contains(t, out.String(), "replayed 4 events (applied)")
+	require.Contains(t, out.String(), "canceled     handled 1, failed 1")
+}
+
+func TestReplay_DryRunDoesNotMarkOffsets(t *testing.T) {
+	t.Parallel()
+
+	calls := 0
+	r := newTestReplayer(fakeOffsets{sarama.OffsetNewest: 2}, ReplayOptions{Group: "g", FromOffset: 0, DryRun: true},
+		func(context.Context, orders.Event) error {
+			calls++
+			return nil
+		})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	h := &replayHandler{r: r, done: cancel, summary: ReplaySummary{DryRun: true}}
+
+	sess := &claimsSession{
+		fakeSession: fakeSession{ctx: ctx},
+		claims:      map[string][]int32{"orders": {0}},
+	}
+	require.NoError(t, h.Setup(sess))
+	require.NoError(t, h.ConsumeClaim(sess, fakeClaim{ch: replayMessages(t, "created", "completed")}))
+
+	require.Equal(t, 2, calls)
+	require.Zero(t, sess.MarkedCount())
+
+	var out bytes.Buffer
+	require.NoError(t, h.result().Write(&out))
+	require.Contains(t, out.String(), "dry run, nothing committed")
+}
+
+func TestReplay_EmptyRangeFinishesWithoutReading(t *testing.T) {
+	t.Parallel()
+
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	r := newTestReplayer(fakeOffsets{sarama.OffsetNewest: 3, from.UnixMilli(): -1},
+		ReplayOptions{Group: "g", FromOffset: -1, FromTime: from},
+		func(context.Context, orders.Event) error {
+			t.Fatal("nothing should be replayed")
+			return nil
+		})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	h := &replayHandler{r: r, done: cancel}
+
+	sess := &claimsSession{
+		fakeSession: fakeSession{ctx: ctx},
+		claims:      map[string][]int32{"orders": {0}},
+	}
+	require.NoError(t, h.Setup(sess))
+	require.Error(t, ctx.Err(), "there is nothing to replay")
+	require.NoError(t, h.ConsumeClaim(sess, fakeClaim{ch: make(chan *sarama.ConsumerMessage)}))
+	require.Zero(t, h.result().Events())
+	require.Empty(t, h.result().Stopped)
+}
+
+// partitionOffsets finds the offsets of every partition in its own fakeOffsets
+type partitionOffsets map[int32]fakeOffsets
+
+func (f partitionOffsets) GetOffset(topic string, partition int32, t int64) (int64, error) {
+	return f[partition].GetOffset(topic, partition, t)
+}
+
+type partitionClaim struct {
+	fakeClaim
+	partition int32
+}
+
+func (c partitionClaim) Partition() int32 { return c.partition }
+
+func TestReplay_PartitionThatFinishesFirstDoesNotEndTheSession(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu      sync.Mutex
+		handled int
+	)
+	offsets := partitionOffsets{
+		0: {sarama.OffsetNewest: 0},
+		1: {sarama.OffsetNewest: 2},
+		2: {sarama.OffsetNewest: 3},
+	}
+	r := newTestReplayer(offsets, ReplayOptions{Group: "g", FromOffset: 0}, func(context.Context, orders.Event) error {
+		mu.Lock()
+		defer mu.Unlock()
+		handled++
+		return nil
+	})
+	r.idle = time.Minute
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	h := &replayHandler{r: r, done: cancel}
+
+	sess := &claimsSession{
+		fakeSession: fakeSession{ctx: ctx},
+		claims:      map[string][]int32{"orders": {0, 1, 2}},
+	}
+	require.NoError(t, h.Setup(sess))
+
+	slow := make(chan *sarama.ConsumerMessage, 3)
+	returned := make(chan int32, 3)
+	for p, ch := range map[int32]chan *sarama.ConsumerMessage{
+		0: make(chan *sarama.ConsumerMessage),
+		1: replayMessages(t, "created", "completed"),
+		2: slow,
+	} {
+		go func() {
+			_ = h.ConsumeClaim(sess, partitionClaim{fakeClaim: fakeClaim{ch: ch}, partition: p})
+			returned <- p
+		}()
+	}
+
+	// partition 0 has nothing to replay and partition 1 reaches its end, partition 2 is still being read
+	require.Never(t, func() bool { return ctx.Err() != nil }, 100*time.Millisecond, 5*time.Millisecond)
+	require.Empty(t, returned)
+	require.Equal(t, []ReplayPartition{{Partition: 2, Next: 0, End: 3}}, h.result().Stopped)
+
+	msgs := replayMessages(t, "created", "created", "completed")
+	for range 3 {
+		slow <- <-msgs
+	}
+	for range 3 {
+		select {
+		case <-returned:
+		case <-time.After(time.Second):
+			t.Fatal("claims must return once every partition reached its end")
+		}
+	}
+	require.Equal(t, 5, handled)
+	require.Empty(t, h.result().Stopped)
+}
+
+func TestReplay_SummaryListsPartitionsThatStoppedEarly(t *testing.T) {
+	t.Parallel()
+
+	r := newTestReplayer(partitionOffsets{
+		0: {sarama.OffsetNewest: 2},
+		1: {sarama.OffsetNewest: 4},
+	}, ReplayOptions{Group: "g", FromOffset: 0}, func(context.Context, orders.Event) error { return nil })
+	r.idle = time.Minute
+	ctx, cancel := context.WithCancel(context.Background())
+	h := &replayHandler{r: r, done: func() {}}
+
+	sess := &claimsSession{
+		fakeSession: fakeSession{ctx: ctx},
+		claims:      map[string][]int32{"orders": {0, 1}},
+	}
+	require.NoError(t, h.Setup(sess))
+
+	full := partitionClaim{fakeClaim: fakeClaim{ch: replayMessages(t, "created", "completed")}}
+	partial := partitionClaim{fakeClaim: fakeClaim{ch: make(chan *sarama.ConsumerMessage, 1)}, partition: 1}
+	partial.ch <- <-replayMessages(t, "created")
+	errs := make(chan error, 2)
+	go func() { errs <- h.ConsumeClaim(sess, full) }()
+	go func() { errs <- h.ConsumeClaim(sess, partial) }()
+	require.Eventually(t, func() bool { return h.result().Events() == 3 }, time.Second, time.Millisecond)
+
+	// the run is canceled while partition 1 is still being read
+	cancel()
+	require.NoError(t, <-errs)
+	require.NoError(t, <-errs)
+
+	sum := h.result()
+	require.Equal(t, []ReplayPartition{{Partition: 1, Next: 1, End: 4}}, sum.Stopped)
+	var out bytes.Buffer
+	require.NoError(t, sum.Write(&out))
+	require.Contains(t, out.String(), "stopped early: partition 1 at offset 1 of 4")
+}
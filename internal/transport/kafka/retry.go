@@ -0,0 +1,147 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"course-go-avito-Orurh/internal/logx"
+	"course-go-avito-Orurh/internal/service/orders"
+)
+
+// RetryConfig configures retries of transient handler errors inside the claim loop
+type RetryConfig struct {
+	// MaxAttempts is the total number of handler calls per message, values below 1 mean a single call
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+type labeledCounter interface {
+	WithLabelValues(lvs ...string) prometheus.Counter
+}
+
+// WithRetry makes the consumer retry transient handler errors with exponential backoff and jitter.
+// retries and giveUps are counters labeled by event status; either may be nil.
+func (c *Consumer) WithRetry(cfg RetryConfig, retries, giveUps labeledCounter) *Consumer {
+	if c != nil {
+		c.retry = cfg
+		c.retries = retries
+		c.giveUps = giveUps
+	}
+	return c
+}
+
+// outage delays are used while a dependency is unavailable and the retry config sets none,
+// so that an outage never turns into a busy loop
+const (
+	outageBaseDelay = 100 * time.Millisecond
+	outageMaxDelay  = 5 * time.Second
+)
+
+// handle calls the handler until it succeeds, fails permanently or the attempts run out.
+// Calls failed with an UnavailableError are retried until the dependency is back and do not
+// count as attempts. It returns the number of calls made and the last error, nil on success.
+// If ctx is done while waiting for the next attempt, the wait is cut short.
+func (c *Consumer) handle(ctx context.Context, ev orders.Event) (int, error) {
+	maxAttempts := max(c.retry.MaxAttempts, 1)
+	status := strings.ToLower(ev.Status)
+
+	for calls, attempt := 1, 1; ; calls++ {
+		start := time.Now()
+		err := c.handler(ctx, ev)
+		c.metrics.observe(ev.Status, time.Since(start))
+		if err == nil {
+			return calls, nil
+		}
+		var perr PermanentError
+		if errors.As(err, &perr) || ctx.Err() != nil {
+			return calls, err
+		}
+		inc(c.metrics.Failed, statusLabel(ev.Status))
+
+		var uerr UnavailableError
+		if errors.As(err, &uerr) {
+			delay := jitter(c.outageBackoff(calls))
+			inc(c.retries, status)
+			c.logger.Warn("kafka handle failed, dependency unavailable, will retry",
+				logx.String("order_id", ev.OrderID),
+				logx.String("status", ev.Status),
+				logx.Int("calls", calls),
+				logx.Duration("delay", delay),
+				logx.Any("err", err),
+			)
+			if err := c.sleep(ctx, delay); err != nil {
+				return calls, err
+			}
+			continue
+		}
+
+		if attempt >= maxAttempts {
+			inc(c.giveUps, status)
+			c.logger.Error("kafka handle failed, retries exhausted",
+				logx.String("order_id", ev.OrderID),
+				logx.String("status", ev.Status),
+				logx.Int("attempts", attempt),
+				logx.Any("err", err),
+			)
+			return calls, err
+		}
+
+		delay := jitter(backoff(c.retry.BaseDelay, c.retry.MaxDelay, attempt))
+		inc(c.retries, status)
+		c.logger.Warn("kafka handle failed, will retry",
+			logx.String("order_id", ev.OrderID),
+			logx.String("status", ev.Status),
+			logx.Int("attempt", attempt),
+			logx.Duration("delay", delay),
+			logx.Any("err", err),
+		)
+		if err := c.sleep(ctx, delay); err != nil {
+			return calls, err
+		}
+		attempt++
+	}
+}
+
+// outageBackoff is the delay before call n+1 while a dependency is unavailable
+func (c *Consumer) outageBackoff(n int) time.Duration {
+	base, maxDelay := c.retry.BaseDelay, c.retry.MaxDelay
+	if base <= 0 {
+		base = outageBaseDelay
+	}
+	if maxDelay <= 0 {
+		maxDelay = max(outageMaxDelay, base)
+	}
+	return backoff(base, maxDelay, n)
+}
+
+func backoff(base, maxDelay time.Duration, attempt int) time.Duration {
+	if base <= 0 {
+		return 0
+	}
+	d := base << (attempt - 1)
+	if d <= 0 || (maxDelay > 0 && d > maxDelay) {
+		return maxDelay
+	}
+	return d
+}
+
+// jitter spreads d over [d/2, d] so that consumers retrying after a shared outage do not line up
+func jitter(d time.Duration) time.Duration {
+	if d <= 1 {
+		return d
+	}
+	half := d / 2
+	return half + rand.N(d-half+1)
+}
+
+func inc(c labeledCounter, status string) {
+	if c != nil {
+		c.WithLabelValues(status).Inc()
+	}
+}
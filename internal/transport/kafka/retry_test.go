@@ -0,0 +1,194 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	"course-go-avito-Orurh/internal/service/orders"
+	testlog "course-go-avito-Orurh/internal/testutil"
+)
+
+func newCounterVec(name string) *prometheus.CounterVec {
+	return prometheus.NewCounterVec(prometheus.CounterOpts{Name: name}, []string{"status"})
+}
+
+func TestBackoff_GrowsAndCaps(t *testing.T) {
+	t.Parallel()
+
+	base, maxDelay := 100*time.Millisecond, time.Second
+	require.Equal(t, 100*time.Millisecond, backoff(base, maxDelay, 1))
+	require.Equal(t, 400*time.Millisecond, backoff(base, maxDelay, 3))
+	require.Equal(t, time.Second, backoff(base, maxDelay, 5))
+	require.Equal(t, time.Second, backoff(base, maxDelay, 80), "no overflow on large attempts")
+	require.Zero(t, backoff(0, maxDelay, 3))
+}
+
+func TestJitter_StaysWithinHalfAndFull(t *testing.T) {
+	t.Parallel()
+
+	for i := 0; i < 1000; i++ {
+		d := jitter(time.Second)
+		require.GreaterOrEqual(t, d, 500*time.Millisecond)
+		require.LessOrEqual(t, d, time.Second)
+	}
+	require.Zero(t, jitter(0))
+}
+
+func TestHandle_RecoversAfterTransientErrors(t *testing.T) {
+	t.Parallel()
+
+	var delays []time.Duration
+	calls := 0
+	retries, giveUps := newCounterVec("retries"), newCounterVec("give_ups")
+	c := (&Consumer{
+		logger: testlog.New().Logger(),
+		handler: func(context.Context, orders.Event) error {
+			calls++
+			if calls < 3 {
+				return errors.New("db down")
+			}
+			return nil
+		},
+		sleepFn: func(_ context.Context, d time.Duration) error {
+			delays = append(delays, d)
+			return nil
+		},
+	}).WithRetry(RetryConfig{MaxAttempts: 5, BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}, retries, giveUps)
+
+	attempts, err := c.handle(context.Background(), orders.Event{OrderID: "o1", Status: "Created"})
+	require.NoError(t, err)
+	require.Equal(t, 3, attempts)
+	require.Len(t, delays, 2)
+	require.InDelta(t, 75*time.Millisecond, delays[0], float64(25*time.Millisecond))
+	require.InDelta(t, 150*time.Millisecond, delays[1], float64(50*time.Millisecond))
+	require.InDelta(t, 2, testutil.ToFloat64(retries.WithLabelValues("created")), 0)
+	require.InDelta(t, 0, testutil.ToFloat64(giveUps.WithLabelValues("created")), 0)
+}
+
+func TestHandle_GivesUpAfterMaxAttempts(t *testing.T) {
+	t.Parallel()
+
+	giveUps := newCounterVec("give_ups")
+	c := (&Consumer{
+		logger:  testlog.New().Logger(),
+		handler: func(context.Context, orders.Event) error { return errors.New("db down") },
+		sleepFn: func(context.Context, time.Duration) error { return nil },
+	}).WithRetry(RetryConfig{MaxAttempts: 4, BaseDelay: time.Millisecond}, nil, giveUps)
+
+	attempts, err := c.handle(context.Background(), orders.Event{OrderID: "o1", Status: "canceled"})
+	require.Error(t, err)
+	require.Equal(t, 4, attempts)
+	require.InDelta(t, 1, testutil.ToFloat64(giveUps.WithLabelValues("canceled")), 0)
+}
+
+func TestHandle_PermanentErrorIsNotRetried(t *testing.T) {
+	t.Parallel()
+
+	calls := 0
+	c := (&Consumer{
+		logger: testlog.New().Logger(),
+		handler: func(context.Context, orders.Event) error {
+			calls++
+			return Permanent(errors.New("bad"))
+		},
+	}).WithRetry(RetryConfig{MaxAttempts: 5}, nil, nil)
+
+	_, err := c.handle(context.Background(), orders.Event{OrderID: "o1"})
+	require.Error(t, err)
+	require.Equal(t, 1, calls)
+}
+
+func TestHandle_UnavailableDoesNotUseUpAttempts(t *testing.T) {
+	t.Parallel()
+
+	var delays []time.Duration
+	calls := 0
+	giveUps := newCounterVec("give_ups")
+	c := (&Consumer{
+		logger: testlog.New().Logger(),
+		handler: func(context.Context, orders.Event) error {
+			calls++
+			switch {
+			case calls <= 8:
+				return Unavailable(errors.New("orders service down"))
+			case calls == 9:
+				return errors.New("db down")
+			}
+			return nil
+		},
+		sleepFn: func(_ context.Context, d time.Duration) error {
+			delays = append(delays, d)
+			return nil
+		},
+	}).WithRetry(RetryConfig{MaxAttempts: 2, BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}, nil, giveUps)
+
+	attempts, err := c.handle(context.Background(), orders.Event{OrderID: "o1", Status: "created"})
+	require.NoError(t, err)
+	require.Equal(t, 10, attempts)
+	require.Len(t, delays, 9)
+	require.LessOrEqual(t, delays[7], time.Second, "the outage backoff is capped by MaxDelay")
+	require.GreaterOrEqual(t, delays[7], 500*time.Millisecond)
+	require.InDelta(t, 0, testutil.ToFloat64(giveUps.WithLabelValues("created")), 0)
+}
+
+func TestConsumeClaim_DependencyUnavailable_NotAckedNorDeadLettered(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &fakeProducer{}
+	calls := 0
+	c := (&Consumer{
+		logger: testlog.New().Logger(),
+		handler: func(context.Context, orders.Event) error {
+			calls++
+			return Unavailable(errors.New("orders service down"))
+		},
+		sleepFn: func(ctx context.Context, _ time.Duration) error {
+			// the outage outlives many times the retry budget, then the session ends
+			if calls == 20 {
+				cancel()
+			}
+			return ctx.Err()
+		},
+		dlq: newTestDLQ(p),
+	}).WithRetry(RetryConfig{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}, nil, nil)
+	h := &groupHandler{c: c}
+
+	sess := &fakeSession{ctx: ctx}
+	msgCh := make(chan *sarama.ConsumerMessage, 1)
+	msgCh <- &sarama.ConsumerMessage{Value: mustMarshal(t, EventDTO{OrderID: "o1", Status: "created"})}
+
+	require.NoError(t, h.ConsumeClaim(sess, fakeClaim{ch: msgCh}))
+	require.Equal(t, 20, calls)
+	require.Zero(t, sess.MarkedCount())
+	require.Empty(t, p.Sent())
+}
+
+func TestConsumeClaim_SessionEndsDuringRetry_NotAcked(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c := (&Consumer{
+		logger:  testlog.New().Logger(),
+		handler: func(context.Context, orders.Event) error { return errors.New("db down") },
+		sleepFn: func(ctx context.Context, _ time.Duration) error {
+			cancel()
+			return ctx.Err()
+		},
+	}).WithRetry(RetryConfig{MaxAttempts: 5, BaseDelay: time.Second}, nil, nil)
+	h := &groupHandler{c: c}
+
+	sess := &fakeSession{ctx: ctx}
+	msgCh := make(chan *sarama.ConsumerMessage, 1)
+	msgCh <- &sarama.ConsumerMessage{Value: mustMarshal(t, EventDTO{OrderID: "o1", Status: "created"})}
+
+	require.NoError(t, h.ConsumeClaim(sess, fakeClaim{ch: msgCh}))
+	require.Zero(t, sess.MarkedCount())
+}
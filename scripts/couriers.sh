@@ -68,7 +68,7 @@ psqlc -c "begin;
                    else now() + (random() * interval '7 days')  -- часть в будущем
               end as deadline
             from generate_series(1, ${DELIVERIES}) gs
-            on conflict (order_id) do nothing;
+            on conflict (order_id) where status in ('assigned','picked_up') do nothing;
           end
           \$\$;
 
@@ -86,7 +86,8 @@ psqlc -c "select indexname, indexdef
           order by indexname;"
 
 # Явная проверка наличия нужных индексов 
-require_index "ux_delivery_order_id"
+require_index "ux_delivery_order_id_active"
+require_index "ix_delivery_order_id"
 require_index "ix_delivery_courier_id"
 require_index "ix_delivery_deadline_courier_id"
 require_index "ix_couriers_status_id"
@@ -96,23 +97,30 @@ psqlc -c "\di+ couriers*"
 psqlc -c "\di+ delivery*"
 
 echo "5. Проверка планов выполнения ключевых запросов (EXPLAIN ANALYZE)"
-echo "  - GetByOrderID / DeleteByOrderID path (delivery.order_id)"
+echo "  - GetByOrderID path (delivery.order_id)"
 OID="$(psqlc -Atc "select order_id from delivery limit 1;")"
 psqlc -c "explain (analyze, buffers)
-          select id, courier_id, order_id, assigned_at, deadline
+          select id, courier_id, order_id, status, assigned_at, deadline
           from delivery
-          where order_id = '${OID}';"
+          where order_id = '${OID}'
+          order by id desc
+          limit 1;"
 
-echo "  - ReleaseCouriers path (delivery.deadline[, courier_id])"
+echo "  - ExpireOverdueDeliveries path (delivery.status, deadline)"
 psqlc -c "explain (analyze, buffers)
-          select d.courier_id
+          select d.id
           from delivery d
-          where d.deadline < now();"
+          where d.status in ('assigned','picked_up')
+            and d.deadline < now()
+          order by d.deadline, d.id
+          limit 100;"
 
-echo "  - FindAvailableCourierForUpdate COUNT(*) subquery path (delivery.courier_id)"
+echo "  - ListAvailableCouriers workload subquery path (delivery.courier_id)"
 CID="$(psqlc -Atc "select courier_id from delivery limit 1;")"
 psqlc -c "explain (analyze, buffers)
-          select count(*)
+          select count(*) filter (where d.status in ('assigned','picked_up')),
+                 count(*),
+                 max(d.assigned_at)
           from delivery d
           where d.courier_id = ${CID};"
 